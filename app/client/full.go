@@ -19,6 +19,7 @@ import (
 	"github.com/filecoin-project/venus/app/submodule/apitypes"
 	"github.com/filecoin-project/venus/pkg/chain"
 	syncTypes "github.com/filecoin-project/venus/pkg/chainsync/types"
+	"github.com/filecoin-project/venus/pkg/config"
 	"github.com/filecoin-project/venus/pkg/crypto"
 	"github.com/filecoin-project/venus/pkg/messagepool"
 	"github.com/filecoin-project/venus/pkg/net"
@@ -55,8 +56,12 @@ type IAccountStruct struct {
 }
 
 type IActorStruct struct {
-	ListActor     func(p0 context.Context) (map[address.Address]*types.Actor, error)                     `perm:"read"`
-	StateGetActor func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*types.Actor, error) `perm:"read"`
+	ListActor         func(p0 context.Context) (map[address.Address]*types.Actor, error)                                                 `perm:"read"`
+	StateDecodeParams func(p0 context.Context, p1 address.Address, p2 abi.MethodNum, p3 []byte, p4 types.TipSetKey) (interface{}, error) `perm:"read"`
+	StateDecodeReturn func(p0 context.Context, p1 address.Address, p2 abi.MethodNum, p3 []byte, p4 types.TipSetKey) (interface{}, error) `perm:"read"`
+	StateDiff         func(p0 context.Context, p1 types.TipSetKey, p2 types.TipSetKey) (*apitypes.StateDiff, error)                      `perm:"read"`
+	StateGetActor     func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*types.Actor, error)                             `perm:"read"`
+	StateReadState    func(p0 context.Context, p1 address.Address, p2 types.TipSetKey) (*apitypes.ActorState, error)                     `perm:"read"`
 }
 
 type IBeaconStruct struct {
@@ -83,6 +88,7 @@ type IChainStruct struct {
 	IBeaconStruct
 	IMinerStateStruct
 	IChainInfoStruct
+	IExecutionStruct
 }
 
 type IChainInfoStruct struct {
@@ -115,6 +121,7 @@ type IChainInfoStruct struct {
 	StateWaitMsg                  func(p0 context.Context, p1 cid.Cid, p2 uint64, p3 abi.ChainEpoch, p4 bool) (*apitypes.MsgLookup, error)                           `perm:"read"`
 	VerifyEntry                   func(p0 *types.BeaconEntry, p1 *types.BeaconEntry, p2 abi.ChainEpoch) bool                                                         `perm:"read"`
 	ChainExport                   func(p0 context.Context, p1 abi.ChainEpoch, p2 bool, p3 types.TipSetKey) (<-chan []byte, error)                                    `perm:"read"`
+	ChainExportWithOptions        func(p0 context.Context, p1 types.TipSetKey, p2 chain.ExportOptions) (<-chan []byte, error)                                        `perm:"read"`
 }
 
 type IConfigStruct struct {
@@ -125,6 +132,11 @@ type IConfigStruct struct {
 type IDiscoveryStruct struct {
 }
 
+type IExecutionStruct struct {
+	StateCompute func(p0 context.Context, p1 abi.ChainEpoch, p2 []*types.UnsignedMessage, p3 types.TipSetKey) (*apitypes.ComputeStateOutput, error) `perm:"read"`
+	StateReplay  func(p0 context.Context, p1 types.TipSetKey, p2 cid.Cid) (*apitypes.InvocResult, error)                                            `perm:"read"`
+}
+
 type IJwtAuthAPIStruct struct {
 	AuthNew func(p0 context.Context, p1 []auth.Permission) ([]byte, error)                                             `perm:"read"`
 	Verify  func(p0 context.Context, p1 string, p2 string, p3 string, p4 string, p5 string) ([]auth.Permission, error) `perm:"read"`
@@ -135,31 +147,42 @@ type IMarketStruct struct {
 }
 
 type IMessagePoolStruct struct {
-	GasBatchEstimateMessageGas func(p0 context.Context, p1 []*types.EstimateMessage, p2 uint64, p3 types.TipSetKey) ([]*types.EstimateResult, error)              `perm:"read"`
-	GasEstimateFeeCap          func(p0 context.Context, p1 *types.UnsignedMessage, p2 int64, p3 types.TipSetKey) (big.Int, error)                                 `perm:"read"`
-	GasEstimateGasLimit        func(p0 context.Context, p1 *types.UnsignedMessage, p2 types.TipSetKey) (int64, error)                                             `perm:"read"`
-	GasEstimateGasPremium      func(p0 context.Context, p1 uint64, p2 address.Address, p3 int64, p4 types.TipSetKey) (big.Int, error)                             `perm:"read"`
-	GasEstimateMessageGas      func(p0 context.Context, p1 *types.UnsignedMessage, p2 *types.MessageSendSpec, p3 types.TipSetKey) (*types.UnsignedMessage, error) `perm:"read"`
-	MpoolBatchPush             func(p0 context.Context, p1 []*types.SignedMessage) ([]cid.Cid, error)                                                             `perm:"read"`
-	MpoolBatchPushMessage      func(p0 context.Context, p1 []*types.UnsignedMessage, p2 *types.MessageSendSpec) ([]*types.SignedMessage, error)                   `perm:"read"`
-	MpoolBatchPushUntrusted    func(p0 context.Context, p1 []*types.SignedMessage) ([]cid.Cid, error)                                                             `perm:"read"`
-	MpoolCheckMessages         func(p0 context.Context, p1 []*apitypes.MessagePrototype) ([][]apitypes.MessageCheckStatus, error)                                 `perm:"read"`
-	MpoolCheckPendingMessages  func(p0 context.Context, p1 address.Address) ([][]apitypes.MessageCheckStatus, error)                                              `perm:"read"`
-	MpoolCheckReplaceMessages  func(p0 context.Context, p1 []*types.Message) ([][]apitypes.MessageCheckStatus, error)                                             `perm:"read"`
-	MpoolClear                 func(p0 context.Context, p1 bool) error                                                                                            `perm:"read"`
-	MpoolDeleteByAdress        func(p0 context.Context, p1 address.Address) error                                                                                 `perm:"read"`
-	MpoolGetConfig             func(p0 context.Context) (*messagepool.MpoolConfig, error)                                                                         `perm:"read"`
-	MpoolGetNonce              func(p0 context.Context, p1 address.Address) (uint64, error)                                                                       `perm:"read"`
-	MpoolPending               func(p0 context.Context, p1 types.TipSetKey) ([]*types.SignedMessage, error)                                                       `perm:"read"`
-	MpoolPublishByAddr         func(p0 context.Context, p1 address.Address) error                                                                                 `perm:"read"`
-	MpoolPublishMessage        func(p0 context.Context, p1 *types.SignedMessage) error                                                                            `perm:"read"`
-	MpoolPush                  func(p0 context.Context, p1 *types.SignedMessage) (cid.Cid, error)                                                                 `perm:"read"`
-	MpoolPushMessage           func(p0 context.Context, p1 *types.UnsignedMessage, p2 *types.MessageSendSpec) (*types.SignedMessage, error)                       `perm:"read"`
-	MpoolPushUntrusted         func(p0 context.Context, p1 *types.SignedMessage) (cid.Cid, error)                                                                 `perm:"read"`
-	MpoolSelect                func(p0 context.Context, p1 types.TipSetKey, p2 float64) ([]*types.SignedMessage, error)                                           `perm:"read"`
-	MpoolSelects               func(p0 context.Context, p1 types.TipSetKey, p2 []float64) ([][]*types.SignedMessage, error)                                       `perm:"read"`
-	MpoolSetConfig             func(p0 context.Context, p1 *messagepool.MpoolConfig) error                                                                        `perm:"read"`
-	MpoolSub                   func(p0 context.Context) (<-chan messagepool.MpoolUpdate, error)                                                                   `perm:"read"`
+	GasBatchEstimateMessageGas   func(p0 context.Context, p1 []*types.EstimateMessage, p2 uint64, p3 types.TipSetKey) ([]*types.EstimateResult, error)                      `perm:"read"`
+	GasEstimateFeeCap            func(p0 context.Context, p1 *types.UnsignedMessage, p2 int64, p3 types.TipSetKey) (big.Int, error)                                         `perm:"read"`
+	GasEstimateGasLimit          func(p0 context.Context, p1 *types.UnsignedMessage, p2 types.TipSetKey) (int64, error)                                                     `perm:"read"`
+	GasEstimateGasPremium        func(p0 context.Context, p1 uint64, p2 address.Address, p3 int64, p4 types.TipSetKey) (big.Int, error)                                     `perm:"read"`
+	GasEstimateMessageGas        func(p0 context.Context, p1 *types.UnsignedMessage, p2 *types.MessageSendSpec, p3 types.TipSetKey) (*types.UnsignedMessage, error)         `perm:"read"`
+	GasEstimateMessageGasExplain func(p0 context.Context, p1 *types.UnsignedMessage, p2 *types.MessageSendSpec, p3 types.TipSetKey) (*messagepool.GasEstimateReport, error) `perm:"read"`
+	MpoolAtomicBatchPushMessage  func(p0 context.Context, p1 []*types.UnsignedMessage, p2 *types.MessageSendSpec) ([]*types.SignedMessage, error)                           `perm:"sign"`
+	MpoolBatchPush               func(p0 context.Context, p1 []*types.SignedMessage) ([]cid.Cid, error)                                                                     `perm:"read"`
+	MpoolBatchPushMessage        func(p0 context.Context, p1 []*types.UnsignedMessage, p2 *types.MessageSendSpec) ([]*types.SignedMessage, error)                           `perm:"read"`
+	MpoolBatchPushUntrusted      func(p0 context.Context, p1 []*types.SignedMessage) ([]cid.Cid, error)                                                                     `perm:"read"`
+	MpoolCheckMessages           func(p0 context.Context, p1 []*apitypes.MessagePrototype) ([][]apitypes.MessageCheckStatus, error)                                         `perm:"read"`
+	MpoolCheckPendingMessages    func(p0 context.Context, p1 address.Address) ([][]apitypes.MessageCheckStatus, error)                                                      `perm:"read"`
+	MpoolCheckReplaceMessages    func(p0 context.Context, p1 []*types.Message) ([][]apitypes.MessageCheckStatus, error)                                                     `perm:"read"`
+	MpoolClear                   func(p0 context.Context, p1 bool) error                                                                                                    `perm:"read"`
+	MpoolDeleteByAdress          func(p0 context.Context, p1 address.Address) error                                                                                         `perm:"read"`
+	MpoolExport                  func(p0 context.Context, p1 bool, p2 messagepool.ExportFormat) ([]byte, error)                                                             `perm:"read"`
+	MpoolFixNonce                func(p0 context.Context, p1 address.Address, p2 *types.MessageSendSpec, p3 bool) (*messagepool.NonceFix, error)                            `perm:"sign"`
+	MpoolGetConfig               func(p0 context.Context) (*messagepool.MpoolConfig, error)                                                                                 `perm:"read"`
+	MpoolGetNonce                func(p0 context.Context, p1 address.Address) (uint64, error)                                                                               `perm:"read"`
+	MpoolGetPolicies             func(p0 context.Context) ([]*messagepool.MpoolPolicy, error)                                                                               `perm:"read"`
+	MpoolImport                  func(p0 context.Context, p1 []byte) (*messagepool.ImportReport, error)                                                                     `perm:"admin"`
+	MpoolMessageHistory          func(p0 context.Context, p1 cid.Cid) (*messagepool.MsgHistory, error)                                                                      `perm:"read"`
+	MpoolPending                 func(p0 context.Context, p1 types.TipSetKey) ([]*types.SignedMessage, error)                                                               `perm:"read"`
+	MpoolPublishByAddr           func(p0 context.Context, p1 address.Address) error                                                                                         `perm:"read"`
+	MpoolPublishMessage          func(p0 context.Context, p1 *types.SignedMessage) error                                                                                    `perm:"read"`
+	MpoolPush                    func(p0 context.Context, p1 *types.SignedMessage) (cid.Cid, error)                                                                         `perm:"read"`
+	MpoolPushMessage             func(p0 context.Context, p1 *types.UnsignedMessage, p2 *types.MessageSendSpec) (*types.SignedMessage, error)                               `perm:"read"`
+	MpoolPushUntrusted           func(p0 context.Context, p1 *types.SignedMessage) (cid.Cid, error)                                                                         `perm:"read"`
+	MpoolRemovePolicy            func(p0 context.Context, p1 messagepool.PolicyScope, p2 address.Address) (bool, error)                                                     `perm:"admin"`
+	MpoolSelect                  func(p0 context.Context, p1 types.TipSetKey, p2 float64) ([]*types.SignedMessage, error)                                                   `perm:"read"`
+	MpoolSelectDebug             func(p0 context.Context, p1 types.TipSetKey, p2 float64) (*messagepool.SelectionReport, error)                                             `perm:"read"`
+	MpoolSelects                 func(p0 context.Context, p1 types.TipSetKey, p2 []float64) ([][]*types.SignedMessage, error)                                               `perm:"read"`
+	MpoolSetConfig               func(p0 context.Context, p1 *messagepool.MpoolConfig) error                                                                                `perm:"read"`
+	MpoolSetPolicy               func(p0 context.Context, p1 *messagepool.MpoolPolicy) error                                                                                `perm:"admin"`
+	MpoolSub                     func(p0 context.Context) (<-chan messagepool.MpoolUpdate, error)                                                                           `perm:"read"`
+	MpoolSubFiltered             func(p0 context.Context, p1 *messagepool.MpoolUpdateFilter) (<-chan messagepool.MpoolUpdate, error)                                        `perm:"read"`
 }
 
 type IMinerStateStruct struct {
@@ -258,19 +281,35 @@ type ISyncerStruct struct {
 }
 
 type IWalletStruct struct {
-	HasPassword          func(p0 context.Context) bool                                                                         `perm:"admin"`
-	LockWallet           func(p0 context.Context) error                                                                        `perm:"admin"`
-	SetPassword          func(p0 context.Context, p1 []byte) error                                                             `perm:"admin"`
-	UnLockWallet         func(p0 context.Context, p1 []byte) error                                                             `perm:"admin"`
-	WalletAddresses      func(p0 context.Context) []address.Address                                                            `perm:"admin"`
-	WalletBalance        func(p0 context.Context, p1 address.Address) (abi.TokenAmount, error)                                 `perm:"read"`
-	WalletDefaultAddress func(p0 context.Context) (address.Address, error)                                                     `perm:"write"`
-	WalletExport         func(p0 address.Address, p1 string) (*crypto.KeyInfo, error)                                          `perm:"admin"`
-	WalletHas            func(p0 context.Context, p1 address.Address) (bool, error)                                            `perm:"write"`
-	WalletImport         func(p0 *crypto.KeyInfo) (address.Address, error)                                                     `perm:"admin"`
-	WalletNewAddress     func(p0 address.Protocol) (address.Address, error)                                                    `perm:"write"`
-	WalletSetDefault     func(p0 context.Context, p1 address.Address) error                                                    `perm:"admin"`
-	WalletSign           func(p0 context.Context, p1 address.Address, p2 []byte, p3 wallet.MsgMeta) (*crypto.Signature, error) `perm:"sign"`
-	WalletSignMessage    func(p0 context.Context, p1 address.Address, p2 *types.UnsignedMessage) (*types.SignedMessage, error) `perm:"sign"`
-	WalletState          func(p0 context.Context) int                                                                          `perm:"admin"`
+	HasPassword               func(p0 context.Context) bool                                                                         `perm:"admin"`
+	LockWallet                func(p0 context.Context) error                                                                        `perm:"admin"`
+	SetPassword               func(p0 context.Context, p1 []byte) error                                                             `perm:"admin"`
+	UnLockWallet              func(p0 context.Context, p1 []byte) error                                                             `perm:"admin"`
+	WalletAddressBackends     func(p0 context.Context) ([]wallet.AddressBackend, error)                                             `perm:"admin"`
+	WalletAddresses           func(p0 context.Context) []address.Address                                                            `perm:"admin"`
+	WalletApprovals           func(p0 context.Context) ([]*wallet.SignRequest, error)                                               `perm:"admin"`
+	WalletApprove             func(p0 context.Context, p1 uint64, p2 string, p3 string) error                                       `perm:"admin"`
+	WalletBackends            func(p0 context.Context) ([]wallet.BackendStatus, error)                                              `perm:"admin"`
+	WalletBalance             func(p0 context.Context, p1 address.Address) (abi.TokenAmount, error)                                 `perm:"read"`
+	WalletChangePassword      func(p0 context.Context, p1 []byte, p2 []byte, p3 *config.PassphraseConfig) error                     `perm:"admin"`
+	WalletDefaultAddress      func(p0 context.Context) (address.Address, error)                                                     `perm:"write"`
+	WalletExport              func(p0 address.Address, p1 string) (*crypto.KeyInfo, error)                                          `perm:"admin"`
+	WalletExportKeyStore      func(p0 context.Context, p1 string, p2 string) (*wallet.KeyStore, error)                              `perm:"admin"`
+	WalletExportMnemonic      func(p0 context.Context, p1 string) (string, error)                                                   `perm:"admin"`
+	WalletHas                 func(p0 context.Context, p1 address.Address) (bool, error)                                            `perm:"write"`
+	WalletImport              func(p0 *crypto.KeyInfo) (address.Address, error)                                                     `perm:"admin"`
+	WalletImportKeyStore      func(p0 context.Context, p1 *wallet.KeyStore, p2 string) ([]address.Address, error)                   `perm:"admin"`
+	WalletNewAddress          func(p0 address.Protocol) (address.Address, error)                                                    `perm:"write"`
+	WalletNewHDAddress        func(p0 context.Context, p1 address.Protocol) (address.Address, error)                                `perm:"write"`
+	WalletNewThresholdAddress func(p0 context.Context, p1 int, p2 []wallet.ThresholdApprover) (address.Address, error)              `perm:"admin"`
+	WalletPolicies            func(p0 context.Context) ([]*wallet.SigningPolicy, error)                                             `perm:"admin"`
+	WalletReject              func(p0 context.Context, p1 uint64, p2 string, p3 string) error                                       `perm:"admin"`
+	WalletRemovePolicy        func(p0 context.Context, p1 address.Address) (bool, error)                                            `perm:"admin"`
+	WalletRestore             func(p0 context.Context, p1 string, p2 address.Protocol, p3 int) ([]address.Address, error)           `perm:"admin"`
+	WalletSetDefault          func(p0 context.Context, p1 address.Address) error                                                    `perm:"admin"`
+	WalletSetPolicy           func(p0 context.Context, p1 *wallet.SigningPolicy) error                                              `perm:"admin"`
+	WalletSign                func(p0 context.Context, p1 address.Address, p2 []byte, p3 wallet.MsgMeta) (*crypto.Signature, error) `perm:"sign"`
+	WalletSignMessage         func(p0 context.Context, p1 address.Address, p2 *types.UnsignedMessage) (*types.SignedMessage, error) `perm:"sign"`
+	WalletState               func(p0 context.Context) int                                                                          `perm:"admin"`
+	WalletThresholdKeys       func(p0 context.Context) ([]*wallet.ThresholdKeyInfo, error)                                          `perm:"admin"`
 }
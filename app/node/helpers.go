@@ -1,13 +1,16 @@
 package node
 
 import (
+	"context"
 	"encoding/json"
 
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-datastore"
+	cbor "github.com/ipfs/go-ipld-cbor"
 	"github.com/pkg/errors"
 
 	"github.com/filecoin-project/venus/pkg/chain"
+	"github.com/filecoin-project/venus/pkg/repo"
 )
 
 // readGenesisCid is a helper function that queries the provided datastore for
@@ -25,3 +28,27 @@ func readGenesisCid(ds datastore.Datastore) (cid.Cid, error) {
 	}
 	return c, nil
 }
+
+// NewChainStore creates the chain store of a repo that is not used by a running
+// daemon, for offline maintenance commands. The head is not loaded.
+func NewChainStore(r repo.Repo) (*chain.Store, error) {
+	genCid, err := readGenesisCid(r.ChainDatastore())
+	if err != nil {
+		return nil, err
+	}
+
+	bs := r.Datastore()
+	return chain.NewStore(r.ChainDatastore(), cbor.NewCborStore(bs), bs, r.Config().NetworkParams.ForkUpgradeParam, genCid), nil
+}
+
+// OpenChainStore creates the chain store of a repo like NewChainStore and loads its head.
+func OpenChainStore(ctx context.Context, r repo.Repo) (*chain.Store, error) {
+	store, err := NewChainStore(r)
+	if err != nil {
+		return nil, err
+	}
+	if err := store.Load(ctx); err != nil {
+		return nil, errors.Wrap(err, "failed to load chain")
+	}
+	return store, nil
+}
@@ -195,6 +195,9 @@ func (node *Node) Start(ctx context.Context) error {
 		return err
 	}
 
+	// Start wallet submodule to health check the remote wallets
+	node.wallet.Start(context.Background())
+
 	/*err = node.market.Start()
 	if err != nil {
 		return err
@@ -223,6 +226,9 @@ func (node *Node) Stop(ctx context.Context) {
 	// Stop paychannel submodule
 	node.paychan.Stop()
 
+	// Stop wallet submodule
+	node.wallet.Stop(ctx)
+
 	// Stop market submodule
 	// node.market.Stop()
 
@@ -26,6 +26,7 @@ type IChain interface {
 	IBeacon
 	IMinerState
 	IChainInfo
+	IExecution
 }
 
 type IAccount interface {
@@ -38,6 +39,21 @@ type IActor interface {
 	StateGetActor(ctx context.Context, actor address.Address, tsk types.TipSetKey) (*types.Actor, error)
 	// Rule[perm:read]
 	ListActor(ctx context.Context) (map[address.Address]*types.Actor, error)
+	// StateReadState returns the actor with its state object decoded, as of the parent state of the tipset tsk.
+	// Rule[perm:read]
+	StateReadState(ctx context.Context, actor address.Address, tsk types.TipSetKey) (*apitypes.ActorState, error)
+	// StateDiff compares the parent states of the tipsets tskA and tskB and returns the actors added,
+	// removed and modified in the state of tskB.
+	// Rule[perm:read]
+	StateDiff(ctx context.Context, tskA, tskB types.TipSetKey) (*apitypes.StateDiff, error)
+	// StateDecodeParams decodes the params of a call to method of the actor toAddr, as of the
+	// parent state of the tipset tsk, into the type the method of the actor code expects.
+	// Rule[perm:read]
+	StateDecodeParams(ctx context.Context, toAddr address.Address, method abi.MethodNum, params []byte, tsk types.TipSetKey) (interface{}, error)
+	// StateDecodeReturn decodes the value returned by method of the actor toAddr, as of the
+	// parent state of the tipset tsk, into the type the method of the actor code returns.
+	// Rule[perm:read]
+	StateDecodeReturn(ctx context.Context, toAddr address.Address, method abi.MethodNum, ret []byte, tsk types.TipSetKey) (interface{}, error)
 }
 
 type IBeacon interface {
@@ -45,6 +61,19 @@ type IBeacon interface {
 	BeaconGetEntry(ctx context.Context, epoch abi.ChainEpoch) (*types.BeaconEntry, error)
 }
 
+type IExecution interface {
+	// StateReplay replays the message mCid on the parent state of the tipset tsk including it, and
+	// returns its result with the full execution trace. When tsk is empty the message is searched on
+	// chain first, if it was replaced on chain the replacing message is replayed and MsgCid is its cid.
+	// Rule[perm:read]
+	StateReplay(ctx context.Context, tsk types.TipSetKey, mCid cid.Cid) (*apitypes.InvocResult, error)
+	// StateCompute computes the state of the tipset tsk, runs the state migrations up to height and
+	// applies msgs on the result. It returns the resulting state root and the result of every message
+	// applied, those of the tipset, including implicit messages, followed by msgs.
+	// Rule[perm:read]
+	StateCompute(ctx context.Context, height abi.ChainEpoch, msgs []*types.UnsignedMessage, tsk types.TipSetKey) (*apitypes.ComputeStateOutput, error)
+}
+
 type IChainInfo interface {
 	// Rule[perm:read]
 	BlockTime(ctx context.Context) time.Duration
@@ -138,6 +167,8 @@ type IChainInfo interface {
 	VerifyEntry(parent, child *types.BeaconEntry, height abi.ChainEpoch) bool
 	// Rule[perm:read]
 	ChainExport(context.Context, abi.ChainEpoch, bool, types.TipSetKey) (<-chan []byte, error)
+	// Rule[perm:read]
+	ChainExportWithOptions(context.Context, types.TipSetKey, chain.ExportOptions) (<-chan []byte, error)
 }
 
 type IMinerState interface {
@@ -25,8 +25,21 @@ type IMessagePool interface {
 	MpoolGetConfig(context.Context) (*messagepool.MpoolConfig, error)
 	// Rule[perm:read]
 	MpoolSetConfig(ctx context.Context, cfg *messagepool.MpoolConfig) error
+	// MpoolGetPolicies returns the per-sender and per-recipient policies of the mpool
+	// Rule[perm:read]
+	MpoolGetPolicies(ctx context.Context) ([]*messagepool.MpoolPolicy, error)
+	// MpoolSetPolicy adds the policy, replacing the policy with the same scope and address
+	// Rule[perm:admin]
+	MpoolSetPolicy(ctx context.Context, policy *messagepool.MpoolPolicy) error
+	// MpoolRemovePolicy removes the policy of the scope and address, it returns false if there is none
+	// Rule[perm:admin]
+	MpoolRemovePolicy(ctx context.Context, scope messagepool.PolicyScope, addr address.Address) (bool, error)
 	// Rule[perm:read]
 	MpoolSelect(context.Context, types.TipSetKey, float64) ([]*types.SignedMessage, error)
+	// MpoolSelectDebug runs the message selection for the tipset without side effects and reports the
+	// message chains, their effective performance and why each message not selected was left out
+	// Rule[perm:read]
+	MpoolSelectDebug(ctx context.Context, tsk types.TipSetKey, ticketQuality float64) (*messagepool.SelectionReport, error)
 	// Rule[perm:read]
 	MpoolSelects(context.Context, types.TipSetKey, []float64) ([][]*types.SignedMessage, error)
 	// Rule[perm:read]
@@ -43,12 +56,38 @@ type IMessagePool interface {
 	MpoolBatchPushUntrusted(ctx context.Context, smsgs []*types.SignedMessage) ([]cid.Cid, error)
 	// Rule[perm:read]
 	MpoolBatchPushMessage(ctx context.Context, msgs []*types.UnsignedMessage, spec *types.MessageSendSpec) ([]*types.SignedMessage, error)
+	// MpoolAtomicBatchPushMessage reserves a range of contiguous nonces for the messages of a sender,
+	// estimates their gas together, signs them and pushes them to mempool, either all of them or none.
+	// Rule[perm:sign]
+	MpoolAtomicBatchPushMessage(ctx context.Context, msgs []*types.UnsignedMessage, spec *types.MessageSendSpec) ([]*types.SignedMessage, error)
 	// Rule[perm:read]
 	MpoolGetNonce(ctx context.Context, addr address.Address) (uint64, error)
+	// MpoolFixNonce fills the nonce gaps between the state nonce and the pending messages of the local
+	// sender with re-signed dropped local messages or self-sends, dryRun only reports the messages.
+	// Rule[perm:sign]
+	MpoolFixNonce(ctx context.Context, from address.Address, spec *types.MessageSendSpec, dryRun bool) (*messagepool.NonceFix, error)
 	// Rule[perm:read]
 	MpoolSub(ctx context.Context) (<-chan messagepool.MpoolUpdate, error)
+	// MpoolExport returns the pending messages, only the messages of the local addresses with local,
+	// in the format of an export file.
+	// Rule[perm:read]
+	MpoolExport(ctx context.Context, local bool, format messagepool.ExportFormat) ([]byte, error)
+	// MpoolImport adds the messages of an export file to the pool as messages from the network.
+	// Rule[perm:admin]
+	MpoolImport(ctx context.Context, data []byte) (*messagepool.ImportReport, error)
+	// MpoolMessageHistory returns when the message was first seen, replaced, republished, included,
+	// executed and reverted, when the message history is enabled.
+	// Rule[perm:read]
+	MpoolMessageHistory(ctx context.Context, c cid.Cid) (*messagepool.MsgHistory, error)
+	// MpoolSubFiltered streams the additions to and removals from the pool matching the filter.
+	// Rule[perm:read]
+	MpoolSubFiltered(ctx context.Context, filter *messagepool.MpoolUpdateFilter) (<-chan messagepool.MpoolUpdate, error)
 	// Rule[perm:read]
 	GasEstimateMessageGas(ctx context.Context, msg *types.UnsignedMessage, spec *types.MessageSendSpec, tsk types.TipSetKey) (*types.UnsignedMessage, error)
+	// GasEstimateMessageGasExplain estimates the gas values of the message like GasEstimateMessageGas
+	// and reports the samples, projections and factors each estimate is based on.
+	// Rule[perm:read]
+	GasEstimateMessageGasExplain(ctx context.Context, msg *types.UnsignedMessage, spec *types.MessageSendSpec, tsk types.TipSetKey) (*messagepool.GasEstimateReport, error)
 	// Rule[perm:read]
 	GasBatchEstimateMessageGas(ctx context.Context, estimateMessages []*types.EstimateMessage, fromNonce uint64, tsk types.TipSetKey) ([]*types.EstimateResult, error)
 	// Rule[perm:read]
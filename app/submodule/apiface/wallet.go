@@ -5,6 +5,7 @@ import (
 
 	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/venus/pkg/config"
 	"github.com/filecoin-project/venus/pkg/crypto"
 	"github.com/filecoin-project/venus/pkg/types"
 	"github.com/filecoin-project/venus/pkg/wallet"
@@ -21,22 +22,70 @@ type IWallet interface {
 	WalletHas(ctx context.Context, addr address.Address) (bool, error)
 	// Rule[perm:write]
 	WalletNewAddress(protocol address.Protocol) (address.Address, error)
+	// WalletNewHDAddress derives the next address of the protocol from the mnemonic of the local wallet.
+	// Rule[perm:write]
+	WalletNewHDAddress(ctx context.Context, protocol address.Protocol) (address.Address, error)
+	// WalletExportMnemonic returns the mnemonic of the local wallet, decrypted with the wallet password.
+	// Rule[perm:admin]
+	WalletExportMnemonic(ctx context.Context, password string) (string, error)
+	// WalletRestore restores the first count addresses of the protocol derived from the mnemonic in the local wallet.
+	// Rule[perm:admin]
+	WalletRestore(ctx context.Context, mnemonic string, protocol address.Protocol, count int) ([]address.Address, error)
 	// Rule[perm:read]
 	WalletBalance(ctx context.Context, addr address.Address) (abi.TokenAmount, error) //not exists in remote
 	// Rule[perm:write]
 	WalletDefaultAddress(ctx context.Context) (address.Address, error) //not exists in remote
 	// Rule[perm:admin]
 	WalletAddresses(ctx context.Context) []address.Address
+	// WalletAddressBackends returns the addresses of the wallet with the local or remote backends owning them.
+	// Rule[perm:admin]
+	WalletAddressBackends(ctx context.Context) ([]wallet.AddressBackend, error)
+	// WalletBackends returns the state of the local backend and of the remote wallets.
+	// Rule[perm:admin]
+	WalletBackends(ctx context.Context) ([]wallet.BackendStatus, error)
 	// Rule[perm:admin]
 	WalletSetDefault(ctx context.Context, addr address.Address) error //not exists in remote
 	// Rule[perm:sign]
 	WalletSignMessage(ctx context.Context, k address.Address, msg *types.UnsignedMessage) (*types.SignedMessage, error)
+	// WalletPolicies returns the signing policies of the local wallet.
+	// Rule[perm:admin]
+	WalletPolicies(ctx context.Context) ([]*wallet.SigningPolicy, error)
+	// WalletSetPolicy adds or replaces the signing policy of an address of the local wallet.
+	// Rule[perm:admin]
+	WalletSetPolicy(ctx context.Context, policy *wallet.SigningPolicy) error
+	// WalletRemovePolicy removes the signing policy of an address of the local wallet, it returns false if there is none.
+	// Rule[perm:admin]
+	WalletRemovePolicy(ctx context.Context, addr address.Address) (bool, error)
+	// WalletNewThresholdAddress generates a secp256k1 key split in a share per approver, threshold of the approvers approve its signatures.
+	// Rule[perm:admin]
+	WalletNewThresholdAddress(ctx context.Context, threshold int, approvers []wallet.ThresholdApprover) (address.Address, error)
+	// WalletThresholdKeys returns the threshold keys of the wallet.
+	// Rule[perm:admin]
+	WalletThresholdKeys(ctx context.Context) ([]*wallet.ThresholdKeyInfo, error)
+	// WalletApprovals returns the requests to sign with the threshold keys, the oldest first.
+	// Rule[perm:admin]
+	WalletApprovals(ctx context.Context) ([]*wallet.SignRequest, error)
+	// WalletApprove approves a sign request with the share of the approver decrypted with password.
+	// Rule[perm:admin]
+	WalletApprove(ctx context.Context, id uint64, approver string, password string) error
+	// WalletReject rejects a sign request as the approver, authenticated with the password of its share.
+	// Rule[perm:admin]
+	WalletReject(ctx context.Context, id uint64, approver string, password string) error
 	// Rule[perm:admin]
 	LockWallet(ctx context.Context) error
 	// Rule[perm:admin]
 	UnLockWallet(ctx context.Context, password []byte) error
 	// Rule[perm:admin]
 	SetPassword(Context context.Context, password []byte) error
+	// WalletChangePassword re-encrypts the keys of the local wallet with the new password, and the scrypt parameters of passphraseCfg if it is not nil.
+	// Rule[perm:admin]
+	WalletChangePassword(ctx context.Context, oldPassword, newPassword []byte, passphraseCfg *config.PassphraseConfig) error
+	// WalletExportKeyStore exports all the keys of the local wallet, decrypted with the wallet password and encrypted with the export password.
+	// Rule[perm:admin]
+	WalletExportKeyStore(ctx context.Context, password, exportPassword string) (*wallet.KeyStore, error)
+	// WalletImportKeyStore imports the keys of a key store decrypted with the export password in the local wallet.
+	// Rule[perm:admin]
+	WalletImportKeyStore(ctx context.Context, ks *wallet.KeyStore, exportPassword string) ([]address.Address, error)
 	// Rule[perm:admin]
 	HasPassword(Context context.Context) bool
 	// Rule[perm:admin]
@@ -3,6 +3,7 @@ package apitypes
 import (
 	"time"
 
+	"github.com/filecoin-project/go-address"
 	"github.com/filecoin-project/go-bitfield"
 	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/big"
@@ -11,6 +12,8 @@ import (
 	"github.com/filecoin-project/venus/pkg/chain"
 	"github.com/filecoin-project/venus/pkg/specactors/builtin/market"
 	"github.com/filecoin-project/venus/pkg/specactors/builtin/power"
+	"github.com/filecoin-project/venus/pkg/state"
+	"github.com/filecoin-project/venus/pkg/state/tree"
 	"github.com/filecoin-project/venus/pkg/types"
 )
 
@@ -40,6 +43,32 @@ type Deadline struct {
 	DisputableProofCount uint64
 }
 
+// ActorState is an actor with its decoded state object.
+type ActorState struct {
+	Balance big.Int
+	Code    cid.Cid
+	State   interface{}
+}
+
+// StateDiff is the difference between the states of two tipsets.
+type StateDiff struct {
+	// From and To are the state roots compared.
+	From     cid.Cid
+	To       cid.Cid
+	Added    []tree.ActorInfo
+	Removed  []tree.ActorInfo
+	Modified []ActorDiff
+}
+
+// ActorDiff is an actor changed between two states, State is the decoded difference
+// of its state for the miner, storage market and storage power actors.
+type ActorDiff struct {
+	Address address.Address
+	From    types.Actor
+	To      types.Actor
+	State   *state.ActorStateDiff `json:",omitempty"`
+}
+
 // BlsMessages[x].cid = Cids[x]
 // SecpkMessages[y].cid = Cids[BlsMessages.length + y]
 type BlockMessages struct {
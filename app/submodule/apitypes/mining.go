@@ -29,4 +29,7 @@ type BlockTemplate struct {
 	Epoch            abi.ChainEpoch
 	Timestamp        uint64
 	WinningPoStProof []proof2.PoStProof
+	// SelectionStrategy, when set, makes the node select the messages of the block from its message
+	// pool with the named strategy for the quality of the ticket, Messages must then be empty
+	SelectionStrategy string `json:",omitempty"`
 }
@@ -5,7 +5,15 @@ import (
 	"github.com/filecoin-project/venus/app/submodule/apiface"
 
 	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/venus/app/submodule/apitypes"
+	"github.com/filecoin-project/venus/pkg/specactors/builtin"
+	"github.com/filecoin-project/venus/pkg/state"
+	"github.com/filecoin-project/venus/pkg/state/tree"
+	// registers the state loaders of the system actor, other actors are imported by the chain module.
+	_ "github.com/filecoin-project/venus/pkg/specactors/builtin/system"
 	"github.com/filecoin-project/venus/pkg/types"
+	"github.com/filecoin-project/venus/pkg/vm"
 	xerrors "github.com/pkg/errors"
 )
 
@@ -38,3 +46,100 @@ func (actorAPI *actorAPI) StateGetActor(ctx context.Context, actor address.Addre
 func (actorAPI *actorAPI) ListActor(ctx context.Context) (map[address.Address]*types.Actor, error) {
 	return actorAPI.chain.ChainReader.LsActors(ctx)
 }
+
+// StateReadState returns the indicated actor with its state object decoded.
+func (actorAPI *actorAPI) StateReadState(ctx context.Context, actor address.Address, tsk types.TipSetKey) (*apitypes.ActorState, error) {
+	act, err := actorAPI.StateGetActor(ctx, actor, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("getting actor %s: %v", actor, err)
+	}
+
+	state, err := builtin.Load(actorAPI.chain.ChainReader.Store(ctx), act)
+	if err != nil {
+		return nil, xerrors.Errorf("loading state of actor %s: %v", actor, err)
+	}
+	// the loaders return the specactors adapters, the on-chain struct is behind GetState.
+	getter, ok := state.(interface{ GetState() interface{} })
+	if !ok {
+		return nil, xerrors.Errorf("state of actor %s has no on-chain representation", actor)
+	}
+
+	return &apitypes.ActorState{
+		Balance: act.Balance,
+		Code:    act.Code,
+		State:   getter.GetState(),
+	}, nil
+}
+
+// StateDiff compares the parent states of the tipsets tskA and tskB.
+func (actorAPI *actorAPI) StateDiff(ctx context.Context, tskA, tskB types.TipSetKey) (*apitypes.StateDiff, error) {
+	tsA, err := actorAPI.chain.ChainReader.GetTipSet(tskA)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %v", tskA, err)
+	}
+	tsB, err := actorAPI.chain.ChainReader.GetTipSet(tskB)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %v", tskB, err)
+	}
+
+	store := actorAPI.chain.ChainReader.Store(ctx)
+	rootA, rootB := tsA.At(0).ParentStateRoot, tsB.At(0).ParentStateRoot
+	treeA, err := tree.LoadState(ctx, store, rootA)
+	if err != nil {
+		return nil, xerrors.Errorf("loading state %s: %v", rootA, err)
+	}
+	treeB, err := tree.LoadState(ctx, store, rootB)
+	if err != nil {
+		return nil, xerrors.Errorf("loading state %s: %v", rootB, err)
+	}
+
+	changes, err := tree.DiffActors(treeA, treeB)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &apitypes.StateDiff{
+		From:     rootA,
+		To:       rootB,
+		Added:    changes.Added,
+		Removed:  changes.Removed,
+		Modified: make([]apitypes.ActorDiff, 0, len(changes.Modified)),
+	}
+	for _, m := range changes.Modified {
+		diff := apitypes.ActorDiff{Address: m.Address, From: m.From, To: m.To}
+		if !m.From.Head.Equals(m.To.Head) {
+			diff.State, err = state.DiffActorState(store, m.Address, &m.From, &m.To)
+			if err != nil {
+				return nil, xerrors.Errorf("diffing state of actor %s: %v", m.Address, err)
+			}
+		}
+		out.Modified = append(out.Modified, diff)
+	}
+	return out, nil
+}
+
+// StateDecodeParams decodes the params of a call to method of the actor toAddr.
+func (actorAPI *actorAPI) StateDecodeParams(ctx context.Context, toAddr address.Address, method abi.MethodNum, params []byte, tsk types.TipSetKey) (interface{}, error) {
+	act, err := actorAPI.StateGetActor(ctx, toAddr, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("getting actor %s: %v", toAddr, err)
+	}
+	decoded, err := vm.DefaultActors.DecodeParams(act.Code, method, params)
+	if err != nil {
+		return nil, xerrors.Errorf("decoding params of method %d of actor %s: %v", method, toAddr, err)
+	}
+	return decoded, nil
+}
+
+// StateDecodeReturn decodes the value returned by method of the actor toAddr.
+func (actorAPI *actorAPI) StateDecodeReturn(ctx context.Context, toAddr address.Address, method abi.MethodNum, ret []byte, tsk types.TipSetKey) (interface{}, error) {
+	act, err := actorAPI.StateGetActor(ctx, toAddr, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("getting actor %s: %v", toAddr, err)
+	}
+	decoded, err := vm.DefaultActors.DecodeReturn(act.Code, method, ret)
+	if err != nil {
+		return nil, xerrors.Errorf("decoding return of method %d of actor %s: %v", method, toAddr, err)
+	}
+	return decoded, nil
+}
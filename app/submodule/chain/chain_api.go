@@ -8,6 +8,7 @@ type chainAPI struct { // nolint: golint
 	apiface.IBeacon
 	apiface.IMinerState
 	apiface.IChainInfo
+	apiface.IExecution
 }
 
 var _ apiface.IChain = &chainAPI{}
@@ -8,6 +8,7 @@ import (
 	"github.com/filecoin-project/venus/app/submodule/apiface/v0api"
 	chainv0api "github.com/filecoin-project/venus/app/submodule/chain/v0api"
 
+	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/ipfs/go-cid"
 
 	"github.com/filecoin-project/venus/app/submodule/apiface"
@@ -20,6 +21,7 @@ import (
 	"github.com/filecoin-project/venus/pkg/fork"
 	"github.com/filecoin-project/venus/pkg/repo"
 	"github.com/filecoin-project/venus/pkg/types"
+	"github.com/filecoin-project/venus/pkg/util/blockstoreutil"
 	"github.com/filecoin-project/venus/pkg/util/ffiwrapper"
 	"github.com/filecoin-project/venus/pkg/vmsupport"
 )
@@ -40,6 +42,8 @@ type ChainSubmodule struct { //nolint
 
 	// Wait for confirm message
 	Waiter *chain.Waiter
+	// MsgIndex is nil unless the msg index is enabled
+	MsgIndex *chain.MsgIndex
 }
 
 // xxx go back to using an interface here
@@ -100,6 +104,22 @@ func NewChainSubmodule(ctx context.Context,
 	if err != nil {
 		return nil, err
 	}
+
+	if ss, ok := blockstore.Blockstore.(*blockstoreutil.SplitStore); ok {
+		chainStore.SubscribeHeadChanges(func(rev, app []*types.TipSet) error {
+			for _, ts := range app {
+				ss.HeadChange(ts.Height())
+			}
+			return nil
+		})
+		ss.Start(chainStore)
+	}
+
+	if cfg := repo.Config().Datastore.MsgIndex; cfg != nil && cfg.Enable {
+		store.MsgIndex = chain.NewMsgIndex(repo.ChainDatastore(), chainStore, messageStore, abi.ChainEpoch(cfg.BackfillEpochs))
+		store.MsgIndex.Start(context.Background())
+		waiter.SetMsgIndex(store.MsgIndex)
+	}
 	return store, nil
 }
 
@@ -110,6 +130,9 @@ func (chain *ChainSubmodule) Start(ctx context.Context) error {
 
 //Stop stop the chain head event
 func (chain *ChainSubmodule) Stop(ctx context.Context) {
+	if chain.MsgIndex != nil {
+		chain.MsgIndex.Close()
+	}
 	chain.ChainReader.Stop()
 }
 
@@ -121,6 +144,7 @@ func (chain *ChainSubmodule) API() apiface.IChain {
 		IBeacon:     NewBeaconAPI(chain),
 		IChainInfo:  NewChainInfoAPI(chain),
 		IMinerState: NewMinerStateAPI(chain),
+		IExecution:  NewExecutionAPI(chain),
 	}
 }
 
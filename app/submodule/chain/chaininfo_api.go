@@ -457,6 +457,16 @@ func (cia *chainInfoAPI) StateWaitMsg(ctx context.Context, mCid cid.Cid, confide
 }
 
 func (cia *chainInfoAPI) ChainExport(ctx context.Context, nroots abi.ChainEpoch, skipoldmsgs bool, tsk types.TipSetKey) (<-chan []byte, error) {
+	return cia.ChainExportWithOptions(ctx, tsk, chain.ExportOptions{
+		Mode:             chain.ExportFull,
+		RecentStateRoots: nroots,
+		SkipOldMsgs:      skipoldmsgs,
+	})
+}
+
+// ChainExportWithOptions streams the part of the chain ending at tsk selected by opts as a car file,
+// the last chunk is empty when the export completed.
+func (cia *chainInfoAPI) ChainExportWithOptions(ctx context.Context, tsk types.TipSetKey, opts chain.ExportOptions) (<-chan []byte, error) {
 	ts, err := cia.chain.ChainReader.GetTipSet(tsk)
 	if err != nil {
 		return nil, xerrors.Errorf("loading tipset %s: %v", tsk, err)
@@ -466,7 +476,7 @@ func (cia *chainInfoAPI) ChainExport(ctx context.Context, nroots abi.ChainEpoch,
 	go func() {
 		bw := bufio.NewWriterSize(w, 1<<20)
 
-		err := cia.chain.ChainReader.Export(ctx, ts, nroots, skipoldmsgs, bw)
+		err := cia.chain.ChainReader.ExportWithOptions(ctx, ts, opts, bw)
 		bw.Flush()            //nolint:errcheck // it is a write to a pipe
 		w.CloseWithError(err) //nolint:errcheck // it is a pipe
 	}()
@@ -0,0 +1,227 @@
+package chain
+
+import (
+	"context"
+	"errors"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/ipfs/go-cid"
+	xerrors "github.com/pkg/errors"
+
+	"github.com/filecoin-project/venus/app/submodule/apiface"
+	"github.com/filecoin-project/venus/app/submodule/apitypes"
+	"github.com/filecoin-project/venus/pkg/consensus"
+	"github.com/filecoin-project/venus/pkg/constants"
+	"github.com/filecoin-project/venus/pkg/state/tree"
+	"github.com/filecoin-project/venus/pkg/types"
+	"github.com/filecoin-project/venus/pkg/vm"
+	"github.com/filecoin-project/venus/pkg/vm/gas"
+)
+
+// errHaltExecution stops the execution of a tipset once the replayed message is found.
+var errHaltExecution = errors.New("halt execution")
+
+var _ apiface.IExecution = &executionAPI{}
+
+type executionAPI struct {
+	chain            *ChainSubmodule
+	gasPriceSchedule *gas.PricesSchedule
+}
+
+// NewExecutionAPI create a new execution api
+func NewExecutionAPI(chain *ChainSubmodule) apiface.IExecution {
+	return &executionAPI{
+		chain:            chain,
+		gasPriceSchedule: gas.NewPricesSchedule(chain.config.Repo().Config().NetworkParams.ForkUpgradeParam),
+	}
+}
+
+// StateReplay replays the message mCid on the parent state of the tipset including it.
+func (ea *executionAPI) StateReplay(ctx context.Context, tsk types.TipSetKey, mCid cid.Cid) (*apitypes.InvocResult, error) {
+	chainMsg, err := ea.chain.MessageStore.LoadMessage(mCid)
+	if err != nil {
+		return nil, xerrors.Errorf("loading message %s: %v", mCid, err)
+	}
+
+	var ts *types.TipSet
+	if tsk.IsEmpty() {
+		found, ok, err := ea.chain.Waiter.Find(ctx, chainMsg, constants.LookbackNoLimit, nil, true)
+		if err != nil {
+			return nil, xerrors.Errorf("searching message %s: %v", mCid, err)
+		}
+		if !ok {
+			return nil, xerrors.Errorf("message %s not found on chain", mCid)
+		}
+		chainMsg = found.Message
+		// the message was executed in found.TS, on top of the tipset including it.
+		ts, err = ea.chain.ChainReader.GetTipSet(found.TS.Parents())
+		if err != nil {
+			return nil, xerrors.Errorf("loading tipset %s: %v", found.TS.Parents(), err)
+		}
+	} else {
+		ts, err = ea.chain.ChainReader.GetTipSet(tsk)
+		if err != nil {
+			return nil, xerrors.Errorf("loading tipset %s: %v", tsk, err)
+		}
+	}
+
+	target := chainMsg.VMMessage().Cid()
+	var result *apitypes.InvocResult
+	_, err = ea.computeTipSet(ctx, ts, func(_ cid.Cid, msg types.ChainMsg, ret *vm.Ret) error {
+		if msg == nil || msg.VMMessage().Cid() != target {
+			return nil
+		}
+		result = invocResult(msg.Cid(), false, ret)
+		return errHaltExecution
+	})
+	if err != nil && !xerrors.Is(err, errHaltExecution) {
+		return nil, xerrors.Errorf("replaying tipset %s: %v", ts.Key(), err)
+	}
+	if result == nil {
+		return nil, xerrors.Errorf("message %s not found in tipset %s", chainMsg.Cid(), ts.Key())
+	}
+	return result, nil
+}
+
+// StateCompute computes the state of the tipset tsk and applies msgs on it at height.
+func (ea *executionAPI) StateCompute(ctx context.Context, height abi.ChainEpoch, msgs []*types.UnsignedMessage, tsk types.TipSetKey) (*apitypes.ComputeStateOutput, error) {
+	ts, err := ea.chain.ChainReader.GetTipSet(tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %v", tsk, err)
+	}
+	if height < ts.Height() {
+		return nil, xerrors.Errorf("height %d is below the height %d of the tipset", height, ts.Height())
+	}
+
+	var trace []*apitypes.InvocResult
+	root, err := ea.computeTipSet(ctx, ts, func(mcid cid.Cid, msg types.ChainMsg, ret *vm.Ret) error {
+		if msg != nil {
+			mcid = msg.Cid()
+		}
+		trace = append(trace, invocResult(mcid, msg == nil, ret))
+		return nil
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("computing tipset %s: %v", ts.Key(), err)
+	}
+
+	for h := ts.Height(); h < height; h++ {
+		root, err = ea.chain.Fork.HandleStateForks(ctx, root, h, ts)
+		if err != nil {
+			return nil, xerrors.Errorf("running state migrations at %d: %v", h, err)
+		}
+	}
+
+	if len(msgs) > 0 {
+		vmi, err := vm.NewVM(ea.vmOption(ts, root, height))
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range msgs {
+			ret, err := vmi.ApplyMessage(msg)
+			if err != nil {
+				return nil, xerrors.Errorf("applying message %s: %v", msg.Cid(), err)
+			}
+			trace = append(trace, invocResult(msg.Cid(), false, ret))
+		}
+		root, err = vmi.Flush()
+		if err != nil {
+			return nil, xerrors.Errorf("flushing state: %v", err)
+		}
+	}
+
+	return &apitypes.ComputeStateOutput{
+		Root:  root,
+		Trace: trace,
+	}, nil
+}
+
+// computeTipSet applies the messages of ts on its parent state and returns the resulting state root.
+// cb is called with the result of every message applied, the cid the vm identifies it with and the
+// message as included on chain, nil for the implicit messages.
+func (ea *executionAPI) computeTipSet(ctx context.Context, ts *types.TipSet, cb func(cid.Cid, types.ChainMsg, *vm.Ret) error) (cid.Cid, error) {
+	if ts.Height() == 0 {
+		// the genesis state is not computed, see consensus.Expected.RunStateTransition.
+		return ts.At(0).ParentStateRoot, nil
+	}
+
+	pts, err := ea.chain.ChainReader.GetTipSet(ts.Parents())
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("loading parent tipset %s: %v", ts.Parents(), err)
+	}
+	blkMsgs, err := ea.chain.MessageStore.LoadTipSetMessage(ctx, ts)
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("loading messages of tipset %s: %v", ts.Key(), err)
+	}
+
+	// the vm identifies messages by their unsigned cid.
+	chainMsgs := make(map[cid.Cid]types.ChainMsg)
+	for _, blk := range blkMsgs {
+		for _, m := range append(blk.BlsMessages, blk.SecpkMessages...) {
+			chainMsgs[m.VMMessage().Cid()] = m
+		}
+	}
+
+	vmOption := ea.vmOption(ts, ts.At(0).ParentStateRoot, ts.Height())
+	root, _, err := ea.chain.Processor.ProcessTipSet(ctx, pts, ts, blkMsgs, vmOption, func(mcid cid.Cid, _ vm.VmMessage, ret *vm.Ret) error {
+		return cb(mcid, chainMsgs[mcid], ret)
+	})
+	return root, err
+}
+
+func (ea *executionAPI) vmOption(ts *types.TipSet, root cid.Cid, epoch abi.ChainEpoch) vm.VmOption {
+	chainReader := ea.chain.ChainReader
+	return vm.VmOption{
+		CircSupplyCalculator: func(ctx context.Context, epoch abi.ChainEpoch, tree tree.Tree) (abi.TokenAmount, error) {
+			dertail, err := chainReader.GetCirculatingSupplyDetailed(ctx, epoch, tree)
+			if err != nil {
+				return abi.TokenAmount{}, err
+			}
+			return dertail.FilCirculating, nil
+		},
+		NtwkVersionGetter: ea.chain.Fork.GetNtwkVersion,
+		Rnd: &consensus.HeadRandomness{
+			Chain: chainReader,
+			Head:  ts.Key(),
+		},
+		BaseFee:          ts.At(0).ParentBaseFee,
+		Fork:             ea.chain.Fork,
+		Epoch:            epoch,
+		GasPriceSchedule: ea.gasPriceSchedule,
+		Bsstore:          chainReader.Blockstore(),
+		PRoot:            root,
+		SysCallsImpl:     ea.chain.SystemCall,
+		Tracing:          true,
+	}
+}
+
+func invocResult(mcid cid.Cid, implicit bool, ret *vm.Ret) *apitypes.InvocResult {
+	trace := ret.GasTracker.ExecutionTrace
+	if trace.MsgRct == nil {
+		trace.MsgRct = &ret.Receipt
+	}
+
+	res := &apitypes.InvocResult{
+		MsgCid:         mcid,
+		Msg:            trace.Msg,
+		MsgRct:         &ret.Receipt,
+		ExecutionTrace: trace,
+		Error:          trace.Error,
+		Duration:       trace.Duration,
+	}
+	// implicit messages pay no gas.
+	if !implicit && trace.Msg != nil {
+		res.GasCost = apitypes.MsgGasCost{
+			Message:            mcid,
+			GasUsed:            big.NewInt(ret.Receipt.GasUsed),
+			BaseFeeBurn:        ret.OutPuts.BaseFeeBurn,
+			OverEstimationBurn: ret.OutPuts.OverEstimationBurn,
+			MinerPenalty:       ret.OutPuts.MinerPenalty,
+			MinerTip:           ret.OutPuts.MinerTip,
+			Refund:             ret.OutPuts.Refund,
+			TotalCost:          big.Sub(trace.Msg.RequiredFunds(), ret.OutPuts.Refund),
+		}
+	}
+	return res
+}
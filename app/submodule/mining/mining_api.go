@@ -205,12 +205,23 @@ func (miningAPI *MiningAPI) minerCreateBlock(ctx context.Context, bt *apitypes.B
 		ParentMessageReceipts: receiptCid,
 	}
 
+	msgs := bt.Messages
+	if bt.SelectionStrategy != "" {
+		if len(msgs) > 0 {
+			return nil, xerrors.Errorf("a block template with a selection strategy cannot have messages")
+		}
+		msgs, err = miningAPI.Ming.MpoolModule.MPool.SelectMessagesWith(ctx, pts, bt.Ticket.Quality(), bt.SelectionStrategy)
+		if err != nil {
+			return nil, xerrors.Errorf("selecting messages: %v", err)
+		}
+	}
+
 	var blsMessages []*types.UnsignedMessage
 	var secpkMessages []*types.SignedMessage
 
 	var blsMsgCids, secpkMsgCids []cid.Cid
 	var blsSigs []crypto.Signature
-	for _, msg := range bt.Messages {
+	for _, msg := range msgs {
 		if msg.Signature.Type == crypto.SigTypeBLS {
 			blsSigs = append(blsSigs, msg.Signature)
 			blsMessages = append(blsMessages, &msg.Message)
@@ -4,6 +4,7 @@ import (
 	"github.com/filecoin-project/venus/app/submodule/apiface"
 	"github.com/filecoin-project/venus/app/submodule/blockstore"
 	chain2 "github.com/filecoin-project/venus/app/submodule/chain"
+	"github.com/filecoin-project/venus/app/submodule/mpool"
 	"github.com/filecoin-project/venus/app/submodule/network"
 	"github.com/filecoin-project/venus/app/submodule/syncer"
 	"github.com/filecoin-project/venus/app/submodule/wallet"
@@ -22,6 +23,7 @@ type MiningModule struct { //nolint
 	BlockStore    *blockstore.BlockstoreSubmodule
 	NetworkModule *network.NetworkSubmodule
 	SyncModule    *syncer.SyncerSubmodule
+	MpoolModule   *mpool.MessagePoolSubmodule
 	Wallet        wallet.WalletSubmodule
 	proofVerifier ffiwrapper.Verifier
 }
@@ -42,6 +44,7 @@ func NewMiningModule(
 	blockStore *blockstore.BlockstoreSubmodule,
 	networkModule *network.NetworkSubmodule,
 	syncModule *syncer.SyncerSubmodule,
+	mpoolModule *mpool.MessagePoolSubmodule,
 	wallet wallet.WalletSubmodule,
 	proofVerifier ffiwrapper.Verifier,
 ) *MiningModule {
@@ -51,6 +54,7 @@ func NewMiningModule(
 		BlockStore:    blockStore,
 		NetworkModule: networkModule,
 		SyncModule:    syncModule,
+		MpoolModule:   mpoolModule,
 		Wallet:        wallet,
 		proofVerifier: proofVerifier,
 	}
@@ -1,6 +1,7 @@
 package mpool
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 
@@ -55,6 +56,21 @@ func (a *MessagePoolAPI) MpoolSetConfig(ctx context.Context, cfg *messagepool.Mp
 	return a.mp.MPool.SetConfig(cfg)
 }
 
+// MpoolGetPolicies returns (a copy of) the policies of the mpool
+func (a *MessagePoolAPI) MpoolGetPolicies(context.Context) ([]*messagepool.MpoolPolicy, error) {
+	return a.mp.MPool.GetPolicies(), nil
+}
+
+// MpoolSetPolicy adds the policy, replacing the policy with the same scope and address
+func (a *MessagePoolAPI) MpoolSetPolicy(ctx context.Context, policy *messagepool.MpoolPolicy) error {
+	return a.mp.MPool.SetPolicy(policy)
+}
+
+// MpoolRemovePolicy removes the policy of the scope and address, it returns false if there is none
+func (a *MessagePoolAPI) MpoolRemovePolicy(ctx context.Context, scope messagepool.PolicyScope, addr address.Address) (bool, error) {
+	return a.mp.MPool.RemovePolicy(scope, addr)
+}
+
 // MpoolSelect returns a list of pending messages for inclusion in the next block
 func (a *MessagePoolAPI) MpoolSelect(ctx context.Context, tsk types.TipSetKey, ticketQuality float64) ([]*types.SignedMessage, error) {
 	ts, err := a.mp.chain.API().ChainGetTipSet(ctx, tsk)
@@ -65,6 +81,17 @@ func (a *MessagePoolAPI) MpoolSelect(ctx context.Context, tsk types.TipSetKey, t
 	return a.mp.MPool.SelectMessages(ctx, ts, ticketQuality)
 }
 
+// MpoolSelectDebug runs the message selection for the tipset without side effects and reports why
+// the messages not selected were left out
+func (a *MessagePoolAPI) MpoolSelectDebug(ctx context.Context, tsk types.TipSetKey, ticketQuality float64) (*messagepool.SelectionReport, error) {
+	ts, err := a.mp.chain.API().ChainGetTipSet(ctx, tsk)
+	if err != nil {
+		return nil, xerrors.Errorf("loading tipset %s: %w", tsk, err)
+	}
+
+	return a.mp.MPool.SelectMessagesDebug(ctx, ts, ticketQuality)
+}
+
 //MpoolSelects The batch selection message is used when multiple blocks need to select messages at the same time
 func (a *MessagePoolAPI) MpoolSelects(ctx context.Context, tsk types.TipSetKey, ticketQualitys []float64) ([][]*types.SignedMessage, error) {
 	ts, err := a.mp.chain.API().ChainGetTipSet(ctx, tsk)
@@ -268,21 +295,143 @@ func (a *MessagePoolAPI) MpoolBatchPushMessage(ctx context.Context, msgs []*type
 	return smsgs, nil
 }
 
+// MpoolAtomicBatchPushMessage reserves a range of contiguous nonces for the messages of a sender,
+// estimates their gas together, signs them and pushes them to mempool, either all of them or none.
+// maxFee of the spec applies to each message.
+func (a *MessagePoolAPI) MpoolAtomicBatchPushMessage(ctx context.Context, msgs []*types.UnsignedMessage, spec *types.MessageSendSpec) ([]*types.SignedMessage, error) {
+	if len(msgs) == 0 {
+		return nil, xerrors.New("no message to push")
+	}
+
+	from := msgs[0].From
+	cpys := make([]*types.UnsignedMessage, 0, len(msgs))
+	for i, msg := range msgs {
+		if msg.From != from {
+			return nil, xerrors.Errorf("message %d is from %s, all the messages must be from %s", i, msg.From, from)
+		}
+		if msg.Nonce != 0 {
+			return nil, xerrors.Errorf("MpoolAtomicBatchPushMessage expects message nonces to be 0, message %d was %d", i, msg.Nonce)
+		}
+		cp := *msg
+		cpys = append(cpys, &cp)
+	}
+
+	fromA, err := a.mp.chain.API().StateAccountKey(ctx, from, types.EmptyTSK)
+	if err != nil {
+		return nil, xerrors.Errorf("getting key address: %w", err)
+	}
+	{
+		done, err := a.pushLocks.TakeLock(ctx, fromA)
+		if err != nil {
+			return nil, xerrors.Errorf("taking lock: %w", err)
+		}
+		defer done()
+	}
+
+	if from.Protocol() == address.ID {
+		log.Warnf("Push from ID address (%s), adjusting to %s", from, fromA)
+	}
+	total := big.Zero()
+	estimates := make([]*types.EstimateMessage, 0, len(cpys))
+	for _, msg := range cpys {
+		msg.From = fromA
+		total = big.Add(total, msg.Value)
+		estimates = append(estimates, &types.EstimateMessage{Msg: msg, Spec: spec})
+	}
+
+	b, err := a.mp.walletAPI.WalletBalance(ctx, fromA)
+	if err != nil {
+		return nil, xerrors.Errorf("mpool push: getting origin balance: %w", err)
+	}
+	if b.LessThan(total) {
+		return nil, xerrors.Errorf("mpool push: not enough funds: %s < %s", b, total)
+	}
+
+	estimate := func(nonce uint64) error {
+		res, err := a.GasBatchEstimateMessageGas(ctx, estimates, nonce, types.EmptyTSK)
+		if err != nil {
+			return xerrors.Errorf("GasBatchEstimateMessageGas error: %w", err)
+		}
+		for i, r := range res {
+			if r.Err != "" {
+				return xerrors.Errorf("estimating message %d: %s", i, r.Err)
+			}
+			if r.Msg.GasPremium.GreaterThan(r.Msg.GasFeeCap) {
+				return xerrors.Errorf("after estimation, GasPremium of message %d is greater than GasFeeCap: %s > %s",
+					i, r.Msg.GasPremium, r.Msg.GasFeeCap)
+			}
+		}
+		return nil
+	}
+
+	// Sign and push the messages
+	return a.mp.msgSigner.SignMessages(ctx, cpys, estimate, func(smsgs []*types.SignedMessage) error {
+		if _, err := a.mp.MPool.PushBatch(ctx, smsgs); err != nil {
+			return xerrors.Errorf("mpool push: failed to push messages: %w", err)
+		}
+		return nil
+	})
+}
+
 // MpoolGetNonce gets next nonce for the specified sender.
 // Note that this method may not be atomic. Use MpoolPushMessage instead.
 func (a *MessagePoolAPI) MpoolGetNonce(ctx context.Context, addr address.Address) (uint64, error) {
 	return a.mp.MPool.GetNonce(ctx, addr, types.EmptyTSK)
 }
 
+// MpoolFixNonce fills the nonce gaps between the state nonce and the pending messages of the local
+// sender with re-signed dropped local messages or self-sends, dryRun only reports the messages.
+func (a *MessagePoolAPI) MpoolFixNonce(ctx context.Context, from address.Address, spec *types.MessageSendSpec, dryRun bool) (*messagepool.NonceFix, error) {
+	return a.mp.MPool.FixNonceGaps(ctx, from, spec, dryRun)
+}
+
 func (a *MessagePoolAPI) MpoolSub(ctx context.Context) (<-chan messagepool.MpoolUpdate, error) {
 	return a.mp.MPool.Updates(ctx)
 }
 
+// MpoolExport returns the pending messages, only the messages of the local addresses with local,
+// in the format of an export file.
+func (a *MessagePoolAPI) MpoolExport(ctx context.Context, local bool, format messagepool.ExportFormat) ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := messagepool.WriteMessages(buf, a.mp.MPool.Export(ctx, local), format); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// MpoolImport adds the messages of an export file to the pool as messages from the network.
+func (a *MessagePoolAPI) MpoolImport(ctx context.Context, data []byte) (*messagepool.ImportReport, error) {
+	msgs, err := messagepool.ReadMessages(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return a.mp.MPool.Import(ctx, msgs), nil
+}
+
+// MpoolMessageHistory returns the lifecycle of a message seen by the pool, when the message history
+// is enabled.
+func (a *MessagePoolAPI) MpoolMessageHistory(ctx context.Context, c cid.Cid) (*messagepool.MsgHistory, error) {
+	if a.mp.history == nil {
+		return nil, xerrors.New("message history is disabled, set mpool.history.enable to record it")
+	}
+	return a.mp.history.Get(ctx, c)
+}
+
+// MpoolSubFiltered streams the additions to and removals from the pool matching the filter.
+func (a *MessagePoolAPI) MpoolSubFiltered(ctx context.Context, filter *messagepool.MpoolUpdateFilter) (<-chan messagepool.MpoolUpdate, error) {
+	return a.mp.MPool.UpdatesFiltered(ctx, filter)
+}
+
 // GasEstimateMessageGas estimates gas values for unset message gas fields
 func (a *MessagePoolAPI) GasEstimateMessageGas(ctx context.Context, msg *types.UnsignedMessage, spec *types.MessageSendSpec, tsk types.TipSetKey) (*types.UnsignedMessage, error) {
 	return a.mp.MPool.GasEstimateMessageGas(ctx, &types.EstimateMessage{Msg: msg, Spec: spec}, tsk)
 }
 
+// GasEstimateMessageGasExplain estimates gas values for unset message gas fields and explains the estimates
+func (a *MessagePoolAPI) GasEstimateMessageGasExplain(ctx context.Context, msg *types.UnsignedMessage, spec *types.MessageSendSpec, tsk types.TipSetKey) (*messagepool.GasEstimateReport, error) {
+	return a.mp.MPool.GasEstimateMessageGasExplain(ctx, &types.EstimateMessage{Msg: msg, Spec: spec}, tsk)
+}
+
 func (a *MessagePoolAPI) GasBatchEstimateMessageGas(ctx context.Context, estimateMessages []*types.EstimateMessage, fromNonce uint64, tsk types.TipSetKey) ([]*types.EstimateResult, error) {
 	return a.mp.MPool.GasBatchEstimateMessageGas(ctx, estimateMessages, fromNonce, tsk)
 }
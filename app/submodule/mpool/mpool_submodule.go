@@ -60,6 +60,7 @@ type MessagePoolSubmodule struct { //nolint
 
 	MPool      *messagepool.MessagePool
 	msgSigner  *messagesigner.MessageSigner
+	history    *messagepool.MessageHistory // nil unless the message history is enabled
 	chain      *chain.ChainSubmodule
 	network    *network.NetworkSubmodule
 	walletAPI  apiface.IWallet
@@ -104,13 +105,31 @@ func NewMpoolSubmodule(cfg messagepoolConfig,
 		return nil, xerrors.Errorf("failed to register message validator: %s", err)
 	}
 
+	msgSigner := messagesigner.NewMessageSigner(wallet.Wallet, mp, cfg.Repo().MetaDatastore())
+	// stuck local messages are replaced through the signer when AutoReplace is set
+	mp.SetResigner(msgSigner)
+	// the messages of threshold keys approved after their push gave up waiting are pushed here
+	if thresholdBackend, err := wallet.Wallet.ThresholdBacked(); err == nil {
+		thresholdBackend.OnApprovedMessage(func(smsg *types.SignedMessage) {
+			if _, err := mp.Push(context.TODO(), smsg); err != nil {
+				log.Errorf("failed to push approved message %s: %s", smsg.Cid(), err)
+			}
+		})
+	}
+
+	var history *messagepool.MessageHistory
+	if hcfg := cfg.Repo().Config().Mpool.History; hcfg != nil && hcfg.Enable {
+		history = messagepool.NewMessageHistory(cfg.Repo().MetaDatastore(), chain.ChainReader, chain.MessageStore, hcfg.MaxAge)
+	}
+
 	return &MessagePoolSubmodule{
 		MPool:      mp,
 		chain:      chain,
 		walletAPI:  wallet.API(),
 		network:    network,
 		networkCfg: networkCfg,
-		msgSigner:  messagesigner.NewMessageSigner(wallet.Wallet, mp, cfg.Repo().MetaDatastore()),
+		msgSigner:  msgSigner,
+		history:    history,
 	}, nil
 }
 
@@ -178,6 +197,12 @@ func (mp *MessagePoolSubmodule) validateLocalMessage(ctx context.Context, msg pu
 
 // Start to the message pubsub topic to learn about messages to mine into blocks.
 func (mp *MessagePoolSubmodule) Start(ctx context.Context) error {
+	if mp.history != nil {
+		if err := mp.history.Start(ctx, mp.MPool); err != nil {
+			return xerrors.Errorf("starting message history: %w", err)
+		}
+	}
+
 	//setup topic
 	topic, err := mp.network.Pubsub.Join(msgsub.Topic(mp.network.NetworkName))
 	if err != nil {
@@ -252,6 +277,9 @@ func (mp *MessagePoolSubmodule) waitForSync(epochs int, subscribe func()) {
 }
 
 func (mp *MessagePoolSubmodule) Stop(ctx context.Context) {
+	if mp.history != nil {
+		mp.history.Close()
+	}
 	err := mp.MPool.Close()
 	if err != nil {
 		log.Errorf("failed to close mpool: %s", err)
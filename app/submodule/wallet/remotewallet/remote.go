@@ -11,7 +11,7 @@ import (
 	"github.com/filecoin-project/venus/pkg/wallet"
 )
 
-var _ wallet.WalletIntersection = &remoteWallet{}
+var _ wallet.RemoteWallet = &remoteWallet{}
 
 type remoteWallet struct {
 	IWallet
@@ -26,11 +26,19 @@ func (w *remoteWallet) Addresses() []address.Address {
 	return wallets
 }
 
+func (w *remoteWallet) ListAddresses(ctx context.Context) ([]address.Address, error) {
+	return w.IWallet.WalletList(ctx)
+}
+
+func (w *remoteWallet) Close() {
+	w.Cancel()
+}
+
 func (w *remoteWallet) HasPassword() bool {
 	return true
 }
 
-func SetupRemoteWallet(info string) (wallet.WalletIntersection, error) {
+func SetupRemoteWallet(info string) (wallet.RemoteWallet, error) {
 	ai, err := ParseAPIInfo(info)
 	if err != nil {
 		return nil, err
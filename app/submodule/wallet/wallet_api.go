@@ -2,6 +2,7 @@ package wallet
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"strings"
 
@@ -10,6 +11,7 @@ import (
 	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/venus/app/submodule/apiface"
+	pconfig "github.com/filecoin-project/venus/pkg/config"
 	"github.com/filecoin-project/venus/pkg/crypto"
 	"github.com/filecoin-project/venus/pkg/types"
 	"github.com/filecoin-project/venus/pkg/wallet"
@@ -69,6 +71,16 @@ func (walletAPI *WalletAPI) WalletAddresses(ctx context.Context) []address.Addre
 	return walletAPI.adapter.Addresses()
 }
 
+// WalletAddressBackends returns the addresses of the wallet with the local or remote backends owning them.
+func (walletAPI *WalletAPI) WalletAddressBackends(ctx context.Context) ([]wallet.AddressBackend, error) {
+	return walletAPI.walletModule.Wallet.AddressBackends(), nil
+}
+
+// WalletBackends returns the state of the local backend and of the remote wallets.
+func (walletAPI *WalletAPI) WalletBackends(ctx context.Context) ([]wallet.BackendStatus, error) {
+	return walletAPI.walletModule.Wallet.BackendStatuses(), nil
+}
+
 // SetWalletDefaultAddress set the specified address as the default in the config.
 func (walletAPI *WalletAPI) WalletSetDefault(ctx context.Context, addr address.Address) error {
 	localAddrs := walletAPI.WalletAddresses(ctx)
@@ -89,6 +101,23 @@ func (walletAPI *WalletAPI) WalletNewAddress(protocol address.Protocol) (address
 	return walletAPI.adapter.NewAddress(protocol)
 }
 
+// WalletNewHDAddress derives the next address of the protocol from the mnemonic of the local wallet,
+// the mnemonic is generated with the first HD address.
+func (walletAPI *WalletAPI) WalletNewHDAddress(ctx context.Context, protocol address.Protocol) (address.Address, error) {
+	return walletAPI.walletModule.Wallet.NewHDAddress(protocol)
+}
+
+// WalletExportMnemonic returns the mnemonic of the local wallet, decrypted with the wallet password.
+func (walletAPI *WalletAPI) WalletExportMnemonic(ctx context.Context, password string) (string, error) {
+	return walletAPI.walletModule.Wallet.ExportMnemonic([]byte(password))
+}
+
+// WalletRestore restores the first count addresses of the protocol derived from the mnemonic in the
+// local wallet.
+func (walletAPI *WalletAPI) WalletRestore(ctx context.Context, mnemonic string, protocol address.Protocol, count int) ([]address.Address, error) {
+	return walletAPI.walletModule.Wallet.RestoreMnemonic(mnemonic, protocol, count)
+}
+
 // WalletImport adds a given set of KeyInfos to the walletModule
 func (walletAPI *WalletAPI) WalletImport(key *crypto.KeyInfo) (address.Address, error) {
 	addr, err := walletAPI.adapter.Import(key)
@@ -124,7 +153,10 @@ func (walletAPI *WalletAPI) WalletSignMessage(ctx context.Context, k address.Add
 		return nil, xerrors.Errorf("serializing message: %w", err)
 	}
 
-	sign, err := walletAPI.WalletSign(ctx, k, mb.Cid().Bytes(), wallet.MsgMeta{Type: wallet.MTChainMsg})
+	sign, err := walletAPI.WalletSign(ctx, k, mb.Cid().Bytes(), wallet.MsgMeta{
+		Type:  wallet.MTChainMsg,
+		Extra: mb.RawData(),
+	})
 	if err != nil {
 		return nil, xerrors.Errorf("failed to sign message: %w", err)
 	}
@@ -135,6 +167,93 @@ func (walletAPI *WalletAPI) WalletSignMessage(ctx context.Context, k address.Add
 	}, nil
 }
 
+// WalletPolicies returns the signing policies of the local wallet.
+func (walletAPI *WalletAPI) WalletPolicies(ctx context.Context) ([]*wallet.SigningPolicy, error) {
+	return walletAPI.walletModule.Wallet.SigningPolicies().List(), nil
+}
+
+// WalletSetPolicy adds or replaces the signing policy of an address of the local wallet.
+func (walletAPI *WalletAPI) WalletSetPolicy(ctx context.Context, policy *wallet.SigningPolicy) error {
+	keyAddr, err := walletAPI.resolveKeyAddr(ctx, policy.Address)
+	if err != nil {
+		return err
+	}
+	p := *policy
+	p.Address = keyAddr
+	return walletAPI.walletModule.Wallet.SigningPolicies().Set(&p)
+}
+
+// WalletRemovePolicy removes the signing policy of an address of the local wallet, it returns
+// false if there is none.
+func (walletAPI *WalletAPI) WalletRemovePolicy(ctx context.Context, addr address.Address) (bool, error) {
+	keyAddr, err := walletAPI.resolveKeyAddr(ctx, addr)
+	if err != nil {
+		return false, err
+	}
+	return walletAPI.walletModule.Wallet.SigningPolicies().Remove(keyAddr)
+}
+
+// WalletNewThresholdAddress generates a secp256k1 key split in a share per approver, threshold of the approvers approve its signatures.
+func (walletAPI *WalletAPI) WalletNewThresholdAddress(ctx context.Context, threshold int, approvers []wallet.ThresholdApprover) (address.Address, error) {
+	backend, err := walletAPI.walletModule.Wallet.ThresholdBacked()
+	if err != nil {
+		return address.Undef, err
+	}
+	return backend.NewAddress(threshold, approvers)
+}
+
+// WalletThresholdKeys returns the threshold keys of the wallet.
+func (walletAPI *WalletAPI) WalletThresholdKeys(ctx context.Context) ([]*wallet.ThresholdKeyInfo, error) {
+	backend, err := walletAPI.walletModule.Wallet.ThresholdBacked()
+	if err != nil {
+		return nil, err
+	}
+	return backend.Keys(), nil
+}
+
+// WalletApprovals returns the requests to sign with the threshold keys, the oldest first.
+func (walletAPI *WalletAPI) WalletApprovals(ctx context.Context) ([]*wallet.SignRequest, error) {
+	backend, err := walletAPI.walletModule.Wallet.ThresholdBacked()
+	if err != nil {
+		return nil, err
+	}
+	return backend.Requests(), nil
+}
+
+// WalletApprove approves a sign request with the share of the approver decrypted with password.
+func (walletAPI *WalletAPI) WalletApprove(ctx context.Context, id uint64, approver string, password string) error {
+	backend, err := walletAPI.walletModule.Wallet.ThresholdBacked()
+	if err != nil {
+		return err
+	}
+	return backend.Approve(id, approver, []byte(password))
+}
+
+// WalletReject rejects a sign request as the approver, authenticated with the password of its share.
+func (walletAPI *WalletAPI) WalletReject(ctx context.Context, id uint64, approver string, password string) error {
+	backend, err := walletAPI.walletModule.Wallet.ThresholdBacked()
+	if err != nil {
+		return err
+	}
+	return backend.Reject(id, approver, []byte(password))
+}
+
+func (walletAPI *WalletAPI) resolveKeyAddr(ctx context.Context, addr address.Address) (address.Address, error) {
+	if addr.Protocol() != address.ID {
+		return addr, nil
+	}
+	head := walletAPI.walletModule.Chain.ChainReader.GetHead()
+	view, err := walletAPI.walletModule.Chain.ChainReader.StateView(head)
+	if err != nil {
+		return address.Undef, err
+	}
+	keyAddr, err := view.ResolveToKeyAddr(ctx, addr)
+	if err != nil {
+		return address.Undef, xerrors.Errorf("failed to resolve ID address %s: %w", addr, err)
+	}
+	return keyAddr, nil
+}
+
 //LockWallet lock wallet
 func (walletAPI *WalletAPI) LockWallet(ctx context.Context) error {
 	return walletAPI.walletModule.Wallet.LockWallet()
@@ -150,6 +269,36 @@ func (walletAPI *WalletAPI) SetPassword(Context context.Context, password []byte
 	return walletAPI.walletModule.Wallet.SetPassword(password)
 }
 
+// WalletChangePassword re-encrypts the keys of the local wallet with the new password, and the scrypt
+// parameters of passphraseCfg if it is not nil, which are then saved in the config.
+func (walletAPI *WalletAPI) WalletChangePassword(ctx context.Context, oldPassword, newPassword []byte, passphraseCfg *pconfig.PassphraseConfig) error {
+	if err := walletAPI.walletModule.Wallet.ChangePassword(oldPassword, newPassword, passphraseCfg); err != nil {
+		return err
+	}
+	if passphraseCfg == nil {
+		return nil
+	}
+	cfgJSON, err := json.Marshal(passphraseCfg)
+	if err != nil {
+		return err
+	}
+	if err := walletAPI.walletModule.Config.Set("walletModule.passphraseConfig", string(cfgJSON)); err != nil {
+		return xerrors.Errorf("the password is changed but saving the passphrase config failed: %w", err)
+	}
+	return nil
+}
+
+// WalletExportKeyStore exports all the keys of the local wallet, decrypted with the wallet password and
+// encrypted with the export password.
+func (walletAPI *WalletAPI) WalletExportKeyStore(ctx context.Context, password, exportPassword string) (*wallet.KeyStore, error) {
+	return walletAPI.walletModule.Wallet.ExportKeyStore([]byte(password), []byte(exportPassword))
+}
+
+// WalletImportKeyStore imports the keys of a key store decrypted with the export password in the local wallet.
+func (walletAPI *WalletAPI) WalletImportKeyStore(ctx context.Context, ks *wallet.KeyStore, exportPassword string) ([]address.Address, error) {
+	return walletAPI.walletModule.Wallet.ImportKeyStore(ks, []byte(exportPassword))
+}
+
 //HasPassword return whether the wallet has password
 func (walletAPI *WalletAPI) HasPassword(Context context.Context) bool {
 	return walletAPI.adapter.HasPassword()
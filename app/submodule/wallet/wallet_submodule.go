@@ -2,6 +2,7 @@ package wallet
 
 import (
 	"context"
+	"time"
 
 	logging "github.com/ipfs/go-log"
 	"github.com/pkg/errors"
@@ -19,6 +20,9 @@ import (
 
 var log = logging.Logger("wallet")
 
+// legacyRemoteName is the name of the remote wallet of 'remoteBackend' in the config.
+const legacyRemoteName = "remote"
+
 // WalletSubmodule enhances the `Node` with a "wallet" and FIL transfer capabilities.
 type WalletSubmodule struct { //nolint
 	Chain   *chain.ChainSubmodule
@@ -26,6 +30,9 @@ type WalletSubmodule struct { //nolint
 	adapter wallet.WalletIntersection
 	Signer  types.Signer
 	Config  *config.ConfigModule
+
+	remoteCheckPeriod time.Duration
+	cancelChecks      context.CancelFunc
 }
 
 type walletRepo interface {
@@ -47,29 +54,97 @@ func NewWalletSubmodule(ctx context.Context,
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to set up walletModule backend")
 	}
-	fcWallet := wallet.New(backend)
+	thresholdBackend, err := wallet.NewThresholdBackend(repo.WalletDatastore(), passphraseCfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to set up threshold wallet backend")
+	}
+	if wait := repo.Config().Wallet.ApprovalWait; wait != "" {
+		if thresholdBackend.Wait, err = time.ParseDuration(wait); err != nil {
+			return nil, errors.Wrap(err, "invalid approval wait")
+		}
+	}
+	fcWallet := wallet.New(backend, thresholdBackend)
+	policies, err := wallet.NewSigningPolicies(repo.WalletDatastore())
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to load wallet signing policies")
+	}
+	fcWallet.SetSigningPolicies(policies)
 	headSigner := state.NewHeadSignView(chain.ChainReader)
 
-	var adapter wallet.WalletIntersection
-	if repo.Config().Wallet.RemoteEnable {
-		if repo.Config().Wallet.RemoteBackend == wallet.StringEmpty {
-			return nil, errors.New("remote backend is empty")
+	remoteCheckPeriod, err := setupRemoteWallets(fcWallet, repo.Config().Wallet)
+	if err != nil {
+		fcWallet.CloseRemotes()
+		return nil, err
+	}
+	// the remote wallets are first checked by Start, until then their addresses are asked on use.
+
+	return &WalletSubmodule{
+		Config:            cfg,
+		Chain:             chain,
+		Wallet:            fcWallet,
+		adapter:           fcWallet,
+		Signer:            state.NewSigner(headSigner, fcWallet),
+		remoteCheckPeriod: remoteCheckPeriod,
+	}, nil
+}
+
+// setupRemoteWallets adds the remote wallets of the config to the wallet, the one of 'remoteBackend'
+// is the default backend when it is enabled. It returns the period of their health checks.
+func setupRemoteWallets(w *wallet.Wallet, cfg *pconfig.WalletConfig) (time.Duration, error) {
+	remotes := cfg.RemoteBackends
+	defaultBackend := cfg.DefaultBackend
+	if cfg.RemoteEnable {
+		if cfg.RemoteBackend == wallet.StringEmpty {
+			return 0, errors.New("remote backend is empty")
 		}
-		adapter, err = remotewallet.SetupRemoteWallet(repo.Config().Wallet.RemoteBackend)
+		remotes = append([]*pconfig.RemoteWalletConfig{{Name: legacyRemoteName, API: cfg.RemoteBackend}}, remotes...)
+		if defaultBackend == "" {
+			defaultBackend = legacyRemoteName
+		}
+	}
+
+	for _, remote := range remotes {
+		rw, err := remotewallet.SetupRemoteWallet(remote.API)
 		if err != nil {
-			return nil, errors.Wrap(err, "failed to set up remote wallet")
+			return 0, errors.Wrapf(err, "failed to set up remote wallet %s", remote.Name)
+		}
+		if err := w.AddRemote(remote.Name, rw); err != nil {
+			rw.Close()
+			return 0, err
 		}
-		log.Info("remote wallet set up")
-	} else {
-		adapter = fcWallet
+		log.Infof("remote wallet %s set up", remote.Name)
 	}
-	return &WalletSubmodule{
-		Config:  cfg,
-		Chain:   chain,
-		Wallet:  fcWallet,
-		adapter: adapter,
-		Signer:  state.NewSigner(headSigner, fcWallet),
-	}, nil
+	if defaultBackend != "" {
+		if err := w.SetDefaultBackend(defaultBackend); err != nil {
+			return 0, err
+		}
+	}
+
+	if cfg.RemoteCheckPeriod == "" {
+		return wallet.DefaultRemoteCheckPeriod, nil
+	}
+	period, err := time.ParseDuration(cfg.RemoteCheckPeriod)
+	if err != nil {
+		return 0, errors.Wrap(err, "invalid remote check period")
+	}
+	if period <= 0 {
+		return 0, errors.Errorf("remote check period %s is not positive", period)
+	}
+	return period, nil
+}
+
+// Start runs the health checks of the remote wallets in the background.
+func (wallet *WalletSubmodule) Start(ctx context.Context) {
+	ctx, wallet.cancelChecks = context.WithCancel(ctx)
+	go wallet.Wallet.RunRemoteChecks(ctx, wallet.remoteCheckPeriod)
+}
+
+// Stop stops the health checks and closes the connections to the remote wallets.
+func (wallet *WalletSubmodule) Stop(ctx context.Context) {
+	if wallet.cancelChecks != nil {
+		wallet.cancelChecks()
+	}
+	wallet.Wallet.CloseRemotes()
 }
 
 //API create a new wallet api implement
@@ -7,8 +7,11 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/filecoin-project/go-address"
 	"github.com/howeyc/gopass"
@@ -18,6 +21,7 @@ import (
 	"github.com/filecoin-project/go-state-types/big"
 	"github.com/filecoin-project/venus/app/node"
 	"github.com/filecoin-project/venus/cmd/tablewriter"
+	"github.com/filecoin-project/venus/pkg/config"
 	"github.com/filecoin-project/venus/pkg/crypto"
 	"github.com/filecoin-project/venus/pkg/types"
 	"github.com/filecoin-project/venus/pkg/wallet"
@@ -31,16 +35,25 @@ var walletCmd = &cmds.Command{
 		Tagline: "Manage your filecoin wallets",
 	},
 	Subcommands: map[string]*cmds.Command{
-		"balance":      balanceCmd,
-		"import":       walletImportCmd,
-		"export":       walletExportCmd,
-		"ls":           addrsLsCmd,
-		"new":          addrsNewCmd,
-		"default":      defaultAddressCmd,
-		"set-default":  setDefaultAddressCmd,
-		"lock":         lockedCmd,
-		"unlock":       unlockedCmd,
-		"set-password": setWalletPassword,
+		"balance":         balanceCmd,
+		"import":          walletImportCmd,
+		"export":          walletExportCmd,
+		"ls":              addrsLsCmd,
+		"new":             addrsNewCmd,
+		"default":         defaultAddressCmd,
+		"set-default":     setDefaultAddressCmd,
+		"lock":            lockedCmd,
+		"unlock":          unlockedCmd,
+		"set-password":    setWalletPassword,
+		"policy":          walletPolicyCmd,
+		"mnemonic":        walletMnemonicCmd,
+		"restore":         walletRestoreCmd,
+		"change-password": walletChangePasswordCmd,
+		"export-all":      walletExportAllCmd,
+		"import-all":      walletImportAllCmd,
+		"backends":        walletBackendsCmd,
+		"threshold":       walletThresholdCmd,
+		"approvals":       walletApprovalsCmd,
 	},
 }
 
@@ -54,19 +67,22 @@ type AddressLsResult struct {
 }
 
 var addrsNewCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Create a new address",
+		ShortDescription: `
+With --hd the address is derived from the mnemonic of the wallet, generated with the first HD address.
+Back up the mnemonic with 'venus wallet mnemonic export', the HD addresses are restored from it with
+'venus wallet restore'.
+`,
+	},
 	Options: []cmds.Option{
 		cmds.StringOption("type", "The type of address to create: bls (default) or secp256k1").WithDefault("bls"),
+		cmds.BoolOption("hd", "Derive the address from the mnemonic of the wallet"),
 	},
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
-		protocolName := req.Options["type"].(string)
-		var protocol address.Protocol
-		switch protocolName {
-		case "secp256k1":
-			protocol = address.SECP256K1
-		case "bls":
-			protocol = address.BLS
-		default:
-			return fmt.Errorf("unrecognized address protocol %s", protocolName)
+		protocol, err := parseAddressProtocol(req.Options["type"].(string))
+		if err != nil {
+			return err
 		}
 
 		if !env.(*node.Env).WalletAPI.HasPassword(req.Context) {
@@ -76,7 +92,12 @@ var addrsNewCmd = &cmds.Command{
 			return errWalletLocked
 		}
 
-		addr, err := env.(*node.Env).WalletAPI.WalletNewAddress(protocol)
+		var addr address.Address
+		if hd, _ := req.Options["hd"].(bool); hd {
+			addr, err = env.(*node.Env).WalletAPI.WalletNewHDAddress(req.Context, protocol)
+		} else {
+			addr, err = env.(*node.Env).WalletAPI.WalletNewAddress(protocol)
+		}
 		if err != nil {
 			return err
 		}
@@ -85,6 +106,17 @@ var addrsNewCmd = &cmds.Command{
 	},
 }
 
+func parseAddressProtocol(name string) (address.Protocol, error) {
+	switch name {
+	case "secp256k1":
+		return address.SECP256K1, nil
+	case "bls":
+		return address.BLS, nil
+	default:
+		return 0, fmt.Errorf("unrecognized address protocol %s", name)
+	}
+}
+
 var addrsLsCmd = &cmds.Command{
 	Options: []cmds.Option{
 		cmds.BoolOption("addr-only", "Only print addresses"),
@@ -95,7 +127,10 @@ var addrsLsCmd = &cmds.Command{
 		api := env.(*node.Env)
 		ctx := req.Context
 
-		addrs := api.WalletAPI.WalletAddresses(req.Context)
+		addrs, err := api.WalletAPI.WalletAddressBackends(req.Context)
+		if err != nil {
+			return err
+		}
 
 		// Assume an error means no default key is set
 		def, _ := api.WalletAPI.WalletDefaultAddress(req.Context)
@@ -103,6 +138,7 @@ var addrsLsCmd = &cmds.Command{
 		buf := new(bytes.Buffer)
 		tw := tablewriter.New(
 			tablewriter.Col("Address"),
+			tablewriter.Col("Backend"),
 			tablewriter.Col("ID"),
 			tablewriter.Col("Balance"),
 			tablewriter.Col("Market(Avail)"),
@@ -115,7 +151,8 @@ var addrsLsCmd = &cmds.Command{
 		if _, ok := req.Options["addr-only"]; ok {
 			addrOnly = true
 		}
-		for _, addr := range addrs {
+		for _, owner := range addrs {
+			addr := owner.Address
 			if addrOnly {
 				writer := NewSilentWriter(buf)
 				writer.WriteStringln(addr.String())
@@ -125,6 +162,7 @@ var addrsLsCmd = &cmds.Command{
 					if !strings.Contains(err.Error(), "actor not found") {
 						tw.Write(map[string]interface{}{
 							"Address": addr,
+							"Backend": strings.Join(owner.Backends, ","),
 							"Error":   err,
 						})
 						continue
@@ -137,6 +175,7 @@ var addrsLsCmd = &cmds.Command{
 
 				row := map[string]interface{}{
 					"Address": addr,
+					"Backend": strings.Join(owner.Backends, ","),
 					"Balance": types.FIL(a.Balance),
 					"Nonce":   a.Nonce,
 				}
@@ -400,3 +439,589 @@ var unlockedCmd = &cmds.Command{
 		return re.Emit("unlocked success")
 	},
 }
+
+var walletPolicyCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Manage the signing policies of the local wallet",
+		ShortDescription: `
+Policies restrict what the wallet signs with an address: the allowed message types, the max value
+of the messages signed per day and the allowed target actors and methods of the messages.
+`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"list": walletPolicyListCmd,
+		"set":  walletPolicySetCmd,
+		"rm":   walletPolicyRemoveCmd,
+	},
+}
+
+var walletPolicyListCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the signing policies of the local wallet",
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		policies, err := env.(*node.Env).WalletAPI.WalletPolicies(req.Context)
+		if err != nil {
+			return err
+		}
+		return re.Emit(policies)
+	},
+}
+
+var walletPolicySetCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Add or replace the signing policy of an address",
+		ShortDescription: `
+The policy is given as json, it replaces the policy of the same address, eg.
+{"Address": "f1...", "AllowedTypes": ["message"], "MaxValuePerDay": "1000000000000000000", "AllowedMethods": [0]}
+MaxValuePerDay caps the value of the messages signed over 24 hours, their gas fees are not counted.
+`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("policy", true, false, "policy in json"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		policy := new(wallet.SigningPolicy)
+		if err := json.Unmarshal([]byte(req.Arguments[0]), policy); err != nil {
+			return fmt.Errorf("parsing policy: %w", err)
+		}
+		return env.(*node.Env).WalletAPI.WalletSetPolicy(req.Context, policy)
+	},
+}
+
+var walletPolicyRemoveCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Remove the signing policy of an address",
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("address", true, false, "address of the policy"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		addr, err := address.NewFromString(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		removed, err := env.(*node.Env).WalletAPI.WalletRemovePolicy(req.Context, addr)
+		if err != nil {
+			return err
+		}
+		if !removed {
+			return fmt.Errorf("no signing policy for %s", addr)
+		}
+		return nil
+	},
+}
+
+var walletMnemonicCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Manage the mnemonic of the HD addresses of the wallet",
+	},
+	Subcommands: map[string]*cmds.Command{
+		"export": walletMnemonicExportCmd,
+	},
+}
+
+var walletMnemonicExportCmd = &cmds.Command{
+	Extra: AdminExtra,
+	Helptext: cmds.HelpText{
+		Tagline: "Print the mnemonic of the HD addresses of the wallet",
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("password", false, false, "Password of the wallet"),
+	},
+	PreRun: func(req *cmds.Request, env cmds.Environment) error {
+		// for testing, skip manual password entry
+		if len(req.Arguments) == 1 && len(req.Arguments[0]) != 0 {
+			return nil
+		}
+		pw, err := gopass.GetPasswdPrompt("Password:", true, os.Stdin, os.Stdout)
+		if err != nil {
+			return err
+		}
+		req.Arguments = []string{string(pw)}
+
+		return nil
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		if env.(*node.Env).WalletAPI.WalletState(req.Context) == wallet.Lock {
+			return errWalletLocked
+		}
+		if len(req.Arguments) != 1 {
+			return re.Emit("A parameter is required.")
+		}
+
+		mnemonic, err := env.(*node.Env).WalletAPI.WalletExportMnemonic(req.Context, req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		return printOneString(re, mnemonic)
+	},
+}
+
+var walletRestoreCmd = &cmds.Command{
+	Extra: AdminExtra,
+	Helptext: cmds.HelpText{
+		Tagline: "Restore the HD addresses derived from a mnemonic",
+		ShortDescription: `
+Restores the first --count addresses of the type derived from the mnemonic, the mnemonic is prompted
+when it is not given. The mnemonic is kept to derive the next addresses with 'venus wallet new --hd'.
+`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("mnemonic", false, false, "mnemonic of the addresses"),
+	},
+	Options: []cmds.Option{
+		cmds.StringOption("type", "The type of the addresses: bls or secp256k1 (default)").WithDefault("secp256k1"),
+		cmds.IntOption("count", "Number of addresses to restore").WithDefault(1),
+	},
+	PreRun: func(req *cmds.Request, env cmds.Environment) error {
+		if len(req.Arguments) == 1 && len(req.Arguments[0]) != 0 {
+			return nil
+		}
+		mnemonic, err := gopass.GetPasswdPrompt("Mnemonic:", true, os.Stdin, os.Stdout)
+		if err != nil {
+			return err
+		}
+		req.Arguments = []string{string(mnemonic)}
+
+		return nil
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		protocol, err := parseAddressProtocol(req.Options["type"].(string))
+		if err != nil {
+			return err
+		}
+		if !env.(*node.Env).WalletAPI.HasPassword(req.Context) {
+			return errMissPassword
+		}
+		if env.(*node.Env).WalletAPI.WalletState(req.Context) == wallet.Lock {
+			return errWalletLocked
+		}
+		if len(req.Arguments) != 1 {
+			return re.Emit("A parameter is required.")
+		}
+
+		count, _ := req.Options["count"].(int)
+		addrs, err := env.(*node.Env).WalletAPI.WalletRestore(req.Context, req.Arguments[0], protocol, count)
+		if err != nil {
+			return err
+		}
+
+		buf := new(bytes.Buffer)
+		writer := NewSilentWriter(buf)
+		for _, addr := range addrs {
+			writer.Println(addr.String())
+		}
+		return re.Emit(buf)
+	},
+}
+
+// promptNewPassword prompts a new password twice.
+func promptNewPassword(prompt string) ([]byte, error) {
+	pw, err := gopass.GetPasswdPrompt(prompt, true, os.Stdin, os.Stdout)
+	if err != nil {
+		return nil, err
+	}
+	pw2, err := gopass.GetPasswdPrompt("Enter "+strings.TrimSuffix(prompt, ":")+" again:", true, os.Stdin, os.Stdout)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(pw, pw2) {
+		return nil, errors.New("the input passwords are inconsistent")
+	}
+	return pw, nil
+}
+
+var walletChangePasswordCmd = &cmds.Command{
+	Extra: AdminExtra,
+	Helptext: cmds.HelpText{
+		Tagline: "Change the password of the wallet",
+		ShortDescription: `
+Re-encrypts all the keys of the wallet with the new password, and the new scrypt parameters when
+--scrypt-n or --scrypt-p is set. The keys are all re-encrypted or none of them.
+`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("old-password", false, false, "current password of the wallet"),
+		cmds.StringArg("new-password", false, false, "new password of the wallet"),
+	},
+	Options: []cmds.Option{
+		cmds.IntOption("scrypt-n", "scrypt N parameter of the encryption of the keys, a power of 2"),
+		cmds.IntOption("scrypt-p", "scrypt P parameter of the encryption of the keys"),
+	},
+	PreRun: func(req *cmds.Request, env cmds.Environment) error {
+		// for testing, skip manual password entry
+		if len(req.Arguments) == 2 {
+			return nil
+		}
+		old, err := gopass.GetPasswdPrompt("Password:", true, os.Stdin, os.Stdout)
+		if err != nil {
+			return err
+		}
+		pw, err := promptNewPassword("New password:")
+		if err != nil {
+			return err
+		}
+		req.Arguments = []string{string(old), string(pw)}
+
+		return nil
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		if len(req.Arguments) != 2 {
+			return re.Emit("Two parameter is required.")
+		}
+
+		var passphraseCfg *config.PassphraseConfig
+		scryptN, hasN := req.Options["scrypt-n"].(int)
+		scryptP, hasP := req.Options["scrypt-p"].(int)
+		if hasN || hasP {
+			cfg, err := env.(*node.Env).ConfigAPI.ConfigGet(req.Context, "walletModule.passphraseConfig")
+			if err != nil {
+				return err
+			}
+			cfgJSON, err := json.Marshal(cfg)
+			if err != nil {
+				return err
+			}
+			passphraseCfg = new(config.PassphraseConfig)
+			if err := json.Unmarshal(cfgJSON, passphraseCfg); err != nil {
+				return err
+			}
+			if hasN {
+				passphraseCfg.ScryptN = scryptN
+			}
+			if hasP {
+				passphraseCfg.ScryptP = scryptP
+			}
+		}
+
+		err := env.(*node.Env).WalletAPI.WalletChangePassword(req.Context, []byte(req.Arguments[0]), []byte(req.Arguments[1]), passphraseCfg)
+		if err != nil {
+			return err
+		}
+		return printOneString(re, "Password changed successfully \n"+
+			"You must REMEMBER your password! Without the password, it's impossible to decrypt the key!")
+	},
+}
+
+var walletExportAllCmd = &cmds.Command{
+	Extra: AdminExtra,
+	Helptext: cmds.HelpText{
+		Tagline: "Export all the keys of the wallet, encrypted with an export password",
+		ShortDescription: `
+The key store holds the keys and the mnemonic of the wallet, it is imported in another wallet with
+'venus wallet import-all'.
+`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("file", true, false, "file to write the key store to"),
+		cmds.StringArg("password", false, false, "password of the wallet"),
+		cmds.StringArg("export-password", false, false, "password of the key store"),
+	},
+	PreRun: func(req *cmds.Request, env cmds.Environment) error {
+		// for testing, skip manual password entry
+		if len(req.Arguments) == 3 {
+			return nil
+		}
+		pw, err := gopass.GetPasswdPrompt("Password:", true, os.Stdin, os.Stdout)
+		if err != nil {
+			return err
+		}
+		exportPw, err := promptNewPassword("Export password:")
+		if err != nil {
+			return err
+		}
+		req.Arguments = []string{req.Arguments[0], string(pw), string(exportPw)}
+
+		return nil
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		if len(req.Arguments) != 3 {
+			return re.Emit("Three parameter is required.")
+		}
+
+		ks, err := env.(*node.Env).WalletAPI.WalletExportKeyStore(req.Context, req.Arguments[1], req.Arguments[2])
+		if err != nil {
+			return err
+		}
+		ksJSON, err := json.Marshal(ks)
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(req.Arguments[0], ksJSON, 0600); err != nil {
+			return err
+		}
+		return printOneString(re, fmt.Sprintf("key store written to %s", req.Arguments[0]))
+	},
+}
+
+var walletImportAllCmd = &cmds.Command{
+	Extra: AdminExtra,
+	Helptext: cmds.HelpText{
+		Tagline: "Import the keys of a key store exported with 'venus wallet export-all'",
+	},
+	Arguments: []cmds.Argument{
+		cmds.FileArg("file", true, false, "file of the key store").EnableStdin(),
+		cmds.StringArg("export-password", false, false, "password of the key store"),
+	},
+	PreRun: func(req *cmds.Request, env cmds.Environment) error {
+		if len(req.Arguments) == 1 {
+			return nil
+		}
+		pw, err := gopass.GetPasswdPrompt("Export password:", true, os.Stdin, os.Stdout)
+		if err != nil {
+			return err
+		}
+		req.Arguments = []string{string(pw)}
+
+		return nil
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		if !env.(*node.Env).WalletAPI.HasPassword(req.Context) {
+			return errMissPassword
+		}
+		if env.(*node.Env).WalletAPI.WalletState(req.Context) == wallet.Lock {
+			return errWalletLocked
+		}
+		if len(req.Arguments) != 1 {
+			return re.Emit("A parameter is required.")
+		}
+		iter := req.Files.Entries()
+		if !iter.Next() {
+			return fmt.Errorf("no file given: %s", iter.Err())
+		}
+		fi, ok := iter.Node().(files.File)
+		if !ok {
+			return fmt.Errorf("given file was not a files.File")
+		}
+
+		var ks wallet.KeyStore
+		if err := json.NewDecoder(fi).Decode(&ks); err != nil {
+			return err
+		}
+		addrs, err := env.(*node.Env).WalletAPI.WalletImportKeyStore(req.Context, &ks, req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		buf := new(bytes.Buffer)
+		writer := NewSilentWriter(buf)
+		for _, addr := range addrs {
+			writer.Println(addr.String())
+		}
+		return re.Emit(buf)
+	},
+}
+
+var walletBackendsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the local and remote wallet backends",
+		ShortDescription: `
+The remote wallets are set in 'walletModule.remoteBackends' of the config, they are health checked every
+'walletModule.remoteCheckPeriod'. An address owned by several remote wallets is signed with the first
+healthy one, the next one is tried when it fails and is no longer healthy.
+`,
+	},
+	Extra: AdminExtra,
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		backends, err := env.(*node.Env).WalletAPI.WalletBackends(req.Context)
+		if err != nil {
+			return err
+		}
+
+		buf := new(bytes.Buffer)
+		tw := tablewriter.New(
+			tablewriter.Col("Name"),
+			tablewriter.Col("Remote"),
+			tablewriter.Col("Default"),
+			tablewriter.Col("Healthy"),
+			tablewriter.Col("Addresses"),
+			tablewriter.Col("Checked"),
+			tablewriter.NewLineCol("Error"))
+		for _, b := range backends {
+			row := map[string]interface{}{
+				"Name":      b.Name,
+				"Remote":    b.Remote,
+				"Healthy":   b.Healthy,
+				"Addresses": b.Addresses,
+				"Error":     b.Error,
+			}
+			if b.Default {
+				row["Default"] = "X"
+			}
+			if !b.CheckedAt.IsZero() {
+				row["Checked"] = b.CheckedAt.Format(time.RFC3339)
+			}
+			tw.Write(row)
+		}
+		if err := tw.Flush(buf); err != nil {
+			return err
+		}
+		return re.Emit(buf)
+	},
+}
+
+var walletThresholdCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Manage the threshold keys of the wallet",
+		ShortDescription: `
+A threshold key is a secp256k1 key split in a share per approver, each share is encrypted with the
+password of its approver. The key signs once enough approvers approve the request with
+'venus wallet approvals approve', it is never exported.
+`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"new": walletThresholdNewCmd,
+		"ls":  walletThresholdListCmd,
+	},
+}
+
+var walletThresholdNewCmd = &cmds.Command{
+	Extra: AdminExtra,
+	Helptext: cmds.HelpText{
+		Tagline: "Create a threshold key",
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("approvers", true, true, "names of the approvers, name=password skips the password prompt"),
+	},
+	Options: []cmds.Option{
+		cmds.IntOption("threshold", "number of approvals needed to sign"),
+	},
+	PreRun: func(req *cmds.Request, env cmds.Environment) error {
+		for i, approver := range req.Arguments {
+			// for testing, skip manual password entry
+			if strings.Contains(approver, "=") {
+				continue
+			}
+			pw, err := promptNewPassword("Password of " + approver + ":")
+			if err != nil {
+				return err
+			}
+			req.Arguments[i] = approver + "=" + string(pw)
+		}
+		return nil
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		threshold, ok := req.Options["threshold"].(int)
+		if !ok {
+			return errors.New("--threshold is required")
+		}
+		approvers := make([]wallet.ThresholdApprover, 0, len(req.Arguments))
+		for _, arg := range req.Arguments {
+			kv := strings.SplitN(arg, "=", 2)
+			if len(kv) != 2 {
+				return fmt.Errorf("missing password of approver %s", arg)
+			}
+			approvers = append(approvers, wallet.ThresholdApprover{Name: kv[0], Password: kv[1]})
+		}
+
+		addr, err := env.(*node.Env).WalletAPI.WalletNewThresholdAddress(req.Context, threshold, approvers)
+		if err != nil {
+			return err
+		}
+		return printOneString(re, addr.String())
+	},
+}
+
+var walletThresholdListCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the threshold keys of the wallet",
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		keys, err := env.(*node.Env).WalletAPI.WalletThresholdKeys(req.Context)
+		if err != nil {
+			return err
+		}
+		return re.Emit(keys)
+	},
+}
+
+var walletApprovalsCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Manage the requests to sign with the threshold keys",
+		ShortDescription: `
+Signing with a threshold key queues a sign request and waits 'walletModule.approvalWait' for its
+approvals. Signing the same data again waits for the same request and returns its signature once
+it is approved. Pushing the same message again, whatever its gas values, does not queue another
+request: a message approved after its push gave up waiting is pushed by the node.
+`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"list":    walletApprovalsListCmd,
+		"approve": walletApproveCmd,
+		"reject":  walletRejectCmd,
+	},
+}
+
+var walletApprovalsListCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the sign requests, the oldest first",
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		requests, err := env.(*node.Env).WalletAPI.WalletApprovals(req.Context)
+		if err != nil {
+			return err
+		}
+		return re.Emit(requests)
+	},
+}
+
+var approvalArguments = []cmds.Argument{
+	cmds.StringArg("id", true, false, "id of the sign request"),
+	cmds.StringArg("approver", true, false, "name of the approver"),
+	cmds.StringArg("password", false, false, "password of the share of the approver"),
+}
+
+func promptApproverPassword(req *cmds.Request, env cmds.Environment) error {
+	// for testing, skip manual password entry
+	if len(req.Arguments) == 3 {
+		return nil
+	}
+	if len(req.Arguments) != 2 {
+		return errors.New("the id of the request and the approver are required")
+	}
+	pw, err := gopass.GetPasswdPrompt("Password of "+req.Arguments[1]+":", true, os.Stdin, os.Stdout)
+	if err != nil {
+		return err
+	}
+	req.Arguments = append(req.Arguments, string(pw))
+	return nil
+}
+
+var walletApproveCmd = &cmds.Command{
+	Extra: AdminExtra,
+	Helptext: cmds.HelpText{
+		Tagline: "Approve a sign request with the share of an approver",
+	},
+	Arguments: approvalArguments,
+	PreRun:    promptApproverPassword,
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		if len(req.Arguments) != 3 {
+			return re.Emit("Three parameter is required.")
+		}
+		id, err := strconv.ParseUint(req.Arguments[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid request id: %w", err)
+		}
+		return env.(*node.Env).WalletAPI.WalletApprove(req.Context, id, req.Arguments[1], req.Arguments[2])
+	},
+}
+
+var walletRejectCmd = &cmds.Command{
+	Extra: AdminExtra,
+	Helptext: cmds.HelpText{
+		Tagline: "Reject a sign request as an approver",
+	},
+	Arguments: approvalArguments,
+	PreRun:    promptApproverPassword,
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		if len(req.Arguments) != 3 {
+			return re.Emit("Three parameter is required.")
+		}
+		id, err := strconv.ParseUint(req.Arguments[0], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid request id: %w", err)
+		}
+		return env.(*node.Env).WalletAPI.WalletReject(req.Context, id, req.Arguments[1], req.Arguments[2])
+	},
+}
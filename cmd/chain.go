@@ -20,7 +20,9 @@ import (
 	"github.com/filecoin-project/venus/app/node"
 	"github.com/filecoin-project/venus/app/submodule/apiface"
 	"github.com/filecoin-project/venus/app/submodule/apitypes"
+	"github.com/filecoin-project/venus/pkg/chain"
 	"github.com/filecoin-project/venus/pkg/constants"
+	"github.com/filecoin-project/venus/pkg/repo"
 	"github.com/filecoin-project/venus/pkg/types"
 )
 
@@ -35,6 +37,9 @@ var chainCmd = &cmds.Command{
 		"getblock": chainGetBlockCmd,
 		"disputer": chainDisputeSetCmd,
 		"export":   chainExportCmd,
+		"prune":    chainPruneCmd,
+		"import":   chainImportCmd,
+		"index":    chainIndexCmd,
 	},
 }
 
@@ -244,6 +249,11 @@ func apiMsgCids(in []apitypes.Message) []cid.Cid {
 var chainExportCmd = &cmds.Command{
 	Helptext: cmds.HelpText{
 		Tagline: "export chain to a car file",
+		ShortDescription: `By default the chain is exported from the tipset down to genesis with its recent state.
+With --from, only the block headers and messages of the epochs [from, to] are exported, without state.
+With --state-only, only the state tree of the tipset is exported.
+Only full exports can be imported back, range and state exports can not.
+Compressed exports are detected automatically when imported.`,
 	},
 	Arguments: []cmds.Argument{
 		cmds.StringArg("outputPath", true, false, ""),
@@ -252,6 +262,10 @@ var chainExportCmd = &cmds.Command{
 		cmds.StringOption("tipset").WithDefault(""),
 		cmds.Int64Option("recent-stateroots", "specify the number of recent state roots to include in the export").WithDefault(int64(0)),
 		cmds.BoolOption("skip-old-msgs").WithDefault(false),
+		cmds.Int64Option("from", "export only the headers and messages from this epoch").WithDefault(int64(-1)),
+		cmds.Int64Option("to", "epoch of the exported tipset, defaults to the tipset option or the head").WithDefault(int64(-1)),
+		cmds.BoolOption("state-only", "export only the state tree of the tipset").WithDefault(false),
+		cmds.BoolOption("compress", "compress the export with zstd").WithDefault(false),
 	},
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
 		if len(req.Arguments) != 1 {
@@ -260,7 +274,51 @@ var chainExportCmd = &cmds.Command{
 
 		rsrs := abi.ChainEpoch(req.Options["recent-stateroots"].(int64))
 		if rsrs > 0 && rsrs < constants.Finality {
-			return fmt.Errorf("\"recent-stateroots\" has to be greater than %d", constants.Finality)
+			return fmt.Errorf("\"recent-stateroots\" has to be at least %d", constants.Finality)
+		}
+
+		skipold := req.Options["skip-old-msgs"].(bool)
+
+		if rsrs == 0 && skipold {
+			return fmt.Errorf("must pass recent stateroots along with skip-old-msgs")
+		}
+
+		opts := chain.ExportOptions{
+			Mode:             chain.ExportFull,
+			RecentStateRoots: rsrs,
+			SkipOldMsgs:      skipold,
+			Compress:         req.Options["compress"].(bool),
+		}
+		from := req.Options["from"].(int64)
+		stateOnly := req.Options["state-only"].(bool)
+		switch {
+		case from >= 0 && stateOnly:
+			return fmt.Errorf("\"from\" and \"state-only\" can not be used together")
+		case (from >= 0 || stateOnly) && (rsrs > 0 || skipold):
+			return fmt.Errorf("\"recent-stateroots\" and \"skip-old-msgs\" only apply to full exports")
+		case from >= 0:
+			opts.Mode = chain.ExportRange
+			opts.From = abi.ChainEpoch(from)
+		case stateOnly:
+			opts.Mode = chain.ExportState
+		}
+
+		ts, err := LoadTipSet(req.Context, req, env.(*node.Env).ChainAPI)
+		if err != nil {
+			return err
+		}
+
+		if to := req.Options["to"].(int64); to >= 0 {
+			if abi.ChainEpoch(to) > ts.Height() {
+				return fmt.Errorf("\"to\" is above the tipset height %d", ts.Height())
+			}
+			ts, err = env.(*node.Env).ChainAPI.ChainGetTipSetByHeight(req.Context, abi.ChainEpoch(to), ts.Key())
+			if err != nil {
+				return err
+			}
+		}
+		if opts.Mode == chain.ExportRange && opts.From > ts.Height() {
+			return fmt.Errorf("\"from\" is above the exported tipset height %d", ts.Height())
 		}
 
 		fi, err := os.Create(req.Arguments[0])
@@ -274,18 +332,7 @@ var chainExportCmd = &cmds.Command{
 			}
 		}()
 
-		ts, err := LoadTipSet(req.Context, req, env.(*node.Env).ChainAPI)
-		if err != nil {
-			return err
-		}
-
-		skipold := req.Options["skip-old-msgs"].(bool)
-
-		if rsrs == 0 && skipold {
-			return fmt.Errorf("must pass recent stateroots along with skip-old-msgs")
-		}
-
-		stream, err := env.(*node.Env).ChainAPI.ChainExport(req.Context, rsrs, skipold, ts.Key())
+		stream, err := env.(*node.Env).ChainAPI.ChainExportWithOptions(req.Context, ts.Key(), opts)
 		if err != nil {
 			return err
 		}
@@ -308,6 +355,162 @@ var chainExportCmd = &cmds.Command{
 	},
 }
 
+var chainPruneCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Remove unreachable objects from the chain blockstore of an offline repo",
+		ShortDescription: `Walks the objects reachable from the chain head, keeping the state of the most recent epochs,
+copies them into a fresh blockstore and swaps it in place of the current one.
+The daemon must not be running.`,
+	},
+	Options: []cmds.Option{
+		cmds.Int64Option("recent-stateroots", "number of recent state roots to keep").WithDefault(int64(2 * constants.Finality)),
+		cmds.BoolOption("skip-old-msgs", "drop the messages older than the kept state roots").WithDefault(false),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		rsrs := abi.ChainEpoch(req.Options["recent-stateroots"].(int64))
+		if rsrs < constants.Finality {
+			return fmt.Errorf("\"recent-stateroots\" has to be at least %d", constants.Finality)
+		}
+		skipold := req.Options["skip-old-msgs"].(bool)
+
+		rep, err := getRepo(req)
+		if err != nil {
+			return err
+		}
+		fsr, ok := rep.(*repo.FSRepo)
+		if !ok {
+			_ = rep.Close()
+			return xerrors.New("prune only supports file system repos")
+		}
+
+		bsPath := fsr.BlockstorePath()
+		prunePath := bsPath + ".prune"
+		copied, err := func() (int, error) {
+			defer func() {
+				_ = rep.Close()
+			}()
+
+			store, err := node.OpenChainStore(req.Context, rep)
+			if err != nil {
+				return 0, err
+			}
+			defer store.Stop()
+
+			if err := os.RemoveAll(prunePath); err != nil {
+				return 0, err
+			}
+			to, err := repo.OpenBadgerBlockstore(prunePath, false)
+			if err != nil {
+				return 0, xerrors.Errorf("failed to open pruned blockstore: %w", err)
+			}
+			defer func() {
+				_ = to.Close()
+			}()
+
+			head := store.GetHead()
+			log.Infof("pruning chain blockstore from head %d, keeping %d state roots", head.Height(), rsrs)
+			return store.CopyReachable(req.Context, head, rsrs, skipold, to)
+		}()
+		if err != nil {
+			_ = os.RemoveAll(prunePath)
+			return err
+		}
+
+		if err := repo.ReplaceBlockstore(bsPath, prunePath); err != nil {
+			return xerrors.Errorf("failed to swap pruned blockstore: %w", err)
+		}
+
+		return re.Emit(fmt.Sprintf("pruned blockstore, %d objects kept", copied))
+	},
+	Type: "",
+}
+
+var chainImportCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Import a chain snapshot into an offline repo",
+		ShortDescription: `Imports a car file, from a path or an HTTP(S) URL, and sets its head as the chain head.
+Zstd compressed car files are detected automatically. An interrupted import of the same file is resumed where it stopped. The daemon must not be running.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("path", true, false, "path or url of the chain snapshot"),
+	},
+	Options: []cmds.Option{
+		cmds.BoolOption("no-resume", "start over instead of resuming an interrupted import").WithDefault(false),
+		cmds.IntOption("validate-tipsets", "number of tipsets below the imported head to re-execute to validate the imported state").WithDefault(0),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		rep, err := getRepo(req)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = rep.Close()
+		}()
+
+		opts := ImportOptions{
+			Resume:          !req.Options["no-resume"].(bool),
+			ValidateTipSets: req.Options["validate-tipsets"].(int),
+		}
+		if err := Import(req.Context, rep, req.Arguments[0], opts); err != nil {
+			return err
+		}
+		return re.Emit("chain snapshot imported")
+	},
+	Type: "",
+}
+
+var chainIndexCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Manage the index of the messages included on chain",
+	},
+	Subcommands: map[string]*cmds.Command{
+		"rebuild": chainIndexRebuildCmd,
+	},
+}
+
+var chainIndexRebuildCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Rebuild the msg index of an offline repo",
+		ShortDescription: `Clears the msg index and indexes the messages of the chain again from the head,
+as far back as messages are available or down to the given number of epochs. The daemon must not be running.`,
+	},
+	Options: []cmds.Option{
+		cmds.Int64Option("epochs", "number of epochs below the head to index, 0 for all").WithDefault(int64(0)),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		epochs := abi.ChainEpoch(req.Options["epochs"].(int64))
+		if epochs < 0 {
+			return fmt.Errorf("\"epochs\" can not be negative")
+		}
+
+		rep, err := getRepo(req)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = rep.Close()
+		}()
+
+		store, err := node.OpenChainStore(req.Context, rep)
+		if err != nil {
+			return err
+		}
+		defer store.Stop()
+
+		messages := chain.NewMessageStore(rep.Datastore(), rep.Config().NetworkParams.ForkUpgradeParam)
+		idx := chain.NewMsgIndex(rep.ChainDatastore(), store, messages, 0)
+
+		head := store.GetHead()
+		log.Infof("rebuilding msg index from head %d", head.Height())
+		indexed, err := idx.Rebuild(req.Context, head, epochs)
+		if err != nil {
+			return err
+		}
+		return re.Emit(fmt.Sprintf("msg index rebuilt, %d tipsets indexed", indexed))
+	},
+	Type: "",
+}
+
 // LoadTipSet gets the tipset from the context, or the head from the API.
 //
 // It always gets the head from the API so commands use a consistent tipset even if time pases.
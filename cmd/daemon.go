@@ -59,6 +59,7 @@ var daemonCmd = &cmds.Command{
 		cmds.StringOption(AuthServiceURL, "venus auth service URL"),
 		cmds.BoolOption(IsRelay, "advertise and allow venus network traffic to be relayed through this node"),
 		cmds.StringOption(ImportSnapshot, "import chain state from a given chain export file or url"),
+		cmds.IntOption(ImportValidateTipSets, "number of tipsets below the imported head to re-execute to validate the imported state"),
 		cmds.StringOption(GenesisFile, "path of file or HTTP(S) URL containing archive of genesis block DAG data"),
 		cmds.StringOption(PeerKeyFile, "path of file containing key to use for new node's libp2p identity"),
 		cmds.StringOption(WalletKeyFile, "path of file containing keys to import into the wallet on initialization"),
@@ -224,7 +225,8 @@ func daemonRun(req *cmds.Request, re cmds.ResponseEmitter) error {
 	}
 	importPath, _ := req.Options[ImportSnapshot].(string)
 	if len(importPath) != 0 {
-		err := Import(rep, importPath)
+		validate, _ := req.Options[ImportValidateTipSets].(int)
+		err := Import(req.Context, rep, importPath, ImportOptions{Resume: true, ValidateTipSets: validate})
 		if err != nil {
 			log.Errorf("failed to import snapshot, import path: %s, error: %s", importPath, err.Error())
 			return err
@@ -1,18 +1,27 @@
 package cmd
 
 import (
-	"bufio"
 	"context"
-	"github.com/filecoin-project/venus/pkg/config"
-	cbor "github.com/ipfs/go-ipld-cbor"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"net/http"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/filecoin-project/venus/app/node"
 	"github.com/filecoin-project/venus/pkg/chain"
+	"github.com/filecoin-project/venus/pkg/consensus"
+	"github.com/filecoin-project/venus/pkg/consensusfault"
+	"github.com/filecoin-project/venus/pkg/constants"
+	"github.com/filecoin-project/venus/pkg/fork"
 	"github.com/filecoin-project/venus/pkg/repo"
-	"github.com/ipfs/go-cid"
+	"github.com/filecoin-project/venus/pkg/types"
+	"github.com/filecoin-project/venus/pkg/util/ffiwrapper/impl"
+	"github.com/filecoin-project/venus/pkg/vm/gas"
+	"github.com/filecoin-project/venus/pkg/vmsupport"
+	cbor "github.com/ipfs/go-ipld-cbor"
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/mitchellh/go-homedir"
 	xerrors "github.com/pkg/errors"
@@ -21,80 +30,208 @@ import (
 
 var logImport = logging.Logger("commands/import")
 
+// ImportOptions configures a chain import.
+type ImportOptions struct {
+	// Resume continues an interrupted import of the same file instead of starting over.
+	Resume bool
+	// ValidateTipSets is the number of tipsets below the imported head to re-execute
+	// to check their state roots, 0 disables validation.
+	ValidateTipSets int
+}
+
 // Import cache tipset cids to store.
 // The value of the cached tipset CIDS is used as the check-point when running `venus daemon`
-func Import(r repo.Repo, fileName string) error {
-	return importChain(r, fileName)
+func Import(ctx context.Context, r repo.Repo, fileName string, opts ImportOptions) error {
+	return importChain(ctx, r, fileName, opts)
 }
 
-func importChain(r repo.Repo, fname string) error {
-	var rd io.Reader
-	var l int64
-	if strings.HasPrefix(fname, "http://") || strings.HasPrefix(fname, "https://") {
-		resp, err := http.Get(fname) //nolint:gosec
-		if err != nil {
-			return err
-		}
-		defer resp.Body.Close() //nolint:errcheck
-
-		if resp.StatusCode != http.StatusOK {
-			return xerrors.Errorf("non-200 response: %d", resp.StatusCode)
-		}
-
-		rd = resp.Body
-		l = resp.ContentLength
-	} else {
-		fname, err := homedir.Expand(fname)
-		if err != nil {
-			return err
-		}
-
-		fi, err := os.Open(fname)
-		if err != nil {
-			return err
-		}
-		defer fi.Close() //nolint:errcheck
-
-		st, err := os.Stat(fname)
-		if err != nil {
-			return err
-		}
-
-		rd = fi
-		l = st.Size()
+func importChain(ctx context.Context, r repo.Repo, fname string, opts ImportOptions) error {
+	if opts.ValidateTipSets < 0 || opts.ValidateTipSets > int(constants.Finality) {
+		return xerrors.Errorf("the number of tipsets to validate must be between 0 and %d", constants.Finality)
 	}
 
-	bs := r.Datastore()
-	// setup a ipldCbor on top of the local store
-	ipldCborStore := cbor.NewCborStore(bs)
-	chainStore := chain.NewStore(r.ChainDatastore(), ipldCborStore, bs, config.DefaultForkUpgradeParam, cid.Undef)
+	chainStore, err := node.NewChainStore(r)
+	if err != nil {
+		return err
+	}
 
-	bufr := bufio.NewReaderSize(rd, 1<<20)
+	source, open, err := importSource(fname)
+	if err != nil {
+		return err
+	}
 
-	bar := pb.New64(l)
-	br := bar.NewProxyReader(bufr)
+	bar := pb.New64(0)
 	bar.ShowTimeLeft = true
 	bar.ShowPercent = true
 	bar.ShowSpeed = true
 	bar.Units = pb.U_BYTES
 
-	bar.Start()
-	tip, err := chainStore.Import(br)
+	started := false
+	tip, err := chainStore.ImportStream(ctx, chain.ImportOptions{
+		Source: source,
+		Resume: opts.Resume,
+		Open: func(offset int64) (io.ReadCloser, error) {
+			rc, size, err := open(offset)
+			if err != nil {
+				return nil, err
+			}
+			bar.SetTotal64(size)
+			bar.Set64(offset)
+			bar.Start()
+			started = true
+			return struct {
+				io.Reader
+				io.Closer
+			}{bar.NewProxyReader(rc), rc}, nil
+		},
+		OnProgress: func(progress chain.ImportProgress) {
+			bar.Postfix(fmt.Sprintf(" %d blocks", progress.Blocks))
+		},
+	})
+	if started {
+		bar.Finish()
+	}
 	if err != nil {
 		return xerrors.Errorf("importing chain failed: %s", err)
 	}
-	bar.Finish()
 
-	err = chainStore.SetHead(context.TODO(), tip)
+	if opts.ValidateTipSets > 0 {
+		if err := validateImportedTipSets(ctx, r, chainStore, tip, opts.ValidateTipSets); err != nil {
+			return xerrors.Errorf("validating imported chain failed: %s", err)
+		}
+	}
+
+	err = chainStore.SetHead(ctx, tip)
 	if err != nil {
 		return xerrors.Errorf("importing chain failed: %s", err)
 	}
 	logImport.Infof("accepting %s as new head", tip.Key().String())
 
-	err = chainStore.WriteCheckPoint(context.TODO(), tip.Key())
+	err = chainStore.WriteCheckPoint(ctx, tip.Key())
 	if err != nil {
 		logImport.Errorf("set check point error: %s", err.Error())
 	}
 
 	return err
 }
+
+// importSource returns the name identifying the car file fname and a function
+// opening it at an offset, which also returns its total size.
+func importSource(fname string) (string, func(offset int64) (io.ReadCloser, int64, error), error) {
+	if strings.HasPrefix(fname, "http://") || strings.HasPrefix(fname, "https://") {
+		return fname, func(offset int64) (io.ReadCloser, int64, error) {
+			req, err := http.NewRequest(http.MethodGet, fname, nil)
+			if err != nil {
+				return nil, 0, err
+			}
+			if offset > 0 {
+				req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+			}
+
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				return nil, 0, err
+			}
+
+			switch {
+			case resp.StatusCode == http.StatusPartialContent && offset > 0:
+				return resp.Body, offset + resp.ContentLength, nil
+			case resp.StatusCode == http.StatusOK:
+				// the server ignored the range, skip what was already imported.
+				if _, err := io.CopyN(ioutil.Discard, resp.Body, offset); err != nil {
+					_ = resp.Body.Close()
+					return nil, 0, err
+				}
+				return resp.Body, resp.ContentLength, nil
+			default:
+				_ = resp.Body.Close()
+				return nil, 0, xerrors.Errorf("non-200 response: %d", resp.StatusCode)
+			}
+		}, nil
+	}
+
+	fname, err := homedir.Expand(fname)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return fname, func(offset int64) (io.ReadCloser, int64, error) {
+		fi, err := os.Open(fname)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		st, err := fi.Stat()
+		if err != nil {
+			_ = fi.Close()
+			return nil, 0, err
+		}
+
+		if _, err := fi.Seek(offset, io.SeekStart); err != nil {
+			_ = fi.Close()
+			return nil, 0, err
+		}
+		return fi, st.Size(), nil
+	}, nil
+}
+
+// validateImportedTipSets re-executes the n tipsets ending at head and checks that
+// they produce the state roots and receipts recorded by the import.
+func validateImportedTipSets(ctx context.Context, r repo.Repo, chainStore *chain.Store, head *types.TipSet, n int) error {
+	bs := r.Datastore()
+	cst := cbor.NewCborStore(bs)
+	netParams := r.Config().NetworkParams
+
+	chainFork, err := fork.NewChainFork(ctx, chainStore, cst, bs, netParams)
+	if err != nil {
+		return err
+	}
+	syscalls := vmsupport.NewSyscalls(consensusfault.NewFaultChecker(chainStore, chainFork), impl.ProofVerifier)
+	processor := consensus.NewExpected(cst,
+		bs,
+		time.Duration(netParams.BlockDelay)*time.Second,
+		chainStore,
+		chainStore,
+		chain.NewMessageStore(bs, netParams.ForkUpgradeParam),
+		chainFork,
+		netParams,
+		gas.NewPricesSchedule(netParams.ForkUpgradeParam),
+		nil,
+		syscalls,
+	)
+
+	ts := head
+	for i := 0; i < n && ts.Height() > 0; i++ {
+		expectedRoot, err := chainStore.GetTipSetStateRoot(ts)
+		if err != nil {
+			return err
+		}
+		expectedReceipts, err := chainStore.GetTipSetReceiptsRoot(ts)
+		if err != nil {
+			return err
+		}
+
+		logImport.Infof("validating tipset %d (%d/%d)", ts.Height(), i+1, n)
+		root, receipts, err := processor.RunStateTransition(ctx, ts, ts.At(0).ParentStateRoot)
+		if err != nil {
+			return xerrors.Wrapf(err, "failed to execute tipset %d", ts.Height())
+		}
+		if !root.Defined() {
+			return xerrors.Errorf("failed to execute tipset %d", ts.Height())
+		}
+		if !root.Equals(expectedRoot) {
+			return xerrors.Errorf("state root mismatch at tipset %d: computed %s, imported %s", ts.Height(), root, expectedRoot)
+		}
+		if !receipts.Equals(expectedReceipts) {
+			return xerrors.Errorf("receipts root mismatch at tipset %d: computed %s, imported %s", ts.Height(), receipts, expectedReceipts)
+		}
+
+		ts, err = chainStore.GetTipSet(ts.Parents())
+		if err != nil {
+			return err
+		}
+	}
+
+	logImport.Infof("validated %d tipsets ending at %d", n, head.Height())
+	return nil
+}
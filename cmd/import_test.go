@@ -0,0 +1,44 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tf "github.com/filecoin-project/venus/pkg/testhelpers/testflags"
+)
+
+func TestImportSourceResumesAtOffset(t *testing.T) {
+	tf.UnitTest(t)
+
+	dir, err := ioutil.TempDir("", "import-source")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir) // nolint: errcheck
+
+	content := []byte("0123456789")
+	fname := filepath.Join(dir, "snapshot.car")
+	require.NoError(t, ioutil.WriteFile(fname, content, 0644))
+
+	server := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	defer server.Close()
+
+	for _, src := range []string{fname, server.URL + "/snapshot.car"} {
+		_, open, err := importSource(src)
+		require.NoError(t, err)
+
+		rc, size, err := open(4)
+		require.NoError(t, err)
+		rest, err := ioutil.ReadAll(rc)
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+
+		assert.Equal(t, int64(len(content)), size, src)
+		assert.Equal(t, content[4:], rest, src)
+	}
+}
@@ -83,6 +83,9 @@ const (
 
 	ImportSnapshot = "import-snapshot"
 
+	// ImportValidateTipSets is the number of imported tipsets to re-execute
+	ImportValidateTipSets = "import-validate-tipsets"
+
 	// wallet password
 	Password = "password"
 
@@ -178,6 +181,13 @@ var rootSubcmdsLocal = map[string]*cmds.Command{
 	"seed":    seedCmd,
 }
 
+// sub commands of daemon commands that operate on the repo directly and must run without a daemon.
+var subcmdsLocal = [][]string{
+	{"chain", "prune"},
+	{"chain", "import"},
+	{"chain", "index"},
+}
+
 // all top level commands, available on daemon. set during init() to avoid configuration loops.
 var rootSubcmdsDaemon = map[string]*cmds.Command{
 	"chain":    chainCmd,
@@ -381,6 +391,23 @@ func requiresDaemon(req *cmds.Request) bool {
 			return false
 		}
 	}
+	for _, path := range subcmdsLocal {
+		if isPathPrefix(path, req.Path) {
+			return false
+		}
+	}
+	return true
+}
+
+func isPathPrefix(prefix, path []string) bool {
+	if len(path) < len(prefix) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
 	return true
 }
 
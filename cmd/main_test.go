@@ -23,4 +23,8 @@ func TestRequiresDaemon(t *testing.T) {
 	reqSubcmdDaemon, err := cmds.NewRequest(context.Background(), []string{"leb128", "decode"}, nil, []string{"A=="}, nil, RootCmd)
 	assert.NoError(t, err)
 	assert.False(t, requiresDaemon(reqSubcmdDaemon))
+
+	reqLocalSubcmd, err := cmds.NewRequest(context.Background(), []string{"chain", "prune"}, nil, []string{}, nil, RootCmd)
+	assert.NoError(t, err)
+	assert.False(t, requiresDaemon(reqLocalSubcmd))
 }
@@ -6,6 +6,7 @@ import (
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"reflect"
 
 	"golang.org/x/xerrors"
@@ -36,8 +37,8 @@ var msgSendCmd = &cmds.Command{
 		Tagline: "Send a message", // This feels too generic...
 	},
 	Arguments: []cmds.Argument{
-		cmds.StringArg("target", true, false, "address of the actor to send the message to"),
-		cmds.StringArg("value", true, false, "amount of FIL"),
+		cmds.StringArg("target", false, false, "address of the actor to send the message to"),
+		cmds.StringArg("value", false, false, "amount of FIL"),
 	},
 	Options: []cmds.Option{
 		cmds.StringOption("value", "Value to send with message in FIL"),
@@ -49,8 +50,19 @@ var msgSendCmd = &cmds.Command{
 		cmds.StringOption("params-json", "specify invocation parameters in json"),
 		cmds.StringOption("params-hex", "specify invocation parameters in hex"),
 		cmds.Uint64Option("method", "The method to invoke on the target actor"),
+		cmds.StringOption("batch", "send the messages of a json file in a batch pushed all or none, instead of target and value"),
 	},
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		if batch, ok := req.Options["batch"].(string); ok {
+			if len(req.Arguments) > 0 {
+				return errors.New("target and value cannot be set with batch")
+			}
+			return sendBatch(req, re, env, batch)
+		}
+		if len(req.Arguments) != 2 {
+			return errors.New("target and value are required")
+		}
+
 		toAddr, err := address.NewFromString(req.Arguments[0])
 		if err != nil {
 			return err
@@ -145,6 +157,86 @@ var msgSendCmd = &cmds.Command{
 	},
 }
 
+// BatchSendEntry is a message of the batch file of send, a json list of entries. Value is in FIL and
+// Params are hex encoded.
+type BatchSendEntry struct {
+	To     address.Address
+	Value  string
+	Method abi.MethodNum
+	Params string
+}
+
+func sendBatch(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment, path string) error {
+	for _, opt := range []string{"value", "nonce", "params-json", "params-hex", "method"} {
+		if _, ok := req.Options[opt]; ok {
+			return xerrors.Errorf("%s cannot be set with batch, set it in the batch file", opt)
+		}
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var entries []BatchSendEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return xerrors.Errorf("parsing batch file: %w", err)
+	}
+	if len(entries) == 0 {
+		return errors.New("batch file has no message")
+	}
+
+	fromAddr, err := fromAddrOrDefault(req, env)
+	if err != nil {
+		return err
+	}
+
+	feecap, premium, gasLimit, err := parseGasOptions(req)
+	if err != nil {
+		return err
+	}
+
+	msgs := make([]*types.UnsignedMessage, 0, len(entries))
+	for i, e := range entries {
+		val, ok := types.NewAttoFILFromFILString(e.Value)
+		if !ok {
+			return xerrors.Errorf("entry %d: mal-formed value %q", i, e.Value)
+		}
+		if e.Method == builtin.MethodSend && fromAddr == e.To {
+			return xerrors.Errorf("entry %d: self-transfer is not allowed", i)
+		}
+		params, err := hex.DecodeString(e.Params)
+		if err != nil {
+			return xerrors.Errorf("entry %d: failed to decode hex params: %w", i, err)
+		}
+		if len(params) == 0 {
+			params = nil
+		}
+
+		msgs = append(msgs, &types.UnsignedMessage{
+			From:       fromAddr,
+			To:         e.To,
+			Value:      val,
+			GasPremium: premium,
+			GasFeeCap:  feecap,
+			GasLimit:   gasLimit,
+			Method:     e.Method,
+			Params:     params,
+		})
+	}
+
+	smsgs, err := env.(*node.Env).MessagePoolAPI.MpoolAtomicBatchPushMessage(req.Context, msgs, nil)
+	if err != nil {
+		return err
+	}
+
+	buf := new(bytes.Buffer)
+	writer := NewSilentWriter(buf)
+	for _, sm := range smsgs {
+		writer.Println(sm.Cid().String())
+	}
+	return re.Emit(buf)
+}
+
 func decodeTypedParams(ctx context.Context, fapi *node.Env, to address.Address, method abi.MethodNum, paramstr string) ([]byte, error) {
 	act, err := fapi.ChainAPI.StateGetActor(ctx, to, types.EmptyTSK)
 	if err != nil {
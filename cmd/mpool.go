@@ -1,11 +1,15 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io/ioutil"
 	"sort"
 	"strconv"
+	"time"
 
 	stdbig "math/big"
 
@@ -28,17 +32,24 @@ var mpoolCmd = &cmds.Command{
 		Tagline: "Manage message pool",
 	},
 	Subcommands: map[string]*cmds.Command{
-		"pending":  mpoolPending,
-		"clear":    mpoolClear,
-		"sub":      mpoolSub,
-		"stat":     mpoolStat,
-		"replace":  mpoolReplaceCmd,
-		"find":     mpoolFindCmd,
-		"config":   mpoolConfig,
-		"gas-perf": mpoolGasPerfCmd,
-		"publish":  mpoolPublish,
-		"delete":   mpoolDeleteAddress,
-		"select":   mpoolSelect,
+		"pending":      mpoolPending,
+		"clear":        mpoolClear,
+		"sub":          mpoolSub,
+		"stat":         mpoolStat,
+		"replace":      mpoolReplaceCmd,
+		"find":         mpoolFindCmd,
+		"config":       mpoolConfig,
+		"policy":       mpoolPolicyCmd,
+		"gas-perf":     mpoolGasPerfCmd,
+		"gas-estimate": mpoolGasEstimateCmd,
+		"publish":      mpoolPublish,
+		"delete":       mpoolDeleteAddress,
+		"select":       mpoolSelect,
+		"explain":      mpoolExplainCmd,
+		"fix-nonce":    mpoolFixNonceCmd,
+		"export":       mpoolExportCmd,
+		"import":       mpoolImportCmd,
+		"history":      mpoolHistoryCmd,
 	},
 }
 
@@ -101,6 +112,71 @@ var mpoolSelect = &cmds.Command{
 	},
 }
 
+var mpoolExplainCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Explain why a pending message is or is not selected for the next block",
+		ShortDescription: `
+Run the message selection on the chain head without side effects and report the chain of the
+message, its effective performance and the reason the message is left out.
+`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("cid", true, false, "cid of the pending message"),
+	},
+	Options: []cmds.Option{
+		cmds.FloatOption("quality", "ticket quality of the block").WithDefault(float64(0.5)),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		mcid, err := cid.Decode(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		quality, _ := req.Options["quality"].(float64)
+
+		head, err := env.(*node.Env).ChainAPI.ChainHead(req.Context)
+		if err != nil {
+			return err
+		}
+		report, err := env.(*node.Env).MessagePoolAPI.MpoolSelectDebug(req.Context, head.Key(), quality)
+		if err != nil {
+			return err
+		}
+
+		var msg *messagepool.MessageReport
+		for _, m := range report.Messages {
+			if m.Cid == mcid {
+				msg = m
+				break
+			}
+		}
+		if msg == nil {
+			return xerrors.Errorf("message %s is not pending", mcid)
+		}
+
+		buf := new(bytes.Buffer)
+		writer := NewSilentWriter(buf)
+		writer.Printf("Message: %s from %s with nonce %d\n", msg.Cid, msg.From, msg.Nonce)
+		writer.Printf("Base fee: %s\n", types.FIL(report.BaseFee))
+		if msg.Selected {
+			writer.Printf("Selected: yes\n")
+		} else {
+			writer.Printf("Selected: no\n")
+			writer.Printf("Reason: %s: %s\n", msg.Reason, msg.Detail)
+		}
+		if msg.Chain >= 0 {
+			chain := report.Chains[msg.Chain]
+			writer.Printf("Chain: %d messages, gas limit %d, gas reward %s, gas perf %f, effective perf %f",
+				len(chain.Messages), chain.GasLimit, types.FIL(chain.GasReward), chain.GasPerf, chain.EffectivePerf)
+			if chain.Priority {
+				writer.Printf(", priority")
+			}
+			writer.Printf("\n")
+		}
+
+		return re.Emit(buf)
+	},
+}
+
 var mpoolPublish = &cmds.Command{
 	Helptext: cmds.HelpText{
 		Tagline:          "publish",
@@ -530,10 +606,12 @@ Get pending messages.
 		cmds.BoolOption("cids", "only print cids of messages in output"),
 		cmds.StringOption("to", "return messages to a given address"),
 		cmds.StringOption("from", "return messages from a given address"),
+		cmds.BoolOption("decode", "decode the params of the messages, ignored with --cids"),
 	},
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
 		local, _ := req.Options["local"].(bool)
 		cids, _ := req.Options["cids"].(bool)
+		decode, _ := req.Options["decode"].(bool)
 		to, _ := req.Options["to"].(string)
 		from, _ := req.Options["from"].(string)
 
@@ -583,16 +661,48 @@ Get pending messages.
 				continue
 			}
 
-			if cids {
-				_ = re.Emit(msg.Cid())
-				_ = re.Emit(err)
-			} else {
-				_ = re.Emit(msg)
+			pending := PendingMessage{CID: msg.Cid()}
+			if !cids {
+				pending.SignedMessage = msg
 			}
+			if decode && !cids {
+				pending.DecodedParams = decodeParams(req.Context, env.(*node.Env).ChainAPI, &msg.Message, types.EmptyTSK)
+			}
+			_ = re.Emit(&pending)
 		}
 
 		return nil
 	},
+	Type: &PendingMessage{},
+}
+
+// PendingMessage is a message of the pool with its signature, only its CID is set with --cids and
+// its params are decoded with --decode.
+type PendingMessage struct {
+	*types.SignedMessage
+	CID cid.Cid
+	DecodedParams
+}
+
+// pendingMessage has the fields of PendingMessage without its json methods.
+type pendingMessage PendingMessage
+
+// MarshalJSON implements json.Marshaler, only the CID is printed with --cids as a bare CID.
+func (pm PendingMessage) MarshalJSON() ([]byte, error) {
+	if pm.SignedMessage == nil {
+		return json.Marshal(pm.CID)
+	}
+	return json.Marshal(pendingMessage(pm))
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (pm *PendingMessage) UnmarshalJSON(data []byte) error {
+	var c cid.Cid
+	if err := json.Unmarshal(data, &c); err == nil {
+		*pm = PendingMessage{CID: c}
+		return nil
+	}
+	return json.Unmarshal(data, (*pendingMessage)(pm))
 }
 
 var mpoolClear = &cmds.Command{
@@ -623,12 +733,53 @@ var mpoolSub = &cmds.Command{
 	Helptext: cmds.HelpText{
 		Tagline: "sub",
 		ShortDescription: `
-Subscribe to mpool changes
+Subscribe to mpool changes, optionally only the changes of the messages matching all the given filters.
+Removals carry the reason the message left the pool: included, replaced, pruned, expired or cleared.
+The republishing of the local messages is only sent with --republished.
 `,
 	},
+	Options: []cmds.Option{
+		cmds.StringsOption("from", "only the messages from one of the given addresses"),
+		cmds.StringsOption("to", "only the messages to one of the given addresses"),
+		cmds.Int64Option("method", "only the messages calling the given method"),
+		cmds.StringOption("min-value", "only the messages sending at least the given value in FIL"),
+		cmds.BoolOption("local", "only the messages from the addresses of the local wallet"),
+		cmds.BoolOption("republished", "also the local messages published again by the republish loop"),
+	},
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
 		ctx := context.TODO()
-		sub, err := env.(*node.Env).MessagePoolAPI.MpoolSub(ctx)
+
+		filter := new(messagepool.MpoolUpdateFilter)
+		from, _ := req.Options["from"].([]string)
+		for _, s := range from {
+			a, err := address.NewFromString(s)
+			if err != nil {
+				return fmt.Errorf("'from' address was invalid: %w", err)
+			}
+			filter.From = append(filter.From, a)
+		}
+		to, _ := req.Options["to"].([]string)
+		for _, s := range to {
+			a, err := address.NewFromString(s)
+			if err != nil {
+				return fmt.Errorf("'to' address was invalid: %w", err)
+			}
+			filter.To = append(filter.To, a)
+		}
+		if method, ok := req.Options["method"].(int64); ok {
+			filter.Methods = []abi.MethodNum{abi.MethodNum(method)}
+		}
+		if minValue, ok := req.Options["min-value"].(string); ok {
+			v, err := types.ParseFIL(minValue)
+			if err != nil {
+				return fmt.Errorf("parsing min-value: %w", err)
+			}
+			filter.MinValue = abi.TokenAmount(v)
+		}
+		filter.LocalOnly, _ = req.Options["local"].(bool)
+		filter.Republished, _ = req.Options["republished"].(bool)
+
+		sub, err := env.(*node.Env).MessagePoolAPI.MpoolSubFiltered(ctx, filter)
 		if err != nil {
 			return err
 		}
@@ -677,6 +828,80 @@ var mpoolConfig = &cmds.Command{
 	},
 }
 
+var mpoolPolicyCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Manage the per-sender and per-recipient policies of the message pool",
+		ShortDescription: `
+Policies restrict the messages pushed to this node from or to an address: the max number of
+pending messages, the max value sent per day, the max fee cap, the allowed or denied counterparties
+and the allowed target actors and methods.
+`,
+	},
+	Subcommands: map[string]*cmds.Command{
+		"list": mpoolPolicyListCmd,
+		"set":  mpoolPolicySetCmd,
+		"rm":   mpoolPolicyRemoveCmd,
+	},
+}
+
+var mpoolPolicyListCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "List the policies of the message pool",
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		policies, err := env.(*node.Env).MessagePoolAPI.MpoolGetPolicies(req.Context)
+		if err != nil {
+			return err
+		}
+		return re.Emit(policies)
+	},
+}
+
+var mpoolPolicySetCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Add or replace a policy of the message pool",
+		ShortDescription: `
+The policy is given as json, it replaces the policy with the same scope and address, eg.
+{"Scope": "sender", "Address": "f1...", "MaxPendingMessages": 10, "MaxValuePerDay": "1000000000000000000"}
+`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("policy", true, false, "policy in json"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		policy := new(messagepool.MpoolPolicy)
+		if err := json.Unmarshal([]byte(req.Arguments[0]), policy); err != nil {
+			return xerrors.Errorf("parsing policy: %w", err)
+		}
+		return env.(*node.Env).MessagePoolAPI.MpoolSetPolicy(req.Context, policy)
+	},
+}
+
+var mpoolPolicyRemoveCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Remove a policy of the message pool",
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("scope", true, false, "scope of the policy, sender or recipient"),
+		cmds.StringArg("address", true, false, "address of the policy"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		addr, err := address.NewFromString(req.Arguments[1])
+		if err != nil {
+			return err
+		}
+		scope := messagepool.PolicyScope(req.Arguments[0])
+		removed, err := env.(*node.Env).MessagePoolAPI.MpoolRemovePolicy(req.Context, scope, addr)
+		if err != nil {
+			return err
+		}
+		if !removed {
+			return xerrors.Errorf("no %s policy for %s", scope, addr)
+		}
+		return nil
+	},
+}
+
 var mpoolGasPerfCmd = &cmds.Command{
 	Helptext: cmds.HelpText{
 		Tagline: "gas-perf",
@@ -754,3 +979,292 @@ Check gas performance of messages in mempool
 		return nil
 	},
 }
+
+var mpoolGasEstimateCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Estimate the gas values of a message",
+		ShortDescription: `Estimates the gas limit, premium and fee cap of a message like it is done when pushing it.
+With --explain the sampled premiums, the projected base fee and the applied overestimation are printed.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("target", true, false, "address of the actor to send the message to"),
+		cmds.StringArg("value", true, false, "amount of FIL"),
+	},
+	Options: []cmds.Option{
+		cmds.StringOption("from", "address to send message from"),
+		cmds.Uint64Option("method", "The method to invoke on the target actor"),
+		cmds.StringOption("params-hex", "specify invocation parameters in hex"),
+		limitOption,
+		cmds.StringOption("max-fee", "maximal total fee in attoFIL, the default max fee otherwise"),
+		cmds.BoolOption("explain", "print how every value was estimated"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		toAddr, err := address.NewFromString(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		val, ok := types.NewAttoFILFromFILString(req.Arguments[1])
+		if !ok {
+			return xerrors.New("mal-formed value")
+		}
+		fromAddr, err := fromAddrOrDefault(req, env)
+		if err != nil {
+			return err
+		}
+
+		msg := &types.UnsignedMessage{
+			From:       fromAddr,
+			To:         toAddr,
+			Value:      val,
+			GasFeeCap:  big.Zero(),
+			GasPremium: big.Zero(),
+		}
+		if method, ok := req.Options["method"].(uint64); ok {
+			msg.Method = abi.MethodNum(method)
+		}
+		if gasLimit, ok := req.Options["gas-limit"].(int64); ok {
+			msg.GasLimit = gasLimit
+		}
+		if paramsHex, ok := req.Options["params-hex"].(string); ok {
+			if msg.Params, err = hex.DecodeString(paramsHex); err != nil {
+				return xerrors.Errorf("failed to decode hex params: %w", err)
+			}
+		}
+
+		var spec *types.MessageSendSpec
+		if maxFee, ok := req.Options["max-fee"].(string); ok {
+			mf, err := big.FromString(maxFee)
+			if err != nil {
+				return xerrors.Errorf("parsing max-fee: %w", err)
+			}
+			spec = &types.MessageSendSpec{MaxFee: mf}
+		}
+
+		report, err := env.(*node.Env).MessagePoolAPI.GasEstimateMessageGasExplain(req.Context, msg, spec, types.EmptyTSK)
+		if err != nil {
+			return err
+		}
+
+		buf := new(bytes.Buffer)
+		writer := NewSilentWriter(buf)
+		writer.Printf("GasLimit:   %d\n", report.Msg.GasLimit)
+		writer.Printf("GasPremium: %s\n", report.Msg.GasPremium)
+		writer.Printf("GasFeeCap:  %s\n", report.Msg.GasFeeCap)
+		if explain, _ := req.Options["explain"].(bool); explain {
+			writeGasEstimateReport(writer, report)
+		}
+
+		return re.Emit(buf)
+	},
+}
+
+func writeGasEstimateReport(w *SilentWriter, report *messagepool.GasEstimateReport) {
+	if r := report.GasLimit; r != nil {
+		w.Printf("\ngas limit: gas used %d x overestimation %.4f = %d\n", r.GasUsed, r.Overestimation, r.GasLimit)
+	}
+
+	if r := report.GasPremium; r != nil {
+		w.Printf("\ngas premium: %d messages sampled in %d blocks for inclusion within %d epochs\n", len(r.Samples), r.Blocks, r.NBlocksIncl)
+		for _, ts := range r.TipSets {
+			w.Printf("  %d: %d messages %s\n", ts.Height, ts.Messages, ts.Key)
+		}
+		w.Printf("  55th percentile premium: %s\n", r.Median)
+		if !r.Minimum.Nil() {
+			w.Printf("  raised to minimum premium: %s\n", r.Minimum)
+		}
+		w.Printf("  noise %.6f: %s\n", r.Noise, r.GasPremium)
+	}
+
+	if r := report.FeeCap; r != nil {
+		w.Printf("\nfee cap: parent base fee %s\n", r.ParentBaseFee)
+		w.Printf("  projected over %d epochs x%.4f: %s\n", r.MaxQueueBlocks, r.IncreaseFactor, r.ProjectedBaseFee)
+		w.Printf("  plus premium %s: %s\n", r.GasPremium, r.GasFeeCap)
+	}
+
+	w.Printf("\nmax fee: %s\n", types.FIL(report.MaxFee))
+	if report.Capped {
+		w.Printf("  fee cap and premium were lowered to respect the max fee\n")
+	}
+}
+
+var mpoolFixNonceCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Fill the nonce gaps of a local sender",
+		ShortDescription: `
+The pending messages of a sender after a nonce gap cannot be included. The gaps between the
+on-chain nonce and the pending messages are filled with re-signed copies of the dropped local
+messages, or with self-sends of no value when the dropped message is unknown.
+`,
+	},
+	Options: []cmds.Option{
+		cmds.StringOption("from", "the local sender"),
+		cmds.BoolOption("dry-run", "only print the messages filling the gaps"),
+		cmds.StringOption("max-fee", "spend up to X attoFIL for each message"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		fromStr, _ := req.Options["from"].(string)
+		if fromStr == "" {
+			return xerrors.New("'from' address is required")
+		}
+		from, err := address.NewFromString(fromStr)
+		if err != nil {
+			return xerrors.Errorf("'from' address was invalid: %w", err)
+		}
+		dryRun, _ := req.Options["dry-run"].(bool)
+
+		var spec *types.MessageSendSpec
+		if maxFee, ok := req.Options["max-fee"].(string); ok {
+			mf, err := big.FromString(maxFee)
+			if err != nil {
+				return xerrors.Errorf("parsing max-fee: %w", err)
+			}
+			spec = &types.MessageSendSpec{MaxFee: mf}
+		}
+
+		fix, err := env.(*node.Env).MessagePoolAPI.MpoolFixNonce(req.Context, from, spec, dryRun)
+		if err != nil {
+			return err
+		}
+
+		buf := new(bytes.Buffer)
+		writer := NewSilentWriter(buf)
+		writer.Printf("state nonce %d, highest pending nonce %d\n", fix.StateNonce, fix.MaxPending)
+		if len(fix.Fills) == 0 {
+			writer.Printf("no nonce gap\n")
+			return re.Emit(buf)
+		}
+		for _, fill := range fix.Fills {
+			kind := "self-send"
+			if fill.Dropped.Defined() {
+				kind = "re-signed " + fill.Dropped.String()
+			}
+			writer.Printf("nonce %d: %s, gas limit %d, fee cap %s, premium %s", fill.Nonce, kind,
+				fill.Message.GasLimit, fill.Message.GasFeeCap, fill.Message.GasPremium)
+			switch {
+			case fill.Error != "":
+				writer.Printf(", failed: %s", fill.Error)
+			case fill.Cid.Defined():
+				writer.Printf(", pushed %s", fill.Cid)
+			}
+			writer.Printf("\n")
+		}
+		if fix.DryRun {
+			writer.Printf("dry run, no message was pushed\n")
+		}
+
+		return re.Emit(buf)
+	},
+}
+
+var mpoolExportCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Export the pending messages to a file",
+		ShortDescription: `
+The file is a gzipped stream of JSON messages, like the test-messages.json.gz of the message pool
+tests, or a car file rooted at the messages. It can be imported in another node with mpool import.
+`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("file", true, false, "the file to write"),
+	},
+	Options: []cmds.Option{
+		cmds.StringOption("format", "the format of the file: json or car").WithDefault(string(messagepool.ExportJSON)),
+		cmds.BoolOption("local", "export the messages of the addresses in the local wallet only"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		format, _ := req.Options["format"].(string)
+		local, _ := req.Options["local"].(bool)
+
+		data, err := env.(*node.Env).MessagePoolAPI.MpoolExport(req.Context, local, messagepool.ExportFormat(format))
+		if err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(req.Arguments[0], data, 0644); err != nil {
+			return err
+		}
+
+		return re.Emit(fmt.Sprintf("exported the pending messages to %s", req.Arguments[0]))
+	},
+}
+
+var mpoolImportCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Import the messages of a file written by mpool export",
+		ShortDescription: `
+The messages are added to the pool as messages received from the network, they are not republished.
+`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("file", true, false, "the file to read"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		data, err := ioutil.ReadFile(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		report, err := env.(*node.Env).MessagePoolAPI.MpoolImport(req.Context, data)
+		if err != nil {
+			return err
+		}
+
+		buf := new(bytes.Buffer)
+		writer := NewSilentWriter(buf)
+		writer.Printf("imported %d messages, %d failed\n", report.Imported, len(report.Failed))
+		for _, f := range report.Failed {
+			writer.Printf("  %s: %s\n", f.Cid, f.Error)
+		}
+
+		return re.Emit(buf)
+	},
+}
+
+var mpoolHistoryCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Show what happened to a message seen by the message pool",
+		ShortDescription: `
+Lists when the message was added to the pool, replaced, republished, included on chain, executed and
+reverted. The history is only recorded with mpool.history.enable set in the config.
+`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("cid", true, false, "the cid of the message"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		mcid, err := cid.Decode(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		hist, err := env.(*node.Env).MessagePoolAPI.MpoolMessageHistory(req.Context, mcid)
+		if err != nil {
+			return err
+		}
+
+		buf := new(bytes.Buffer)
+		writer := NewSilentWriter(buf)
+		local := ""
+		if hist.Local {
+			local = " (local)"
+		}
+		writer.Printf("%s from %s nonce %d%s\n", hist.Message, hist.From, hist.Nonce, local)
+		for _, evt := range hist.Events {
+			writer.Printf("%s  %-11s", evt.Time.Format(time.RFC3339), evt.Type)
+			if evt.Reason != "" {
+				writer.Printf("  reason: %s", evt.Reason)
+			}
+			if evt.ReplacedBy != nil {
+				writer.Printf("  by: %s", evt.ReplacedBy)
+			}
+			if evt.TipSet != nil {
+				writer.Printf("  height: %d tipset: %s", evt.Height, evt.TipSet)
+			}
+			if evt.Receipt != nil {
+				writer.Printf("  exit code: %d gas used: %d", evt.Receipt.ExitCode, evt.Receipt.GasUsed)
+			}
+			writer.Println()
+		}
+
+		return re.Emit(buf)
+	},
+}
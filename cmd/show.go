@@ -1,12 +1,16 @@
 package cmd
 
 import (
+	"context"
+
 	"github.com/filecoin-project/venus/app/node"
+	"github.com/filecoin-project/venus/app/submodule/apiface"
 	"github.com/filecoin-project/venus/app/submodule/apitypes"
 	"github.com/filecoin-project/venus/pkg/types"
 
 	"github.com/ipfs/go-cid"
 	cmds "github.com/ipfs/go-ipfs-cmds"
+	"golang.org/x/xerrors"
 )
 
 var showCmd = &cmds.Command{
@@ -102,13 +106,16 @@ var showMessageCmd = &cmds.Command{
 
 var showMessagesCmd = &cmds.Command{
 	Helptext: cmds.HelpText{
-		Tagline: "Show a filecoin message collection by txmeta CID",
-		ShortDescription: `Prints info for all messages in a collection,
-at the given CID.  This CID is found in the "Messages" field of
-the filecoin block header.`,
+		Tagline: "Show the messages included in a block by the block CID",
+		ShortDescription: `Prints info for all messages included in the block
+with the given CID. With --decode the params of every message are decoded
+according to the method of the actor receiving it.`,
 	},
 	Arguments: []cmds.Argument{
-		cmds.StringArg("cid", true, false, "CID of message collection to show"),
+		cmds.StringArg("cid", true, false, "CID of block to show the messages of"),
+	},
+	Options: []cmds.Option{
+		cmds.BoolOption("decode", "decode the params of the messages"),
 	},
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
 		cid, err := cid.Decode(req.Arguments[0])
@@ -116,14 +123,31 @@ the filecoin block header.`,
 			return err
 		}
 
-		bmsg, err := env.(*node.Env).ChainAPI.ChainGetBlockMessages(req.Context, cid)
+		chainAPI := env.(*node.Env).ChainAPI
+		bmsg, err := chainAPI.ChainGetBlockMessages(req.Context, cid)
 		if err != nil {
 			return err
 		}
 
-		return re.Emit(bmsg)
+		if decode, _ := req.Options["decode"].(bool); !decode {
+			return re.Emit(bmsg)
+		}
+
+		// the messages are applied on the parent state of the block.
+		tsk := types.NewTipSetKey(cid)
+		decoded := DecodedBlockMessages{
+			BlockMessages: *bmsg,
+			Decoded:       make([]DecodedParams, 0, len(bmsg.Cids)),
+		}
+		for _, msg := range bmsg.BlsMessages {
+			decoded.Decoded = append(decoded.Decoded, decodeParams(req.Context, chainAPI, msg, tsk))
+		}
+		for _, msg := range bmsg.SecpkMessages {
+			decoded.Decoded = append(decoded.Decoded, decodeParams(req.Context, chainAPI, &msg.Message, tsk))
+		}
+		return re.Emit(&decoded)
 	},
-	Type: &apitypes.BlockMessages{},
+	Type: &DecodedBlockMessages{},
 }
 
 var showReceiptsCmd = &cmds.Command{
@@ -131,23 +155,117 @@ var showReceiptsCmd = &cmds.Command{
 		Tagline: "Show a filecoin receipt collection by its CID",
 		ShortDescription: `Prints info for all receipts in a collection,
 at the given CID.  MessageReceipt collection CIDs are found in the "ParentMessageReceipts"
-field of the filecoin block header.`,
+field of the filecoin block header. With --decode the return values are decoded
+according to the methods called, which requires --block to be the CID of the block
+the collection is the "ParentMessageReceipts" of.`,
 	},
 	Arguments: []cmds.Argument{
 		cmds.StringArg("cid", true, false, "CID of receipt collection to show"),
 	},
+	Options: []cmds.Option{
+		cmds.BoolOption("decode", "decode the return values of the receipts"),
+		cmds.StringOption("block", "CID of the block referencing the receipt collection, required by --decode"),
+	},
 	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
-		cid, err := cid.Decode(req.Arguments[0])
+		rctsCid, err := cid.Decode(req.Arguments[0])
 		if err != nil {
 			return err
 		}
 
-		receipts, err := env.(*node.Env).ChainAPI.ChainGetReceipts(req.Context, cid)
+		chainAPI := env.(*node.Env).ChainAPI
+		receipts, err := chainAPI.ChainGetReceipts(req.Context, rctsCid)
 		if err != nil {
 			return err
 		}
 
-		return re.Emit(receipts)
+		if decode, _ := req.Options["decode"].(bool); !decode {
+			plain := make([]DecodedReceipt, len(receipts))
+			for i, rct := range receipts {
+				plain[i] = DecodedReceipt{MessageReceipt: rct}
+			}
+			return re.Emit(plain)
+		}
+
+		blockStr, _ := req.Options["block"].(string)
+		if blockStr == "" {
+			return xerrors.New("--decode requires --block")
+		}
+		blkCid, err := cid.Decode(blockStr)
+		if err != nil {
+			return err
+		}
+		blk, err := chainAPI.ChainGetBlock(req.Context, blkCid)
+		if err != nil {
+			return err
+		}
+		if !blk.ParentMessageReceipts.Equals(rctsCid) {
+			return xerrors.Errorf("the parent receipts of block %s are %s, not %s", blkCid, blk.ParentMessageReceipts, rctsCid)
+		}
+		msgs, err := chainAPI.ChainGetParentMessages(req.Context, blkCid)
+		if err != nil {
+			return err
+		}
+		if len(msgs) != len(receipts) {
+			return xerrors.Errorf("found %d parent messages for %d receipts", len(msgs), len(receipts))
+		}
+
+		decoded := make([]DecodedReceipt, len(receipts))
+		for i, rct := range receipts {
+			msgCid := msgs[i].Cid
+			decoded[i] = DecodedReceipt{
+				MessageReceipt: rct,
+				Message:        &msgCid,
+			}
+			if rct.ExitCode.IsError() {
+				continue
+			}
+			ret, err := chainAPI.StateDecodeReturn(req.Context, msgs[i].Message.To, msgs[i].Message.Method, rct.ReturnValue, blk.Parents)
+			if err != nil {
+				decoded[i].DecodeError = err.Error()
+				continue
+			}
+			decoded[i].Return = ret
+		}
+		return re.Emit(decoded)
 	},
-	Type: []types.MessageReceipt{},
+	Type: []DecodedReceipt{},
+}
+
+// DecodedParams are the params of a message decoded according to the method called, a failure
+// to decode them is reported in DecodeError.
+type DecodedParams struct {
+	Params      interface{} `json:",omitempty"`
+	DecodeError string      `json:",omitempty"`
+}
+
+// DecodedBlockMessages are the messages of a block, with their params decoded in the order of Cids
+// when --decode is set.
+type DecodedBlockMessages struct {
+	apitypes.BlockMessages
+	Decoded []DecodedParams `json:",omitempty"`
+}
+
+// DecodedReceipt is a receipt, with the message it is for and its return value decoded according to
+// the method called when --decode is set.
+type DecodedReceipt struct {
+	types.MessageReceipt
+	Message     *cid.Cid    `json:",omitempty"`
+	Return      interface{} `json:",omitempty"`
+	DecodeError string      `json:",omitempty"`
+}
+
+// decodeParams decodes the params of msg with the actors in the parent state of tsk, a failure
+// to decode is reported in the result rather than failing the whole command.
+func decodeParams(ctx context.Context, chainAPI apiface.IChain, msg *types.UnsignedMessage, tsk types.TipSetKey) DecodedParams {
+	var decoded DecodedParams
+	if len(msg.Params) == 0 {
+		return decoded
+	}
+	params, err := chainAPI.StateDecodeParams(ctx, msg.To, msg.Method, msg.Params, tsk)
+	if err != nil {
+		decoded.DecodeError = err.Error()
+		return decoded
+	}
+	decoded.Params = params
+	return decoded
 }
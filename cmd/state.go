@@ -45,12 +45,16 @@ var stateCmd = &cmds.Command{
 		"active-sectors":  stateActiveSectorsCmd,
 		"sector":          stateSectorCmd,
 		"get-actor":       stateGetActorCmd,
+		"read-state":      stateReadStateCmd,
+		"diff":            stateDiffCmd,
 		"lookup":          stateLookupIDCmd,
 		"sector-size":     stateSectorSizeCmd,
 		"get-deal":        stateGetDealSetCmd,
 		"miner-info":      stateMinerInfo,
 		"network-version": stateNtwkVersionCmd,
 		"list-actor":      stateListActorCmd,
+		"replay":          stateReplayCmd,
+		"compute":         stateComputeCmd,
 	},
 }
 
@@ -352,6 +356,70 @@ var stateGetActorCmd = &cmds.Command{
 	Type: ActorInfo{},
 }
 
+var stateReadStateCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline:          "Print the decoded state of an actor",
+		ShortDescription: `Prints the balance, code and state object of the actor as JSON, for any version of the built-in actors.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("address", true, false, "Address of actor to read the state of"),
+	},
+	Options: []cmds.Option{
+		cmds.StringOption("tipset", "specify tipset to read the state at, defaults to the head").WithDefault(""),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		addr, err := address.NewFromString(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		ts, err := LoadTipSet(req.Context, req, env.(*node.Env).ChainAPI)
+		if err != nil {
+			return err
+		}
+
+		state, err := env.(*node.Env).ChainAPI.StateReadState(req.Context, addr, ts.Key())
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(state)
+	},
+	Type: apitypes.ActorState{},
+}
+
+var stateDiffCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Compare the states of two tipsets",
+		ShortDescription: `Prints the actors added, removed and modified between the parent states of the two tipsets.
+For the miner, storage market and storage power actors the changes of their state are decoded.
+Tipsets are given as comma separated block CIDs or as @<height>.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("from", true, false, "tipset to compare from"),
+		cmds.StringArg("to", true, false, "tipset to compare to"),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		chainAPI := env.(*node.Env).ChainAPI
+		from, err := ParseTipSetRef(req.Context, chainAPI, req.Arguments[0])
+		if err != nil {
+			return err
+		}
+		to, err := ParseTipSetRef(req.Context, chainAPI, req.Arguments[1])
+		if err != nil {
+			return err
+		}
+
+		diff, err := chainAPI.StateDiff(req.Context, from.Key(), to.Key())
+		if err != nil {
+			return err
+		}
+
+		return re.Emit(diff)
+	},
+	Type: apitypes.StateDiff{},
+}
+
 var stateLookupIDCmd = &cmds.Command{
 	Helptext: cmds.HelpText{
 		Tagline: "Find corresponding ID address",
@@ -588,6 +656,148 @@ var stateListActorCmd = &cmds.Command{
 	},
 }
 
+var stateReplayCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Replay a message that was included on chain",
+		ShortDescription: `Replays the message on the parent state of the tipset including it and prints its receipt.
+Without --tipset the message is searched on chain, if it was replaced the replacing message is replayed.`,
+	},
+	Arguments: []cmds.Argument{
+		cmds.StringArg("cid", true, false, "CID of the message to replay"),
+	},
+	Options: []cmds.Option{
+		cmds.StringOption("tipset", "tipset including the message, searched on chain by default").WithDefault(""),
+		cmds.BoolOption("show-trace", "print the calls made by the message").WithDefault(false),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		mcid, err := cid.Decode(req.Arguments[0])
+		if err != nil {
+			return err
+		}
+
+		tsk := types.EmptyTSK
+		if tss := req.Options["tipset"].(string); tss != "" {
+			ts, err := ParseTipSetRef(req.Context, env.(*node.Env).ChainAPI, tss)
+			if err != nil {
+				return err
+			}
+			tsk = ts.Key()
+		}
+
+		res, err := env.(*node.Env).ChainAPI.StateReplay(req.Context, tsk, mcid)
+		if err != nil {
+			return err
+		}
+
+		buf := new(bytes.Buffer)
+		writer := NewSilentWriter(buf)
+		if res.MsgCid != mcid {
+			writer.Printf("message was replaced by: %s\n", res.MsgCid)
+		}
+		writeInvocResult(writer, res, req.Options["show-trace"].(bool))
+
+		return re.Emit(buf)
+	},
+}
+
+var stateComputeCmd = &cmds.Command{
+	Helptext: cmds.HelpText{
+		Tagline: "Compute the state of a tipset",
+		ShortDescription: `Applies the messages of the tipset on its parent state and prints the resulting state root.
+With --height, the state migrations up to that height are run on the result, and with --apply-mpool-messages
+the pending messages of the message pool are applied on top of it.`,
+	},
+	Options: []cmds.Option{
+		cmds.StringOption("tipset").WithDefault(""),
+		cmds.Int64Option("height", "height to compute the state at, defaults to the tipset height").WithDefault(int64(-1)),
+		cmds.BoolOption("apply-mpool-messages", "apply the pending messages of the message pool").WithDefault(false),
+		cmds.BoolOption("show-trace", "print the result and the calls of every message applied").WithDefault(false),
+	},
+	Run: func(req *cmds.Request, re cmds.ResponseEmitter, env cmds.Environment) error {
+		ts, err := LoadTipSet(req.Context, req, env.(*node.Env).ChainAPI)
+		if err != nil {
+			return err
+		}
+
+		height := ts.Height()
+		if h := req.Options["height"].(int64); h >= 0 {
+			height = abi.ChainEpoch(h)
+		}
+
+		var msgs []*types.UnsignedMessage
+		if req.Options["apply-mpool-messages"].(bool) {
+			pending, err := env.(*node.Env).MessagePoolAPI.MpoolPending(req.Context, ts.Key())
+			if err != nil {
+				return err
+			}
+			for _, sm := range pending {
+				msgs = append(msgs, &sm.Message)
+			}
+		}
+
+		out, err := env.(*node.Env).ChainAPI.StateCompute(req.Context, height, msgs, ts.Key())
+		if err != nil {
+			return err
+		}
+
+		buf := new(bytes.Buffer)
+		writer := NewSilentWriter(buf)
+		writer.Printf("computed state root: %s\n", out.Root)
+		if req.Options["show-trace"].(bool) {
+			for _, res := range out.Trace {
+				writer.Println()
+				if res.Msg != nil && res.Msg.From == builtin.SystemActorAddr {
+					writer.Printf("implicit message: %s\n", res.MsgCid)
+				} else {
+					writer.Printf("message: %s\n", res.MsgCid)
+				}
+				writeInvocResult(writer, res, true)
+			}
+		}
+
+		return re.Emit(buf)
+	},
+}
+
+func writeInvocResult(writer *SilentWriter, res *apitypes.InvocResult, showTrace bool) {
+	writer.Printf("Exit Code: %d\n", res.MsgRct.ExitCode)
+	writer.Printf("Gas Used: %d\n", res.MsgRct.GasUsed)
+	writer.Printf("Return: %x\n", res.MsgRct.ReturnValue)
+	if res.Error != "" {
+		writer.Printf("Error: %s\n", res.Error)
+	}
+	writer.Printf("Duration: %s\n", res.Duration)
+	if showTrace {
+		writer.Println("Trace:")
+		writeExecutionTrace(writer, "  ", &res.ExecutionTrace)
+	}
+}
+
+// writeExecutionTrace writes trace and its subcalls as a tree, with the gas charged by each call.
+func writeExecutionTrace(writer *SilentWriter, indent string, trace *types.ExecutionTrace) {
+	var gasCharged int64
+	for _, charge := range trace.GasCharges {
+		gasCharged += charge.TotalGas
+	}
+
+	if trace.Msg != nil {
+		writer.Printf("%s%s -> %s method %d value %s", indent, trace.Msg.From, trace.Msg.To, trace.Msg.Method, types.FIL(trace.Msg.Value))
+	} else {
+		writer.Printf("%s<unknown call>", indent)
+	}
+	if trace.MsgRct != nil {
+		writer.Printf(", exit code %d", trace.MsgRct.ExitCode)
+	}
+	writer.Printf(", gas charged %d, took %s\n", gasCharged, trace.Duration)
+	if trace.Error != "" {
+		writer.Printf("%s  error: %s\n", indent, trace.Error)
+	}
+
+	for i := range trace.Subcalls {
+		writeExecutionTrace(writer, indent+"  ", &trace.Subcalls[i])
+	}
+}
+
 func makeActorView(act *types.Actor, addr address.Address) *ActorView {
 	return &ActorView{
 		Address: addr.String(),
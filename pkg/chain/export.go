@@ -0,0 +1,198 @@
+package chain
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/ipfs/go-cid"
+	"github.com/ipld/go-car"
+	carutil "github.com/ipld/go-car/util"
+	"github.com/klauspost/compress/zstd"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus/pkg/types"
+)
+
+// zstdMagic starts every zstd frame, it is used to detect compressed car files.
+var zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+
+// ExportMode selects what part of the chain is exported.
+type ExportMode int
+
+const (
+	// ExportFull exports the chain from the tipset down to genesis with the
+	// state roots of the most recent epochs, see Store.Export.
+	ExportFull ExportMode = iota
+	// ExportRange exports the block headers and messages of the epochs from
+	// ExportOptions.From up to the tipset, without any state.
+	ExportRange
+	// ExportState exports the state tree of the tipset only.
+	ExportState
+)
+
+// ExportOptions configures Store.ExportWithOptions.
+type ExportOptions struct {
+	Mode ExportMode
+	// RecentStateRoots is the number of epochs of state roots included in a full export.
+	RecentStateRoots abi.ChainEpoch
+	// SkipOldMsgs leaves the messages older than RecentStateRoots out of a full export.
+	SkipOldMsgs bool
+	// From is the lowest epoch included in a range export.
+	From abi.ChainEpoch
+	// Compress compresses the car file with zstd.
+	Compress bool
+}
+
+// ExportWithOptions writes the part of the chain selected by opts, ending at ts, to w as a car file.
+// A range export is rooted at ts like a full export, a state export is rooted at the parent
+// state root of ts, which is the state the tipset was built on.
+func (store *Store) ExportWithOptions(ctx context.Context, ts *types.TipSet, opts ExportOptions, w io.Writer) (err error) {
+	if opts.Compress {
+		zw, zerr := zstd.NewWriter(w)
+		if zerr != nil {
+			return xerrors.Errorf("failed to create zstd writer: %w", zerr)
+		}
+		defer func() {
+			if cerr := zw.Close(); cerr != nil && err == nil {
+				err = xerrors.Errorf("failed to flush zstd writer: %w", cerr)
+			}
+		}()
+		w = zw
+	}
+
+	switch opts.Mode {
+	case ExportFull:
+		return store.Export(ctx, ts, opts.RecentStateRoots, opts.SkipOldMsgs, w)
+	case ExportRange:
+		if opts.From < 0 || opts.From > ts.Height() {
+			return xerrors.Errorf("invalid range [%d, %d]", opts.From, ts.Height())
+		}
+		return store.writeCar(w, ts.Cids(), func(cb func(cid.Cid) error) error {
+			return store.walkRange(ctx, ts, opts.From, cb)
+		})
+	case ExportState:
+		root := ts.At(0).ParentStateRoot
+		return store.writeCar(w, []cid.Cid{root}, func(cb func(cid.Cid) error) error {
+			return store.walkState(ctx, root, cb)
+		})
+	default:
+		return xerrors.Errorf("unknown export mode %d", opts.Mode)
+	}
+}
+
+// walkRange calls cb for the block headers and messages of the chain ending at ts
+// whose height is at least from.
+func (store *Store) walkRange(ctx context.Context, ts *types.TipSet, from abi.ChainEpoch, cb func(cid.Cid) error) error {
+	seen := cid.NewSet()
+	walked := cid.NewSet()
+
+	blocksToWalk := ts.Cids()
+	for len(blocksToWalk) > 0 {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		next := blocksToWalk[0]
+		blocksToWalk = blocksToWalk[1:]
+		if !seen.Visit(next) {
+			continue
+		}
+
+		b, err := store.GetBlock(ctx, next)
+		if err != nil {
+			return xerrors.Errorf("getting block: %w", err)
+		}
+		if b.Height < from {
+			continue
+		}
+		if err := cb(next); err != nil {
+			return err
+		}
+
+		if walked.Visit(b.Messages) {
+			mcids, err := recurseLinks(store.bsstore, walked, b.Messages, []cid.Cid{b.Messages})
+			if err != nil {
+				return xerrors.Errorf("recursing messages failed: %w", err)
+			}
+			for _, c := range mcids {
+				if c.Prefix().Codec != cid.DagCBOR {
+					continue
+				}
+				if err := cb(c); err != nil {
+					return err
+				}
+			}
+		}
+
+		if b.Height > from {
+			blocksToWalk = append(blocksToWalk, b.Parents.Cids()...)
+		}
+	}
+	return nil
+}
+
+// walkState calls cb for every object of the state tree at root.
+func (store *Store) walkState(ctx context.Context, root cid.Cid, cb func(cid.Cid) error) error {
+	cids, err := recurseLinks(store.bsstore, cid.NewSet(), root, []cid.Cid{root})
+	if err != nil {
+		return xerrors.Errorf("recursing state failed: %w", err)
+	}
+
+	for _, c := range cids {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if c.Prefix().Codec != cid.DagCBOR {
+			continue
+		}
+		if err := cb(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeCar writes a car file with the given roots and the objects visited by walk.
+func (store *Store) writeCar(w io.Writer, roots []cid.Cid, walk func(cb func(cid.Cid) error) error) error {
+	h := &car.CarHeader{
+		Roots:   roots,
+		Version: 1,
+	}
+
+	if err := car.WriteHeader(h, w); err != nil {
+		return xerrors.Errorf("failed to write car header: %s", err)
+	}
+
+	return walk(func(c cid.Cid) error {
+		blk, err := store.bsstore.Get(c)
+		if err != nil {
+			return xerrors.Errorf("writing object to car, bs.Get: %w", err)
+		}
+
+		if err := carutil.LdWrite(w, c.Bytes(), blk.RawData()); err != nil {
+			return xerrors.Errorf("failed to write block to car output: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// openCar returns a buffered reader of the car file read from r, which is decompressed
+// if it is zstd compressed, and a function releasing the decompressor.
+func openCar(r io.Reader) (*bufio.Reader, bool, func(), error) {
+	br := bufio.NewReaderSize(r, 1<<20)
+	magic, err := br.Peek(len(zstdMagic))
+	if err != nil || !bytes.Equal(magic, zstdMagic) {
+		// a read error here shows up again when reading the car header.
+		return br, false, func() {}, nil
+	}
+
+	dec, err := zstd.NewReader(br)
+	if err != nil {
+		return nil, false, nil, xerrors.Errorf("failed to create zstd reader: %w", err)
+	}
+	return bufio.NewReaderSize(dec, 1<<20), true, dec.Close, nil
+}
@@ -0,0 +1,181 @@
+package chain_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/ipfs/go-cid"
+	"github.com/ipld/go-car"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/venus/pkg/chain"
+	"github.com/filecoin-project/venus/pkg/repo"
+	tf "github.com/filecoin-project/venus/pkg/testhelpers/testflags"
+	"github.com/filecoin-project/venus/pkg/types"
+	"github.com/filecoin-project/venus/pkg/util/blockstoreutil"
+)
+
+func TestExportRangeCompressed(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	builder := chain.NewBuilder(t, address.Undef)
+	genTS := builder.Genesis()
+	link1 := builder.AppendOn(genTS, 1)
+	link2 := builder.AppendOn(link1, 2)
+	link3 := builder.AppendOn(link2, 1)
+
+	var buf bytes.Buffer
+	opts := chain.ExportOptions{Mode: chain.ExportRange, From: link2.Height(), Compress: true}
+	require.NoError(t, builder.Store().ExportWithOptions(ctx, link3, opts, &buf))
+
+	dec, err := zstd.NewReader(&buf)
+	require.NoError(t, err)
+	defer dec.Close()
+
+	bs := blockstoreutil.NewTemporary()
+	header, err := car.LoadCar(bs, dec)
+	require.NoError(t, err)
+	assert.Equal(t, link3.Cids(), header.Roots)
+
+	for _, blk := range append(link3.Blocks(), link2.Blocks()...) {
+		has, err := bs.Has(blk.Cid())
+		require.NoError(t, err)
+		assert.True(t, has)
+
+		has, err = bs.Has(blk.Messages)
+		require.NoError(t, err)
+		assert.True(t, has)
+	}
+	// blocks below the range and state are left out.
+	has, err := bs.Has(link1.At(0).Cid())
+	require.NoError(t, err)
+	assert.False(t, has)
+	has, err = bs.Has(link3.At(0).ParentStateRoot)
+	require.NoError(t, err)
+	assert.False(t, has)
+}
+
+func TestImportDetectsCompressedCar(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	builder := chain.NewBuilder(t, address.Undef)
+	genTS := builder.Genesis()
+	link1 := builder.AppendOn(genTS, 1)
+	link2 := builder.AppendOn(link1, 2)
+
+	var buf bytes.Buffer
+	opts := chain.ExportOptions{Mode: chain.ExportFull, RecentStateRoots: 2, Compress: true}
+	require.NoError(t, builder.Store().ExportWithOptions(ctx, link2, opts, &buf))
+
+	r := repo.NewInMemoryRepo()
+	cs := newChainStore(r, genTS)
+	head, err := cs.Import(&buf)
+	require.NoError(t, err)
+	// like for plain car files, the parent of the root tipset is used as head.
+	assert.Equal(t, link1.Key(), head.Key())
+}
+
+func TestImportRoundTrip(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	builder := chain.NewBuilder(t, address.Undef)
+	genTS := builder.Genesis()
+	link1 := builder.AppendOn(genTS, 1)
+	link2 := builder.AppendOn(link1, 2)
+	link3 := builder.AppendOn(link2, 1)
+
+	export := func(opts chain.ExportOptions) *bytes.Buffer {
+		var buf bytes.Buffer
+		require.NoError(t, builder.Store().ExportWithOptions(ctx, link3, opts, &buf))
+		return &buf
+	}
+
+	t.Run("full", func(t *testing.T) {
+		for _, compress := range []bool{false, true} {
+			buf := export(chain.ExportOptions{Mode: chain.ExportFull, RecentStateRoots: 2, Compress: compress})
+			cs := newChainStore(repo.NewInMemoryRepo(), genTS)
+			head, err := cs.Import(buf)
+			require.NoError(t, err)
+			assert.Equal(t, link2.Key(), head.Key())
+		}
+	})
+
+	t.Run("range", func(t *testing.T) {
+		buf := export(chain.ExportOptions{Mode: chain.ExportRange, From: link2.Height()})
+		cs := newChainStore(repo.NewInMemoryRepo(), genTS)
+		_, err := cs.Import(buf)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "range exports can not be imported")
+
+		// the genesis block of the node does not complete the range
+		r := repo.NewInMemoryRepo()
+		genBlk, err := genTS.At(0).ToStorageBlock()
+		require.NoError(t, err)
+		require.NoError(t, r.Datastore().Put(genBlk))
+		cs = newChainStore(r, genTS)
+		_, err = cs.Import(export(chain.ExportOptions{Mode: chain.ExportRange, From: link2.Height()}))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "range exports can not be imported")
+
+		// a range reaching genesis still has no state for the head
+		cs = newChainStore(repo.NewInMemoryRepo(), genTS)
+		_, err = cs.Import(export(chain.ExportOptions{Mode: chain.ExportRange, From: 0}))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "range exports can not be imported")
+	})
+
+	t.Run("state", func(t *testing.T) {
+		buf := export(chain.ExportOptions{Mode: chain.ExportState, Compress: true})
+		cs := newChainStore(repo.NewInMemoryRepo(), genTS)
+		_, err := cs.Import(buf)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "state exports can not be imported")
+	})
+}
+
+func TestCopyReachable(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	builder := chain.NewBuilder(t, address.Undef)
+	genTS := builder.Genesis()
+	link1 := builder.AppendOn(genTS, 1)
+	link2 := builder.AppendOn(link1, 2)
+	link3 := builder.AppendOn(link2, 1)
+	// record a computed state of link3, made of the objects of link2
+	require.NoError(t, builder.Store().PutTipSetMetadata(ctx, &chain.TipSetMetadata{
+		TipSet:          link3,
+		TipSetStateRoot: link2.At(0).ParentStateRoot,
+		TipSetReceipts:  link2.At(0).ParentMessageReceipts,
+	}))
+
+	to := blockstoreutil.NewTemporary()
+	copied, err := builder.Store().CopyReachable(ctx, link3, 1, true, to)
+	require.NoError(t, err)
+	assert.Equal(t, len(to), copied)
+
+	mustHave := func(c cid.Cid) {
+		has, err := to.Has(c)
+		require.NoError(t, err)
+		assert.True(t, has, c.String())
+	}
+	for _, ts := range []*types.TipSet{genTS, link1, link2, link3} {
+		for _, blk := range ts.Blocks() {
+			mustHave(blk.Cid())
+		}
+	}
+	mustHave(link3.At(0).Messages)
+	mustHave(link3.At(0).ParentStateRoot)
+	mustHave(genTS.At(0).ParentStateRoot)
+
+	// the state computed on top of the tipset is copied as well
+	mustHave(link2.At(0).ParentStateRoot)
+	mustHave(link2.At(0).ParentMessageReceipts)
+}
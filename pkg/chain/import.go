@@ -0,0 +1,210 @@
+package chain
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/ipld/go-car"
+	carutil "github.com/ipld/go-car/util"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus/pkg/types"
+)
+
+// ImportProgressKey is the key at which the progress of an interrupted import is written in the datastore.
+var ImportProgressKey = datastore.NewKey("/chain/importProgress")
+
+// defaultImportBatchSize is the number of blocks written at once, progress is saved after each batch.
+const defaultImportBatchSize = 4096
+
+// ImportProgress is the progress of a chain import.
+type ImportProgress struct {
+	// Source identifies the imported car file.
+	Source string
+	// Roots are the roots of the car file.
+	Roots []cid.Cid
+	// Offset is the number of bytes of the car file already imported,
+	// counted after decompression for a compressed car file.
+	Offset int64
+	// Compressed is set when the car file is zstd compressed.
+	Compressed bool
+	// Blocks is the number of blocks already imported.
+	Blocks int64
+}
+
+// ImportOptions configures Store.ImportStream.
+type ImportOptions struct {
+	// Source identifies the car file, an interrupted import is only resumed for the same source.
+	Source string
+	// Resume continues an interrupted import of Source instead of starting over.
+	Resume bool
+	// Open returns the car file starting at offset. A compressed car file can not
+	// be read from the middle, it is always opened at offset 0 and skipped through.
+	Open func(offset int64) (io.ReadCloser, error)
+	// OnProgress is called each time a batch of blocks has been written.
+	OnProgress func(ImportProgress)
+	// BatchSize is the number of blocks written between two progress saves,
+	// 4096 if zero.
+	BatchSize int
+}
+
+// ImportStream imports a car file block by block, saving its progress after each
+// batch so that an interrupted import can be resumed from where it stopped.
+// It returns the tipset to use as head, like Import.
+func (store *Store) ImportStream(ctx context.Context, opts ImportOptions) (*types.TipSet, error) {
+	progress := ImportProgress{Source: opts.Source}
+	if opts.Resume {
+		saved, err := store.loadImportProgress()
+		if err != nil {
+			return nil, err
+		}
+		if saved != nil && saved.Source == opts.Source {
+			log.Infof("resuming import of %s at %d bytes, %d blocks", saved.Source, saved.Offset, saved.Blocks)
+			progress = *saved
+		}
+	}
+
+	openAt := progress.Offset
+	if progress.Compressed {
+		openAt = 0
+	}
+	rc, err := opts.Open(openAt)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close() //nolint:errcheck
+
+	var br *bufio.Reader
+	if openAt == 0 {
+		var release func()
+		br, progress.Compressed, release, err = openCar(rc)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+	} else {
+		br = bufio.NewReaderSize(rc, 1<<20)
+	}
+
+	if progress.Compressed && progress.Offset > 0 {
+		if _, err := io.CopyN(ioutil.Discard, br, progress.Offset); err != nil {
+			return nil, xerrors.Errorf("failed to skip imported data: %w", err)
+		}
+	}
+	if progress.Offset == 0 {
+		hb, n, err := carutil.LdRead(br)
+		if err != nil {
+			return nil, xerrors.Errorf("failed to read car header: %w", err)
+		}
+		var header car.CarHeader
+		if err := cbor.DecodeInto(hb, &header); err != nil {
+			return nil, xerrors.Errorf("invalid car header: %w", err)
+		}
+		if header.Version != 1 {
+			return nil, xerrors.Errorf("invalid car version: %d", header.Version)
+		}
+		progress.Roots = header.Roots
+		progress.Offset = int64(n)
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultImportBatchSize
+	}
+	batch := make([]blocks.Block, 0, batchSize)
+	flush := func(offset int64) error {
+		if err := store.bsstore.PutMany(batch); err != nil {
+			return xerrors.Errorf("failed to write blocks: %w", err)
+		}
+		progress.Blocks += int64(len(batch))
+		progress.Offset = offset
+		batch = batch[:0]
+
+		if err := store.saveImportProgress(&progress); err != nil {
+			return err
+		}
+		if opts.OnProgress != nil {
+			opts.OnProgress(progress)
+		}
+		return nil
+	}
+
+	offset := progress.Offset
+	for {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		data, size, err := carutil.LdRead(br)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, xerrors.Errorf("failed to read block at offset %d: %w", offset, err)
+		}
+
+		n, c, err := cid.CidFromBytes(data)
+		if err != nil {
+			return nil, xerrors.Errorf("invalid cid at offset %d: %w", offset, err)
+		}
+		blk, err := blocks.NewBlockWithCid(data[n:], c)
+		if err != nil {
+			return nil, err
+		}
+		batch = append(batch, blk)
+		offset += int64(size)
+
+		if len(batch) >= batchSize {
+			if err := flush(offset); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := flush(offset); err != nil {
+		return nil, err
+	}
+
+	head, err := store.importRoots(progress.Roots)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := store.ds.Delete(ImportProgressKey); err != nil {
+		return nil, xerrors.Errorf("failed to clear import progress: %w", err)
+	}
+	return head, nil
+}
+
+func (store *Store) loadImportProgress() (*ImportProgress, error) {
+	val, err := store.ds.Get(ImportProgressKey)
+	if err == datastore.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("failed to read import progress: %w", err)
+	}
+
+	var progress ImportProgress
+	if err := json.Unmarshal(val, &progress); err != nil {
+		return nil, xerrors.Errorf("failed to decode import progress: %w", err)
+	}
+	return &progress, nil
+}
+
+func (store *Store) saveImportProgress(progress *ImportProgress) error {
+	val, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+	if err := store.ds.Put(ImportProgressKey, val); err != nil {
+		return xerrors.Errorf("failed to save import progress: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package chain_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/venus/pkg/chain"
+	"github.com/filecoin-project/venus/pkg/repo"
+	tf "github.com/filecoin-project/venus/pkg/testhelpers/testflags"
+	"github.com/filecoin-project/venus/pkg/types"
+)
+
+func TestImportStreamResume(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	builder := chain.NewBuilder(t, address.Undef)
+	genTS := builder.Genesis()
+	link1 := builder.AppendOn(genTS, 1)
+	link2 := builder.AppendOn(link1, 2)
+	link3 := builder.AppendOn(link2, 1)
+
+	for _, compress := range []bool{false, true} {
+		var buf bytes.Buffer
+		opts := chain.ExportOptions{Mode: chain.ExportFull, RecentStateRoots: 2, Compress: compress}
+		require.NoError(t, builder.Store().ExportWithOptions(ctx, link3, opts, &buf))
+		data := buf.Bytes()
+
+		var opened []int64
+		open := func(offset int64) (io.ReadCloser, error) {
+			opened = append(opened, offset)
+			return ioutil.NopCloser(bytes.NewReader(data[offset:])), nil
+		}
+
+		r := repo.NewInMemoryRepo()
+		cs := newChainStore(r, genTS)
+
+		// interrupt the import after the first batch.
+		interruptCtx, cancel := context.WithCancel(ctx)
+		var first chain.ImportProgress
+		_, err := cs.ImportStream(interruptCtx, chain.ImportOptions{
+			Source:    "snapshot.car",
+			Open:      open,
+			BatchSize: 2,
+			OnProgress: func(p chain.ImportProgress) {
+				if first.Blocks == 0 {
+					first = p
+				}
+				cancel()
+			},
+		})
+		require.Equal(t, context.Canceled, err)
+		assert.Equal(t, int64(2), first.Blocks)
+		assert.Equal(t, compress, first.Compressed)
+		assert.True(t, first.Offset > 0)
+
+		val, err := r.ChainDatastore().Get(chain.ImportProgressKey)
+		require.NoError(t, err)
+		var saved chain.ImportProgress
+		require.NoError(t, json.Unmarshal(val, &saved))
+		assert.Equal(t, first, saved)
+
+		// an import of another source starts over.
+		opened = nil
+		otherCtx, cancelOther := context.WithCancel(ctx)
+		_, err = cs.ImportStream(otherCtx, chain.ImportOptions{
+			Source:     "other.car",
+			Resume:     true,
+			Open:       open,
+			BatchSize:  2,
+			OnProgress: func(chain.ImportProgress) { cancelOther() },
+		})
+		require.Equal(t, context.Canceled, err)
+		assert.Equal(t, []int64{0}, opened)
+
+		// restore the progress of the first import and resume it.
+		require.NoError(t, r.ChainDatastore().Put(chain.ImportProgressKey, val))
+		opened = nil
+		var last chain.ImportProgress
+		head, err := cs.ImportStream(ctx, chain.ImportOptions{
+			Source:     "snapshot.car",
+			Resume:     true,
+			Open:       open,
+			BatchSize:  2,
+			OnProgress: func(p chain.ImportProgress) { last = p },
+		})
+		require.NoError(t, err)
+		assert.Equal(t, link2.Key(), head.Key())
+
+		if compress {
+			// a compressed car file is reopened from the start and skipped through.
+			assert.Equal(t, []int64{0}, opened)
+		} else {
+			assert.Equal(t, []int64{saved.Offset}, opened)
+		}
+		assert.True(t, last.Offset > saved.Offset)
+		assert.True(t, last.Blocks > saved.Blocks)
+		if !compress {
+			assert.Equal(t, int64(len(data)), last.Offset)
+		}
+
+		for _, ts := range []*types.TipSet{genTS, link1, link2, link3} {
+			for _, blk := range ts.Blocks() {
+				has, err := r.Datastore().Has(blk.Cid())
+				require.NoError(t, err)
+				assert.True(t, has)
+			}
+		}
+
+		// the progress is cleared once the import completed.
+		_, err = r.ChainDatastore().Get(chain.ImportProgressKey)
+		assert.Equal(t, datastore.ErrNotFound, err)
+	}
+}
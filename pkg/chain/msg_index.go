@@ -0,0 +1,348 @@
+package chain
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	"github.com/ipfs/go-datastore/query"
+	blockstore "github.com/ipfs/go-ipfs-blockstore"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus/pkg/types"
+)
+
+// ErrMsgNotIndexed is returned by MsgIndex.GetMsgInfo for messages that are not in the index.
+var ErrMsgNotIndexed = xerrors.New("message not found in the index")
+
+var (
+	msgIndexPrefix    = datastore.NewKey("/msgindex")
+	msgIndexMsgKey    = datastore.NewKey("/msg")
+	msgIndexTipSetKey = datastore.NewKey("/tipset")
+)
+
+// msgIndexClearBatch is the number of keys deleted per batch when the index is cleared.
+const msgIndexClearBatch = 1024
+
+// MsgInfo is the location of a message on chain.
+type MsgInfo struct {
+	// Message is the cid of the message, the signed cid for secp messages.
+	Message cid.Cid
+	// TipSet is the tipset that includes the message, its receipt is in the state computed on top of it.
+	TipSet types.TipSetKey
+	// Epoch is the height of TipSet.
+	Epoch abi.ChainEpoch
+	// Block is the first block of TipSet including the message.
+	Block cid.Cid
+	// Index is the position of the message in the block, bls messages come first.
+	Index int
+}
+
+// MsgIndexReader looks up where messages were included on chain.
+type MsgIndexReader interface {
+	GetMsgInfo(ctx context.Context, c cid.Cid) (*MsgInfo, error)
+}
+
+type msgIndexChainReader interface {
+	GetTipSet(types.TipSetKey) (*types.TipSet, error)
+	SubHeadChanges(context.Context) chan []*HeadChange
+}
+
+type msgIndexMessageReader interface {
+	ReadMsgMetaCids(ctx context.Context, mmc cid.Cid) ([]cid.Cid, []cid.Cid, error)
+}
+
+// MsgIndex is a persistent index of the messages included on chain, kept up to date
+// with the head changes of the chain store. Tipsets are indexed as they are applied
+// and removed from the index when they are reverted.
+type MsgIndex struct {
+	ds       datastore.Batching
+	chain    msgIndexChainReader
+	messages msgIndexMessageReader
+
+	// backfillEpochs limits how far below the head the index is filled on start, 0 means no limit.
+	backfillEpochs abi.ChainEpoch
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+var _ MsgIndexReader = (*MsgIndex)(nil)
+
+// NewMsgIndex creates a message index stored in ds.
+func NewMsgIndex(ds datastore.Batching, chain msgIndexChainReader, messages msgIndexMessageReader, backfillEpochs abi.ChainEpoch) *MsgIndex {
+	return &MsgIndex{
+		ds:             namespace.Wrap(ds, msgIndexPrefix),
+		chain:          chain,
+		messages:       messages,
+		backfillEpochs: backfillEpochs,
+	}
+}
+
+// Start follows the head changes of the chain in the background. The tipsets between
+// the current head and the last indexed tipset are indexed by another goroutine, the
+// backfill can walk down to genesis and must not hold up the head changes.
+func (idx *MsgIndex) Start(ctx context.Context) {
+	ctx, idx.cancel = context.WithCancel(ctx)
+	ch := idx.chain.SubHeadChanges(ctx)
+
+	idx.wg.Add(1)
+	go func() {
+		defer idx.wg.Done()
+		for changes := range ch {
+			for _, change := range changes {
+				var err error
+				switch change.Type {
+				case HCCurrent:
+					head := change.Val
+					idx.wg.Add(1)
+					go func() {
+						defer idx.wg.Done()
+						if err := idx.backfill(ctx, head, idx.backfillEpochs); err != nil && ctx.Err() == nil {
+							log.Errorf("failed to backfill msg index from %d: %s", head.Height(), err)
+						}
+					}()
+				case HCApply:
+					err = idx.indexTipSet(ctx, change.Val)
+				case HCRevert:
+					err = idx.revertTipSet(ctx, change.Val)
+				}
+				if err != nil {
+					log.Errorf("failed to update msg index at %d: %s", change.Val.Height(), err)
+				}
+			}
+		}
+	}()
+}
+
+// Close stops following the chain.
+func (idx *MsgIndex) Close() {
+	if idx.cancel != nil {
+		idx.cancel()
+	}
+	idx.wg.Wait()
+}
+
+// GetMsgInfo returns where the message c was included, ErrMsgNotIndexed if it is not in the index.
+func (idx *MsgIndex) GetMsgInfo(ctx context.Context, c cid.Cid) (*MsgInfo, error) {
+	val, err := idx.ds.Get(msgIndexMsgKey.ChildString(c.String()))
+	if err == datastore.ErrNotFound {
+		return nil, ErrMsgNotIndexed
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("failed to read msg index: %w", err)
+	}
+
+	var info MsgInfo
+	if err := json.Unmarshal(val, &info); err != nil {
+		return nil, xerrors.Errorf("failed to decode msg index entry: %w", err)
+	}
+	return &info, nil
+}
+
+// Rebuild clears the index and indexes the chain ending at head again, down to
+// epochs below head or as far as messages are available when epochs is 0.
+// It returns the number of tipsets indexed.
+func (idx *MsgIndex) Rebuild(ctx context.Context, head *types.TipSet, epochs abi.ChainEpoch) (int, error) {
+	if err := idx.clear(); err != nil {
+		return 0, xerrors.Errorf("failed to clear msg index: %w", err)
+	}
+
+	var indexed int
+	err := idx.walkBack(ctx, head, epochs, func(ts *types.TipSet) (bool, error) {
+		if err := idx.indexTipSet(ctx, ts); err != nil {
+			return false, err
+		}
+		indexed++
+		return true, nil
+	})
+	return indexed, err
+}
+
+// clear deletes the keys of the index as they are listed, msgIndexClearBatch at a time.
+func (idx *MsgIndex) clear() error {
+	res, err := idx.ds.Query(query.Query{KeysOnly: true})
+	if err != nil {
+		return err
+	}
+	defer res.Close() //nolint:errcheck
+
+	batch, err := idx.ds.Batch()
+	if err != nil {
+		return err
+	}
+	pending := 0
+	for r := range res.Next() {
+		if r.Error != nil {
+			return r.Error
+		}
+		if err := batch.Delete(datastore.NewKey(r.Key)); err != nil {
+			return err
+		}
+		if pending++; pending < msgIndexClearBatch {
+			continue
+		}
+		if err := batch.Commit(); err != nil {
+			return err
+		}
+		if batch, err = idx.ds.Batch(); err != nil {
+			return err
+		}
+		pending = 0
+	}
+	return batch.Commit()
+}
+
+// backfill indexes the tipsets below head down to the first one already indexed.
+func (idx *MsgIndex) backfill(ctx context.Context, head *types.TipSet, epochs abi.ChainEpoch) error {
+	var indexed int
+	err := idx.walkBack(ctx, head, epochs, func(ts *types.TipSet) (bool, error) {
+		has, err := idx.ds.Has(tipSetIndexKey(ts.Key()))
+		if err != nil || has {
+			return false, err
+		}
+		if err := idx.indexTipSet(ctx, ts); err != nil {
+			return false, err
+		}
+		indexed++
+		return true, nil
+	})
+	if indexed > 0 {
+		log.Infow("msg index backfilled", "head", head.Height(), "tipsets", indexed)
+	}
+	return err
+}
+
+// walkBack calls cb for the tipsets from head down to epochs below it, genesis, or the
+// first tipset whose messages are not available, until cb returns false.
+func (idx *MsgIndex) walkBack(ctx context.Context, head *types.TipSet, epochs abi.ChainEpoch, cb func(*types.TipSet) (bool, error)) error {
+	for ts := head; ts.Height() > 0; {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if epochs > 0 && ts.Height() <= head.Height()-epochs {
+			return nil
+		}
+
+		more, err := cb(ts)
+		if err != nil {
+			if xerrors.Is(err, blockstore.ErrNotFound) {
+				// the chain was imported from a snapshot without older messages.
+				log.Infof("msg index stops at %d, messages are not available below", ts.Height())
+				return nil
+			}
+			return err
+		}
+		if !more {
+			return nil
+		}
+
+		parent, err := idx.chain.GetTipSet(ts.Parents())
+		if err != nil {
+			log.Infof("msg index stops at %d, tipsets are not available below: %s", ts.Height(), err)
+			return nil
+		}
+		ts = parent
+	}
+	return nil
+}
+
+// concatCids returns the cids of a then b in a new slice, the slices returned by ReadMsgMetaCids
+// may be shared so they are not appended to.
+func concatCids(a, b []cid.Cid) []cid.Cid {
+	out := make([]cid.Cid, len(a)+len(b))
+	copy(out, a)
+	copy(out[len(a):], b)
+	return out
+}
+
+// indexTipSet adds the messages included in ts to the index.
+func (idx *MsgIndex) indexTipSet(ctx context.Context, ts *types.TipSet) error {
+	batch, err := idx.ds.Batch()
+	if err != nil {
+		return err
+	}
+
+	seen := cid.NewSet()
+	for _, blk := range ts.Blocks() {
+		blsCids, secpCids, err := idx.messages.ReadMsgMetaCids(ctx, blk.Messages)
+		if err != nil {
+			return xerrors.Errorf("failed to load messages of block %s: %w", blk.Cid(), err)
+		}
+
+		for i, c := range concatCids(blsCids, secpCids) {
+			if !seen.Visit(c) {
+				continue
+			}
+			val, err := json.Marshal(&MsgInfo{
+				Message: c,
+				TipSet:  ts.Key(),
+				Epoch:   ts.Height(),
+				Block:   blk.Cid(),
+				Index:   i,
+			})
+			if err != nil {
+				return err
+			}
+			if err := batch.Put(msgIndexMsgKey.ChildString(c.String()), val); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := batch.Put(tipSetIndexKey(ts.Key()), []byte{}); err != nil {
+		return err
+	}
+	return batch.Commit()
+}
+
+// revertTipSet removes the messages included in ts from the index, unless they
+// have been indexed again in another tipset since.
+func (idx *MsgIndex) revertTipSet(ctx context.Context, ts *types.TipSet) error {
+	batch, err := idx.ds.Batch()
+	if err != nil {
+		return err
+	}
+
+	for _, blk := range ts.Blocks() {
+		blsCids, secpCids, err := idx.messages.ReadMsgMetaCids(ctx, blk.Messages)
+		if err != nil {
+			return xerrors.Errorf("failed to load messages of block %s: %w", blk.Cid(), err)
+		}
+
+		for _, c := range concatCids(blsCids, secpCids) {
+			info, err := idx.GetMsgInfo(ctx, c)
+			if err == ErrMsgNotIndexed {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			if !info.TipSet.Equals(ts.Key()) {
+				continue
+			}
+			if err := batch.Delete(msgIndexMsgKey.ChildString(c.String())); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := batch.Delete(tipSetIndexKey(ts.Key())); err != nil {
+		return err
+	}
+	return batch.Commit()
+}
+
+func tipSetIndexKey(tsk types.TipSetKey) datastore.Key {
+	cids := tsk.Cids()
+	strs := make([]string, len(cids))
+	for i, c := range cids {
+		strs[i] = c.String()
+	}
+	return msgIndexTipSetKey.ChildString(strings.Join(strs, ","))
+}
@@ -0,0 +1,63 @@
+package chain
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tf "github.com/filecoin-project/venus/pkg/testhelpers/testflags"
+	"github.com/filecoin-project/venus/pkg/types"
+)
+
+func TestMsgIndexRebuildAndRevert(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	builder := NewBuilder(t, address.Undef)
+	msgs := types.NewMsgs(3)
+	link1 := builder.BuildOneOn(builder.Genesis(), func(bb *BlockBuilder) {
+		bb.AddMessages(nil, msgs[:2])
+	})
+	link2 := builder.BuildOneOn(link1, func(bb *BlockBuilder) {
+		bb.AddMessages(nil, msgs[2:])
+	})
+
+	idx := NewMsgIndex(dssync.MutexWrap(datastore.NewMapDatastore()), builder.store, builder.mstore, 0)
+	indexed, err := idx.Rebuild(ctx, link2, 0)
+	require.NoError(t, err)
+	assert.Equal(t, 2, indexed)
+
+	info, err := idx.GetMsgInfo(ctx, msgs[1].Cid())
+	require.NoError(t, err)
+	assert.Equal(t, link1.Key(), info.TipSet)
+	assert.Equal(t, link1.Height(), info.Epoch)
+	assert.Equal(t, link1.At(0).Cid(), info.Block)
+	assert.Equal(t, 1, info.Index)
+
+	info, err = idx.GetMsgInfo(ctx, msgs[2].Cid())
+	require.NoError(t, err)
+	assert.Equal(t, link2.Key(), info.TipSet)
+
+	_, err = idx.GetMsgInfo(ctx, types.NewMsgs(4)[3].Cid())
+	assert.Equal(t, ErrMsgNotIndexed, err)
+
+	// reverting link2 drops its messages, backfilling from link1 finds it already indexed.
+	require.NoError(t, idx.revertTipSet(ctx, link2))
+	_, err = idx.GetMsgInfo(ctx, msgs[2].Cid())
+	assert.Equal(t, ErrMsgNotIndexed, err)
+	require.NoError(t, idx.backfill(ctx, link2, 0))
+	_, err = idx.GetMsgInfo(ctx, msgs[2].Cid())
+	assert.NoError(t, err)
+
+	// rebuilding with a limit only indexes the top of the chain.
+	indexed, err = idx.Rebuild(ctx, link2, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, indexed)
+	_, err = idx.GetMsgInfo(ctx, msgs[0].Cid())
+	assert.Equal(t, ErrMsgNotIndexed, err)
+}
@@ -18,13 +18,13 @@ import (
 	"github.com/filecoin-project/go-state-types/big"
 	blockadt "github.com/filecoin-project/specs-actors/actors/util/adt"
 	lru "github.com/hashicorp/golang-lru"
+	blocks "github.com/ipfs/go-block-format"
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-datastore"
 	blockstore "github.com/ipfs/go-ipfs-blockstore"
 	cbor "github.com/ipfs/go-ipld-cbor"
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/ipld/go-car"
-	carutil "github.com/ipld/go-car/util"
 	"github.com/pkg/errors"
 	cbg "github.com/whyrusleeping/cbor-gen"
 	"go.opencensus.io/trace"
@@ -788,26 +788,8 @@ func recurseLinks(bs blockstore.Blockstore, walked *cid.Set, root cid.Cid, in []
 }
 
 func (store *Store) Export(ctx context.Context, ts *types.TipSet, inclRecentRoots abi.ChainEpoch, skipOldMsgs bool, w io.Writer) error {
-	h := &car.CarHeader{
-		Roots:   ts.Cids(),
-		Version: 1,
-	}
-
-	if err := car.WriteHeader(h, w); err != nil {
-		return xerrors.Errorf("failed to write car header: %s", err)
-	}
-
-	return store.WalkSnapshot(ctx, ts, inclRecentRoots, skipOldMsgs, true, func(c cid.Cid) error {
-		blk, err := store.bsstore.Get(c)
-		if err != nil {
-			return xerrors.Errorf("writing object to car, bs.Get: %w", err)
-		}
-
-		if err := carutil.LdWrite(w, c.Bytes(), blk.RawData()); err != nil {
-			return xerrors.Errorf("failed to write block to car output: %w", err)
-		}
-
-		return nil
+	return store.writeCar(w, ts.Cids(), func(cb func(cid.Cid) error) error {
+		return store.WalkSnapshot(ctx, ts, inclRecentRoots, skipOldMsgs, true, cb)
 	})
 }
 
@@ -915,16 +897,125 @@ func (store *Store) WalkSnapshot(ctx context.Context, ts *types.TipSet, inclRece
 	return nil
 }
 
-//Import import a car file into local db
+// WalkHotObjects walks every object reachable from the current head that has to
+// be kept in a hot blockstore: all block headers, the messages and state roots of
+// the last inclRecentRoots epochs, and the computed state of the head itself.
+// It returns the height of the head it walked from.
+func (store *Store) WalkHotObjects(ctx context.Context, inclRecentRoots abi.ChainEpoch, cb func(cid.Cid) error) (abi.ChainEpoch, error) {
+	head := store.GetHead()
+	if !head.Defined() {
+		return 0, xerrors.New("chain head is not set")
+	}
+
+	if err := store.walkWithComputedState(ctx, head, inclRecentRoots, true, cb); err != nil {
+		return 0, err
+	}
+	return head.Height(), nil
+}
+
+// CopyReachable copies every object reachable from ts into to, keeping the state
+// roots of the last inclRecentRoots epochs and the computed state of ts.
+// It returns the number of objects copied.
+func (store *Store) CopyReachable(ctx context.Context, ts *types.TipSet, inclRecentRoots abi.ChainEpoch, skipOldMsgs bool, to blockstore.Blockstore) (int, error) {
+	const batchSize = 1024
+
+	var copied int
+	batch := make([]blocks.Block, 0, batchSize)
+	err := store.walkWithComputedState(ctx, ts, inclRecentRoots, skipOldMsgs, func(c cid.Cid) error {
+		blk, err := store.bsstore.Get(c)
+		if err != nil {
+			return xerrors.Errorf("failed to read object %s: %w", c, err)
+		}
+
+		batch = append(batch, blk)
+		if len(batch) < batchSize {
+			return nil
+		}
+		if err := to.PutMany(batch); err != nil {
+			return xerrors.Errorf("failed to write objects: %w", err)
+		}
+		copied += len(batch)
+		batch = batch[:0]
+		return nil
+	})
+	if err != nil {
+		return copied, err
+	}
+
+	if err := to.PutMany(batch); err != nil {
+		return copied, xerrors.Errorf("failed to write objects: %w", err)
+	}
+	return copied + len(batch), nil
+}
+
+// walkWithComputedState walks the snapshot of ts like WalkSnapshot, and also the
+// state and receipts computed on top of ts, which are not referenced by any block yet.
+func (store *Store) walkWithComputedState(ctx context.Context, ts *types.TipSet, inclRecentRoots abi.ChainEpoch, skipOldMsgs bool, cb func(cid.Cid) error) error {
+	walked := cid.NewSet()
+	err := store.WalkSnapshot(ctx, ts, inclRecentRoots, skipOldMsgs, false, func(c cid.Cid) error {
+		walked.Add(c)
+		return cb(c)
+	})
+	if err != nil {
+		return err
+	}
+
+	tsm, err := store.tipIndex.Get(ts)
+	if err != nil {
+		return xerrors.Errorf("failed to load tipset metadata: %w", err)
+	}
+	for _, root := range []cid.Cid{tsm.TipSetStateRoot, tsm.TipSetReceipts} {
+		if !walked.Visit(root) {
+			continue
+		}
+		links, err := recurseLinks(store.bsstore, walked, root, []cid.Cid{root})
+		if err != nil {
+			return xerrors.Errorf("recursing computed state failed: %w", err)
+		}
+		for _, c := range links {
+			// like WalkSnapshot, only dag-cbor objects are stored.
+			if c.Prefix().Codec != cid.DagCBOR {
+				continue
+			}
+			if err := cb(c); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Import import a car file into local db, the file may be zstd compressed.
 func (store *Store) Import(r io.Reader) (*types.TipSet, error) {
-	header, err := car.LoadCar(store.bsstore, r)
+	br, _, release, err := openCar(r)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	header, err := car.LoadCar(store.bsstore, br)
 	if err != nil {
 		return nil, xerrors.Errorf("loadcar failed: %w", err)
 	}
 
-	root, err := store.GetTipSet(types.NewTipSetKey(header.Roots...))
+	return store.importRoots(header.Roots)
+}
+
+// importRoots builds the tipset metadata of the chain imported with the given car roots
+// and returns the tipset to use as head. Only full exports can be imported, state exports
+// have no tipset as root and range exports have no state for the head.
+func (store *Store) importRoots(roots []cid.Cid) (*types.TipSet, error) {
+	root, err := store.GetTipSet(types.NewTipSetKey(roots...))
 	if err != nil {
-		return nil, xerrors.Errorf("failed to load root tipset from chainfile: %w", err)
+		return nil, xerrors.Errorf("failed to load root tipset from chainfile, state exports can not be imported: %w", err)
+	}
+	// a full export includes the state roots of its recent epochs, a range export none.
+	hasState, err := store.bsstore.Has(root.At(0).ParentStateRoot)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to check head state root: %w", err)
+	}
+	if !hasState {
+		return nil, xerrors.Errorf("chainfile has no state for the head, range exports can not be imported")
 	}
 
 	parent := root.Parents()
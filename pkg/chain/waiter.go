@@ -28,6 +28,7 @@ type MsgLookup struct {
 type waiterChainReader interface {
 	GetHead() *types.TipSet
 	GetTipSet(types.TipSetKey) (*types.TipSet, error)
+	GetTipSetByHeight(context.Context, *types.TipSet, abi.ChainEpoch, bool) (*types.TipSet, error)
 	LookupID(context.Context, *types.TipSet, address.Address) (address.Address, error)
 	GetActorAt(context.Context, *types.TipSet, address.Address) (*types.Actor, error)
 	GetTipSetReceiptsRoot(*types.TipSet) (cid.Cid, error)
@@ -40,6 +41,7 @@ type Waiter struct {
 	messageProvider MessageProvider
 	cst             cbor.IpldStore
 	bs              bstore.Blockstore
+	msgIndex        MsgIndexReader
 }
 
 // ChainMessage is an on-chain message with its block and receipt.
@@ -63,6 +65,11 @@ func NewWaiter(chainStore waiterChainReader, messages MessageProvider, bs bstore
 	}
 }
 
+// SetMsgIndex makes the waiter look messages up in idx before searching the chain.
+func (w *Waiter) SetMsgIndex(idx MsgIndexReader) {
+	w.msgIndex = idx
+}
+
 // Find searches the blockchain history (but doesn't wait).
 func (w *Waiter) Find(ctx context.Context, msg types.ChainMsg, lookback abi.ChainEpoch, ts *types.TipSet, allowReplaced bool) (*ChainMessage, bool, error) {
 	if ts == nil {
@@ -111,6 +118,17 @@ func (w *Waiter) findMessage(ctx context.Context, from *types.TipSet, m types.Ch
 	limitHeight := from.Height() - lookback
 	noLimit := lookback == constants.LookbackNoLimit
 
+	if w.msgIndex != nil {
+		msg, found, err := w.findIndexedMessage(ctx, from, m, allowReplaced)
+		if err != nil {
+			log.Warnf("failed to look message %s up in the index: %s", m.Cid(), err)
+		}
+		if found && (noLimit || msg.TS.Height() > limitHeight) {
+			return msg, true, nil
+		}
+		// replaced messages are not indexed under the searched cid, search the chain.
+	}
+
 	cur := from
 	curActor, err := w.chainReader.GetActorAt(ctx, cur, m.VMMessage().From)
 	if err != nil {
@@ -177,6 +195,36 @@ func (w *Waiter) findMessage(ctx context.Context, from *types.TipSet, m types.Ch
 	}
 }
 
+// findIndexedMessage looks the message up in the msg index and returns it if it was
+// included in the chain ending at from and executed by a tipset of that chain.
+func (w *Waiter) findIndexedMessage(ctx context.Context, from *types.TipSet, m types.ChainMsg, allowReplaced bool) (*ChainMessage, bool, error) {
+	info, err := w.msgIndex.GetMsgInfo(ctx, m.Cid())
+	if err == ErrMsgNotIndexed {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	// the receipt is in the state computed by the first tipset above the including one.
+	for h := info.Epoch + 1; h <= from.Height(); h++ {
+		ts, err := w.chainReader.GetTipSetByHeight(ctx, from, h, true)
+		if err != nil {
+			return nil, false, err
+		}
+		if ts.Height() <= info.Epoch {
+			// null round
+			continue
+		}
+		if !ts.Parents().Equals(info.TipSet) {
+			// the including tipset is not part of this chain.
+			return nil, false, nil
+		}
+		return w.receiptForTipset(ctx, ts, m, allowReplaced)
+	}
+	return nil, false, nil
+}
+
 // waitForMessage looks for a matching message in a channel of tipsets and returns
 // the message, block and receipt, when it is found. Reads until the channel is
 // closed or the context done. Returns the found message/block (or nil if the
@@ -72,8 +72,38 @@ func newDefaultAPIConfig() *APIConfig {
 // DatastoreConfig holds all the configuration options for the datastore.
 // TODO: use the advanced datastore configuration from ipfs
 type DatastoreConfig struct {
-	Type string `json:"type"`
-	Path string `json:"path"`
+	Type       string            `json:"type"`
+	Path       string            `json:"path"`
+	SplitStore *SplitStoreConfig `json:"splitstore,omitempty"`
+	MsgIndex   *MsgIndexConfig   `json:"msgIndex,omitempty"`
+}
+
+// SplitStoreConfig holds the configuration of the hot/cold chain blockstore.
+type SplitStoreConfig struct {
+	// Enable splits the chain blockstore in a hot and a cold store.
+	Enable bool `json:"enable"`
+	// HotStoreFinalities is the number of finalities of state kept in the hot store.
+	HotStoreFinalities int64 `json:"hotStoreFinalities"`
+	// CompactionFinalities is the number of finalities the head has to advance
+	// past the last compaction before the hot store is compacted again.
+	CompactionFinalities int64 `json:"compactionFinalities"`
+	// ColdStoreType is either "badger", to move old objects into a cold store,
+	// or "discard", to delete them.
+	ColdStoreType string `json:"coldStoreType"`
+	// ColdStorePath is the path of the cold store, relative to the repo.
+	ColdStorePath string `json:"coldStorePath"`
+	// ColdStoreFinalities is the number of finalities of state kept in the cold
+	// store, older objects are pruned from it. Zero never prunes the cold store.
+	ColdStoreFinalities int64 `json:"coldStoreFinalities"`
+}
+
+// MsgIndexConfig holds the configuration of the index of the messages included on chain.
+type MsgIndexConfig struct {
+	// Enable keeps an index of the messages included on chain, used to search messages.
+	Enable bool `json:"enable"`
+	// BackfillEpochs is the number of epochs below the head indexed on startup,
+	// 0 indexes as far back as messages are available.
+	BackfillEpochs int64 `json:"backfillEpochs"`
 }
 
 // Validators hold the list of validation functions for each configuration
@@ -87,8 +117,27 @@ var Validators = map[string]func(string, string) error{
 
 func newDefaultDatastoreConfig() *DatastoreConfig {
 	return &DatastoreConfig{
-		Type: "badgerds",
-		Path: "badger",
+		Type:       "badgerds",
+		Path:       "badger",
+		SplitStore: newDefaultSplitStoreConfig(),
+		MsgIndex:   newDefaultMsgIndexConfig(),
+	}
+}
+
+func newDefaultSplitStoreConfig() *SplitStoreConfig {
+	return &SplitStoreConfig{
+		Enable:               false,
+		HotStoreFinalities:   2,
+		CompactionFinalities: 1,
+		ColdStoreType:        "badger",
+		ColdStorePath:        "badger-cold",
+	}
+}
+
+func newDefaultMsgIndexConfig() *MsgIndexConfig {
+	return &MsgIndexConfig{
+		Enable:         false,
+		BackfillEpochs: 0,
 	}
 }
 
@@ -126,6 +175,22 @@ type WalletConfig struct {
 	PassphraseConfig PassphraseConfig `json:"passphraseConfig,omitempty"`
 	RemoteEnable     bool             `json:"remoteEnable"`
 	RemoteBackend    string           `json:"remoteBackend"`
+	// RemoteBackends are the remote wallets aggregated with the local wallet.
+	RemoteBackends []*RemoteWalletConfig `json:"remoteBackends,omitempty"`
+	// DefaultBackend is the name of the backend new addresses are created and imported in, the local
+	// wallet when it is empty.
+	DefaultBackend string `json:"defaultBackend,omitempty"`
+	// RemoteCheckPeriod is how often the remote wallets are health checked, 30s when it is empty.
+	RemoteCheckPeriod string `json:"remoteCheckPeriod,omitempty"`
+	// ApprovalWait is how long signing with a threshold key waits for the approvals, 1m when it is empty.
+	ApprovalWait string `json:"approvalWait,omitempty"`
+}
+
+// RemoteWalletConfig is a remote wallet, such as venus-wallet, aggregated with the local wallet.
+type RemoteWalletConfig struct {
+	Name string `json:"name"`
+	// API is the api info of the wallet, "token:multiaddr".
+	API string `json:"api"`
 }
 
 type PassphraseConfig struct {
@@ -232,6 +297,17 @@ type MessagePoolConfig struct {
 	MaxNonceGap uint64 `json:"maxNonceGap"`
 	// MaxFee
 	MaxFee types.FIL `json:"maxFee"`
+	// History keeps the lifecycle of the messages seen by the message pool
+	History *MessageHistoryConfig `json:"history,omitempty"`
+}
+
+// MessageHistoryConfig holds the configuration of the history of the messages seen by the message pool.
+type MessageHistoryConfig struct {
+	// Enable records when the messages are added to the pool, replaced, republished, included on
+	// chain, executed and reverted.
+	Enable bool `json:"enable"`
+	// MaxAge is how long the history of a message is kept after its last event, 7 days if 0.
+	MaxAge time.Duration `json:"maxAge"`
 }
 
 var DefaultMessagePoolParam = &MessagePoolConfig{
@@ -243,6 +319,7 @@ func newDefaultMessagePoolConfig() *MessagePoolConfig {
 	return &MessagePoolConfig{
 		MaxNonceGap: 100,
 		MaxFee:      DefaultDefaultMaxFee,
+		History:     &MessageHistoryConfig{},
 	}
 }
 
@@ -50,10 +50,12 @@ func NewConfiguredProcessor(actors vm.ActorCodeLoader, syscalls vm.SyscallsImpl)
 }
 
 // ProcessTipSet computes the state transition specified by the messages in all blocks in a TipSet.
+// When cb is not nil it is called with the result of every message applied, including implicit ones.
 func (p *DefaultProcessor) ProcessTipSet(ctx context.Context,
 	parent, ts *types.TipSet,
 	msgs []types.BlockMessagesInfo,
 	vmOption vm.VmOption,
+	cb vm.ExecCallBack,
 ) (cid.Cid, []types.MessageReceipt, error) {
 	_, span := trace.StartSpan(ctx, "DefaultProcessor.ProcessTipSet")
 	span.AddAttributes(trace.StringAttribute("tipset", ts.String()))
@@ -69,7 +71,7 @@ func (p *DefaultProcessor) ProcessTipSet(ctx context.Context,
 		return cid.Undef, nil, err
 	}
 
-	return v.ApplyTipSetMessages(msgs, ts, parentEpoch, epoch, nil)
+	return v.ApplyTipSetMessages(msgs, ts, parentEpoch, epoch, cb)
 }
 
 //ProcessImplicitMessage compute the state of specify message but this functions skip value, gas,check
@@ -0,0 +1,146 @@
+package messagepool
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus/pkg/net/msgsub"
+	"github.com/filecoin-project/venus/pkg/types"
+)
+
+// PushBatch adds local messages of a sender with contiguous nonces to the pool and publishes them.
+// The messages are added all or none: when one of them is rejected, the ones already added are
+// removed and no update is sent for them.
+func (mp *MessagePool) PushBatch(ctx context.Context, msgs []*types.SignedMessage) ([]cid.Cid, error) {
+	if len(msgs) == 0 {
+		return nil, xerrors.New("no message to push")
+	}
+
+	first := &msgs[0].Message
+	for i, m := range msgs {
+		if m.Message.From != first.From {
+			return nil, xerrors.Errorf("message %d is from %s, not %s", i, m.Message.From, first.From)
+		}
+		if m.Message.Nonce != first.Nonce+uint64(i) {
+			return nil, xerrors.Errorf("message %d has nonce %d, expected %d: the nonces must be contiguous", i, m.Message.Nonce, first.Nonce+uint64(i))
+		}
+		if err := mp.checkMessage(m); err != nil {
+			return nil, xerrors.Errorf("message %d: %w", i, err)
+		}
+	}
+
+	// serialize push access to reduce lock contention
+	mp.addSema <- struct{}{}
+	defer func() {
+		<-mp.addSema
+	}()
+
+	mp.curTSLk.Lock()
+	publish, err := mp.addBatchTS(ctx, msgs, mp.curTS)
+	mp.curTSLk.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	cids := make([]cid.Cid, 0, len(msgs))
+	for i, m := range msgs {
+		cids = append(cids, m.Cid())
+		if !publish[i] {
+			continue
+		}
+
+		// the messages are in the pool, the republish loop sends the ones failing here
+		buf := new(bytes.Buffer)
+		if err := m.MarshalCBOR(buf); err != nil {
+			log.Warnf("error serializing message %s: %s", m.Cid(), err)
+			continue
+		}
+		if err := mp.api.PubSubPublish(msgsub.Topic(mp.netName), buf.Bytes()); err != nil {
+			log.Warnf("error publishing message %s: %s", m.Cid(), err)
+		}
+	}
+
+	return cids, nil
+}
+
+// addBatchTS adds the messages as local ones or none of them, it returns whether each message should
+// be published.
+func (mp *MessagePool) addBatchTS(ctx context.Context, msgs []*types.SignedMessage, curTS *types.TipSet) ([]bool, error) {
+	from := msgs[0].Message.From
+	snonce, err := mp.getStateNonce(ctx, from, curTS)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to look up actor state nonce: %s: %v", err, ErrSoftValidationFailure)
+	}
+
+	if snonce > msgs[0].Message.Nonce {
+		return nil, xerrors.Errorf("minimum expected nonce is %d: %v", snonce, ErrNonceTooLow)
+	}
+
+	mp.lk.Lock()
+	defer mp.lk.Unlock()
+
+	// a replaced message could not be restored on failure
+	mset, ok, err := mp.getPendingMset(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		for _, m := range msgs {
+			if _, has := mset.msgs[m.Message.Nonce]; has {
+				return nil, xerrors.Errorf("message from %s with nonce %d already in mpool: %v", from, m.Message.Nonce, ErrSoftValidationFailure)
+			}
+		}
+	}
+
+	// the sender is marked local by the first message, it is unmarked if the batch is rolled back
+	wasLocal, err := mp.isLocal(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+
+	var held []MpoolUpdate
+	var heldSpends []policySpend
+	mp.heldUpdates = &held
+	mp.heldSpends = &heldSpends
+	spends := append([]policySpend{}, mp.policySpends...)
+
+	publish := make([]bool, 0, len(msgs))
+	for i, m := range msgs {
+		p, err := mp.addTSLocked(ctx, m, curTS, true, false)
+		if err != nil {
+			mp.rollbackBatch(ctx, msgs[:i])
+			if !wasLocal {
+				mp.unsetLocal(ctx, from)
+			}
+			mp.policySpends = spends
+			mp.heldUpdates = nil
+			mp.heldSpends = nil
+			return nil, xerrors.Errorf("message %d (nonce %d): %w", i, m.Message.Nonce, err)
+		}
+		publish = append(publish, p)
+	}
+
+	mp.heldUpdates = nil
+	mp.heldSpends = nil
+	for _, u := range held {
+		mp.publishUpdate(u)
+	}
+	mp.persistPolicySpends(heldSpends...)
+	return publish, nil
+}
+
+// rollbackBatch removes the added messages of a batch, the highest nonces first so that the next
+// nonce of the sender is restored. It must be called with mp.lk held.
+func (mp *MessagePool) rollbackBatch(ctx context.Context, added []*types.SignedMessage) {
+	for i := len(added) - 1; i >= 0; i-- {
+		m := added[i]
+		mp.remove(ctx, m.Message.From, m.Message.Nonce, false, removal{reason: RemovePruned})
+		if err := mp.localMsgs.Delete(datastore.NewKey(string(m.Cid().Bytes()))); err != nil {
+			log.Warnf("error deleting local message: %s", err)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package messagepool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tf "github.com/filecoin-project/venus/pkg/testhelpers/testflags"
+	"github.com/filecoin-project/venus/pkg/types"
+)
+
+func TestPushBatch(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tma := newTestMpoolAPI()
+	w, mp := newWalletAndMpool(t, tma)
+
+	a1, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	a2, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	target := mkAddress(1001)
+	tma.setBalance(a1, 1)
+	tma.setBalance(a2, 1)
+	tma.nextBlock()
+
+	sub, err := mp.Updates(ctx)
+	require.NoError(t, err)
+
+	var msgs []*types.SignedMessage
+	for i := uint64(0); i < 3; i++ {
+		msgs = append(msgs, mkMessage(a1, target, i, w))
+	}
+	cids, err := mp.PushBatch(ctx, msgs)
+	require.NoError(t, err)
+	require.Len(t, cids, 3)
+	for i, m := range msgs {
+		assert.Equal(t, m.Cid(), cids[i])
+		u := nextUpdate(t, sub)
+		assert.Equal(t, MpoolAdd, u.Type)
+		assert.Equal(t, m.Cid(), u.Message.Cid())
+		assert.True(t, u.Local)
+	}
+	assert.Equal(t, 3, tma.published)
+	nonce, err := mp.GetNonce(ctx, a1, types.EmptyTSK)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), nonce)
+
+	// invalid batches
+	_, err = mp.PushBatch(ctx, nil)
+	assert.Error(t, err)
+	_, err = mp.PushBatch(ctx, []*types.SignedMessage{mkMessage(a1, target, 3, w), mkMessage(a1, target, 5, w)})
+	assert.Error(t, err)
+	_, err = mp.PushBatch(ctx, []*types.SignedMessage{mkMessage(a1, target, 3, w), mkMessage(a2, target, 4, w)})
+	assert.Error(t, err)
+	// nonces already pending
+	_, err = mp.PushBatch(ctx, []*types.SignedMessage{mkMessage(a1, mkAddress(1002), 2, w), mkMessage(a1, target, 3, w)})
+	assert.Error(t, err)
+
+	// the third message is rejected by the policy, the batch is rolled back
+	require.NoError(t, mp.SetPolicy(&MpoolPolicy{
+		Scope:              PolicySender,
+		Address:            a2,
+		MaxPendingMessages: 2,
+	}))
+	msgs = nil
+	for i := uint64(0); i < 3; i++ {
+		msgs = append(msgs, mkMessage(a2, target, i, w))
+	}
+	_, err = mp.PushBatch(ctx, msgs)
+	require.Error(t, err)
+	assert.Empty(t, mp.pendingFor(ctx, a2))
+	assert.Equal(t, 3, tma.published)
+	nonce, err = mp.GetNonce(ctx, a2, types.EmptyTSK)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), nonce)
+	// the sender of the rolled back batch is not kept local
+	mp.lk.Lock()
+	local, err := mp.isLocal(ctx, a2)
+	mp.lk.Unlock()
+	require.NoError(t, err)
+	assert.False(t, local)
+
+	res, err := mp.localMsgs.Query(query.Query{KeysOnly: true})
+	require.NoError(t, err)
+	entries, err := res.Rest()
+	require.NoError(t, err)
+	assert.Len(t, entries, 3)
+	// the spends of the rolled back messages are not persisted
+	spends, err := loadPolicySpends(mp.ds)
+	require.NoError(t, err)
+	assert.Empty(t, spends)
+
+	select {
+	case u := <-sub:
+		t.Fatalf("unexpected update %v", u)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	_, err = mp.PushBatch(ctx, msgs[:2])
+	require.NoError(t, err)
+	assert.Len(t, mp.pendingFor(ctx, a2), 2)
+	spends, err = loadPolicySpends(mp.ds)
+	require.NoError(t, err)
+	assert.Len(t, spends, 2)
+}
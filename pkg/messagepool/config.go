@@ -8,6 +8,7 @@ import (
 	"github.com/ipfs/go-datastore"
 
 	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/venus/pkg/repo"
 )
 
@@ -17,6 +18,7 @@ var (
 	MemPoolSizeLimitLoDefault = 20000
 	PruneCooldownDefault      = time.Minute
 	GasLimitOverestimation    = 1.25
+	AutoReplaceEpochsDefault  = abi.ChainEpoch(10)
 
 	ConfigKey = datastore.NewKey("/mpool/config")
 )
@@ -28,6 +30,37 @@ type MpoolConfig struct {
 	ReplaceByFeeRatio      float64
 	PruneCooldown          time.Duration
 	GasLimitOverestimation float64
+
+	// SelectionStrategy is the name of the strategy selecting the messages of the blocks, see
+	// SelectionStrategy, the default strategy if empty
+	SelectionStrategy string
+	// SelectLocalFirst makes the priority strategy select the messages of the local addresses first
+	SelectLocalFirst bool
+	// MaxSenderBlockMessages caps the messages of a sender in a block with the priority strategy, 0 for no cap
+	MaxSenderBlockMessages int
+
+	// PersistPending enables the snapshot of the pending messages on shutdown, they are reloaded on start
+	PersistPending bool
+	// PersistPendingLimit bounds the snapshot to the best messages by gas performance, 0 for all messages
+	PersistPendingLimit int
+
+	// AutoReplace enables the replacement of the local messages still pending after AutoReplaceEpochs
+	AutoReplace       bool
+	AutoReplaceEpochs abi.ChainEpoch
+	// AutoReplaceMaxFee is the max fee of a replacing message, the default max fee of the node when not set
+	AutoReplaceMaxFee abi.TokenAmount
+	// AutoReplaceMaxFees overrides AutoReplaceMaxFee for the listed senders
+	AutoReplaceMaxFees []AddressMaxFee
+
+	// MessageTTL is the number of epochs after which a pending message is removed as expired, the
+	// messages of the local and priority addresses never expire, 0 for no expiry
+	MessageTTL abi.ChainEpoch
+}
+
+// AddressMaxFee is the max fee of the messages of an address.
+type AddressMaxFee struct {
+	Address address.Address
+	MaxFee  abi.TokenAmount
 }
 
 func (mc *MpoolConfig) Clone() *MpoolConfig {
@@ -77,6 +110,21 @@ func validateConfg(cfg *MpoolConfig) error {
 	if cfg.GasLimitOverestimation < 1 {
 		return fmt.Errorf("'GasLimitOverestimation' cannot be less than 1")
 	}
+	if _, err := GetSelectionStrategy(cfg.SelectionStrategy); err != nil {
+		return err
+	}
+	if cfg.MaxSenderBlockMessages < 0 {
+		return fmt.Errorf("'MaxSenderBlockMessages' cannot be negative")
+	}
+	if cfg.PersistPendingLimit < 0 {
+		return fmt.Errorf("'PersistPendingLimit' cannot be negative")
+	}
+	if cfg.AutoReplace && cfg.AutoReplaceEpochs <= 0 {
+		return fmt.Errorf("'AutoReplaceEpochs' must be positive")
+	}
+	if cfg.MessageTTL < 0 {
+		return fmt.Errorf("'MessageTTL' cannot be negative")
+	}
 	return nil
 }
 
@@ -104,5 +152,6 @@ func DefaultConfig() *MpoolConfig {
 		ReplaceByFeeRatio:      ReplaceByFeeRatioDefault,
 		PruneCooldown:          PruneCooldownDefault,
 		GasLimitOverestimation: GasLimitOverestimation,
+		AutoReplaceEpochs:      AutoReplaceEpochsDefault,
 	}
 }
@@ -0,0 +1,211 @@
+package messagepool
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/ipld/go-car"
+	carutil "github.com/ipld/go-car/util"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus/pkg/constants"
+	"github.com/filecoin-project/venus/pkg/types"
+)
+
+// ExportFormat is the file format of the exported pending messages.
+type ExportFormat string
+
+const (
+	// ExportJSON is a gzipped stream of JSON signed messages, the format of test-messages.json.gz
+	ExportJSON ExportFormat = "json"
+	// ExportCar is a car file rooted at the signed messages, in order
+	ExportCar ExportFormat = "car"
+)
+
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// ImportReport is the outcome of the import of messages in the pool.
+type ImportReport struct {
+	Imported int
+	Failed   []*ImportFailure
+}
+
+// ImportFailure is a message that could not be imported.
+type ImportFailure struct {
+	Cid   cid.Cid
+	Error string
+}
+
+// WriteMessages writes the messages to w in the format.
+func WriteMessages(w io.Writer, msgs []*types.SignedMessage, format ExportFormat) error {
+	switch format {
+	case ExportJSON, "":
+		gzw := gzip.NewWriter(w)
+		enc := json.NewEncoder(gzw)
+		enc.SetIndent("", "  ")
+		for _, m := range msgs {
+			if err := enc.Encode(m); err != nil {
+				return xerrors.Errorf("encoding message %s: %w", m.Cid(), err)
+			}
+		}
+		return gzw.Close()
+	case ExportCar:
+		// the blocks are the signed messages, bls ones included, so the roots are not the message cids
+		var roots []cid.Cid
+		var data [][]byte
+		for _, m := range msgs {
+			buf := new(bytes.Buffer)
+			if err := m.MarshalCBOR(buf); err != nil {
+				return xerrors.Errorf("error serializing message: %w", err)
+			}
+			c, err := constants.DefaultCidBuilder.Sum(buf.Bytes())
+			if err != nil {
+				return err
+			}
+			roots = append(roots, c)
+			data = append(data, buf.Bytes())
+		}
+
+		if err := car.WriteHeader(&car.CarHeader{Roots: roots, Version: 1}, w); err != nil {
+			return xerrors.Errorf("failed to write car header: %w", err)
+		}
+		for i, c := range roots {
+			if err := carutil.LdWrite(w, c.Bytes(), data[i]); err != nil {
+				return xerrors.Errorf("failed to write message to car output: %w", err)
+			}
+		}
+		return nil
+	default:
+		return xerrors.Errorf("unknown export format %q", format)
+	}
+}
+
+// ReadMessages reads the messages written by WriteMessages in any format.
+func ReadMessages(r io.Reader) ([]*types.SignedMessage, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(len(gzipMagic))
+	if err != nil {
+		return nil, xerrors.Errorf("reading messages: %w", err)
+	}
+	if bytes.Equal(magic, gzipMagic) {
+		return readJSONMessages(br)
+	}
+	return readCarMessages(br)
+}
+
+func readJSONMessages(r io.Reader) ([]*types.SignedMessage, error) {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer gzr.Close() //nolint:errcheck
+
+	var msgs []*types.SignedMessage
+	dec := json.NewDecoder(gzr)
+	for {
+		m := new(types.SignedMessage)
+		err := dec.Decode(m)
+		if err == io.EOF {
+			return msgs, nil
+		}
+		if err != nil {
+			return nil, xerrors.Errorf("decoding message %d: %w", len(msgs), err)
+		}
+		msgs = append(msgs, m)
+	}
+}
+
+func readCarMessages(r *bufio.Reader) ([]*types.SignedMessage, error) {
+	hb, _, err := carutil.LdRead(r)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to read car header: %w", err)
+	}
+	var header car.CarHeader
+	if err := cbor.DecodeInto(hb, &header); err != nil {
+		return nil, xerrors.Errorf("invalid car header: %w", err)
+	}
+	if header.Version != 1 {
+		return nil, xerrors.Errorf("invalid car version: %d", header.Version)
+	}
+
+	blocks := make(map[cid.Cid][]byte, len(header.Roots))
+	for {
+		c, _, data, err := carutil.ReadNode(r)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, xerrors.Errorf("failed to read car block: %w", err)
+		}
+		blocks[c] = data
+	}
+
+	msgs := make([]*types.SignedMessage, 0, len(header.Roots))
+	for _, root := range header.Roots {
+		data, ok := blocks[root]
+		if !ok {
+			return nil, xerrors.Errorf("message %s missing from the car file", root)
+		}
+		m := new(types.SignedMessage)
+		if err := m.UnmarshalCBOR(bytes.NewReader(data)); err != nil {
+			return nil, xerrors.Errorf("unmarshaling message %s: %w", root, err)
+		}
+		msgs = append(msgs, m)
+	}
+	return msgs, nil
+}
+
+// Export returns the pending messages sorted by sender and nonce, only the messages of the local
+// addresses with local.
+func (mp *MessagePool) Export(ctx context.Context, local bool) []*types.SignedMessage {
+	mp.curTSLk.Lock()
+	defer mp.curTSLk.Unlock()
+
+	mp.lk.Lock()
+	defer mp.lk.Unlock()
+
+	var msgs []*types.SignedMessage
+	mp.forEachPending(func(actor address.Address, mset *msgSet) {
+		if _, isLocal := mp.localAddrs[actor]; local && !isLocal {
+			return
+		}
+		for _, m := range mset.msgs {
+			msgs = append(msgs, m)
+		}
+	})
+	sortMessages(msgs)
+	return msgs
+}
+
+// Import adds the messages to the pool as messages received from the network, in nonce order.
+func (mp *MessagePool) Import(ctx context.Context, msgs []*types.SignedMessage) *ImportReport {
+	msgs = append([]*types.SignedMessage{}, msgs...)
+	sortMessages(msgs)
+
+	report := &ImportReport{}
+	for _, m := range msgs {
+		if err := mp.Add(ctx, m); err != nil {
+			report.Failed = append(report.Failed, &ImportFailure{Cid: m.Cid(), Error: err.Error()})
+			continue
+		}
+		report.Imported++
+	}
+	return report
+}
+
+func sortMessages(msgs []*types.SignedMessage) {
+	sort.Slice(msgs, func(i, j int) bool {
+		if msgs[i].Message.From != msgs[j].Message.From {
+			return msgs[i].Message.From.String() < msgs[j].Message.From.String()
+		}
+		return msgs[i].Message.Nonce < msgs[j].Message.Nonce
+	})
+}
@@ -0,0 +1,100 @@
+package messagepool
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/venus/pkg/config"
+	tf "github.com/filecoin-project/venus/pkg/testhelpers/testflags"
+	"github.com/filecoin-project/venus/pkg/types"
+)
+
+func TestExportImport(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+
+	tma := newTestMpoolAPI()
+	w, mp := newWalletAndMpool(t, tma)
+
+	a1, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	a2, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	target := mkAddress(1001)
+	tma.setBalance(a1, 1)
+	tma.setBalance(a2, 1)
+
+	var local, all []*types.SignedMessage
+	for i := uint64(0); i < 3; i++ {
+		m := mkMessage(a1, target, i, w)
+		_, err := mp.Push(ctx, m)
+		require.NoError(t, err)
+		local = append(local, m)
+
+		m = mkMessage(a2, target, i, w)
+		mustAdd(t, mp, m)
+		all = append(all, m)
+	}
+	all = append(all, local...)
+	sortMessages(all)
+
+	assert.Equal(t, local, mp.Export(ctx, true))
+	exported := mp.Export(ctx, false)
+	require.Equal(t, all, exported)
+
+	for _, format := range []ExportFormat{ExportJSON, ExportCar} {
+		buf := new(bytes.Buffer)
+		require.NoError(t, WriteMessages(buf, exported, format))
+		msgs, err := ReadMessages(buf)
+		require.NoError(t, err)
+		require.Len(t, msgs, len(exported), format)
+		for i, m := range msgs {
+			assert.Equal(t, exported[i].Cid(), m.Cid(), format)
+		}
+	}
+	assert.Error(t, WriteMessages(new(bytes.Buffer), exported, "xml"))
+
+	// import in another pool, in reverse order to check the messages are sorted
+	mp2, err := New(tma, datastore.NewMapDatastore(), config.DefaultForkUpgradeParam, config.DefaultMessagePoolParam, "mptest", nil, nil, nil)
+	require.NoError(t, err)
+	defer mp2.Close() //nolint:errcheck
+
+	reversed := make([]*types.SignedMessage, 0, len(exported))
+	for i := len(exported) - 1; i >= 0; i-- {
+		reversed = append(reversed, exported[i])
+	}
+	report := mp2.Import(ctx, reversed)
+	assert.Equal(t, len(exported), report.Imported)
+	assert.Empty(t, report.Failed)
+	assert.Equal(t, exported, mp2.Export(ctx, false))
+	// imported messages are not local
+	assert.Empty(t, mp2.Export(ctx, true))
+
+	report = mp2.Import(ctx, exported)
+	assert.Equal(t, 0, report.Imported)
+	require.Len(t, report.Failed, len(exported))
+	for i, f := range report.Failed {
+		assert.Equal(t, exported[i].Cid(), f.Cid)
+		assert.NotEmpty(t, f.Error)
+	}
+}
+
+func TestReadMessagesFixture(t *testing.T) {
+	tf.UnitTest(t)
+
+	file, err := os.Open("test-messages.json.gz")
+	require.NoError(t, err)
+	defer file.Close() //nolint:errcheck
+
+	msgs, err := ReadMessages(file)
+	require.NoError(t, err)
+	assert.NotEmpty(t, msgs)
+}
@@ -71,16 +71,84 @@ func (g *GasPriceCache) GetTSGasStats(provider Provider, ts *types.TipSet) ([]Ga
 	return prices, nil
 }
 
+// GasEstimateReport explains how the gas values of a message were estimated, the reports
+// of the values already set in the message are nil.
+type GasEstimateReport struct {
+	// Msg is the message with its estimated gas values.
+	Msg        *types.Message
+	GasLimit   *GasLimitReport   `json:",omitempty"`
+	GasPremium *GasPremiumReport `json:",omitempty"`
+	FeeCap     *FeeCapReport     `json:",omitempty"`
+	// MaxFee is the maximal total fee of the message, Capped is set when the fee cap
+	// and the premium were lowered to respect it.
+	MaxFee abi.TokenAmount
+	Capped bool
+}
+
+// GasLimitReport explains a gas limit estimate.
+type GasLimitReport struct {
+	// GasUsed is the gas used by the message applied after the pending messages of its sender.
+	GasUsed int64
+	// Overestimation is the factor applied to GasUsed.
+	Overestimation float64
+	GasLimit       int64
+}
+
+// GasPremiumReport explains a gas premium estimate.
+type GasPremiumReport struct {
+	NBlocksIncl uint64
+	// TipSets are the tipsets whose messages were sampled, from the parent of the head down.
+	TipSets []GasPremiumTipSet
+	Blocks  int
+	// Samples are the premiums and gas limits of the sampled messages, by descending premium.
+	Samples []GasMeta
+	// Median is the premium at the 55th percentile of the gas limits of the samples.
+	Median abi.TokenAmount
+	// Minimum is the premium used instead of Median when it is below MinGasPremium.
+	Minimum abi.TokenAmount
+	// Noise is the random factor applied to normalize the behaviour of message selection.
+	Noise      float64
+	GasPremium abi.TokenAmount
+}
+
+// GasPremiumTipSet is a tipset sampled for the gas premium estimate.
+type GasPremiumTipSet struct {
+	Key      types.TipSetKey
+	Height   abi.ChainEpoch
+	Messages int
+}
+
+// FeeCapReport explains a fee cap estimate.
+type FeeCapReport struct {
+	// ParentBaseFee is the base fee of the head.
+	ParentBaseFee  abi.TokenAmount
+	MaxQueueBlocks int64
+	// IncreaseFactor is the maximal increase of the base fee over MaxQueueBlocks.
+	IncreaseFactor   float64
+	ProjectedBaseFee abi.TokenAmount
+	// GasPremium is the premium of the message, added to the projected base fee.
+	GasPremium abi.TokenAmount
+	GasFeeCap  abi.TokenAmount
+}
+
 func (mp *MessagePool) GasEstimateFeeCap(
 	ctx context.Context,
 	msg *types.UnsignedMessage,
 	maxqueueblks int64,
 	tsk types.TipSetKey,
 ) (big.Int, error) {
-	ts, err := mp.api.ChainHead()
+	report, err := mp.estimateFeeCap(msg, maxqueueblks)
 	if err != nil {
 		return types.NewGasFeeCap(0), err
 	}
+	return report.GasFeeCap, nil
+}
+
+func (mp *MessagePool) estimateFeeCap(msg *types.UnsignedMessage, maxqueueblks int64) (*FeeCapReport, error) {
+	ts, err := mp.api.ChainHead()
+	if err != nil {
+		return nil, err
+	}
 
 	parentBaseFee := ts.Blocks()[0].ParentBaseFee
 	increaseFactor := math.Pow(1.+1./float64(constants.BaseFeeMaxChangeDenom), float64(maxqueueblks))
@@ -88,11 +156,20 @@ func (mp *MessagePool) GasEstimateFeeCap(
 	feeInFuture := types.BigMul(parentBaseFee, types.NewInt(uint64(increaseFactor*(1<<8))))
 	out := types.BigDiv(feeInFuture, types.NewInt(1<<8))
 
+	report := &FeeCapReport{
+		ParentBaseFee:    parentBaseFee,
+		MaxQueueBlocks:   maxqueueblks,
+		IncreaseFactor:   increaseFactor,
+		ProjectedBaseFee: out,
+		GasPremium:       big.Zero(),
+	}
 	if msg.GasPremium != types.EmptyInt {
+		report.GasPremium = msg.GasPremium
 		out = types.BigAdd(out, msg.GasPremium)
 	}
+	report.GasFeeCap = out
 
-	return out, nil
+	return report, nil
 }
 
 // finds 55th percntile instead of median to put negative pressure on gas price
@@ -129,16 +206,25 @@ func (mp *MessagePool) GasEstimateGasPremium(
 	_ types.TipSetKey,
 	cache *GasPriceCache,
 ) (big.Int, error) {
+	report, err := mp.estimateGasPremium(nblocksincl, cache)
+	if err != nil {
+		return types.BigInt{}, err
+	}
+	return report.GasPremium, nil
+}
+
+func (mp *MessagePool) estimateGasPremium(nblocksincl uint64, cache *GasPriceCache) (*GasPremiumReport, error) {
 	if nblocksincl == 0 {
 		nblocksincl = 1
 	}
 
 	var prices []GasMeta
 	var blocks int
+	report := &GasPremiumReport{NBlocksIncl: nblocksincl}
 
 	ts, err := mp.api.ChainHead()
 	if err != nil {
-		return big.Int{}, err
+		return nil, err
 	}
 
 	for i := uint64(0); i < nblocksincl*2; i++ {
@@ -148,20 +234,24 @@ func (mp *MessagePool) GasEstimateGasPremium(
 
 		pts, err := mp.api.LoadTipSet(ts.Parents())
 		if err != nil {
-			return types.BigInt{}, err
+			return nil, err
 		}
 
 		blocks += len(pts.Blocks())
 		meta, err := cache.GetTSGasStats(mp.api, pts)
 		if err != nil {
-			return types.BigInt{}, err
+			return nil, err
 		}
 		prices = append(prices, meta...)
+		report.TipSets = append(report.TipSets, GasPremiumTipSet{Key: pts.Key(), Height: pts.Height(), Messages: len(meta)})
 
 		ts = pts
 	}
 
 	premium := medianGasPremium(prices, blocks)
+	report.Blocks = blocks
+	report.Samples = prices
+	report.Median = premium
 
 	if big.Cmp(premium, big.NewInt(MinGasPremium)) < 0 {
 		switch nblocksincl {
@@ -172,6 +262,7 @@ func (mp *MessagePool) GasEstimateGasPremium(
 		default:
 			premium = big.NewInt(MinGasPremium)
 		}
+		report.Minimum = premium
 	}
 
 	// add some noise to normalize behaviour of message selection
@@ -180,7 +271,9 @@ func (mp *MessagePool) GasEstimateGasPremium(
 	noise := 1 + rand.NormFloat64()*0.005
 	premium = types.BigMul(premium, types.NewInt(uint64(noise*(1<<precision))+1))
 	premium = types.BigDiv(premium, types.NewInt(1<<precision))
-	return premium, nil
+	report.Noise = noise
+	report.GasPremium = premium
+	return report, nil
 }
 
 func (mp *MessagePool) GasEstimateGasLimit(ctx context.Context, msgIn *types.UnsignedMessage, tsk types.TipSetKey) (int64, error) {
@@ -272,37 +365,68 @@ func (mp *MessagePool) evalMessageGasLimit(ctx context.Context, msgIn *types.Mes
 	return res.Receipt.GasUsed + 76e3, nil
 }
 
-func (mp *MessagePool) GasEstimateMessageGas(ctx context.Context, estimateMessage *types.EstimateMessage, _ types.TipSetKey) (*types.Message, error) {
+func (mp *MessagePool) GasEstimateMessageGas(ctx context.Context, estimateMessage *types.EstimateMessage, tsk types.TipSetKey) (*types.Message, error) {
+	report, err := mp.GasEstimateMessageGasExplain(ctx, estimateMessage, tsk)
+	if err != nil {
+		return nil, err
+	}
+	return report.Msg, nil
+}
+
+// GasEstimateMessageGasExplain estimates the gas values of the message like GasEstimateMessageGas
+// and reports the inputs of every estimate.
+func (mp *MessagePool) GasEstimateMessageGasExplain(ctx context.Context, estimateMessage *types.EstimateMessage, _ types.TipSetKey) (*GasEstimateReport, error) {
 	if estimateMessage == nil || estimateMessage.Msg == nil {
 		return nil, xerrors.Errorf("estimate message is nil")
 	}
+	report := &GasEstimateReport{Msg: estimateMessage.Msg}
 	if estimateMessage.Msg.GasLimit == 0 {
 		gasLimit, err := mp.GasEstimateGasLimit(ctx, estimateMessage.Msg, types.TipSetKey{})
 		if err != nil {
 			return nil, xerrors.Errorf("estimating gas used: %w", err)
 		}
-		estimateMessage.Msg.GasLimit = int64(float64(gasLimit) * estimateMessage.Spec.GasOverEstimation)
+		overestimation := mp.gasOverEstimation(estimateMessage.Spec)
+		estimateMessage.Msg.GasLimit = int64(float64(gasLimit) * overestimation)
+		report.GasLimit = &GasLimitReport{
+			GasUsed:        gasLimit,
+			Overestimation: overestimation,
+			GasLimit:       estimateMessage.Msg.GasLimit,
+		}
 	}
 
 	if estimateMessage.Msg.GasPremium == types.EmptyInt || types.BigCmp(estimateMessage.Msg.GasPremium, types.NewInt(0)) == 0 {
-		gasPremium, err := mp.GasEstimateGasPremium(ctx, 10, estimateMessage.Msg.From, estimateMessage.Msg.GasLimit, types.TipSetKey{}, mp.PriceCache)
+		premiumReport, err := mp.estimateGasPremium(10, mp.PriceCache)
 		if err != nil {
 			return nil, xerrors.Errorf("estimating gas price: %w", err)
 		}
-		estimateMessage.Msg.GasPremium = gasPremium
+		estimateMessage.Msg.GasPremium = premiumReport.GasPremium
+		report.GasPremium = premiumReport
 	}
 
 	if estimateMessage.Msg.GasFeeCap == types.EmptyInt || types.BigCmp(estimateMessage.Msg.GasFeeCap, types.NewInt(0)) == 0 {
-		feeCap, err := mp.GasEstimateFeeCap(ctx, estimateMessage.Msg, 20, types.EmptyTSK)
+		feeCapReport, err := mp.estimateFeeCap(estimateMessage.Msg, 20)
 		if err != nil {
 			return nil, xerrors.Errorf("estimating fee cap: %w", err)
 		}
-		estimateMessage.Msg.GasFeeCap = feeCap
+		estimateMessage.Msg.GasFeeCap = feeCapReport.GasFeeCap
+		report.FeeCap = feeCapReport
 	}
 
+	feeCap := estimateMessage.Msg.GasFeeCap
+	report.MaxFee = maxFeeFor(mp.GetMaxFee, estimateMessage.Spec)
 	CapGasFee(mp.GetMaxFee, estimateMessage.Msg, estimateMessage.Spec)
+	report.Capped = !feeCap.Equals(estimateMessage.Msg.GasFeeCap)
 
-	return estimateMessage.Msg, nil
+	return report, nil
+}
+
+// gasOverEstimation returns the factor applied to the gas used to get the gas limit, the one
+// of the send spec if set, GasLimitOverestimation of the configuration otherwise.
+func (mp *MessagePool) gasOverEstimation(spec *types.MessageSendSpec) float64 {
+	if spec != nil && spec.GasOverEstimation > 0 {
+		return spec.GasOverEstimation
+	}
+	return mp.GetConfig().GasLimitOverestimation
 }
 
 func (mp *MessagePool) GasBatchEstimateMessageGas(ctx context.Context, estimateMessages []*types.EstimateMessage, fromNonce uint64, tsk types.TipSetKey) ([]*types.EstimateResult, error) {
@@ -341,7 +465,7 @@ func (mp *MessagePool) GasBatchEstimateMessageGas(ctx context.Context, estimateM
 				})
 				continue
 			}
-			estimateMsg.GasLimit = int64(float64(gasUsed) * estimateMessage.Spec.GasOverEstimation)
+			estimateMsg.GasLimit = int64(float64(gasUsed) * mp.gasOverEstimation(estimateMessage.Spec))
 		}
 
 		if estimateMsg.GasPremium == types.EmptyInt || types.BigCmp(estimateMsg.GasPremium, types.NewInt(0)) == 0 {
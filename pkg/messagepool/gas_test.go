@@ -0,0 +1,64 @@
+package messagepool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tf "github.com/filecoin-project/venus/pkg/testhelpers/testflags"
+	"github.com/filecoin-project/venus/pkg/types"
+)
+
+func TestGasEstimateMessageGasExplain(t *testing.T) {
+	tf.UnitTest(t)
+
+	tma := newTestMpoolAPI()
+	w, mp := newWalletAndMpool(t, tma)
+
+	sender, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	target := mkAddress(1001)
+
+	a := tma.nextBlock()
+	tma.setBlockMessages(a, mkMessage(sender, target, 0, w), mkMessage(sender, target, 1, w))
+	head := tma.nextBlock()
+
+	msg := &types.UnsignedMessage{
+		From:       sender,
+		To:         target,
+		Value:      big.Zero(),
+		GasLimit:   1000,
+		GasFeeCap:  big.Zero(),
+		GasPremium: big.Zero(),
+	}
+	report, err := mp.GasEstimateMessageGasExplain(context.Background(), &types.EstimateMessage{Msg: msg}, types.EmptyTSK)
+	require.NoError(t, err)
+
+	// the gas limit is given.
+	assert.Nil(t, report.GasLimit)
+	assert.Equal(t, int64(1000), report.Msg.GasLimit)
+
+	premium := report.GasPremium
+	require.NotNil(t, premium)
+	require.Len(t, premium.TipSets, 2)
+	assert.Equal(t, a.Cid(), premium.TipSets[0].Key.Cids()[0])
+	assert.Equal(t, 2, premium.TipSets[0].Messages)
+	assert.Equal(t, 2, premium.Blocks)
+	assert.Len(t, premium.Samples, 2)
+	assert.Equal(t, big.NewInt(1), premium.Median)
+	// the sampled premiums are below the minimum.
+	assert.Equal(t, big.NewInt(MinGasPremium), premium.Minimum)
+	assert.Equal(t, premium.GasPremium, report.Msg.GasPremium)
+
+	feeCap := report.FeeCap
+	require.NotNil(t, feeCap)
+	assert.Equal(t, head.ParentBaseFee, feeCap.ParentBaseFee)
+	assert.Equal(t, int64(20), feeCap.MaxQueueBlocks)
+	assert.Equal(t, big.Add(feeCap.ProjectedBaseFee, premium.GasPremium), feeCap.GasFeeCap)
+	assert.Equal(t, feeCap.GasFeeCap, report.Msg.GasFeeCap)
+	assert.False(t, report.Capped)
+}
@@ -0,0 +1,374 @@
+package messagepool
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	"github.com/ipfs/go-datastore/query"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus/pkg/chain"
+	"github.com/filecoin-project/venus/pkg/constants"
+	"github.com/filecoin-project/venus/pkg/types"
+)
+
+// ErrNoMsgHistory is returned by MessageHistory.Get for messages never seen by the pool.
+var ErrNoMsgHistory = xerrors.New("no history for the message")
+
+var msgHistoryPrefix = datastore.NewKey("/mpool/history")
+
+// DefaultMsgHistoryMaxAge is how long the history of a message is kept after its last event by default.
+const DefaultMsgHistoryMaxAge = 7 * 24 * time.Hour
+
+// msgHistoryPruneInterval is how often the histories older than the max age are dropped.
+const msgHistoryPruneInterval = time.Hour
+
+// MsgHistoryEventType is what happened to a message.
+type MsgHistoryEventType string
+
+const (
+	// HistoryAdded is recorded when the message is added to the pool, the first one is when it was
+	// first seen
+	HistoryAdded MsgHistoryEventType = "added"
+	// HistoryReplaced is recorded when another message with the same nonce replaced the message in
+	// the pool or was included on chain, ReplacedBy is set
+	HistoryReplaced MsgHistoryEventType = "replaced"
+	// HistoryRemoved is recorded when the message is pruned, expired or cleared from the pool, Reason
+	// is set
+	HistoryRemoved MsgHistoryEventType = "removed"
+	// HistoryRepublished is recorded when the local message is published again
+	HistoryRepublished MsgHistoryEventType = "republished"
+	// HistoryIncluded is recorded when a tipset including the message is applied, TipSet is set
+	HistoryIncluded MsgHistoryEventType = "included"
+	// HistoryExecuted is recorded when the receipt of the message is computed on top of the tipset
+	// including it, TipSet and Receipt are set
+	HistoryExecuted MsgHistoryEventType = "executed"
+	// HistoryReverted is recorded when a tipset including the message is reverted, TipSet is set
+	HistoryReverted MsgHistoryEventType = "reverted"
+)
+
+// MsgHistoryEvent is an event of the lifecycle of a message.
+type MsgHistoryEvent struct {
+	Type MsgHistoryEventType
+	Time time.Time
+
+	Reason     MpoolRemoveReason     `json:",omitempty"`
+	ReplacedBy *cid.Cid              `json:",omitempty"`
+	TipSet     *types.TipSetKey      `json:",omitempty"`
+	Height     abi.ChainEpoch        `json:",omitempty"`
+	Receipt    *types.MessageReceipt `json:",omitempty"`
+}
+
+// MsgHistory is the lifecycle of a message seen by the pool, its events are in order.
+type MsgHistory struct {
+	Message cid.Cid
+	From    address.Address
+	Nonce   uint64
+	Local   bool
+	Events  []*MsgHistoryEvent
+}
+
+type historyChainReader interface {
+	GetTipSet(types.TipSetKey) (*types.TipSet, error)
+	SubHeadChanges(context.Context) chan []*chain.HeadChange
+	GetParentReceipt(*types.BlockHeader, int) (*types.MessageReceipt, error)
+}
+
+type historyMessageReader interface {
+	MessagesForTipset(*types.TipSet) ([]types.ChainMsg, error)
+}
+
+// MessageHistory records the lifecycle of the messages seen by the pool, from the updates of the pool
+// and the head changes of the chain. Only the messages seen by the pool are tracked on chain, and the
+// history of a message is dropped once its last event is older than the max age.
+type MessageHistory struct {
+	lk       sync.Mutex
+	ds       datastore.Batching
+	chain    historyChainReader
+	messages historyMessageReader
+	maxAge   time.Duration
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewMessageHistory creates a message history stored in ds, keeping the history of a message for
+// maxAge after its last event, DefaultMsgHistoryMaxAge if maxAge is not positive.
+func NewMessageHistory(ds datastore.Batching, chain historyChainReader, messages historyMessageReader, maxAge time.Duration) *MessageHistory {
+	if maxAge <= 0 {
+		maxAge = DefaultMsgHistoryMaxAge
+	}
+	return &MessageHistory{
+		ds:       namespace.Wrap(ds, msgHistoryPrefix),
+		chain:    chain,
+		messages: messages,
+		maxAge:   maxAge,
+	}
+}
+
+// Start follows the updates of the pool and the head changes of the chain in the background.
+func (h *MessageHistory) Start(ctx context.Context, mp *MessagePool) error {
+	ctx, h.cancel = context.WithCancel(ctx)
+
+	updates, err := mp.UpdatesFiltered(ctx, &MpoolUpdateFilter{Republished: true})
+	if err != nil {
+		h.cancel()
+		return err
+	}
+	changes := h.chain.SubHeadChanges(ctx)
+
+	h.wg.Add(3)
+	go func() {
+		defer h.wg.Done()
+		for u := range updates {
+			if err := h.recordUpdate(u); err != nil {
+				log.Errorf("failed to record message history of %s: %s", u.Message.Cid(), err)
+			}
+		}
+	}()
+	go func() {
+		defer h.wg.Done()
+		for hcs := range changes {
+			for _, hc := range hcs {
+				var err error
+				switch hc.Type {
+				case chain.HCApply:
+					err = h.applyTipSet(ctx, hc.Val)
+				case chain.HCRevert:
+					err = h.revertTipSet(ctx, hc.Val)
+				}
+				if err != nil {
+					log.Errorf("failed to record message history at %d: %s", hc.Val.Height(), err)
+				}
+			}
+		}
+	}()
+	go func() {
+		defer h.wg.Done()
+		ticker := constants.Clock.Ticker(msgHistoryPruneInterval)
+		defer ticker.Stop()
+		for {
+			if err := h.prune(constants.Clock.Now().Add(-h.maxAge)); err != nil {
+				log.Errorf("failed to prune message history: %s", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return nil
+}
+
+// Close stops following the pool and the chain.
+func (h *MessageHistory) Close() {
+	if h.cancel != nil {
+		h.cancel()
+	}
+	h.wg.Wait()
+}
+
+// Get returns the history of the message c, ErrNoMsgHistory if the pool never saw it.
+func (h *MessageHistory) Get(ctx context.Context, c cid.Cid) (*MsgHistory, error) {
+	h.lk.Lock()
+	defer h.lk.Unlock()
+
+	return h.get(c)
+}
+
+func (h *MessageHistory) get(c cid.Cid) (*MsgHistory, error) {
+	val, err := h.ds.Get(datastore.NewKey(c.String()))
+	if err == datastore.ErrNotFound {
+		return nil, ErrNoMsgHistory
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("failed to read message history: %w", err)
+	}
+
+	var hist MsgHistory
+	if err := json.Unmarshal(val, &hist); err != nil {
+		return nil, xerrors.Errorf("failed to decode message history: %w", err)
+	}
+	return &hist, nil
+}
+
+// record appends the event to the history of the message m, created with m if tracked is false.
+// The events of untracked messages are dropped when tracked is true.
+func (h *MessageHistory) record(m types.ChainMsg, local bool, tracked bool, evt *MsgHistoryEvent) error {
+	h.lk.Lock()
+	defer h.lk.Unlock()
+
+	c := m.Cid()
+	hist, err := h.get(c)
+	switch {
+	case err == ErrNoMsgHistory && tracked:
+		return nil
+	case err == ErrNoMsgHistory:
+		hist = &MsgHistory{Message: c, From: m.VMMessage().From, Nonce: m.VMMessage().Nonce}
+	case err != nil:
+		return err
+	}
+	hist.Local = hist.Local || local
+
+	evt.Time = constants.Clock.Now()
+	hist.Events = append(hist.Events, evt)
+
+	val, err := json.Marshal(hist)
+	if err != nil {
+		return err
+	}
+	return h.ds.Put(datastore.NewKey(c.String()), val)
+}
+
+func (h *MessageHistory) recordUpdate(u MpoolUpdate) error {
+	switch u.Type {
+	case MpoolAdd:
+		return h.record(u.Message, u.Local, false, &MsgHistoryEvent{Type: HistoryAdded})
+	case MpoolRepublish:
+		return h.record(u.Message, u.Local, false, &MsgHistoryEvent{Type: HistoryRepublished})
+	case MpoolRemove:
+		switch u.Reason {
+		case RemoveIncluded:
+			// recorded from the head changes, including the messages the pool did not have anymore
+			return nil
+		case RemoveReplaced:
+			return h.record(u.Message, u.Local, false, &MsgHistoryEvent{
+				Type:       HistoryReplaced,
+				ReplacedBy: u.ReplacedBy,
+				TipSet:     u.TipSet,
+				Height:     u.Height,
+			})
+		default:
+			return h.record(u.Message, u.Local, false, &MsgHistoryEvent{Type: HistoryRemoved, Reason: u.Reason})
+		}
+	}
+	return nil
+}
+
+// prune drops the histories whose last event is before the cutoff. The histories are listed and
+// decoded without h.lk, holding it for the whole pass would hold up the updates of the pool.
+func (h *MessageHistory) prune(cutoff time.Time) error {
+	res, err := h.ds.Query(query.Query{})
+	if err != nil {
+		return xerrors.Errorf("failed to query message history: %w", err)
+	}
+	defer res.Close() // nolint: errcheck
+
+	var expired []datastore.Key
+	for r := range res.Next() {
+		if r.Error != nil {
+			return xerrors.Errorf("failed to read message history: %w", r.Error)
+		}
+		var hist MsgHistory
+		if err := json.Unmarshal(r.Value, &hist); err != nil {
+			return xerrors.Errorf("failed to decode message history: %w", err)
+		}
+		if hist.expired(cutoff) {
+			expired = append(expired, datastore.NewKey(r.Key))
+		}
+	}
+
+	for _, key := range expired {
+		if err := h.deleteExpired(key, cutoff); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteExpired deletes the history at key if its last event is still before the cutoff, an event
+// may have been recorded since it was listed.
+func (h *MessageHistory) deleteExpired(key datastore.Key, cutoff time.Time) error {
+	h.lk.Lock()
+	defer h.lk.Unlock()
+
+	val, err := h.ds.Get(key)
+	if err == datastore.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return xerrors.Errorf("failed to read message history: %w", err)
+	}
+	var hist MsgHistory
+	if err := json.Unmarshal(val, &hist); err != nil {
+		return xerrors.Errorf("failed to decode message history: %w", err)
+	}
+	if !hist.expired(cutoff) {
+		return nil
+	}
+	if err := h.ds.Delete(key); err != nil {
+		return xerrors.Errorf("failed to delete message history: %w", err)
+	}
+	return nil
+}
+
+// expired reports whether the last event of the history is before the cutoff.
+func (hist *MsgHistory) expired(cutoff time.Time) bool {
+	n := len(hist.Events)
+	return n == 0 || hist.Events[n-1].Time.Before(cutoff)
+}
+
+// applyTipSet records the inclusion of the tracked messages of ts, and the execution of the tracked
+// messages of its parent, whose receipts are in ts.
+func (h *MessageHistory) applyTipSet(ctx context.Context, ts *types.TipSet) error {
+	tsk := ts.Key()
+	msgs, err := h.messages.MessagesForTipset(ts)
+	if err != nil {
+		return xerrors.Errorf("failed to load messages of %s: %w", tsk, err)
+	}
+	for _, m := range msgs {
+		if err := h.record(m, false, true, &MsgHistoryEvent{Type: HistoryIncluded, TipSet: &tsk, Height: ts.Height()}); err != nil {
+			return err
+		}
+	}
+
+	if ts.Height() == 0 {
+		return nil
+	}
+	parent, err := h.chain.GetTipSet(ts.Parents())
+	if err != nil {
+		return xerrors.Errorf("failed to load parent of %s: %w", tsk, err)
+	}
+	parentKey := parent.Key()
+	msgs, err = h.messages.MessagesForTipset(parent)
+	if err != nil {
+		return xerrors.Errorf("failed to load messages of %s: %w", parentKey, err)
+	}
+	for i, m := range msgs {
+		if _, err := h.Get(ctx, m.Cid()); err == ErrNoMsgHistory {
+			continue
+		}
+		receipt, err := h.chain.GetParentReceipt(ts.Blocks()[0], i)
+		if err != nil {
+			return xerrors.Errorf("failed to load receipt %d of %s: %w", i, tsk, err)
+		}
+		evt := &MsgHistoryEvent{Type: HistoryExecuted, TipSet: &parentKey, Height: parent.Height(), Receipt: receipt}
+		if err := h.record(m, false, true, evt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revertTipSet records the revert of the tracked messages of ts.
+func (h *MessageHistory) revertTipSet(ctx context.Context, ts *types.TipSet) error {
+	tsk := ts.Key()
+	msgs, err := h.messages.MessagesForTipset(ts)
+	if err != nil {
+		return xerrors.Errorf("failed to load messages of %s: %w", tsk, err)
+	}
+	for _, m := range msgs {
+		if err := h.record(m, false, true, &MsgHistoryEvent{Type: HistoryReverted, TipSet: &tsk, Height: ts.Height()}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
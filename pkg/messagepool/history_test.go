@@ -0,0 +1,173 @@
+package messagepool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/exitcode"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus/pkg/chain"
+	tf "github.com/filecoin-project/venus/pkg/testhelpers/testflags"
+	"github.com/filecoin-project/venus/pkg/types"
+	"github.com/filecoin-project/venus/pkg/wallet"
+)
+
+// testHistoryChain serves the tipsets and block messages of a testMpoolAPI.
+type testHistoryChain struct {
+	tma      *testMpoolAPI
+	changes  chan []*chain.HeadChange
+	receipts map[cid.Cid][]types.MessageReceipt
+}
+
+func (hc *testHistoryChain) GetTipSet(key types.TipSetKey) (*types.TipSet, error) {
+	for _, ts := range hc.tma.tipsets {
+		if ts.Key().Equals(key) {
+			return ts, nil
+		}
+	}
+	return nil, xerrors.Errorf("tipset %s not found", key)
+}
+
+func (hc *testHistoryChain) SubHeadChanges(ctx context.Context) chan []*chain.HeadChange {
+	out := make(chan []*chain.HeadChange, 16)
+	go func() {
+		defer close(out)
+		for {
+			select {
+			case changes := <-hc.changes:
+				out <- changes
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func (hc *testHistoryChain) GetParentReceipt(b *types.BlockHeader, i int) (*types.MessageReceipt, error) {
+	receipts := hc.receipts[b.Cid()]
+	if i >= len(receipts) {
+		return nil, xerrors.Errorf("receipt %d not found", i)
+	}
+	return &receipts[i], nil
+}
+
+func (hc *testHistoryChain) MessagesForTipset(ts *types.TipSet) ([]types.ChainMsg, error) {
+	var msgs []types.ChainMsg
+	for _, b := range ts.Blocks() {
+		for _, m := range hc.tma.bmsgs[b.Cid()] {
+			msgs = append(msgs, m)
+		}
+	}
+	return msgs, nil
+}
+
+func waitHistory(t *testing.T, h *MessageHistory, c cid.Cid, events int) *MsgHistory {
+	t.Helper()
+
+	var hist *MsgHistory
+	require.Eventually(t, func() bool {
+		var err error
+		hist, err = h.Get(context.Background(), c)
+		return err == nil && len(hist.Events) >= events
+	}, 5*time.Second, 10*time.Millisecond)
+	return hist
+}
+
+func TestMessageHistory(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tma := newTestMpoolAPI()
+	w, mp := newWalletAndMpool(t, tma)
+
+	a1, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	a2, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	target := mkAddress(1001)
+	tma.setBalance(a1, 1)
+	tma.setBalance(a2, 1)
+
+	hc := &testHistoryChain{
+		tma:      tma,
+		changes:  make(chan []*chain.HeadChange, 16),
+		receipts: make(map[cid.Cid][]types.MessageReceipt),
+	}
+	h := NewMessageHistory(datastore.NewMapDatastore(), hc, hc, 0)
+	require.NoError(t, h.Start(ctx, mp))
+	defer h.Close()
+
+	m := mkMessage(a1, target, 0, w)
+	_, err = mp.Push(ctx, m)
+	require.NoError(t, err)
+
+	rbf := mkMessage(a1, target, 0, w)
+	rbf.Message.GasPremium = ComputeMinRBF(rbf.Message.GasPremium)
+	sig, err := w.WalletSign(a1, rbf.Message.Cid().Bytes(), wallet.MsgMeta{})
+	require.NoError(t, err)
+	rbf.Signature = *sig
+	_, err = mp.Push(ctx, rbf)
+	require.NoError(t, err)
+
+	require.NoError(t, mp.republishPendingMessages(ctx))
+	waitHistory(t, h, rbf.Cid(), 2)
+
+	// other is included but never seen by the pool
+	other := mkMessage(a2, target, 0, w)
+	b1 := tma.nextBlock()
+	tma.setBlockMessages(b1, rbf, other)
+	b2 := tma.nextBlock()
+	hc.receipts[b2.Cid()] = []types.MessageReceipt{{ExitCode: exitcode.Ok, GasUsed: 123}, {ExitCode: exitcode.Ok}}
+	hc.changes <- []*chain.HeadChange{{Type: chain.HCApply, Val: mkTipSet(b1)}}
+	hc.changes <- []*chain.HeadChange{{Type: chain.HCApply, Val: mkTipSet(b2)}}
+	hc.changes <- []*chain.HeadChange{{Type: chain.HCRevert, Val: mkTipSet(b2)}, {Type: chain.HCRevert, Val: mkTipSet(b1)}}
+
+	hist := waitHistory(t, h, m.Cid(), 2)
+	assert.True(t, hist.Local)
+	assert.Equal(t, a1, hist.From)
+	assert.Equal(t, HistoryAdded, hist.Events[0].Type)
+	assert.Equal(t, HistoryReplaced, hist.Events[1].Type)
+	require.NotNil(t, hist.Events[1].ReplacedBy)
+	assert.Equal(t, rbf.Cid(), *hist.Events[1].ReplacedBy)
+
+	b1Key := mkTipSet(b1).Key()
+	hist = waitHistory(t, h, rbf.Cid(), 5)
+	var got []MsgHistoryEventType
+	for _, evt := range hist.Events {
+		got = append(got, evt.Type)
+	}
+	assert.Equal(t, []MsgHistoryEventType{HistoryAdded, HistoryRepublished, HistoryIncluded, HistoryExecuted, HistoryReverted}, got)
+
+	included := hist.Events[2]
+	require.NotNil(t, included.TipSet)
+	assert.Equal(t, b1Key, *included.TipSet)
+	assert.Equal(t, b1.Height, included.Height)
+
+	executed := hist.Events[3]
+	require.NotNil(t, executed.TipSet)
+	assert.Equal(t, b1Key, *executed.TipSet)
+	require.NotNil(t, executed.Receipt)
+	assert.Equal(t, int64(123), executed.Receipt.GasUsed)
+
+	_, err = h.Get(ctx, other.Cid())
+	assert.Equal(t, ErrNoMsgHistory, err)
+
+	// the histories are dropped once their last event is older than the max age
+	last := hist.Events[len(hist.Events)-1].Time
+	require.NoError(t, h.prune(last))
+	_, err = h.Get(ctx, rbf.Cid())
+	require.NoError(t, err)
+	require.NoError(t, h.prune(last.Add(time.Nanosecond)))
+	_, err = h.Get(ctx, rbf.Cid())
+	assert.Equal(t, ErrNoMsgHistory, err)
+}
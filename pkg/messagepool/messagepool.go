@@ -47,11 +47,40 @@ type MpoolChange int
 const (
 	MpoolAdd MpoolChange = iota
 	MpoolRemove
+	// MpoolRepublish is sent for a local message published again by the republish loop
+	MpoolRepublish
+)
+
+// MpoolRemoveReason is why a message was removed from the pool.
+type MpoolRemoveReason string
+
+const (
+	// RemoveIncluded is set for a message included in a tipset
+	RemoveIncluded MpoolRemoveReason = "included"
+	// RemoveReplaced is set for a message replaced by fee, or whose nonce is used by another message
+	// included in a tipset
+	RemoveReplaced MpoolRemoveReason = "replaced"
+	// RemovePruned is set for a message pruned when the pool is over its size limit
+	RemovePruned MpoolRemoveReason = "pruned"
+	// RemoveExpired is set for a message still pending after the MessageTTL of the pool
+	RemoveExpired MpoolRemoveReason = "expired"
+	// RemoveCleared is set for a message removed by Clear
+	RemoveCleared MpoolRemoveReason = "cleared"
 )
 
 type MpoolUpdate struct {
 	Type    MpoolChange
 	Message *types.SignedMessage
+	// Local is set for the messages from a local address
+	Local bool `json:",omitempty"`
+
+	// Reason is set for MpoolRemove
+	Reason MpoolRemoveReason `json:",omitempty"`
+	// TipSet and Height are the tipset including the message, or the message with the same nonce
+	TipSet *types.TipSetKey `json:",omitempty"`
+	Height abi.ChainEpoch   `json:",omitempty"`
+	// ReplacedBy is the message with the same nonce for RemoveReplaced
+	ReplacedBy *cid.Cid `json:",omitempty"`
 }
 
 var log = logging.Logger("messagepool")
@@ -106,6 +135,7 @@ const (
 	evtTypeMpoolAdd = iota
 	evtTypeMpoolRemove
 	evtTypeMpoolRepub
+	evtTypeMpoolReplace
 )
 
 // MessagePoolEvt is the journal entry for message pool events.
@@ -152,6 +182,13 @@ type MessagePool struct {
 
 	republished map[cid.Cid]struct{}
 
+	replaceTk *clock.Ticker
+	resigner  MessageResigner
+	// epochs at which the pending local messages were first seen by the replace loop
+	replaceSeen map[cid.Cid]abi.ChainEpoch
+	// epochs at which the pending messages were first seen by the expiry check
+	expireSeen map[cid.Cid]abi.ChainEpoch
+
 	// do NOT access this map directly, use isLocal, setLocal, and forEachLocal respectively
 	localAddrs map[address.Address]struct{}
 
@@ -159,12 +196,18 @@ type MessagePool struct {
 	pending map[address.Address]*msgSet
 
 	keyCache map[address.Address]address.Address
+	// noKeyRecipients are the recipients found without key address at the current head, see recipientKey
+	noKeyRecipients map[address.Address]struct{}
 
 	curTSLk sync.Mutex // DO NOT LOCK INSIDE lk
 	curTS   *types.TipSet
 
-	cfgLk sync.Mutex
-	cfg   *MpoolConfig
+	cfgLk    sync.Mutex
+	cfg      *MpoolConfig
+	policies []*MpoolPolicy
+
+	// values of the messages accepted over PolicyValueWindow, guarded by lk
+	policySpends []policySpend
 
 	api Provider
 
@@ -181,14 +224,21 @@ type MessagePool struct {
 	blsSigCache *lru.TwoQueueCache
 
 	changes *lps.PubSub
+	// heldUpdates collects the updates instead of publishing them while a batch is added, guarded by lk
+	heldUpdates *[]MpoolUpdate
+	// heldSpends collects the policy spends instead of persisting them while a batch is added, guarded by lk
+	heldSpends *[]policySpend
 
 	localMsgs datastore.Datastore
 
+	// snapshot of the pending messages, see savePending
+	pendingMsgs datastore.Batching
+
 	netName string
 
 	sigValCache *lru.TwoQueueCache
 
-	evtTypes [3]journal.EventType
+	evtTypes [4]journal.EventType
 	journal  journal.Journal
 
 	forkParams       *config.ForkUpgradeConfig
@@ -211,6 +261,8 @@ type msgSet struct {
 	msgs          map[uint64]*types.SignedMessage
 	nextNonce     uint64
 	requiredFunds *stdbig.Int
+	// recipients counts the messages by recipient, as set in the messages
+	recipients map[address.Address]int
 }
 
 func newMsgSet(nonce uint64) *msgSet {
@@ -218,6 +270,13 @@ func newMsgSet(nonce uint64) *msgSet {
 		msgs:          make(map[uint64]*types.SignedMessage),
 		nextNonce:     nonce,
 		requiredFunds: stdbig.NewInt(0),
+		recipients:    make(map[address.Address]int),
+	}
+}
+
+func (ms *msgSet) rmRecipient(to address.Address) {
+	if ms.recipients[to]--; ms.recipients[to] <= 0 {
+		delete(ms.recipients, to)
 	}
 }
 
@@ -227,6 +286,21 @@ func ComputeMinRBF(curPrem abi.TokenAmount) abi.TokenAmount {
 }
 
 func CapGasFee(mff DefaultMaxFeeFunc, msg *types.Message, sendSepc *types.MessageSendSpec) {
+	maxFee := maxFeeFor(mff, sendSepc)
+
+	gl := types.NewInt(uint64(msg.GasLimit))
+	totalFee := types.BigMul(msg.GasFeeCap, gl)
+
+	if totalFee.LessThanEqual(maxFee) {
+		return
+	}
+
+	msg.GasFeeCap = big.Div(maxFee, gl)
+	msg.GasPremium = big.Min(msg.GasFeeCap, msg.GasPremium) // cap premium at FeeCap
+}
+
+// maxFeeFor returns the max fee of the send spec, the default one when it is not set.
+func maxFeeFor(mff DefaultMaxFeeFunc, sendSepc *types.MessageSendSpec) abi.TokenAmount {
 	var maxFee abi.TokenAmount
 	if sendSepc != nil {
 		maxFee = sendSepc.MaxFee
@@ -239,16 +313,7 @@ func CapGasFee(mff DefaultMaxFeeFunc, msg *types.Message, sendSepc *types.Messag
 		}
 		maxFee = mf
 	}
-
-	gl := types.NewInt(uint64(msg.GasLimit))
-	totalFee := types.BigMul(msg.GasFeeCap, gl)
-
-	if totalFee.LessThanEqual(maxFee) {
-		return
-	}
-
-	msg.GasFeeCap = big.Div(maxFee, gl)
-	msg.GasPremium = big.Min(msg.GasFeeCap, msg.GasPremium) // cap premium at FeeCap
+	return maxFee
 }
 
 func (ms *msgSet) add(m *types.SignedMessage, mp *MessagePool, strict, untrusted bool) (bool, error) {
@@ -309,6 +374,7 @@ func (ms *msgSet) add(m *types.SignedMessage, mp *MessagePool, strict, untrusted
 
 		ms.requiredFunds.Sub(ms.requiredFunds, exms.Message.RequiredFunds().Int)
 		//ms.requiredFunds.Sub(ms.requiredFunds, exms.Message.Value.Int)
+		ms.rmRecipient(exms.Message.To)
 	}
 
 	if !has && strict && len(ms.msgs) >= maxActorPendingMessages {
@@ -325,6 +391,7 @@ func (ms *msgSet) add(m *types.SignedMessage, mp *MessagePool, strict, untrusted
 	ms.msgs[m.Message.Nonce] = m
 	ms.requiredFunds.Add(ms.requiredFunds, m.Message.RequiredFunds().Int)
 	//ms.requiredFunds.Add(ms.requiredFunds, m.Message.Value.Int)
+	ms.recipients[m.Message.To]++
 
 	return !has, nil
 }
@@ -345,6 +412,7 @@ func (ms *msgSet) rm(nonce uint64, applied bool) {
 
 	ms.requiredFunds.Sub(ms.requiredFunds, m.Message.RequiredFunds().Int)
 	//ms.requiredFunds.Sub(ms.requiredFunds, m.Message.Value.Int)
+	ms.rmRecipient(m.Message.To)
 	delete(ms.msgs, nonce)
 
 	// adjust next nonce
@@ -407,6 +475,15 @@ func New(api Provider,
 		return nil, xerrors.Errorf("error loading mpool config: %v", err)
 	}
 
+	policies, err := loadPolicies(ds)
+	if err != nil {
+		return nil, xerrors.Errorf("error loading mpool policies: %v", err)
+	}
+	policySpends, err := loadPolicySpends(ds)
+	if err != nil {
+		return nil, xerrors.Errorf("error loading mpool policy spends: %v", err)
+	}
+
 	if j == nil {
 		j = journal.NilJournal()
 	}
@@ -417,6 +494,9 @@ func New(api Provider,
 		closer:        make(chan struct{}),
 		repubTk:       constants.Clock.Ticker(RepublishInterval),
 		repubTrigger:  make(chan struct{}, 1),
+		replaceTk:     constants.Clock.Ticker(AutoReplaceInterval),
+		replaceSeen:   make(map[cid.Cid]abi.ChainEpoch),
+		expireSeen:    make(map[cid.Cid]abi.ChainEpoch),
 		localAddrs:    make(map[address.Address]struct{}),
 		pending:       make(map[address.Address]*msgSet),
 		keyCache:      make(map[address.Address]address.Address),
@@ -427,15 +507,19 @@ func New(api Provider,
 		sigValCache:   verifcache,
 		changes:       lps.New(50),
 		localMsgs:     namespace.Wrap(ds, datastore.NewKey(localMsgsDs)),
+		pendingMsgs:   namespace.Wrap(ds, datastore.NewKey(pendingMsgsDs)),
 		api:           api,
 		netName:       netName,
 		gp:            gp,
 		ap:            ap,
 		cfg:           cfg,
+		policies:      policies,
+		policySpends:  policySpends,
 		evtTypes: [...]journal.EventType{
-			evtTypeMpoolAdd:    j.RegisterEventType("mpool", "add"),
-			evtTypeMpoolRemove: j.RegisterEventType("mpool", "remove"),
-			evtTypeMpoolRepub:  j.RegisterEventType("mpool", "repub"),
+			evtTypeMpoolAdd:     j.RegisterEventType("mpool", "add"),
+			evtTypeMpoolRemove:  j.RegisterEventType("mpool", "remove"),
+			evtTypeMpoolRepub:   j.RegisterEventType("mpool", "repub"),
+			evtTypeMpoolReplace: j.RegisterEventType("mpool", "replace"),
 		},
 		journal:          j,
 		forkParams:       forkParams,
@@ -464,12 +548,18 @@ func New(api Provider,
 	go func() {
 		defer cancel()
 		err := mp.loadLocal(ctx)
+		if err != nil {
+			log.Errorf("loading local messages: %+v", err)
+		}
+
+		// the snapshot is loaded after the local messages so that they are not added twice
+		err = mp.loadPending(ctx)
 
 		mp.lk.Unlock()
 		mp.curTSLk.Unlock()
 
 		if err != nil {
-			log.Errorf("loading local messages: %+v", err)
+			log.Errorf("loading pending messages: %+v", err)
 		}
 
 		log.Info("mpool ready")
@@ -522,6 +612,15 @@ func (mp *MessagePool) setPendingMset(ctx context.Context, addr address.Address,
 	return nil
 }
 
+func (mp *MessagePool) unsetLocal(ctx context.Context, addr address.Address) {
+	ra, err := mp.resolveToKey(ctx, addr)
+	if err != nil {
+		return
+	}
+
+	delete(mp.localAddrs, ra)
+}
+
 // This method isn't strictly necessary, since it doesn't resolve any addresses, but it's safer to have
 func (mp *MessagePool) forEachPending(f func(address.Address, *msgSet)) {
 	for la, ms := range mp.pending {
@@ -641,6 +740,9 @@ func (mp *MessagePool) PublishMsg(smsg *types.SignedMessage) error {
 
 func (mp *MessagePool) Close() error {
 	close(mp.closer)
+	if err := mp.savePending(context.TODO()); err != nil {
+		log.Errorf("saving pending messages: %+v", err)
+	}
 	return mp.journal.Close()
 }
 
@@ -668,6 +770,12 @@ func (mp *MessagePool) runLoop(ctx context.Context) {
 				log.Errorf("error while republishing messages: %s", err)
 			}
 
+		case <-mp.replaceTk.C:
+			if err := mp.replaceStuckMessages(ctx); err != nil {
+				log.Errorf("error while replacing stuck messages: %s", err)
+			}
+			mp.expireMessages(ctx)
+
 		case <-mp.pruneTrigger:
 			if err := mp.pruneExcessMessages(); err != nil {
 				log.Errorf("failed to prune excess messages from mempool: %s", err)
@@ -675,6 +783,7 @@ func (mp *MessagePool) runLoop(ctx context.Context) {
 
 		case <-mp.closer:
 			mp.repubTk.Stop()
+			mp.replaceTk.Stop()
 			return
 		}
 	}
@@ -923,6 +1032,12 @@ func (mp *MessagePool) addTS(ctx context.Context, m *types.SignedMessage, curTS
 	mp.lk.Lock()
 	defer mp.lk.Unlock()
 
+	return mp.addTSLocked(ctx, m, curTS, local, untrusted)
+}
+
+// addTSLocked checks and adds the message, the checks of the state nonce excepted. It must be
+// called with mp.lk held.
+func (mp *MessagePool) addTSLocked(ctx context.Context, m *types.SignedMessage, curTS *types.TipSet, local, untrusted bool) (bool, error) {
 	publish, err := mp.verifyMsgBeforeAdd(m, curTS, local)
 	if err != nil {
 		return false, err
@@ -932,11 +1047,40 @@ func (mp *MessagePool) addTS(ctx context.Context, m *types.SignedMessage, curTS
 		return false, err
 	}
 
+	// policies restrict the messages pushed to this node, not the ones relayed from the network
+	policed := local || untrusted
+	if policed {
+		if err := mp.checkPolicies(ctx, m, curTS); err != nil {
+			return false, err
+		}
+	}
+
+	// mark the sender as local first, for the update published by addLocked, a sender
+	// is only kept local if its message is accepted
+	markedLocal := false
+	if local {
+		wasLocal, err := mp.isLocal(ctx, m.Message.From)
+		if err != nil {
+			return false, err
+		}
+		if err := mp.setLocal(ctx, m.Message.From); err != nil {
+			return false, err
+		}
+		markedLocal = !wasLocal
+	}
+
 	err = mp.addLocked(ctx, m, !local, untrusted)
 	if err != nil {
+		if markedLocal {
+			mp.unsetLocal(ctx, m.Message.From)
+		}
 		return false, err
 	}
 
+	if policed {
+		mp.recordPolicySpend(ctx, m)
+	}
+
 	if local {
 		err = mp.addLocal(ctx, m)
 		if err != nil {
@@ -947,7 +1091,9 @@ func (mp *MessagePool) addTS(ctx context.Context, m *types.SignedMessage, curTS
 	return publish, nil
 }
 
-func (mp *MessagePool) addLoaded(ctx context.Context, m *types.SignedMessage) error {
+// addLoaded adds a message loaded from the datastore after revalidating it against the current
+// tipset, remote messages must meet the base fee lower bound.
+func (mp *MessagePool) addLoaded(ctx context.Context, m *types.SignedMessage, local bool) error {
 	err := mp.checkMessage(m)
 	if err != nil {
 		return err
@@ -968,7 +1114,7 @@ func (mp *MessagePool) addLoaded(ctx context.Context, m *types.SignedMessage) er
 		return xerrors.Errorf("minimum expected nonce is %d: %w", snonce, ErrNonceTooLow)
 	}
 
-	_, err = mp.verifyMsgBeforeAdd(m, curTS, true)
+	_, err = mp.verifyMsgBeforeAdd(m, curTS, local)
 	if err != nil {
 		return err
 	}
@@ -1022,12 +1168,30 @@ func (mp *MessagePool) addLocked(ctx context.Context, m *types.SignedMessage, st
 		}
 	}
 
+	exms, replace := mset.msgs[m.Message.Nonce]
+
 	incr, err := mset.add(m, mp, strict, untrusted)
 	if err != nil {
 		log.Debug(err)
 		return err
 	}
 
+	local, err := mp.isLocal(ctx, m.Message.From)
+	if err != nil {
+		log.Debugf("mpooladd failed to check local: %s", err)
+	}
+
+	if replace && exms.Cid() != m.Cid() {
+		mc := m.Cid()
+		mp.publishUpdate(MpoolUpdate{
+			Type:       MpoolRemove,
+			Message:    exms,
+			Local:      local,
+			Reason:     RemoveReplaced,
+			ReplacedBy: &mc,
+		})
+	}
+
 	if incr {
 		mp.currentSize++
 		if mp.currentSize > mp.cfg.SizeLimitHigh {
@@ -1039,10 +1203,11 @@ func (mp *MessagePool) addLocked(ctx context.Context, m *types.SignedMessage, st
 		}
 	}
 
-	mp.changes.Pub(MpoolUpdate{
+	mp.publishUpdate(MpoolUpdate{
 		Type:    MpoolAdd,
 		Message: m,
-	}, localUpdates)
+		Local:   local,
+	})
 
 	mp.journal.RecordEvent(mp.evtTypes[evtTypeMpoolAdd], func() interface{} {
 		mc := m.Cid()
@@ -1156,14 +1321,54 @@ func (mp *MessagePool) PushUntrusted(ctx context.Context, m *types.SignedMessage
 	return m.Cid(), nil
 }
 
+// removal describes why messages are removed from the pool.
+type removal struct {
+	reason MpoolRemoveReason
+	// ts and included are the tipset and the message using the nonce for RemoveIncluded
+	ts       *types.TipSet
+	included cid.Cid
+}
+
+func (r removal) update(m *types.SignedMessage, local bool) MpoolUpdate {
+	u := MpoolUpdate{
+		Type:    MpoolRemove,
+		Message: m,
+		Local:   local,
+		Reason:  r.reason,
+	}
+	if r.ts != nil {
+		tsk := r.ts.Key()
+		u.TipSet = &tsk
+		u.Height = r.ts.Height()
+	}
+	if r.reason == RemoveIncluded && r.included.Defined() && r.included != m.Cid() {
+		included := r.included
+		u.Reason = RemoveReplaced
+		u.ReplacedBy = &included
+	}
+	return u
+}
+
 func (mp *MessagePool) Remove(ctx context.Context, from address.Address, nonce uint64, applied bool) {
 	mp.lk.Lock()
 	defer mp.lk.Unlock()
 
-	mp.remove(ctx, from, nonce, applied)
+	r := removal{reason: RemovePruned}
+	if applied {
+		r.reason = RemoveIncluded
+	}
+	mp.remove(ctx, from, nonce, applied, r)
+}
+
+// removeIncluded removes the message with the nonce of the message included in the tipset.
+func (mp *MessagePool) removeIncluded(ctx context.Context, from address.Address, nonce uint64, included cid.Cid, ts *types.TipSet) {
+	mp.lk.Lock()
+	defer mp.lk.Unlock()
+
+	mp.remove(ctx, from, nonce, true, removal{reason: RemoveIncluded, ts: ts, included: included})
 }
 
-func (mp *MessagePool) remove(ctx context.Context, from address.Address, nonce uint64, applied bool) {
+func (mp *MessagePool) remove(ctx context.Context, from address.Address, nonce uint64, applied bool, r removal) {
 	mset, ok, err := mp.getPendingMset(ctx, from)
 	if err != nil {
 		log.Debugf("mpoolremove failed to get mset: %s", err)
@@ -1175,10 +1380,11 @@ func (mp *MessagePool) remove(ctx context.Context, from address.Address, nonce u
 	}
 
 	if m, ok := mset.msgs[nonce]; ok {
-		mp.changes.Pub(MpoolUpdate{
-			Type:    MpoolRemove,
-			Message: m,
-		}, localUpdates)
+		local, err := mp.isLocal(ctx, from)
+		if err != nil {
+			log.Debugf("mpoolremove failed to check local: %s", err)
+		}
+		mp.publishUpdate(r.update(m, local))
 
 		mp.journal.RecordEvent(mp.evtTypes[evtTypeMpoolRemove], func() interface{} {
 			return MessagePoolEvt{
@@ -1247,6 +1453,11 @@ func (mp *MessagePool) HeadChange(ctx context.Context, revert []*types.TipSet, a
 	mp.curTSLk.Lock()
 	defer mp.curTSLk.Unlock()
 
+	mp.lk.Lock()
+	// the recipients may have a key address at the new head
+	mp.noKeyRecipients = nil
+	mp.lk.Unlock()
+
 	repubTrigger := false
 	rmsgs := make(map[address.Address]map[uint64]*types.SignedMessage)
 	add := func(m *types.SignedMessage) {
@@ -1257,10 +1468,10 @@ func (mp *MessagePool) HeadChange(ctx context.Context, revert []*types.TipSet, a
 		}
 		s[m.Message.Nonce] = m
 	}
-	rm := func(from address.Address, nonce uint64) {
+	rm := func(from address.Address, nonce uint64, included cid.Cid, ts *types.TipSet) {
 		s, ok := rmsgs[from]
 		if !ok {
-			mp.Remove(ctx, from, nonce, true)
+			mp.removeIncluded(ctx, from, nonce, included, ts)
 			return
 		}
 
@@ -1269,7 +1480,7 @@ func (mp *MessagePool) HeadChange(ctx context.Context, revert []*types.TipSet, a
 			return
 		}
 
-		mp.Remove(ctx, from, nonce, true)
+		mp.removeIncluded(ctx, from, nonce, included, ts)
 	}
 
 	maybeRepub := func(cid cid.Cid) {
@@ -1321,12 +1532,12 @@ func (mp *MessagePool) HeadChange(ctx context.Context, revert []*types.TipSet, a
 			}
 
 			for _, msg := range smsgs {
-				rm(msg.Message.From, msg.Message.Nonce)
+				rm(msg.Message.From, msg.Message.Nonce, msg.Cid(), ts)
 				maybeRepub(msg.Cid())
 			}
 
 			for _, msg := range bmsgs {
-				rm(msg.From, msg.Nonce)
+				rm(msg.From, msg.Nonce, msg.Cid(), ts)
 				maybeRepub(msg.Cid())
 			}
 		}
@@ -1529,32 +1740,7 @@ func (mp *MessagePool) RecoverSig(msg *types.UnsignedMessage) *types.SignedMessa
 }
 
 func (mp *MessagePool) Updates(ctx context.Context) (<-chan MpoolUpdate, error) {
-	out := make(chan MpoolUpdate, 20)
-	sub := mp.changes.Sub(localUpdates)
-
-	go func() {
-		defer mp.changes.Unsub(sub, localUpdates)
-		defer close(out)
-
-		for {
-			select {
-			case u := <-sub:
-				select {
-				case out <- u.(MpoolUpdate):
-				case <-ctx.Done():
-					return
-				case <-mp.closer:
-					return
-				}
-			case <-ctx.Done():
-				return
-			case <-mp.closer:
-				return
-			}
-		}
-	}()
-
-	return out, nil
+	return mp.UpdatesFiltered(ctx, nil)
 }
 
 func (mp *MessagePool) loadLocal(ctx context.Context) error {
@@ -1578,7 +1764,7 @@ func (mp *MessagePool) loadLocal(ctx context.Context) error {
 			return xerrors.Errorf("unmarshaling local message: %v", err)
 		}
 
-		if err := mp.addLoaded(ctx, &sm); err != nil {
+		if err := mp.addLoaded(ctx, &sm, true); err != nil {
 			if xerrors.Is(err, ErrNonceTooLow) {
 				continue // todo: drop the message from local cache (if above certain confidence threshold)
 			}
@@ -1619,6 +1805,9 @@ func (mp *MessagePool) Clear(ctx context.Context, local bool) {
 			}
 		})
 
+		mp.forEachPending(func(a address.Address, ms *msgSet) {
+			mp.publishCleared(ctx, a, ms)
+		})
 		mp.clearPending()
 		mp.republished = nil
 
@@ -1640,9 +1829,20 @@ func (mp *MessagePool) Clear(ctx context.Context, local bool) {
 			log.Warnf("errored while deleting mset: %w", err)
 			return
 		}
+		mp.publishCleared(ctx, a, ms)
 	})
 }
 
+func (mp *MessagePool) publishCleared(ctx context.Context, a address.Address, ms *msgSet) {
+	local, err := mp.isLocal(ctx, a)
+	if err != nil {
+		log.Debugf("failed to check local: %s", err)
+	}
+	for _, m := range ms.msgs {
+		mp.publishUpdate(removal{reason: RemoveCleared}.update(m, local))
+	}
+}
+
 func getBaseFeeLowerBound(baseFee, factor big.Int) big.Int {
 	baseFeeLowerBound := big.Div(baseFee, factor)
 	if big.Cmp(baseFeeLowerBound, minimumBaseFee) < 0 {
@@ -185,7 +185,7 @@ func (tma *testMpoolAPI) setBlockMessages(h *types.BlockHeader, msgs ...*types.S
 }
 
 func (tma *testMpoolAPI) ChainHead() (*types.TipSet, error) {
-	return &types.TipSet{}, nil
+	return tma.tipsets[len(tma.tipsets)-1], nil
 }
 
 func (tma *testMpoolAPI) ChainTipSet(key types.TipSetKey) (*types.TipSet, error) {
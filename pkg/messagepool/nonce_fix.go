@@ -0,0 +1,202 @@
+package messagepool
+
+import (
+	"bytes"
+	"context"
+	"sort"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore/query"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus/pkg/types"
+)
+
+// NonceFill is a message filling a nonce gap of a local sender.
+type NonceFill struct {
+	Nonce uint64
+	// Dropped is the dropped local message with the nonce re-signed by the fill, undefined for a
+	// self-send
+	Dropped cid.Cid
+	Message *types.UnsignedMessage
+	// Cid is the cid of the pushed message, undefined for a dry run or if the message was not pushed
+	Cid   cid.Cid
+	Error string `json:",omitempty"`
+}
+
+// NonceFix reports the nonce gaps of a local sender between its state nonce and the pending
+// messages, and the messages filling them.
+type NonceFix struct {
+	From       address.Address
+	StateNonce uint64
+	// MaxPending is the highest pending nonce of the sender
+	MaxPending uint64
+	DryRun     bool
+	Fills      []*NonceFill
+}
+
+// FixNonceGaps fills the nonce gaps of the local sender from with re-signed copies of its dropped
+// local messages, or with self-sends of no value when the message with the nonce is unknown. The
+// fees of the messages are re-estimated and capped by the max fee of the spec. With dryRun, the
+// messages are reported but neither signed nor pushed.
+func (mp *MessagePool) FixNonceGaps(ctx context.Context, from address.Address, spec *types.MessageSendSpec, dryRun bool) (*NonceFix, error) {
+	mp.curTSLk.Lock()
+	ts := mp.curTS
+	mp.lk.Lock()
+	local, err := mp.isLocal(ctx, from)
+	if err != nil {
+		mp.lk.Unlock()
+		mp.curTSLk.Unlock()
+		return nil, xerrors.Errorf("resolving sender: %w", err)
+	}
+	var pending []uint64
+	if mset, ok, err := mp.getPendingMset(ctx, from); err == nil && ok {
+		for nonce := range mset.msgs {
+			pending = append(pending, nonce)
+		}
+	}
+	resigner := mp.resigner
+	mp.lk.Unlock()
+	mp.curTSLk.Unlock()
+
+	if !local {
+		return nil, xerrors.Errorf("%s is not a local sender", from)
+	}
+
+	snonce, err := mp.getStateNonce(ctx, from, ts)
+	if err != nil {
+		return nil, xerrors.Errorf("getting state nonce: %w", err)
+	}
+
+	fix := &NonceFix{From: from, StateNonce: snonce, DryRun: dryRun}
+	if len(pending) == 0 {
+		return fix, nil
+	}
+
+	sort.Slice(pending, func(i, j int) bool { return pending[i] < pending[j] })
+	fix.MaxPending = pending[len(pending)-1]
+
+	isPending := make(map[uint64]struct{}, len(pending))
+	for _, nonce := range pending {
+		isPending[nonce] = struct{}{}
+	}
+	var gaps []uint64
+	for nonce := snonce; nonce < fix.MaxPending; nonce++ {
+		if _, ok := isPending[nonce]; !ok {
+			gaps = append(gaps, nonce)
+		}
+	}
+	if len(gaps) == 0 {
+		return fix, nil
+	}
+	if !dryRun && resigner == nil {
+		return nil, xerrors.Errorf("cannot fill %d nonce gaps without a signer", len(gaps))
+	}
+
+	dropped, err := mp.droppedLocalMessages(ctx, from, gaps)
+	if err != nil {
+		return nil, err
+	}
+
+	// the gaps are filled in order, a failure leaves the later gaps unfilled
+	failed := false
+	for _, nonce := range gaps {
+		fill := &NonceFill{Nonce: nonce}
+		fix.Fills = append(fix.Fills, fill)
+
+		msg := &types.UnsignedMessage{
+			From:  from,
+			To:    from,
+			Value: big.Zero(),
+		}
+		if m, ok := dropped[nonce]; ok {
+			fill.Dropped = m.Cid()
+			cpy := m.Message
+			msg = &cpy
+		}
+		msg.Nonce = nonce
+		msg.GasFeeCap = big.Zero()
+		msg.GasPremium = big.Zero()
+		fill.Message = msg
+
+		if failed {
+			fill.Error = "not filled after a previous failure"
+			continue
+		}
+
+		if _, err := mp.GasEstimateMessageGas(ctx, &types.EstimateMessage{Msg: msg, Spec: spec}, types.EmptyTSK); err != nil {
+			fill.Error = xerrors.Errorf("estimating gas values: %w", err).Error()
+			failed = true
+			continue
+		}
+		if dryRun {
+			continue
+		}
+
+		smsg, err := resigner.ResignMessage(ctx, msg)
+		if err != nil {
+			fill.Error = xerrors.Errorf("signing message: %w", err).Error()
+			failed = true
+			continue
+		}
+		if _, err := mp.Push(ctx, smsg); err != nil {
+			fill.Error = xerrors.Errorf("pushing message: %w", err).Error()
+			failed = true
+			continue
+		}
+		fill.Cid = smsg.Cid()
+		log.Infow("filled nonce gap", "from", from, "nonce", nonce, "cid", fill.Cid, "dropped", fill.Dropped)
+	}
+
+	return fix, nil
+}
+
+// droppedLocalMessages returns the local messages from the sender with one of the nonces, the one
+// with the highest premium for a nonce replaced by fee.
+func (mp *MessagePool) droppedLocalMessages(ctx context.Context, from address.Address, nonces []uint64) (map[uint64]*types.SignedMessage, error) {
+	mp.curTSLk.Lock()
+	defer mp.curTSLk.Unlock()
+
+	mp.lk.Lock()
+	defer mp.lk.Unlock()
+
+	ka, err := mp.resolveToKey(ctx, from)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := make(map[uint64]struct{}, len(nonces))
+	for _, nonce := range nonces {
+		wanted[nonce] = struct{}{}
+	}
+
+	res, err := mp.localMsgs.Query(query.Query{})
+	if err != nil {
+		return nil, xerrors.Errorf("query local messages: %v", err)
+	}
+
+	dropped := make(map[uint64]*types.SignedMessage)
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, xerrors.Errorf("r.Error: %v", r.Error)
+		}
+
+		var sm types.SignedMessage
+		if err := sm.UnmarshalCBOR(bytes.NewReader(r.Value)); err != nil {
+			return nil, xerrors.Errorf("unmarshaling local message: %v", err)
+		}
+		if _, ok := wanted[sm.Message.Nonce]; !ok {
+			continue
+		}
+		if sm.Message.From != from && sm.Message.From != ka {
+			continue
+		}
+		if prev, ok := dropped[sm.Message.Nonce]; ok && big.Cmp(prev.Message.GasPremium, sm.Message.GasPremium) >= 0 {
+			continue
+		}
+		dropped[sm.Message.Nonce] = &sm
+	}
+	return dropped, nil
+}
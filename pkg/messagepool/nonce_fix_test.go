@@ -0,0 +1,83 @@
+package messagepool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tf "github.com/filecoin-project/venus/pkg/testhelpers/testflags"
+	"github.com/filecoin-project/venus/pkg/types"
+)
+
+func TestFixNonceGaps(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	tma := newTestMpoolAPI()
+	w, mp := newWalletAndMpool(t, tma)
+	mp.SetResigner(&testResigner{w: w})
+
+	a1, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	a2, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	target := mkAddress(1001)
+	tma.setBalance(a1, 1) // in FIL
+	tma.setBalance(a2, 1) // in FIL
+
+	var msgs []*types.SignedMessage
+	for i := uint64(0); i < 4; i++ {
+		m := mkMessage(a1, target, i, w)
+		_, err := mp.Push(ctx, m)
+		require.NoError(t, err)
+		msgs = append(msgs, m)
+	}
+	mustAdd(t, mp, mkMessage(a2, target, 0, w))
+
+	fix, err := mp.FixNonceGaps(ctx, a1, nil, false)
+	require.NoError(t, err)
+	assert.Empty(t, fix.Fills)
+
+	// nonces 1 and 2 are dropped. The gas limit of a self-send cannot be estimated without a vm, so
+	// the dropped messages are known.
+	mp.Remove(ctx, a1, 1, false)
+	mp.Remove(ctx, a1, 2, false)
+
+	fix, err = mp.FixNonceGaps(ctx, a1, nil, true)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), fix.StateNonce)
+	assert.Equal(t, uint64(3), fix.MaxPending)
+	require.Len(t, fix.Fills, 2)
+	assert.Equal(t, uint64(1), fix.Fills[0].Nonce)
+	assert.Equal(t, msgs[1].Cid(), fix.Fills[0].Dropped)
+	assert.Equal(t, uint64(2), fix.Fills[1].Nonce)
+	assert.Equal(t, msgs[2].Cid(), fix.Fills[1].Dropped)
+	for _, fill := range fix.Fills {
+		assert.Empty(t, fill.Error)
+		assert.False(t, fill.Cid.Defined())
+		assert.True(t, fill.Message.GasFeeCap.GreaterThan(types.ZeroFIL))
+	}
+	pending, _ := mp.PendingFor(ctx, a1)
+	assert.Len(t, pending, 2)
+
+	fix, err = mp.FixNonceGaps(ctx, a1, nil, false)
+	require.NoError(t, err)
+	require.Len(t, fix.Fills, 2)
+	pending, _ = mp.PendingFor(ctx, a1)
+	require.Len(t, pending, 4)
+	for i, m := range pending {
+		assert.Equal(t, uint64(i), m.Message.Nonce)
+	}
+	for i, fill := range fix.Fills {
+		assert.Equal(t, fill.Cid, pending[i+1].Cid())
+		assert.Equal(t, msgs[i+1].Message.To, pending[i+1].Message.To)
+		assert.Equal(t, msgs[i+1].Message.Value, pending[i+1].Message.Value)
+	}
+
+	// only local senders are fixed
+	_, err = mp.FixNonceGaps(ctx, a2, nil, true)
+	assert.Error(t, err)
+}
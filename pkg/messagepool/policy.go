@@ -0,0 +1,540 @@
+package messagepool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus/pkg/constants"
+	"github.com/filecoin-project/venus/pkg/repo"
+	"github.com/filecoin-project/venus/pkg/specactors/builtin"
+	"github.com/filecoin-project/venus/pkg/types"
+)
+
+var (
+	PolicyKey = datastore.NewKey("/mpool/policies")
+	// PolicySpendsKey is the prefix of the values accepted over PolicyValueWindow, stored per sender
+	// and nonce so that MaxValuePerDay holds across restarts.
+	PolicySpendsKey = datastore.NewKey("/mpool/policySpends")
+
+	// PolicyValueWindow is the period over which MaxValuePerDay is enforced.
+	PolicyValueWindow = 24 * time.Hour
+
+	ErrPolicyViolation = errors.New("message violates mpool policy")
+)
+
+// PolicyScope is the field of a message the address of a policy is matched against.
+type PolicyScope string
+
+const (
+	PolicySender    PolicyScope = "sender"
+	PolicyRecipient PolicyScope = "recipient"
+)
+
+// PolicyRule names the rule of a policy a message violated.
+type PolicyRule string
+
+const (
+	RuleMaxPending     PolicyRule = "max-pending"
+	RuleMaxValuePerDay PolicyRule = "max-value-per-day"
+	RuleMaxFeeCap      PolicyRule = "max-fee-cap"
+	RuleDenied         PolicyRule = "denied"
+	RuleNotAllowed     PolicyRule = "not-allowed"
+	RuleActor          PolicyRule = "actor-not-allowed"
+	RuleMethod         PolicyRule = "method-not-allowed"
+)
+
+// MpoolPolicy restricts the messages pushed locally or through PushUntrusted from or to an
+// address, the zero value of a limit or an empty list is not enforced.
+type MpoolPolicy struct {
+	Scope   PolicyScope
+	Address address.Address
+
+	// MaxPendingMessages is the max number of messages from or to the address in the pool.
+	MaxPendingMessages int
+	// MaxValuePerDay is the max value sent from or to the address over PolicyValueWindow.
+	MaxValuePerDay abi.TokenAmount
+	// MaxFeeCap is the max GasFeeCap of a message.
+	MaxFeeCap abi.TokenAmount
+
+	// Allow and Deny are the counterparties, recipients for a sender policy and senders for a recipient policy.
+	Allow []address.Address
+	Deny  []address.Address
+	// AllowedActors are the names of the actors messages may be sent to, eg. account, multisig or storageminer.
+	AllowedActors []string
+	// AllowedMethods are the methods messages may call.
+	AllowedMethods []abi.MethodNum
+}
+
+func (p *MpoolPolicy) validate() error {
+	switch p.Scope {
+	case PolicySender, PolicyRecipient:
+	default:
+		return xerrors.Errorf("unknown policy scope %q", p.Scope)
+	}
+	if p.Address == address.Undef {
+		return xerrors.Errorf("%s policy has no address", p.Scope)
+	}
+	if p.MaxPendingMessages < 0 {
+		return xerrors.Errorf("'MaxPendingMessages' of %s policy %s is negative", p.Scope, p.Address)
+	}
+	if p.MaxValuePerDay.Int != nil && p.MaxValuePerDay.Sign() < 0 {
+		return xerrors.Errorf("'MaxValuePerDay' of %s policy %s is negative", p.Scope, p.Address)
+	}
+	if p.MaxFeeCap.Int != nil && p.MaxFeeCap.Sign() < 0 {
+		return xerrors.Errorf("'MaxFeeCap' of %s policy %s is negative", p.Scope, p.Address)
+	}
+	return nil
+}
+
+// PolicyError is the structured error of a message rejected by a policy, it wraps ErrPolicyViolation.
+type PolicyError struct {
+	Scope   PolicyScope
+	Address address.Address
+	Rule    PolicyRule
+	Reason  string
+}
+
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("%s policy of %s: %s: %s: %v", e.Scope, e.Address, e.Rule, e.Reason, ErrPolicyViolation)
+}
+
+func (e *PolicyError) Unwrap() error {
+	return ErrPolicyViolation
+}
+
+// policySpend is the value of a message accepted while policies were set, kept for PolicyValueWindow.
+type policySpend struct {
+	At    time.Time
+	From  address.Address
+	To    address.Address
+	Nonce uint64
+	Value abi.TokenAmount
+}
+
+func loadPolicies(ds repo.Datastore) ([]*MpoolPolicy, error) {
+	havePolicies, err := ds.Has(PolicyKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if !havePolicies {
+		return nil, nil
+	}
+
+	policiesBytes, err := ds.Get(PolicyKey)
+	if err != nil {
+		return nil, err
+	}
+	var policies []*MpoolPolicy
+	err = json.Unmarshal(policiesBytes, &policies)
+	return policies, err
+}
+
+func savePolicies(policies []*MpoolPolicy, ds repo.Datastore) error {
+	policiesBytes, err := json.Marshal(policies)
+	if err != nil {
+		return err
+	}
+	return ds.Put(PolicyKey, policiesBytes)
+}
+
+func policySpendKey(from address.Address, nonce uint64) datastore.Key {
+	return PolicySpendsKey.ChildString(from.String()).ChildString(strconv.FormatUint(nonce, 10))
+}
+
+func loadPolicySpends(ds repo.Datastore) ([]policySpend, error) {
+	res, err := ds.Query(query.Query{Prefix: PolicySpendsKey.String()})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close() //nolint:errcheck
+
+	var spends []policySpend
+	for r := range res.Next() {
+		if r.Error != nil {
+			return nil, r.Error
+		}
+		var spend policySpend
+		if err := json.Unmarshal(r.Value, &spend); err != nil {
+			return nil, xerrors.Errorf("unmarshaling policy spend %s: %w", r.Key, err)
+		}
+		spends = append(spends, spend)
+	}
+	// prunePolicySpends expects the oldest spends first
+	sort.Slice(spends, func(i, j int) bool {
+		return spends[i].At.Before(spends[j].At)
+	})
+	return spends, nil
+}
+
+func savePolicySpend(spend policySpend, ds repo.Datastore) error {
+	spendBytes, err := json.Marshal(spend)
+	if err != nil {
+		return err
+	}
+	return ds.Put(policySpendKey(spend.From, spend.Nonce), spendBytes)
+}
+
+func clonePolicies(policies []*MpoolPolicy) []*MpoolPolicy {
+	out := make([]*MpoolPolicy, 0, len(policies))
+	for _, p := range policies {
+		c := *p
+		if c.MaxValuePerDay.Int == nil {
+			c.MaxValuePerDay = big.Zero()
+		}
+		if c.MaxFeeCap.Int == nil {
+			c.MaxFeeCap = big.Zero()
+		}
+		c.Allow = append([]address.Address(nil), p.Allow...)
+		c.Deny = append([]address.Address(nil), p.Deny...)
+		c.AllowedActors = append([]string(nil), p.AllowedActors...)
+		c.AllowedMethods = append([]abi.MethodNum(nil), p.AllowedMethods...)
+		out = append(out, &c)
+	}
+	return out
+}
+
+// GetPolicies returns (a copy of) the policies of the pool.
+func (mp *MessagePool) GetPolicies() []*MpoolPolicy {
+	mp.cfgLk.Lock()
+	defer mp.cfgLk.Unlock()
+	return clonePolicies(mp.policies)
+}
+
+// SetPolicies replaces the policies of the pool, there is at most one policy per scope and address.
+func (mp *MessagePool) SetPolicies(policies []*MpoolPolicy) error {
+	seen := make(map[PolicyScope]map[address.Address]struct{})
+	for _, p := range policies {
+		if err := p.validate(); err != nil {
+			return err
+		}
+		if seen[p.Scope] == nil {
+			seen[p.Scope] = make(map[address.Address]struct{})
+		}
+		if _, ok := seen[p.Scope][p.Address]; ok {
+			return xerrors.Errorf("duplicate %s policy for %s", p.Scope, p.Address)
+		}
+		seen[p.Scope][p.Address] = struct{}{}
+	}
+	policies = clonePolicies(policies)
+
+	mp.cfgLk.Lock()
+	defer mp.cfgLk.Unlock()
+	if err := savePolicies(policies, mp.ds); err != nil {
+		return xerrors.Errorf("persisting mpool policies: %w", err)
+	}
+	mp.policies = policies
+	return nil
+}
+
+// SetPolicy adds the policy, replacing the policy with the same scope and address.
+func (mp *MessagePool) SetPolicy(policy *MpoolPolicy) error {
+	policies := mp.GetPolicies()
+	for i, p := range policies {
+		if p.Scope == policy.Scope && p.Address == policy.Address {
+			policies[i] = policy
+			return mp.SetPolicies(policies)
+		}
+	}
+	return mp.SetPolicies(append(policies, policy))
+}
+
+// RemovePolicy removes the policy of the scope and address, it returns false if there is none.
+func (mp *MessagePool) RemovePolicy(scope PolicyScope, addr address.Address) (bool, error) {
+	policies := mp.GetPolicies()
+	for i, p := range policies {
+		if p.Scope == scope && p.Address == addr {
+			return true, mp.SetPolicies(append(policies[:i], policies[i+1:]...))
+		}
+	}
+	return false, nil
+}
+
+// matchPolicy reports whether the policy applies to the message, addresses are compared by their
+// key address. It must be called with mp.lk held.
+func (mp *MessagePool) matchPolicy(ctx context.Context, p *MpoolPolicy, from, to address.Address) bool {
+	if p.Scope == PolicyRecipient {
+		return mp.sameAddress(ctx, p.Address, to)
+	}
+	return mp.sameAddress(ctx, p.Address, from)
+}
+
+// sameAddress reports whether a and b are the same actor, an ID address and the key address of
+// the same account match. It must be called with mp.lk held.
+func (mp *MessagePool) sameAddress(ctx context.Context, a, b address.Address) bool {
+	if a == b {
+		return true
+	}
+	ka, err := mp.resolveToKey(ctx, a)
+	if err != nil {
+		return false
+	}
+	kb, err := mp.resolveToKey(ctx, b)
+	if err != nil {
+		return false
+	}
+	return ka == kb
+}
+
+// containsAddress reports whether addr is one of addrs, compared with sameAddress.
+// It must be called with mp.lk held.
+func (mp *MessagePool) containsAddress(ctx context.Context, addrs []address.Address, addr address.Address) bool {
+	for _, a := range addrs {
+		if mp.sameAddress(ctx, a, addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPolicies checks the message against the policies matching its sender and recipient.
+// It must be called with mp.lk held.
+func (mp *MessagePool) checkPolicies(ctx context.Context, m *types.SignedMessage, curTS *types.TipSet) error {
+	policies := mp.GetPolicies()
+	if len(policies) == 0 {
+		return nil
+	}
+
+	msg := &m.Message
+	for _, p := range policies {
+		if !mp.matchPolicy(ctx, p, msg.From, msg.To) {
+			continue
+		}
+		reject := func(rule PolicyRule, format string, args ...interface{}) error {
+			return &PolicyError{Scope: p.Scope, Address: p.Address, Rule: rule, Reason: fmt.Sprintf(format, args...)}
+		}
+
+		counterparty := msg.To
+		if p.Scope == PolicyRecipient {
+			counterparty = msg.From
+		}
+		if mp.containsAddress(ctx, p.Deny, counterparty) {
+			return reject(RuleDenied, "%s is denied", counterparty)
+		}
+		if len(p.Allow) > 0 && !mp.containsAddress(ctx, p.Allow, counterparty) {
+			return reject(RuleNotAllowed, "%s is not allowed", counterparty)
+		}
+
+		if len(p.AllowedMethods) > 0 && !containsMethod(p.AllowedMethods, msg.Method) {
+			return reject(RuleMethod, "method %d is not allowed", msg.Method)
+		}
+
+		if len(p.AllowedActors) > 0 {
+			name, err := mp.actorName(msg.To, curTS)
+			if err != nil {
+				return reject(RuleActor, "looking up actor %s: %s", msg.To, err)
+			}
+			if !containsString(p.AllowedActors, name) {
+				return reject(RuleActor, "%s actor %s is not allowed", name, msg.To)
+			}
+		}
+
+		if !isUnset(p.MaxFeeCap) && msg.GasFeeCap.GreaterThan(p.MaxFeeCap) {
+			return reject(RuleMaxFeeCap, "GasFeeCap %s exceeds %s", msg.GasFeeCap, p.MaxFeeCap)
+		}
+
+		if p.MaxPendingMessages > 0 {
+			pending := mp.countPending(ctx, p, msg)
+			if pending >= p.MaxPendingMessages {
+				return reject(RuleMaxPending, "%d messages are pending", pending)
+			}
+		}
+
+		if !isUnset(p.MaxValuePerDay) {
+			sent := big.Add(mp.spentWithin(ctx, p, msg), msg.Value)
+			if sent.GreaterThan(p.MaxValuePerDay) {
+				return reject(RuleMaxValuePerDay, "sending %s would total %s over %s, more than %s",
+					types.FIL(msg.Value), types.FIL(sent), PolicyValueWindow, types.FIL(p.MaxValuePerDay))
+			}
+		}
+	}
+	return nil
+}
+
+// countPending returns the number of pending messages matching the policy, the message replaced by msg excluded.
+// It must be called with mp.lk held.
+func (mp *MessagePool) countPending(ctx context.Context, p *MpoolPolicy, msg *types.UnsignedMessage) int {
+	if p.Scope == PolicySender {
+		mset, ok, err := mp.getPendingMset(ctx, msg.From)
+		if err != nil || !ok {
+			return 0
+		}
+		count := len(mset.msgs)
+		if _, replaced := mset.msgs[msg.Nonce]; replaced {
+			count--
+		}
+		return count
+	}
+
+	// the messages are counted by recipient in their sets, the recipients are resolved once
+	target := mp.recipientKey(ctx, p.Address)
+	from, _ := mp.resolveToKey(ctx, msg.From)
+	count := 0
+	mp.forEachPending(func(a address.Address, mset *msgSet) {
+		for to, n := range mset.recipients {
+			if mp.recipientKey(ctx, to) == target {
+				count += n
+			}
+		}
+		if a != from {
+			return
+		}
+		if replaced, ok := mset.msgs[msg.Nonce]; ok && mp.recipientKey(ctx, replaced.Message.To) == target {
+			count--
+		}
+	})
+	return count
+}
+
+// recipientKey returns the key address of the recipient to, to itself if it has none such as a miner
+// actor: two recipients match when their recipientKey are the same, like with sameAddress. Unlike
+// resolveToKey the recipients without key address are cached too, until the next head change. It
+// must be called with mp.lk held.
+func (mp *MessagePool) recipientKey(ctx context.Context, to address.Address) address.Address {
+	if _, ok := mp.noKeyRecipients[to]; ok {
+		return to
+	}
+	key, err := mp.resolveToKey(ctx, to)
+	if err != nil {
+		if mp.noKeyRecipients == nil {
+			mp.noKeyRecipients = make(map[address.Address]struct{})
+		}
+		mp.noKeyRecipients[to] = struct{}{}
+		return to
+	}
+	return key
+}
+
+// spentWithin returns the value of the messages matching the policy accepted over PolicyValueWindow,
+// the message replaced by msg excluded. It must be called with mp.lk held.
+func (mp *MessagePool) spentWithin(ctx context.Context, p *MpoolPolicy, msg *types.UnsignedMessage) abi.TokenAmount {
+	mp.prunePolicySpends()
+
+	from, _ := mp.resolveToKey(ctx, msg.From)
+	total := big.Zero()
+	for _, s := range mp.policySpends {
+		if s.From == from && s.Nonce == msg.Nonce {
+			continue
+		}
+		if mp.matchPolicy(ctx, p, s.From, s.To) {
+			total = big.Add(total, s.Value)
+		}
+	}
+	return total
+}
+
+// recordPolicySpend records the value of an accepted message while policies are set, a replaced
+// message is overwritten. The spend is persisted unless a batch is being added, see
+// persistPolicySpends. It must be called with mp.lk held.
+func (mp *MessagePool) recordPolicySpend(ctx context.Context, m *types.SignedMessage) {
+	mp.cfgLk.Lock()
+	enabled := len(mp.policies) > 0
+	mp.cfgLk.Unlock()
+	if !enabled {
+		return
+	}
+
+	mp.prunePolicySpends()
+
+	from, err := mp.resolveToKey(ctx, m.Message.From)
+	if err != nil {
+		from = m.Message.From
+	}
+	spend := policySpend{
+		At:    constants.Clock.Now(),
+		From:  from,
+		To:    m.Message.To,
+		Nonce: m.Message.Nonce,
+		Value: m.Message.Value,
+	}
+	replaced := false
+	for i, s := range mp.policySpends {
+		if s.From == from && s.Nonce == spend.Nonce {
+			spend.At = s.At
+			mp.policySpends[i] = spend
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		mp.policySpends = append(mp.policySpends, spend)
+	}
+
+	if mp.heldSpends != nil {
+		*mp.heldSpends = append(*mp.heldSpends, spend)
+		return
+	}
+	mp.persistPolicySpends(spend)
+}
+
+// persistPolicySpends stores the spends. The messages are already accepted, a spend failing to
+// persist is only lost on restart.
+func (mp *MessagePool) persistPolicySpends(spends ...policySpend) {
+	for _, spend := range spends {
+		if err := savePolicySpend(spend, mp.ds); err != nil {
+			log.Errorf("persisting mpool policy spend: %v", err)
+		}
+	}
+}
+
+// prunePolicySpends drops the spends older than PolicyValueWindow. It must be called with mp.lk held.
+func (mp *MessagePool) prunePolicySpends() {
+	cutoff := constants.Clock.Now().Add(-PolicyValueWindow)
+	i := 0
+	for i < len(mp.policySpends) && !mp.policySpends[i].At.After(cutoff) {
+		if err := mp.ds.Delete(policySpendKey(mp.policySpends[i].From, mp.policySpends[i].Nonce)); err != nil {
+			log.Warnf("deleting mpool policy spend: %v", err)
+		}
+		i++
+	}
+	mp.policySpends = mp.policySpends[i:]
+}
+
+// actorName returns the name of the builtin actor at addr, eg. multisig. An address without actor
+// is an account if it is a key address.
+func (mp *MessagePool) actorName(addr address.Address, curTS *types.TipSet) (string, error) {
+	act, err := mp.api.GetActorAfter(addr, curTS)
+	if err != nil {
+		if addr.Protocol() == address.SECP256K1 || addr.Protocol() == address.BLS {
+			return "account", nil
+		}
+		return "", err
+	}
+	return path.Base(builtin.ActorNameByCode(act.Code)), nil
+}
+
+// isUnset reports whether a policy limit is not enforced.
+func isUnset(limit abi.TokenAmount) bool {
+	return limit.Int == nil || limit.IsZero()
+}
+
+func containsMethod(methods []abi.MethodNum, method abi.MethodNum) bool {
+	for _, m := range methods {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(strs []string, str string) bool {
+	for _, s := range strs {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
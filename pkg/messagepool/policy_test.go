@@ -0,0 +1,220 @@
+package messagepool
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/venus/pkg/config"
+	tf "github.com/filecoin-project/venus/pkg/testhelpers/testflags"
+	"github.com/filecoin-project/venus/pkg/wallet"
+)
+
+func requirePolicyError(t *testing.T, err error, scope PolicyScope, rule PolicyRule) {
+	t.Helper()
+
+	var perr *PolicyError
+	require.True(t, errors.As(err, &perr), "expected a policy error, got %v", err)
+	assert.True(t, errors.Is(err, ErrPolicyViolation))
+	assert.Equal(t, scope, perr.Scope)
+	assert.Equal(t, rule, perr.Rule)
+}
+
+func TestPolicies(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	tma := newTestMpoolAPI()
+	ds := datastore.NewMapDatastore()
+	mp, err := New(tma, ds, config.DefaultForkUpgradeParam, config.DefaultMessagePoolParam, "mptest", nil, nil, nil)
+	require.NoError(t, err)
+
+	w := newWallet(t)
+	a1, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	a2, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	a3, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	target := mkAddress(1001)
+	denied := mkAddress(1002)
+	tma.nextBlock()
+
+	require.NoError(t, mp.SetPolicy(&MpoolPolicy{
+		Scope:              PolicySender,
+		Address:            a1,
+		MaxPendingMessages: 2,
+		Deny:               []address.Address{denied},
+	}))
+	require.NoError(t, mp.SetPolicy(&MpoolPolicy{
+		Scope:          PolicyRecipient,
+		Address:        target,
+		MaxValuePerDay: abi.NewTokenAmount(3),
+		AllowedMethods: []abi.MethodNum{0},
+	}))
+	assert.Error(t, mp.SetPolicy(&MpoolPolicy{Scope: "nobody", Address: a1}))
+
+	// sender policy
+	_, err = mp.Push(ctx, mkMessage(a1, denied, 0, w))
+	requirePolicyError(t, err, PolicySender, RuleDenied)
+
+	for i := uint64(0); i < 2; i++ {
+		_, err = mp.Push(ctx, mkMessage(a1, target, i, w))
+		require.NoError(t, err)
+	}
+	_, err = mp.Push(ctx, mkMessage(a1, target, 2, w))
+	requirePolicyError(t, err, PolicySender, RuleMaxPending)
+
+	// replacing a pending message does not count against the limit.
+	rbf := mkMessage(a1, target, 1, w)
+	rbf.Message.GasPremium = ComputeMinRBF(rbf.Message.GasPremium)
+	sig, err := w.WalletSign(a1, rbf.Message.Cid().Bytes(), wallet.MsgMeta{})
+	require.NoError(t, err)
+	rbf.Signature = *sig
+	_, err = mp.Push(ctx, rbf)
+	require.NoError(t, err)
+
+	// recipient policy, a1 already sent 2 to target.
+	method := mkMessage(a2, target, 0, w)
+	method.Message.Method = 2
+	sig, err = w.WalletSign(a2, method.Message.Cid().Bytes(), wallet.MsgMeta{})
+	require.NoError(t, err)
+	method.Signature = *sig
+	_, err = mp.Push(ctx, method)
+	requirePolicyError(t, err, PolicyRecipient, RuleMethod)
+
+	_, err = mp.Push(ctx, mkMessage(a2, target, 0, w))
+	require.NoError(t, err)
+	_, err = mp.Push(ctx, mkMessage(a2, target, 1, w))
+	requirePolicyError(t, err, PolicyRecipient, RuleMaxValuePerDay)
+
+	// messages relayed from the network are not policed.
+	require.NoError(t, mp.Add(ctx, mkMessage(a3, target, 0, w)))
+
+	// policies are persisted.
+	mp2, err := New(tma, ds, config.DefaultForkUpgradeParam, config.DefaultMessagePoolParam, "mptest", nil, nil, nil)
+	require.NoError(t, err)
+	expected, err := json.Marshal(mp.GetPolicies())
+	require.NoError(t, err)
+	actual, err := json.Marshal(mp2.GetPolicies())
+	require.NoError(t, err)
+	assert.JSONEq(t, string(expected), string(actual))
+
+	// and so are the values they accepted, 3 were sent to target.
+	mp2.lk.Lock()
+	spent := mp2.spentWithin(ctx, mp2.GetPolicies()[1], &mkMessage(a2, target, 1, w).Message)
+	mp2.lk.Unlock()
+	assert.Equal(t, abi.NewTokenAmount(3), spent)
+
+	removed, err := mp.RemovePolicy(PolicyRecipient, target)
+	require.NoError(t, err)
+	assert.True(t, removed)
+	removed, err = mp.RemovePolicy(PolicyRecipient, target)
+	require.NoError(t, err)
+	assert.False(t, removed)
+	_, err = mp.Push(ctx, mkMessage(a2, target, 1, w))
+	require.NoError(t, err)
+}
+
+func TestPoliciesResolveAddresses(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	tma := newTestMpoolAPI()
+	mp, err := New(tma, datastore.NewMapDatastore(), config.DefaultForkUpgradeParam, config.DefaultMessagePoolParam, "mptest", nil, nil, nil)
+	require.NoError(t, err)
+
+	w := newWallet(t)
+	a1, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	a2, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	denied, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	tma.nextBlock()
+
+	// the test api only resolves key addresses, give the accounts an ID address.
+	deniedID := mkAddress(1001)
+	recipientID := mkAddress(1002)
+	mp.lk.Lock()
+	mp.keyCache[deniedID] = denied
+	mp.keyCache[recipientID] = a2
+	mp.lk.Unlock()
+
+	require.NoError(t, mp.SetPolicy(&MpoolPolicy{
+		Scope:   PolicySender,
+		Address: a1,
+		Deny:    []address.Address{deniedID},
+	}))
+	require.NoError(t, mp.SetPolicy(&MpoolPolicy{
+		Scope:          PolicyRecipient,
+		Address:        a2,
+		AllowedMethods: []abi.MethodNum{2},
+	}))
+
+	// the deny list matches the key address of the denied ID.
+	_, err = mp.Push(ctx, mkMessage(a1, denied, 0, w))
+	requirePolicyError(t, err, PolicySender, RuleDenied)
+
+	// the recipient policy of a key address applies to messages sent to its ID.
+	_, err = mp.Push(ctx, mkMessage(a1, recipientID, 0, w))
+	requirePolicyError(t, err, PolicyRecipient, RuleMethod)
+}
+
+func TestPolicyRecipientPendingCount(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	tma := newTestMpoolAPI()
+	mp, err := New(tma, datastore.NewMapDatastore(), config.DefaultForkUpgradeParam, config.DefaultMessagePoolParam, "mptest", nil, nil, nil)
+	require.NoError(t, err)
+
+	w := newWallet(t)
+	a1, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	a2, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	a3, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	target := mkAddress(1001)
+	other := mkAddress(1002)
+	tma.nextBlock()
+
+	require.NoError(t, mp.SetPolicy(&MpoolPolicy{
+		Scope:              PolicyRecipient,
+		Address:            target,
+		MaxPendingMessages: 2,
+	}))
+
+	_, err = mp.Push(ctx, mkMessage(a1, target, 0, w))
+	require.NoError(t, err)
+	_, err = mp.Push(ctx, mkMessage(a2, target, 0, w))
+	require.NoError(t, err)
+	_, err = mp.Push(ctx, mkMessage(a3, target, 0, w))
+	requirePolicyError(t, err, PolicyRecipient, RuleMaxPending)
+
+	// replacing a message to target by one to another recipient frees a pending slot.
+	rbf := mkMessage(a1, other, 0, w)
+	rbf.Message.GasPremium = ComputeMinRBF(rbf.Message.GasPremium)
+	sig, err := w.WalletSign(a1, rbf.Message.Cid().Bytes(), wallet.MsgMeta{})
+	require.NoError(t, err)
+	rbf.Signature = *sig
+	_, err = mp.Push(ctx, rbf)
+	require.NoError(t, err)
+	_, err = mp.Push(ctx, mkMessage(a3, target, 0, w))
+	require.NoError(t, err)
+
+	mp.lk.Lock()
+	mset, ok, err := mp.getPendingMset(ctx, a1)
+	mp.lk.Unlock()
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, map[address.Address]int{other: 1}, mset.recipients)
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/ipfs/go-cid"
 	"golang.org/x/xerrors"
 
@@ -112,8 +113,67 @@ keepLoop:
 	// and remove all messages that are still in pruneMsgs after processing the chains
 	log.Infof("Pruning %d messages", len(pruneMsgs))
 	for _, m := range pruneMsgs {
-		mp.remove(ctx, m.Message.From, m.Message.Nonce, false)
+		mp.remove(ctx, m.Message.From, m.Message.Nonce, false, removal{reason: RemovePruned})
 	}
 
 	return nil
 }
+
+// expireMessages removes the messages pending for MessageTTL epochs, the messages of the local and
+// priority addresses are kept. It does nothing unless MessageTTL is set.
+func (mp *MessagePool) expireMessages(ctx context.Context) {
+	cfg := mp.GetConfig()
+
+	mp.curTSLk.Lock()
+	defer mp.curTSLk.Unlock()
+	mp.lk.Lock()
+	defer mp.lk.Unlock()
+
+	if cfg.MessageTTL <= 0 {
+		mp.expireSeen = make(map[cid.Cid]abi.ChainEpoch)
+		return
+	}
+	ts := mp.curTS
+
+	protected := make(map[address.Address]struct{})
+	for _, actor := range cfg.PriorityAddrs {
+		pk, err := mp.resolveToKey(ctx, actor)
+		if err != nil {
+			log.Debugf("expireMessages failed to resolve priority address: %s", err)
+			continue
+		}
+		protected[pk] = struct{}{}
+	}
+	mp.forEachLocal(ctx, func(ctx context.Context, actor address.Address) {
+		protected[actor] = struct{}{}
+	})
+
+	var expired []*types.SignedMessage
+	seen := make(map[cid.Cid]abi.ChainEpoch)
+	mp.forEachPending(func(actor address.Address, mset *msgSet) {
+		if _, ok := protected[actor]; ok {
+			return
+		}
+		for _, m := range mset.msgs {
+			c := m.Cid()
+			at, ok := mp.expireSeen[c]
+			if !ok {
+				at = ts.Height()
+			}
+			if ts.Height()-at >= cfg.MessageTTL {
+				expired = append(expired, m)
+				continue
+			}
+			seen[c] = at
+		}
+	})
+	// only the messages still pending are tracked
+	mp.expireSeen = seen
+
+	if len(expired) > 0 {
+		log.Infof("Expiring %d messages", len(expired))
+	}
+	for _, m := range expired {
+		mp.remove(ctx, m.Message.From, m.Message.Nonce, false, removal{reason: RemoveExpired})
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
 	"github.com/ipfs/go-cid"
 	"golang.org/x/xerrors"
 
@@ -20,6 +22,14 @@ const repubMsgLimit = 30
 
 var RepublishBatchDelay = 100 * time.Millisecond
 
+// AutoReplaceInterval is the period at which the local messages are checked for replacement.
+var AutoReplaceInterval = time.Duration(constants.MainNetBlockDelaySecs) * time.Second
+
+// MessageResigner signs a message again keeping its nonce, it is used to replace stuck messages.
+type MessageResigner interface {
+	ResignMessage(ctx context.Context, msg *types.Message) (*types.SignedMessage, error)
+}
+
 func (mp *MessagePool) republishPendingMessages(ctx context.Context) error {
 	mp.curTSLk.Lock()
 	ts := mp.curTS
@@ -172,7 +182,147 @@ LOOP:
 	mp.lk.Lock()
 	// update the republished set so that we can trigger early republish from head changes
 	mp.republished = republished
+	for _, m := range msgs[:count] {
+		mp.publishUpdate(MpoolUpdate{Type: MpoolRepublish, Message: m, Local: true})
+	}
 	mp.lk.Unlock()
 
 	return nil
 }
+
+// SetResigner sets the signer of the replacing messages, stuck messages are not replaced without one.
+func (mp *MessagePool) SetResigner(resigner MessageResigner) {
+	mp.lk.Lock()
+	defer mp.lk.Unlock()
+	mp.resigner = resigner
+}
+
+type stuckMessage struct {
+	msg    *types.SignedMessage
+	maxFee abi.TokenAmount
+}
+
+// replaceStuckMessages replaces the local messages pending for AutoReplaceEpochs with messages
+// with re-estimated fees, the premium of a replacing message is at least the replace by fee minimum
+// and its fee cap is capped by the max fee of the sender. It does nothing unless AutoReplace is set.
+func (mp *MessagePool) replaceStuckMessages(ctx context.Context) error {
+	cfg := mp.GetConfig()
+	if !cfg.AutoReplace {
+		return nil
+	}
+
+	mp.curTSLk.Lock()
+	ts := mp.curTS
+
+	var stuck []stuckMessage
+	seen := make(map[cid.Cid]abi.ChainEpoch)
+	mp.lk.Lock()
+	resigner := mp.resigner
+	for actor := range mp.localAddrs {
+		mset, ok := mp.pending[actor]
+		if !ok {
+			continue
+		}
+		for _, m := range mset.msgs {
+			c := m.Cid()
+			at, ok := mp.replaceSeen[c]
+			if !ok {
+				at = ts.Height()
+			}
+			seen[c] = at
+			if ts.Height()-at >= cfg.AutoReplaceEpochs {
+				stuck = append(stuck, stuckMessage{msg: m, maxFee: mp.autoReplaceMaxFee(ctx, cfg, actor)})
+			}
+		}
+	}
+	// only the messages still pending are tracked
+	mp.replaceSeen = seen
+	mp.lk.Unlock()
+	mp.curTSLk.Unlock()
+
+	if len(stuck) == 0 {
+		return nil
+	}
+	if resigner == nil {
+		return xerrors.Errorf("cannot replace %d stuck messages without a signer", len(stuck))
+	}
+
+	sort.Slice(stuck, func(i, j int) bool {
+		if stuck[i].msg.Message.From != stuck[j].msg.Message.From {
+			return stuck[i].msg.Message.From.String() < stuck[j].msg.Message.From.String()
+		}
+		return stuck[i].msg.Message.Nonce < stuck[j].msg.Message.Nonce
+	})
+
+	for _, s := range stuck {
+		replacing, err := mp.replaceMessage(ctx, s.msg, s.maxFee, resigner)
+		if err != nil {
+			log.Warnf("failed to replace message from %s with nonce %d: %s", s.msg.Message.From, s.msg.Message.Nonce, err)
+			continue
+		}
+		if replacing == nil {
+			continue
+		}
+
+		log.Infow("replaced stuck message", "from", s.msg.Message.From, "nonce", s.msg.Message.Nonce,
+			"old", s.msg.Cid(), "new", replacing.Cid(), "feecap", replacing.Message.GasFeeCap, "premium", replacing.Message.GasPremium)
+		old := s.msg
+		mp.journal.RecordEvent(mp.evtTypes[evtTypeMpoolReplace], func() interface{} {
+			return MessagePoolEvt{
+				Action: "replace",
+				Messages: []MessagePoolEvtMessage{
+					{UnsignedMessage: old.Message, CID: old.Cid()},
+					{UnsignedMessage: replacing.Message, CID: replacing.Cid()},
+				},
+			}
+		})
+	}
+
+	return nil
+}
+
+// replaceMessage pushes a message with the fees of m re-estimated, it returns nil if the max fee
+// does not allow a replace by fee.
+func (mp *MessagePool) replaceMessage(ctx context.Context, m *types.SignedMessage, maxFee abi.TokenAmount, resigner MessageResigner) (*types.SignedMessage, error) {
+	minRBF := ComputeMinRBF(m.Message.GasPremium)
+
+	msg := m.Message
+	msg.GasFeeCap = big.Zero()
+	msg.GasPremium = big.Zero()
+	spec := &types.MessageSendSpec{MaxFee: maxFee}
+	if _, err := mp.GasEstimateMessageGas(ctx, &types.EstimateMessage{Msg: &msg, Spec: spec}, types.EmptyTSK); err != nil {
+		return nil, xerrors.Errorf("estimating gas values: %w", err)
+	}
+
+	msg.GasPremium = big.Max(msg.GasPremium, minRBF)
+	msg.GasFeeCap = big.Max(msg.GasFeeCap, msg.GasPremium)
+	CapGasFee(mp.GetMaxFee, &msg, spec)
+	if msg.GasPremium.LessThan(minRBF) {
+		log.Warnf("not replacing message from %s with nonce %d: max fee %s does not allow a premium of %s",
+			msg.From, msg.Nonce, types.FIL(maxFeeFor(mp.GetMaxFee, spec)), minRBF)
+		return nil, nil
+	}
+
+	replacing, err := resigner.ResignMessage(ctx, &msg)
+	if err != nil {
+		return nil, xerrors.Errorf("signing message: %w", err)
+	}
+	if _, err := mp.Push(ctx, replacing); err != nil {
+		return nil, xerrors.Errorf("pushing message: %w", err)
+	}
+	return replacing, nil
+}
+
+// autoReplaceMaxFee returns the max fee of the replacing messages of the local address actor.
+// It must be called with mp.lk held.
+func (mp *MessagePool) autoReplaceMaxFee(ctx context.Context, cfg *MpoolConfig, actor address.Address) abi.TokenAmount {
+	for _, mf := range cfg.AutoReplaceMaxFees {
+		if mf.Address == actor {
+			return mf.MaxFee
+		}
+		if ka, err := mp.resolveToKey(ctx, mf.Address); err == nil && ka == actor {
+			return mf.MaxFee
+		}
+	}
+	return cfg.AutoReplaceMaxFee
+}
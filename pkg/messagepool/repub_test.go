@@ -6,12 +6,17 @@ import (
 	"time"
 
 	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
 	builtin2 "github.com/filecoin-project/specs-actors/v2/actors/builtin"
 	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/filecoin-project/venus/pkg/config"
 	"github.com/filecoin-project/venus/pkg/messagepool/gasguess"
 	tf "github.com/filecoin-project/venus/pkg/testhelpers/testflags"
+	"github.com/filecoin-project/venus/pkg/types"
+	"github.com/filecoin-project/venus/pkg/wallet"
 )
 
 func TestRepubMessages(t *testing.T) {
@@ -67,3 +72,66 @@ func TestRepubMessages(t *testing.T) {
 		t.Fatalf("expected to have published 20 messages, but got %d instead", tma.published)
 	}
 }
+
+type testResigner struct {
+	w *wallet.Wallet
+}
+
+func (r *testResigner) ResignMessage(ctx context.Context, msg *types.Message) (*types.SignedMessage, error) {
+	sig, err := r.w.WalletSign(msg.From, msg.Cid().Bytes(), wallet.MsgMeta{})
+	if err != nil {
+		return nil, err
+	}
+	return &types.SignedMessage{Message: *msg, Signature: *sig}, nil
+}
+
+func TestReplaceStuckMessages(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	tma := newTestMpoolAPI()
+	w, mp := newWalletAndMpool(t, tma)
+	mp.SetResigner(&testResigner{w: w})
+
+	a1, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	a2, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	target := mkAddress(1001)
+	tma.setBalance(a1, 1) // in FIL
+	tma.setBalance(a2, 1) // in FIL
+
+	m1 := mkMessage(a1, target, 0, w)
+	_, err = mp.Push(ctx, m1)
+	require.NoError(t, err)
+	m2 := mkMessage(a2, target, 0, w)
+	_, err = mp.Push(ctx, m2)
+	require.NoError(t, err)
+
+	cfg := mp.GetConfig()
+	cfg.AutoReplace = true
+	cfg.AutoReplaceEpochs = 2
+	// the budget of a2 is too low for any replacement
+	cfg.AutoReplaceMaxFees = []AddressMaxFee{{Address: a2, MaxFee: abi.NewTokenAmount(1)}}
+	require.NoError(t, mp.SetConfig(cfg))
+
+	pendingFor := func(from address.Address) *types.SignedMessage {
+		msgs, _ := mp.PendingFor(ctx, from)
+		require.Len(t, msgs, 1)
+		return msgs[0]
+	}
+
+	require.NoError(t, mp.replaceStuckMessages(ctx))
+	tma.applyBlock(t, tma.nextBlock())
+	require.NoError(t, mp.replaceStuckMessages(ctx))
+	assert.Equal(t, m1.Cid(), pendingFor(a1).Cid())
+
+	tma.applyBlock(t, tma.nextBlock())
+	require.NoError(t, mp.replaceStuckMessages(ctx))
+
+	replaced := pendingFor(a1)
+	assert.NotEqual(t, m1.Cid(), replaced.Cid())
+	assert.Equal(t, m1.Message.Nonce, replaced.Message.Nonce)
+	assert.True(t, replaced.Message.GasPremium.GreaterThanEqual(ComputeMinRBF(m1.Message.GasPremium)))
+	assert.Equal(t, m2.Cid(), pendingFor(a2).Cid())
+}
@@ -2,6 +2,7 @@ package messagepool
 
 import (
 	"context"
+	"fmt"
 	"math/big"
 	"math/rand"
 	"sort"
@@ -37,21 +38,27 @@ type msgChain struct {
 }
 
 func (mp *MessagePool) SelectMessages(ctx context.Context, ts *types.TipSet, tq float64) (msgs []*types.SignedMessage, err error) {
+	return mp.SelectMessagesWith(ctx, ts, tq, "")
+}
+
+// SelectMessagesWith selects the messages of a block on top of ts with the named selection strategy,
+// the strategy of the config if the name is empty.
+func (mp *MessagePool) SelectMessagesWith(ctx context.Context, ts *types.TipSet, tq float64, strategy string) (msgs []*types.SignedMessage, err error) {
 	mp.curTSLk.Lock()
 	defer mp.curTSLk.Unlock()
 
 	mp.lk.Lock()
 	defer mp.lk.Unlock()
 
-	// if the ticket quality is high enough that the first block has higher probability
-	// than any other block, then we don't bother with optimal selection because the
-	// first block will always have higher effective performance
-	if tq > 0.84 {
-		msgs, err = mp.selectMessagesGreedy(ctx, mp.curTS, ts)
-	} else {
-		msgs, err = mp.selectMessagesOptimal(ctx, mp.curTS, ts, tq)
+	if strategy == "" {
+		strategy = mp.cfg.SelectionStrategy
+	}
+	s, err := GetSelectionStrategy(strategy)
+	if err != nil {
+		return nil, err
 	}
 
+	msgs, err = mp.selectMessages(ctx, ts, tq, s)
 	if err != nil {
 		return nil, err
 	}
@@ -63,6 +70,19 @@ func (mp *MessagePool) SelectMessages(ctx context.Context, ts *types.TipSet, tq
 	return msgs, nil
 }
 
+// selectMessages selects the messages on top of ts with the strategy, without the MaxBlockMessages
+// limit. It is called with the pool locked.
+func (mp *MessagePool) selectMessages(ctx context.Context, ts *types.TipSet, tq float64, s SelectionStrategy) ([]*types.SignedMessage, error) {
+	// Load messages for the target tipset; if it is the same as the current tipset in the mpool
+	//    then this is just the pending messages
+	pending, err := mp.getPendingMessages(mp.curTS, ts)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.SelectMessages(ctx, mp, ts, pending, tq)
+}
+
 func deleteSelectedMessages(pending map[address.Address]map[uint64]*types.SignedMessage, msgs []*types.SignedMessage) map[address.Address]map[uint64]*types.SignedMessage {
 	// messages from the same wallet cannot be scattered in multiple blocks in a cycle, eg b1{nonce: 20~30}, b2{nonce: 31~40}
 	for _, msg := range msgs {
@@ -87,6 +107,11 @@ func (mp *MessagePool) MultipleSelectMessages(ctx context.Context, ts *types.Tip
 		return nil, err
 	}
 
+	s, err := GetSelectionStrategy(mp.cfg.SelectionStrategy)
+	if err != nil {
+		return nil, err
+	}
+
 	msgss = make([][]*types.SignedMessage, len(tqs))
 	var msgs []*types.SignedMessage
 
@@ -95,12 +120,7 @@ func (mp *MessagePool) MultipleSelectMessages(ctx context.Context, ts *types.Tip
 			break
 		}
 
-		if tq > 0.84 {
-			msgs, err = mp.multiSelectMessagesGreedy(ctx, mp.curTS, ts, pending)
-		} else {
-			msgs, err = mp.multiSelectMessagesOptimal(ctx, mp.curTS, ts, tq, pending)
-		}
-
+		msgs, err = s.SelectMessages(ctx, mp, ts, pending, tq)
 		if err != nil {
 			return nil, err
 		}
@@ -125,324 +145,7 @@ func (mp *MessagePool) MultipleSelectMessages(ctx context.Context, ts *types.Tip
 	return msgss, nil
 }
 
-func (mp *MessagePool) multiSelectMessagesOptimal(ctx context.Context, curTS, ts *types.TipSet, tq float64, pending map[address.Address]map[uint64]*types.SignedMessage) ([]*types.SignedMessage, error) {
-	start := time.Now()
-
-	baseFee, err := mp.api.ChainComputeBaseFee(context.TODO(), ts)
-	if err != nil {
-		return nil, xerrors.Errorf("computing basefee: %v", err)
-	}
-
-	if len(pending) == 0 {
-		return nil, nil
-	}
-
-	// defer only here so if we have no pending messages we don't spam
-	defer func() {
-		log.Infow("message selection done", "took", time.Since(start))
-	}()
-
-	// 0b. Select all priority messages that fit in the block
-	minGas := int64(gasguess.MinGas)
-	result, gasLimit := mp.selectPriorityMessages(ctx, pending, baseFee, ts)
-
-	// have we filled the block?
-	if gasLimit < minGas {
-		return result, nil
-	}
-
-	// 1. Create a list of dependent message chains with maximal gas reward per limit consumed
-	startChains := time.Now()
-	var chains []*msgChain
-	for actor, mset := range pending {
-		next := mp.createMessageChains(actor, mset, baseFee, ts)
-		chains = append(chains, next...)
-	}
-	if dt := time.Since(startChains); dt > time.Millisecond {
-		log.Infow("create message chains done", "took", dt)
-	}
-
-	// 2. Sort the chains
-	sort.Slice(chains, func(i, j int) bool {
-		return chains[i].Before(chains[j])
-	})
-
-	if len(chains) != 0 && chains[0].gasPerf < 0 {
-		log.Warnw("all messages in mpool have non-positive gas performance", "bestGasPerf", chains[0].gasPerf)
-		return result, nil
-	}
-
-	// 3. Parition chains into blocks (without trimming)
-	//    we use the full blockGasLimit (as opposed to the residual gas limit from the
-	//    priority message selection) as we have to account for what other miners are doing
-	nextChain := 0
-	partitions := make([][]*msgChain, MaxBlocks)
-	for i := 0; i < MaxBlocks && nextChain < len(chains); i++ {
-		gasLimit := int64(constants.BlockGasLimit)
-		for nextChain < len(chains) {
-			chain := chains[nextChain]
-			nextChain++
-			partitions[i] = append(partitions[i], chain)
-			gasLimit -= chain.gasLimit
-			if gasLimit < minGas {
-				break
-			}
-		}
-
-	}
-
-	// 4. Compute effective performance for each chain, based on the partition they fall into
-	//    The effective performance is the gasPerf of the chain * block probability
-	blockProb := mp.blockProbabilities(tq)
-	effChains := 0
-	for i := 0; i < MaxBlocks; i++ {
-		for _, chain := range partitions[i] {
-			chain.SetEffectivePerf(blockProb[i])
-		}
-		effChains += len(partitions[i])
-	}
-
-	// nullify the effective performance of chains that don't fit in any partition
-	for _, chain := range chains[effChains:] {
-		chain.SetNullEffectivePerf()
-	}
-
-	// 5. Resort the chains based on effective performance
-	sort.Slice(chains, func(i, j int) bool {
-		return chains[i].BeforeEffective(chains[j])
-	})
-
-	// 6. Merge the head chains to produce the list of messages selected for inclusion
-	//    subject to the residual gas limit
-	//    When a chain is merged in, all its previous dependent chains *must* also be
-	//    merged in or we'll have a broken block
-	startMerge := time.Now()
-	last := len(chains)
-	for i, chain := range chains {
-		// did we run out of performing chains?
-		if chain.gasPerf < 0 {
-			break
-		}
-
-		// has it already been merged?
-		if chain.merged {
-			continue
-		}
-
-		// compute the dependencies that must be merged and the gas limit including deps
-		chainGasLimit := chain.gasLimit
-		var chainDeps []*msgChain
-		for curChain := chain.prev; curChain != nil && !curChain.merged; curChain = curChain.prev {
-			chainDeps = append(chainDeps, curChain)
-			chainGasLimit += curChain.gasLimit
-		}
-
-		// does it all fit in the block?
-		if chainGasLimit <= gasLimit {
-			// include it together with all dependencies
-			for i := len(chainDeps) - 1; i >= 0; i-- {
-				curChain := chainDeps[i]
-				curChain.merged = true
-				result = append(result, curChain.msgs...)
-			}
-
-			chain.merged = true
-			// adjust the effective pefromance for all subsequent chains
-			if next := chain.next; next != nil && next.effPerf > 0 {
-				next.effPerf += next.parentOffset
-				for next = next.next; next != nil && next.effPerf > 0; next = next.next {
-					next.setEffPerf()
-				}
-			}
-			result = append(result, chain.msgs...)
-			gasLimit -= chainGasLimit
-
-			// resort to account for already merged chains and effective performance adjustments
-			// the sort *must* be stable or we end up getting negative gasPerfs pushed up.
-			sort.SliceStable(chains[i+1:], func(i, j int) bool {
-				return chains[i].BeforeEffective(chains[j])
-			})
-
-			continue
-		}
-
-		// we can't fit this chain and its dependencies because of block gasLimit -- we are
-		// at the edge
-		last = i
-		break
-	}
-	if dt := time.Since(startMerge); dt > time.Millisecond {
-		log.Infow("merge message chains done", "took", dt)
-	}
-
-	// 7. We have reached the edge of what can fit wholesale; if we still hae available
-	//    gasLimit to pack some more chains, then trim the last chain and push it down.
-	//    Trimming invalidaates subsequent dependent chains so that they can't be selected
-	//    as their dependency cannot be (fully) included.
-	//    We do this in a loop because the blocker might have been inordinately large and
-	//    we might have to do it multiple times to satisfy tail packing
-	startTail := time.Now()
-tailLoop:
-	for gasLimit >= minGas && last < len(chains) {
-		// trim if necessary
-		if chains[last].gasLimit > gasLimit {
-			chains[last].Trim(gasLimit, mp, baseFee)
-		}
-
-		// push down if it hasn't been invalidated
-		if chains[last].valid {
-			for i := last; i < len(chains)-1; i++ {
-				if chains[i].BeforeEffective(chains[i+1]) {
-					break
-				}
-				chains[i], chains[i+1] = chains[i+1], chains[i]
-			}
-		}
-
-		// select the next (valid and fitting) chain and its dependencies for inclusion
-		for i, chain := range chains[last:] {
-			// has the chain been invalidated?
-			if !chain.valid {
-				continue
-			}
-
-			// has it already been merged?
-			if chain.merged {
-				continue
-			}
-
-			// if gasPerf < 0 we have no more profitable chains
-			if chain.gasPerf < 0 {
-				break tailLoop
-			}
-
-			// compute the dependencies that must be merged and the gas limit including deps
-			chainGasLimit := chain.gasLimit
-			depGasLimit := int64(0)
-			var chainDeps []*msgChain
-			for curChain := chain.prev; curChain != nil && !curChain.merged; curChain = curChain.prev {
-				chainDeps = append(chainDeps, curChain)
-				chainGasLimit += curChain.gasLimit
-				depGasLimit += curChain.gasLimit
-			}
-
-			// does it all fit in the bock
-			if chainGasLimit <= gasLimit {
-				// include it together with all dependencies
-				for i := len(chainDeps) - 1; i >= 0; i-- {
-					curChain := chainDeps[i]
-					curChain.merged = true
-					result = append(result, curChain.msgs...)
-				}
-
-				chain.merged = true
-				result = append(result, chain.msgs...)
-				gasLimit -= chainGasLimit
-				continue
-			}
-
-			// it doesn't all fit; now we have to take into account the dependent chains before
-			// making a decision about trimming or invalidating.
-			// if the dependencies exceed the gas limit, then we must invalidate the chain
-			// as it can never be included.
-			// Otherwise we can just trim and continue
-			if depGasLimit > gasLimit {
-				chain.Invalidate()
-				last += i + 1
-				continue tailLoop
-			}
-
-			// dependencies fit, just trim it
-			chain.Trim(gasLimit-depGasLimit, mp, baseFee)
-			last += i
-			continue tailLoop
-		}
-
-		// the merge loop ended after processing all the chains and we we probably have still
-		// gas to spare; end the loop.
-		break
-	}
-	if dt := time.Since(startTail); dt > time.Millisecond {
-		log.Infow("pack tail chains done", "took", dt)
-	}
-
-	// if we have gasLimit to spare, pick some random (non-negative) chains to fill the block
-	// we pick randomly so that we minimize the probability of duplication among all miners
-	if gasLimit >= minGas {
-		randomCount := 0
-
-		startRandom := time.Now()
-		shuffleChains(chains)
-
-		for _, chain := range chains {
-			// have we filled the block
-			if gasLimit < minGas {
-				break
-			}
-
-			// has it been merged or invalidated?
-			if chain.merged || !chain.valid {
-				continue
-			}
-
-			// is it negative?
-			if chain.gasPerf < 0 {
-				continue
-			}
-
-			// compute the dependencies that must be merged and the gas limit including deps
-			chainGasLimit := chain.gasLimit
-			depGasLimit := int64(0)
-			var chainDeps []*msgChain
-			for curChain := chain.prev; curChain != nil && !curChain.merged; curChain = curChain.prev {
-				chainDeps = append(chainDeps, curChain)
-				chainGasLimit += curChain.gasLimit
-				depGasLimit += curChain.gasLimit
-			}
-
-			// do the deps fit? if the deps won't fit, invalidate the chain
-			if depGasLimit > gasLimit {
-				chain.Invalidate()
-				continue
-			}
-
-			// do they fit as is? if it doesn't, trim to make it fit if possible
-			if chainGasLimit > gasLimit {
-				chain.Trim(gasLimit-depGasLimit, mp, baseFee)
-
-				if !chain.valid {
-					continue
-				}
-			}
-
-			// include it together with all dependencies
-			for i := len(chainDeps) - 1; i >= 0; i-- {
-				curChain := chainDeps[i]
-				curChain.merged = true
-				result = append(result, curChain.msgs...)
-				randomCount += len(curChain.msgs)
-			}
-
-			chain.merged = true
-			result = append(result, chain.msgs...)
-			randomCount += len(chain.msgs)
-			gasLimit -= chainGasLimit
-		}
-
-		if dt := time.Since(startRandom); dt > time.Millisecond {
-			log.Infow("pack random tail chains done", "took", dt)
-		}
-
-		if randomCount > 0 {
-			log.Warnf("optimal selection failed to pack a block; picked %d messages with random selection",
-				randomCount)
-		}
-	}
-
-	return result, nil
-}
-
-func (mp *MessagePool) selectMessagesOptimal(ctx context.Context, curTS, ts *types.TipSet, tq float64) ([]*types.SignedMessage, error) {
+func (mp *MessagePool) multiSelectMessagesOptimal(ctx context.Context, curTS, ts *types.TipSet, tq float64, pending map[address.Address]map[uint64]*types.SignedMessage, priority []address.Address) ([]*types.SignedMessage, error) {
 	start := time.Now()
 
 	baseFee, err := mp.api.ChainComputeBaseFee(context.TODO(), ts)
@@ -450,13 +153,6 @@ func (mp *MessagePool) selectMessagesOptimal(ctx context.Context, curTS, ts *typ
 		return nil, xerrors.Errorf("computing basefee: %v", err)
 	}
 
-	// 0. Load messages from the target tipset; if it is the same as the current tipset in
-	//    the mpool, then this is just the pending messages
-	pending, err := mp.getPendingMessages(curTS, ts)
-	if err != nil {
-		return nil, err
-	}
-
 	if len(pending) == 0 {
 		return nil, nil
 	}
@@ -468,7 +164,7 @@ func (mp *MessagePool) selectMessagesOptimal(ctx context.Context, curTS, ts *typ
 
 	// 0b. Select all priority messages that fit in the block
 	minGas := int64(gasguess.MinGas)
-	result, gasLimit := mp.selectPriorityMessages(ctx, pending, baseFee, ts)
+	result, gasLimit := mp.selectPriorityMessages(ctx, pending, baseFee, ts, priority)
 
 	// have we filled the block?
 	if gasLimit < minGas {
@@ -766,136 +462,7 @@ tailLoop:
 	return result, nil
 }
 
-func (mp *MessagePool) multiSelectMessagesGreedy(ctx context.Context, curTS, ts *types.TipSet, pending map[address.Address]map[uint64]*types.SignedMessage) ([]*types.SignedMessage, error) {
-	start := time.Now()
-
-	baseFee, err := mp.api.ChainComputeBaseFee(context.TODO(), ts)
-	if err != nil {
-		return nil, xerrors.Errorf("computing basefee: %v", err)
-	}
-
-	if len(pending) == 0 {
-		return nil, nil
-	}
-
-	// defer only here so if we have no pending messages we don't spam
-	defer func() {
-		log.Infow("message selection done", "took", time.Since(start))
-	}()
-
-	// 0b. Select all priority messages that fit in the block
-	minGas := int64(gasguess.MinGas)
-	result, gasLimit := mp.selectPriorityMessages(ctx, pending, baseFee, ts)
-
-	// have we filled the block?
-	if gasLimit < minGas {
-		return result, nil
-	}
-
-	// 1. Create a list of dependent message chains with maximal gas reward per limit consumed
-	startChains := time.Now()
-	var chains []*msgChain
-	for actor, mset := range pending {
-		next := mp.createMessageChains(actor, mset, baseFee, ts)
-		chains = append(chains, next...)
-	}
-	if dt := time.Since(startChains); dt > time.Millisecond {
-		log.Infow("create message chains done", "took", dt)
-	}
-
-	// 2. Sort the chains
-	sort.Slice(chains, func(i, j int) bool {
-		return chains[i].Before(chains[j])
-	})
-
-	if len(chains) != 0 && chains[0].gasPerf < 0 {
-		log.Warnw("all messages in mpool have non-positive gas performance", "bestGasPerf", chains[0].gasPerf)
-		return result, nil
-	}
-
-	// 3. Merge the head chains to produce the list of messages selected for inclusion, subject to
-	//    the block gas limit.
-	startMerge := time.Now()
-	last := len(chains)
-	for i, chain := range chains {
-		// did we run out of performing chains?
-		if chain.gasPerf < 0 {
-			break
-		}
-
-		// does it fit in the block?
-		if chain.gasLimit <= gasLimit {
-			gasLimit -= chain.gasLimit
-			result = append(result, chain.msgs...)
-			continue
-		}
-
-		// we can't fit this chain because of block gasLimit -- we are at the edge
-		last = i
-		break
-	}
-	if dt := time.Since(startMerge); dt > time.Millisecond {
-		log.Infow("merge message chains done", "took", dt)
-	}
-
-	// 4. We have reached the edge of what we can fit wholesale; if we still have available gasLimit
-	// to pack some more chains, then trim the last chain and push it down.
-	// Trimming invalidates subsequent dependent chains so that they can't be selected as their
-	// dependency cannot be (fully) included.
-	// We do this in a loop because the blocker might have been inordinately large and we might
-	// have to do it multiple times to satisfy tail packing.
-	startTail := time.Now()
-tailLoop:
-	for gasLimit >= minGas && last < len(chains) {
-		// trim
-		chains[last].Trim(gasLimit, mp, baseFee)
-
-		// push down if it hasn't been invalidated
-		if chains[last].valid {
-			for i := last; i < len(chains)-1; i++ {
-				if chains[i].Before(chains[i+1]) {
-					break
-				}
-				chains[i], chains[i+1] = chains[i+1], chains[i]
-			}
-		}
-
-		// select the next (valid and fitting) chain for inclusion
-		for i, chain := range chains[last:] {
-			// has the chain been invalidated?
-			if !chain.valid {
-				continue
-			}
-
-			// if gasPerf < 0 we have no more profitable chains
-			if chain.gasPerf < 0 {
-				break tailLoop
-			}
-
-			// does it fit in the bock?
-			if chain.gasLimit <= gasLimit {
-				gasLimit -= chain.gasLimit
-				result = append(result, chain.msgs...)
-				continue
-			}
-
-			// this chain needs to be trimmed
-			last += i
-			continue tailLoop
-		}
-
-		// the merge loop ended after processing all the chains and we probably still have
-		// gas to spare; end the loop
-		break
-	}
-	if dt := time.Since(startTail); dt > time.Millisecond {
-		log.Infow("pack tail chains done", "took", dt)
-	}
-
-	return result, nil
-}
-
-func (mp *MessagePool) selectMessagesGreedy(ctx context.Context, curTS, ts *types.TipSet) ([]*types.SignedMessage, error) {
+func (mp *MessagePool) multiSelectMessagesGreedy(ctx context.Context, curTS, ts *types.TipSet, pending map[address.Address]map[uint64]*types.SignedMessage, priority []address.Address) ([]*types.SignedMessage, error) {
 	start := time.Now()
 
 	baseFee, err := mp.api.ChainComputeBaseFee(context.TODO(), ts)
@@ -903,13 +470,6 @@ func (mp *MessagePool) selectMessagesGreedy(ctx context.Context, curTS, ts *type
 		return nil, xerrors.Errorf("computing basefee: %v", err)
 	}
 
-	// 0. Load messages for the target tipset; if it is the same as the current tipset in the mpool
-	//    then this is just the pending messages
-	pending, err := mp.getPendingMessages(curTS, ts)
-	if err != nil {
-		return nil, err
-	}
-
 	if len(pending) == 0 {
 		return nil, nil
 	}
@@ -921,7 +481,7 @@ func (mp *MessagePool) selectMessagesGreedy(ctx context.Context, curTS, ts *type
 
 	// 0b. Select all priority messages that fit in the block
 	minGas := int64(gasguess.MinGas)
-	result, gasLimit := mp.selectPriorityMessages(ctx, pending, baseFee, ts)
+	result, gasLimit := mp.selectPriorityMessages(ctx, pending, baseFee, ts, priority)
 
 	// have we filled the block?
 	if gasLimit < minGas {
@@ -1031,7 +591,7 @@ tailLoop:
 	return result, nil
 }
 
-func (mp *MessagePool) selectPriorityMessages(ctx context.Context, pending map[address.Address]map[uint64]*types.SignedMessage, baseFee tbig.Int, ts *types.TipSet) ([]*types.SignedMessage, int64) {
+func (mp *MessagePool) selectPriorityMessages(ctx context.Context, pending map[address.Address]map[uint64]*types.SignedMessage, baseFee tbig.Int, ts *types.TipSet, priority []address.Address) ([]*types.SignedMessage, int64) {
 	start := time.Now()
 	defer func() {
 		if dt := time.Since(start); dt > time.Millisecond {
@@ -1045,7 +605,6 @@ func (mp *MessagePool) selectPriorityMessages(ctx context.Context, pending map[a
 
 	// 1. Get priority actor chains
 	var chains []*msgChain
-	priority := mp.cfg.PriorityAddrs
 	for _, actor := range priority {
 		pk, err := mp.resolveToKey(ctx, actor)
 		if err != nil {
@@ -1209,6 +768,16 @@ func (*MessagePool) getGasPerf(gasReward *big.Int, gasLimit int64) float64 {
 }
 
 func (mp *MessagePool) createMessageChains(actor address.Address, mset map[uint64]*types.SignedMessage, baseFee types.BigInt, ts *types.TipSet) []*msgChain {
+	return mp.createMessageChainsExplain(actor, mset, baseFee, ts, nil)
+}
+
+// createMessageChainsExplain creates the message chains of the actor like createMessageChains,
+// exclude is called, when not nil, for every message left out of the chains.
+func (mp *MessagePool) createMessageChainsExplain(actor address.Address, mset map[uint64]*types.SignedMessage, baseFee types.BigInt, ts *types.TipSet, exclude excludeFunc) []*msgChain {
+	if exclude == nil {
+		exclude = func(*types.SignedMessage, ExclusionReason, string) {}
+	}
+
 	// collect all messages
 	msgs := make([]*types.SignedMessage, 0, len(mset))
 	for _, m := range mset {
@@ -1230,6 +799,9 @@ func (mp *MessagePool) createMessageChains(actor address.Address, mset map[uint6
 	a, err := mp.api.GetActorAfter(actor, ts)
 	if err != nil {
 		log.Errorf("failed to load actor state, not building chain for %s: %w", actor, err)
+		for _, m := range msgs {
+			exclude(m, ReasonActorState, err.Error())
+		}
 		return nil
 	}
 
@@ -1246,27 +818,33 @@ func (mp *MessagePool) createMessageChains(actor address.Address, mset map[uint6
 		if m.Message.Nonce < curNonce {
 			log.Warnf("encountered message from actor %s with nonce (%d) less than the current nonce (%d)",
 				actor, m.Message.Nonce, curNonce)
+			exclude(m, ReasonNonceTooLow, fmt.Sprintf("actor nonce is %d", curNonce))
 			skip++
 			continue
 		}
 
 		if m.Message.Nonce != curNonce {
+			exclude(m, ReasonNonceGap, fmt.Sprintf("expected nonce %d", curNonce))
 			break
 		}
 		curNonce++
 
 		minGas := mp.gasPriceSchedule.PricelistByEpoch(curHeight).OnChainMessage(m.ChainLength()).Total()
 		if m.Message.GasLimit < minGas {
+			exclude(m, ReasonMinGas, fmt.Sprintf("minimum gas is %d", minGas))
 			break
 		}
 
 		gasLimit += m.Message.GasLimit
 		if gasLimit > constants.BlockGasLimit {
+			exclude(m, ReasonChainGasLimit, fmt.Sprintf("gas limit of the chain up to the message is %d", gasLimit))
 			break
 		}
 
 		required := m.Message.RequiredFunds().Int
 		if balance.Cmp(required) < 0 {
+			exclude(m, ReasonBalance, fmt.Sprintf("required %s, remaining balance %s",
+				types.FIL(tbig.Int{Int: required}), types.FIL(tbig.Int{Int: balance})))
 			break
 		}
 
@@ -1279,6 +857,11 @@ func (mp *MessagePool) createMessageChains(actor address.Address, mset map[uint6
 		rewards = append(rewards, gasReward)
 	}
 
+	// the messages after an excluded one cannot be included either
+	for j := i + 1; j < len(msgs); j++ {
+		exclude(msgs[j], ReasonDependency, fmt.Sprintf("nonce %d is excluded", msgs[i].Message.Nonce))
+	}
+
 	// check we have a sane set of messages to construct the chains
 	if i > skip {
 		msgs = msgs[skip:i]
@@ -0,0 +1,247 @@
+package messagepool
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	tbig "github.com/filecoin-project/go-state-types/big"
+	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus/pkg/constants"
+	"github.com/filecoin-project/venus/pkg/messagepool/gasguess"
+	"github.com/filecoin-project/venus/pkg/types"
+)
+
+// ExclusionReason is why message selection left a pending message out.
+type ExclusionReason string
+
+const (
+	ReasonActorState     ExclusionReason = "actor-state"
+	ReasonNonceTooLow    ExclusionReason = "nonce-too-low"
+	ReasonNonceGap       ExclusionReason = "nonce-gap"
+	ReasonMinGas         ExclusionReason = "gas-limit-below-minimum"
+	ReasonChainGasLimit  ExclusionReason = "chain-exceeds-block-gas-limit"
+	ReasonBalance        ExclusionReason = "insufficient-balance"
+	ReasonDependency     ExclusionReason = "dependency-excluded"
+	ReasonNegativePerf   ExclusionReason = "negative-gas-performance"
+	ReasonLowPerf        ExclusionReason = "low-gas-performance"
+	ReasonBlockMessages  ExclusionReason = "block-message-limit"
+	ReasonSenderMessages ExclusionReason = "sender-message-limit"
+)
+
+type excludeFunc func(m *types.SignedMessage, reason ExclusionReason, detail string)
+
+// SelectionReport is the outcome of a dry run of the message selection.
+type SelectionReport struct {
+	BaseFee       abi.TokenAmount
+	TicketQuality float64
+	// Greedy is set when the ticket quality is high enough for the greedy selection
+	Greedy bool
+	// GasLimit is the total gas limit of the selected messages
+	GasLimit int64
+	Chains   []*ChainReport
+	Messages []*MessageReport
+}
+
+// ChainReport is a chain of dependent messages of an actor the selection takes or leaves as a whole.
+type ChainReport struct {
+	ID            int
+	From          address.Address
+	Priority      bool
+	Messages      []cid.Cid
+	GasLimit      int64
+	GasReward     abi.TokenAmount
+	GasPerf       float64
+	EffectivePerf float64
+}
+
+// MessageReport is the selection outcome of a pending message.
+type MessageReport struct {
+	Cid      cid.Cid
+	From     address.Address
+	Nonce    uint64
+	Selected bool
+	// Chain is the ID of the chain of the message, -1 if the message is in no chain
+	Chain  int
+	Reason ExclusionReason `json:",omitempty"`
+	Detail string          `json:",omitempty"`
+}
+
+// SelectMessagesDebug runs the message selection for the tipset like SelectMessages, with the strategy
+// of the config, and reports the chains built from the pending messages, their effective performance
+// and the reason each message not selected was left out. Like SelectMessages, it does not modify the pool.
+func (mp *MessagePool) SelectMessagesDebug(ctx context.Context, ts *types.TipSet, tq float64) (*SelectionReport, error) {
+	mp.curTSLk.Lock()
+	defer mp.curTSLk.Unlock()
+
+	mp.lk.Lock()
+	defer mp.lk.Unlock()
+
+	s, err := GetSelectionStrategy(mp.cfg.SelectionStrategy)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SelectionReport{TicketQuality: tq, Greedy: tq > 0.84}
+
+	selected, err := mp.selectMessages(ctx, ts, tq, s)
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make(map[cid.Cid]int, len(selected))
+	for i, m := range selected {
+		positions[m.Cid()] = i
+	}
+
+	baseFee, err := mp.api.ChainComputeBaseFee(ctx, ts)
+	if err != nil {
+		return nil, xerrors.Errorf("computing basefee: %v", err)
+	}
+	report.BaseFee = baseFee
+
+	// the selection consumes the pending set, rebuild the chains from a new one
+	pending, err := mp.getPendingMessages(mp.curTS, ts)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make(map[cid.Cid]*MessageReport)
+	exclude := func(m *types.SignedMessage, reason ExclusionReason, detail string) {
+		messages[m.Cid()] = &MessageReport{
+			Cid:    m.Cid(),
+			From:   m.Message.From,
+			Nonce:  m.Message.Nonce,
+			Chain:  -1,
+			Reason: reason,
+			Detail: detail,
+		}
+	}
+
+	priorityAddrs := mp.cfg.PriorityAddrs
+	if es, ok := s.(explainingStrategy); ok {
+		es.prune(mp, pending, exclude)
+		priorityAddrs = es.priority(mp)
+	}
+
+	priorityActors := make(map[address.Address]struct{})
+	for _, actor := range priorityAddrs {
+		if pk, err := mp.resolveToKey(ctx, actor); err == nil {
+			priorityActors[pk] = struct{}{}
+		}
+	}
+
+	var chains, others []*msgChain
+	priority := make(map[*msgChain]bool)
+	for actor, mset := range pending {
+		next := mp.createMessageChainsExplain(actor, mset, baseFee, ts, exclude)
+		_, isPriority := priorityActors[actor]
+		for _, chain := range next {
+			priority[chain] = isPriority
+			if isPriority {
+				chain.effPerf = chain.gasPerf
+			} else {
+				others = append(others, chain)
+			}
+		}
+		chains = append(chains, next...)
+	}
+
+	// the effective performance of the chains, as partitioned in blocks by the optimal selection
+	sort.Slice(others, func(i, j int) bool {
+		return others[i].Before(others[j])
+	})
+	minGas := int64(gasguess.MinGas)
+	blockProb := mp.blockProbabilities(tq)
+	nextChain := 0
+	for i := 0; i < MaxBlocks && nextChain < len(others); i++ {
+		gasLimit := int64(constants.BlockGasLimit)
+		for nextChain < len(others) {
+			chain := others[nextChain]
+			nextChain++
+			chain.SetEffectivePerf(blockProb[i])
+			gasLimit -= chain.gasLimit
+			if gasLimit < minGas {
+				break
+			}
+		}
+	}
+	for _, chain := range others[nextChain:] {
+		chain.SetNullEffectivePerf()
+	}
+
+	sort.SliceStable(chains, func(i, j int) bool {
+		if priority[chains[i]] != priority[chains[j]] {
+			return priority[chains[i]]
+		}
+		return chains[i].BeforeEffective(chains[j])
+	})
+
+	for id, chain := range chains {
+		cr := &ChainReport{
+			ID:            id,
+			From:          chain.msgs[0].Message.From,
+			Priority:      priority[chain],
+			GasLimit:      chain.gasLimit,
+			GasReward:     tbig.Int{Int: chain.gasReward},
+			GasPerf:       chain.gasPerf,
+			EffectivePerf: chain.effPerf,
+		}
+		for _, m := range chain.msgs {
+			c := m.Cid()
+			cr.Messages = append(cr.Messages, c)
+
+			pos, ok := positions[c]
+			mr := &MessageReport{
+				Cid:      c,
+				From:     m.Message.From,
+				Nonce:    m.Message.Nonce,
+				Selected: ok && pos < MaxBlockMessages,
+				Chain:    id,
+			}
+			switch {
+			case mr.Selected:
+				report.GasLimit += m.Message.GasLimit
+			case ok:
+				mr.Reason = ReasonBlockMessages
+				mr.Detail = fmt.Sprintf("a block has at most %d messages", MaxBlockMessages)
+			case chain.gasPerf < 0:
+				mr.Reason = ReasonNegativePerf
+				mr.Detail = fmt.Sprintf("gas performance of the chain is %f", chain.gasPerf)
+			default:
+				mr.Reason = ReasonLowPerf
+				mr.Detail = fmt.Sprintf("effective performance of the chain is %f, the block gas limit is used by better chains", chain.effPerf)
+			}
+			messages[c] = mr
+		}
+		report.Chains = append(report.Chains, cr)
+	}
+
+	for _, mr := range messages {
+		report.Messages = append(report.Messages, mr)
+	}
+	sort.Slice(report.Messages, func(i, j int) bool {
+		if report.Messages[i].From != report.Messages[j].From {
+			return report.Messages[i].From.String() < report.Messages[j].From.String()
+		}
+		return report.Messages[i].Nonce < report.Messages[j].Nonce
+	})
+
+	// a message whose predecessor is left out cannot be selected whatever its performance
+	for i := 1; i < len(report.Messages); i++ {
+		prev, mr := report.Messages[i-1], report.Messages[i]
+		if mr.Selected || mr.Reason == ReasonDependency || prev.From != mr.From || prev.Selected || prev.Nonce+1 != mr.Nonce {
+			continue
+		}
+		if mr.Chain >= 0 && mr.Reason != ReasonBlockMessages {
+			mr.Reason = ReasonDependency
+			mr.Detail = fmt.Sprintf("nonce %d is not selected", prev.Nonce)
+		}
+	}
+
+	return report, nil
+}
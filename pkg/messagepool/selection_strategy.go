@@ -0,0 +1,165 @@
+package messagepool
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/filecoin-project/go-address"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus/pkg/types"
+)
+
+const (
+	// DefaultSelection is the name of DefaultSelectionStrategy
+	DefaultSelection = "default"
+	// PrioritySelection is the name of PrioritySelectionStrategy
+	PrioritySelection = "priority"
+)
+
+// SelectionStrategy selects the messages of a block mined on top of a tipset.
+type SelectionStrategy interface {
+	// SelectMessages selects the messages of a block on top of ts from pending, the messages by
+	// sender and nonce that can be included on top of ts, for a ticket of quality tq. It is called
+	// with the pool locked and may modify pending.
+	SelectMessages(ctx context.Context, mp *MessagePool, ts *types.TipSet, pending map[address.Address]map[uint64]*types.SignedMessage, tq float64) ([]*types.SignedMessage, error)
+}
+
+// DefaultSelectionStrategy selects the messages of the priority addresses first, then the chains of
+// messages with the best gas performance: greedily if the ticket quality is high enough for the first
+// block to be the most likely, by effective performance over the likely blocks otherwise.
+var DefaultSelectionStrategy SelectionStrategy = defaultSelection{}
+
+// PrioritySelectionStrategy is DefaultSelectionStrategy selecting the messages of the local addresses
+// first when SelectLocalFirst is set, and at most MaxSenderBlockMessages messages of a sender.
+var PrioritySelectionStrategy SelectionStrategy = prioritySelection{}
+
+var (
+	strategiesLk sync.RWMutex
+	strategies   = map[string]SelectionStrategy{
+		DefaultSelection:  DefaultSelectionStrategy,
+		PrioritySelection: PrioritySelectionStrategy,
+	}
+)
+
+// RegisterSelectionStrategy makes the strategy selectable by name in the config and the block
+// templates, replacing the strategy with the same name.
+func RegisterSelectionStrategy(name string, strategy SelectionStrategy) {
+	strategiesLk.Lock()
+	defer strategiesLk.Unlock()
+	strategies[name] = strategy
+}
+
+// GetSelectionStrategy returns the strategy with the name, DefaultSelectionStrategy for an empty name.
+func GetSelectionStrategy(name string) (SelectionStrategy, error) {
+	if name == "" {
+		name = DefaultSelection
+	}
+
+	strategiesLk.RLock()
+	defer strategiesLk.RUnlock()
+	s, ok := strategies[name]
+	if !ok {
+		return nil, xerrors.Errorf("unknown message selection strategy %q", name)
+	}
+	return s, nil
+}
+
+// SelectionStrategies returns the names of the registered strategies.
+func SelectionStrategies() []string {
+	strategiesLk.RLock()
+	defer strategiesLk.RUnlock()
+
+	names := make([]string, 0, len(strategies))
+	for name := range strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// explainingStrategy is implemented by the strategies whose selection the dry run of
+// SelectMessagesDebug can explain: the senders selected first and the messages left out before the
+// selection. The dry run of another strategy reports the priority addresses of the config.
+type explainingStrategy interface {
+	// priority returns the senders whose messages are selected first.
+	priority(mp *MessagePool) []address.Address
+	// prune removes from pending the messages the strategy never selects, passing them to exclude
+	// if it is not nil.
+	prune(mp *MessagePool, pending map[address.Address]map[uint64]*types.SignedMessage, exclude excludeFunc)
+}
+
+type defaultSelection struct{}
+
+func (s defaultSelection) SelectMessages(ctx context.Context, mp *MessagePool, ts *types.TipSet, pending map[address.Address]map[uint64]*types.SignedMessage, tq float64) ([]*types.SignedMessage, error) {
+	return mp.selectPending(ctx, ts, pending, tq, s.priority(mp))
+}
+
+func (defaultSelection) priority(mp *MessagePool) []address.Address {
+	return mp.cfg.PriorityAddrs
+}
+
+func (defaultSelection) prune(*MessagePool, map[address.Address]map[uint64]*types.SignedMessage, excludeFunc) {
+}
+
+type prioritySelection struct{}
+
+func (s prioritySelection) SelectMessages(ctx context.Context, mp *MessagePool, ts *types.TipSet, pending map[address.Address]map[uint64]*types.SignedMessage, tq float64) ([]*types.SignedMessage, error) {
+	s.prune(mp, pending, nil)
+	return mp.selectPending(ctx, ts, pending, tq, s.priority(mp))
+}
+
+func (prioritySelection) priority(mp *MessagePool) []address.Address {
+	var priority []address.Address
+	if mp.cfg.SelectLocalFirst {
+		for actor := range mp.localAddrs {
+			priority = append(priority, actor)
+		}
+		sort.Slice(priority, func(i, j int) bool {
+			return priority[i].String() < priority[j].String()
+		})
+	}
+	return append(priority, mp.cfg.PriorityAddrs...)
+}
+
+func (prioritySelection) prune(mp *MessagePool, pending map[address.Address]map[uint64]*types.SignedMessage, exclude excludeFunc) {
+	limit := mp.cfg.MaxSenderBlockMessages
+	if limit <= 0 {
+		return
+	}
+	for actor, mset := range pending {
+		if len(mset) <= limit {
+			continue
+		}
+		nonces := make([]uint64, 0, len(mset))
+		for nonce := range mset {
+			nonces = append(nonces, nonce)
+		}
+		sort.Slice(nonces, func(i, j int) bool { return nonces[i] < nonces[j] })
+
+		// the first messages are kept so that the nonces stay contiguous
+		capped := make(map[uint64]*types.SignedMessage, limit)
+		for _, nonce := range nonces[:limit] {
+			capped[nonce] = mset[nonce]
+		}
+		if exclude != nil {
+			for _, nonce := range nonces[limit:] {
+				exclude(mset[nonce], ReasonSenderMessages, fmt.Sprintf("a sender has at most %d messages in a block", limit))
+			}
+		}
+		pending[actor] = capped
+	}
+}
+
+// selectPending selects the messages of the priority senders, then the other ones by gas performance.
+// if the ticket quality is high enough that the first block has higher probability than any other
+// block, then we don't bother with optimal selection because the first block will always have
+// higher effective performance.
+func (mp *MessagePool) selectPending(ctx context.Context, ts *types.TipSet, pending map[address.Address]map[uint64]*types.SignedMessage, tq float64, priority []address.Address) ([]*types.SignedMessage, error) {
+	if tq > 0.84 {
+		return mp.multiSelectMessagesGreedy(ctx, mp.curTS, ts, pending, priority)
+	}
+	return mp.multiSelectMessagesOptimal(ctx, mp.curTS, ts, tq, pending, priority)
+}
@@ -0,0 +1,102 @@
+package messagepool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/venus/pkg/constants"
+	tf "github.com/filecoin-project/venus/pkg/testhelpers/testflags"
+	"github.com/filecoin-project/venus/pkg/types"
+)
+
+// checkBlockMessages checks that the messages fit in a block and that the messages of a sender
+// have contiguous nonces from 0, the state nonce of the test actors.
+func checkBlockMessages(t *testing.T, msgs []*types.SignedMessage) map[address.Address]int {
+	t.Helper()
+
+	require.LessOrEqual(t, len(msgs), MaxBlockMessages)
+
+	gasLimit := int64(0)
+	counts := make(map[address.Address]int)
+	for _, m := range msgs {
+		gasLimit += m.Message.GasLimit
+		require.Equal(t, uint64(counts[m.Message.From]), m.Message.Nonce, "nonce gap for %s", m.Message.From)
+		counts[m.Message.From]++
+	}
+	require.LessOrEqual(t, gasLimit, int64(constants.BlockGasLimit))
+	return counts
+}
+
+// onlyLocalSelection selects the messages of the local addresses only.
+type onlyLocalSelection struct{}
+
+func (onlyLocalSelection) SelectMessages(ctx context.Context, mp *MessagePool, ts *types.TipSet, pending map[address.Address]map[uint64]*types.SignedMessage, tq float64) ([]*types.SignedMessage, error) {
+	for actor := range pending {
+		if _, ok := mp.localAddrs[actor]; !ok {
+			delete(pending, actor)
+		}
+	}
+	return DefaultSelectionStrategy.SelectMessages(ctx, mp, ts, pending, tq)
+}
+
+func TestRealWorldSelectionStrategies(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	mp, ts, _ := makeRealWorldMpool(t)
+
+	// the last actor of the default selection with at least 2 messages is local
+	selected, err := mp.SelectMessagesWith(ctx, ts, 1.0, DefaultSelection)
+	require.NoError(t, err)
+	counts := checkBlockMessages(t, selected)
+	var local address.Address
+	for i := len(selected) - 1; i >= 0; i-- {
+		if from := selected[i].Message.From; counts[from] >= 2 {
+			local = from
+			break
+		}
+	}
+	require.NotEqual(t, address.Undef, local)
+	require.NotEqual(t, local, selected[0].Message.From)
+	mp.lk.Lock()
+	require.NoError(t, mp.setLocal(ctx, local))
+	mp.lk.Unlock()
+
+	cfg := mp.GetConfig()
+	cfg.SelectLocalFirst = true
+	cfg.MaxSenderBlockMessages = 2
+	require.NoError(t, mp.SetConfig(cfg))
+
+	RegisterSelectionStrategy("only-local", onlyLocalSelection{})
+
+	for _, name := range SelectionStrategies() {
+		for _, tq := range []float64{1.0, .8, .1} {
+			selected, err := mp.SelectMessagesWith(ctx, ts, tq, name)
+			require.NoError(t, err)
+			require.NotEmpty(t, selected)
+			counts := checkBlockMessages(t, selected)
+
+			if name == "only-local" {
+				assert.Equal(t, map[address.Address]int{local: counts[local]}, counts)
+			}
+			if name != PrioritySelection {
+				continue
+			}
+			for from, count := range counts {
+				assert.LessOrEqual(t, count, 2, "too many messages from %s", from)
+			}
+			for i := 0; i < 2; i++ {
+				assert.Equal(t, local, selected[i].Message.From, "local messages must come first")
+			}
+		}
+	}
+
+	cfg.SelectionStrategy = "unknown"
+	assert.Error(t, mp.SetConfig(cfg))
+	_, err = mp.SelectMessagesWith(ctx, ts, 1, "unknown")
+	assert.Error(t, err)
+}
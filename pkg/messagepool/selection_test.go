@@ -1,11 +1,8 @@
 package messagepool
 
 import (
-	"compress/gzip"
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
 	"math"
 	"math/big"
 	"math/rand"
@@ -15,7 +12,6 @@ import (
 
 	"github.com/filecoin-project/go-address"
 	tbig "github.com/filecoin-project/go-state-types/big"
-	"github.com/filecoin-project/go-state-types/crypto"
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-datastore"
 	logging "github.com/ipfs/go-log"
@@ -1058,7 +1054,11 @@ func testCompetitiveMessageSelection(t *testing.T, rng *rand.Rand, getPremium fu
 	logging.SetLogLevel("messagepool", "error") // nolint: errcheck
 
 	// 1. greedy selection
-	greedyMsgs, err := mp.selectMessagesGreedy(context.Background(), ts, ts)
+	pending, err := mp.getPendingMessages(ts, ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	greedyMsgs, err := mp.multiSelectMessagesGreedy(context.Background(), ts, ts, pending, mp.cfg.PriorityAddrs)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -1247,61 +1247,38 @@ func TestGasReward(t *testing.T) {
 	}
 }
 
-type SignedMessage struct {
-	Message   types.UnsignedMessage
-	Signature crypto.Signature
-}
-
-func TestRealWorldSelection(t *testing.T) {
-	tf.UnitTest(t)
-
+// makeRealWorldMpool makes a pool with the pending messages of test-messages.json.gz, or of the
+// mpool export file set in MPOOL_TEST_MESSAGES, the senders are mapped to test actors and their
+// nonces start from 0.
+func makeRealWorldMpool(t *testing.T) (*MessagePool, *types.TipSet, map[address.Address]address.Address) {
 	// load test-messages.json.gz and rewrite the messages so that
 	// 1) we map each real actor to a test actor so that we can sign the messages
 	// 2) adjust the nonces so that they start from 0
-	file, err := os.Open("test-messages.json.gz")
+	path := "test-messages.json.gz"
+	if p := os.Getenv("MPOOL_TEST_MESSAGES"); p != "" {
+		path = p
+	}
+	file, err := os.Open(path)
 	if err != nil {
 		t.Fatal(err)
 	}
+	defer file.Close() //nolint:errcheck
 
-	gzr, err := gzip.NewReader(file)
+	msgs, err := ReadMessages(file)
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	dec := json.NewDecoder(gzr)
-
-	var msgs []*types.SignedMessage
 	baseNonces := make(map[address.Address]uint64)
-
-readLoop:
-	for {
-		m := new(SignedMessage)
-		err := dec.Decode(m)
-		switch err {
-		case nil:
-			sm := types.SignedMessage{
-				Message:   m.Message,
-				Signature: m.Signature,
-			}
-			msgs = append(msgs, &sm)
-
-			nonce, ok := baseNonces[m.Message.From]
-			if !ok || m.Message.Nonce < nonce {
-				baseNonces[m.Message.From] = m.Message.Nonce
-			}
-
-		case io.EOF:
-			break readLoop
-
-		default:
-			t.Fatal(err)
+	for _, m := range msgs {
+		nonce, ok := baseNonces[m.Message.From]
+		if !ok || m.Message.Nonce < nonce {
+			baseNonces[m.Message.From] = m.Message.Nonce
 		}
 	}
 
 	actorMap := make(map[address.Address]address.Address)
 	actorWallets := make(map[address.Address]*wallet.Wallet)
-	ctx := context.Background()
-
 	for _, m := range msgs {
 		baseNonce := baseNonces[m.Message.From]
 
@@ -1357,6 +1334,15 @@ readLoop:
 		mustAdd(t, mp, m)
 	}
 
+	return mp, ts, actorMap
+}
+
+func TestRealWorldSelection(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	mp, ts, _ := makeRealWorldMpool(t)
+
 	// do message selection and check block packing
 	minGasLimit := int64(0.9 * float64(constants.BlockGasLimit))
 
@@ -1431,3 +1417,101 @@ readLoop:
 	}
 
 }
+
+func TestSelectMessagesDebug(t *testing.T) {
+	tf.UnitTest(t)
+
+	mp, tma := makeTestMpool()
+
+	w := newWallet(t)
+	a1, err := w.NewAddress(address.SECP256K1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a2, err := w.NewAddress(address.SECP256K1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	block := tma.nextBlock()
+	ts := mkTipSet(block)
+	tma.applyBlock(t, block)
+
+	gasLimit := gasguess.Costs[gasguess.CostKey{Code: builtin2.StorageMarketActorCodeID, M: 2}]
+
+	tma.setBalance(a1, 1) // in FIL
+	tma.setBalance(a2, 1) // in FIL
+
+	// a1: nonces 0 and 1 are selectable, 3 is gapped and 4 depends on it
+	for _, nonce := range []uint64{0, 1, 3, 4} {
+		mustAdd(t, mp, makeTestMessage(w, a1, a2, nonce, gasLimit, 100))
+	}
+	// a2: the fee cap is below the base fee
+	mustAdd(t, mp, makeTestMessage(w, a2, a1, 0, gasLimit, 1))
+	tma.baseFee = tbig.NewInt(150)
+
+	report, err := mp.SelectMessagesDebug(context.Background(), ts, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reasons := make(map[address.Address]map[uint64]ExclusionReason)
+	for _, m := range report.Messages {
+		if reasons[m.From] == nil {
+			reasons[m.From] = make(map[uint64]ExclusionReason)
+		}
+		if m.Selected != (m.Reason == "") {
+			t.Fatalf("message %d from %s: selected %t with reason %q", m.Nonce, m.From, m.Selected, m.Reason)
+		}
+		reasons[m.From][m.Nonce] = m.Reason
+	}
+
+	expected := map[address.Address]map[uint64]ExclusionReason{
+		a1: {0: "", 1: "", 3: ReasonNonceGap, 4: ReasonDependency},
+		a2: {0: ReasonNegativePerf},
+	}
+	if fmt.Sprint(reasons) != fmt.Sprint(expected) {
+		t.Fatalf("expected reasons %v, got %v", expected, reasons)
+	}
+
+	// the selectable messages of a1 form a chain, the message of a2 another.
+	if len(report.Chains) != 2 {
+		t.Fatalf("expected 2 chains, got %d", len(report.Chains))
+	}
+	if report.Chains[0].From != a1 || len(report.Chains[0].Messages) != 2 || report.Chains[0].EffectivePerf <= 0 {
+		t.Fatalf("unexpected first chain %+v", report.Chains[0])
+	}
+	if report.Chains[1].GasPerf >= 0 {
+		t.Fatalf("expected a negative gas performance, got %f", report.Chains[1].GasPerf)
+	}
+	if report.GasLimit != 2*gasLimit {
+		t.Fatalf("expected a gas limit of %d, got %d", 2*gasLimit, report.GasLimit)
+	}
+
+	// the dry run goes through the strategy of the config
+	cfg := mp.GetConfig()
+	cfg.SelectionStrategy = PrioritySelection
+	cfg.MaxSenderBlockMessages = 1
+	if err := mp.SetConfig(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err = mp.SelectMessagesDebug(context.Background(), ts, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range report.Messages {
+		if m.From != a1 {
+			continue
+		}
+		if expected := (m.Nonce == 0); m.Selected != expected {
+			t.Fatalf("message %d from a1: expected selected %t, got %t", m.Nonce, expected, m.Selected)
+		}
+		if m.Nonce > 0 && m.Reason != ReasonSenderMessages {
+			t.Fatalf("message %d from a1: expected reason %q, got %q", m.Nonce, ReasonSenderMessages, m.Reason)
+		}
+	}
+	if report.GasLimit != gasLimit {
+		t.Fatalf("expected a gas limit of %d, got %d", gasLimit, report.GasLimit)
+	}
+}
@@ -0,0 +1,163 @@
+package messagepool
+
+import (
+	"bytes"
+	"context"
+	"sort"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus/pkg/types"
+)
+
+const pendingMsgsDs = "/mpool/pending"
+
+// savePending snapshots the pending messages to the datastore when PersistPending is set, the
+// PersistPendingLimit best messages by gas performance if it is set. The previous snapshot is replaced.
+func (mp *MessagePool) savePending(ctx context.Context) error {
+	cfg := mp.GetConfig()
+	if !cfg.PersistPending {
+		return nil
+	}
+
+	mp.curTSLk.Lock()
+	defer mp.curTSLk.Unlock()
+
+	mp.lk.Lock()
+	defer mp.lk.Unlock()
+
+	var msgs []*types.SignedMessage
+	if cfg.PersistPendingLimit > 0 {
+		var err error
+		if msgs, err = mp.bestPending(ctx, cfg.PersistPendingLimit); err != nil {
+			return err
+		}
+	} else {
+		msgs, _ = mp.allPending(ctx)
+	}
+
+	if err := mp.clearPendingSnapshot(); err != nil {
+		return err
+	}
+
+	batch, err := mp.pendingMsgs.Batch()
+	if err != nil {
+		return xerrors.Errorf("creating batch: %v", err)
+	}
+	for _, m := range msgs {
+		buf := new(bytes.Buffer)
+		if err := m.MarshalCBOR(buf); err != nil {
+			return xerrors.Errorf("error serializing message: %v", err)
+		}
+		if err := batch.Put(datastore.NewKey(string(m.Cid().Bytes())), buf.Bytes()); err != nil {
+			return xerrors.Errorf("persisting pending message: %v", err)
+		}
+	}
+	if err := batch.Commit(); err != nil {
+		return xerrors.Errorf("persisting pending messages: %v", err)
+	}
+
+	log.Infof("saved %d pending messages", len(msgs))
+	return nil
+}
+
+// bestPending returns at most limit pending messages taking the message chains by gas performance,
+// the messages that cannot be included on the current tipset are left out.
+// It must be called with mp.curTSLk and mp.lk held.
+func (mp *MessagePool) bestPending(ctx context.Context, limit int) ([]*types.SignedMessage, error) {
+	baseFee, err := mp.api.ChainComputeBaseFee(ctx, mp.curTS)
+	if err != nil {
+		return nil, xerrors.Errorf("computing basefee: %v", err)
+	}
+
+	var chains []*msgChain
+	mp.forEachPending(func(actor address.Address, mset *msgSet) {
+		chains = append(chains, mp.createMessageChains(actor, mset.msgs, baseFee, mp.curTS)...)
+	})
+	sort.Slice(chains, func(i, j int) bool {
+		return chains[i].Before(chains[j])
+	})
+
+	// the chains of an actor are sorted by decreasing performance, so a chain is always taken after
+	// the chain it depends on
+	var msgs []*types.SignedMessage
+	for _, chain := range chains {
+		if len(msgs)+len(chain.msgs) > limit {
+			msgs = append(msgs, chain.msgs[:limit-len(msgs)]...)
+			break
+		}
+		msgs = append(msgs, chain.msgs...)
+	}
+	return msgs, nil
+}
+
+// loadPending adds the messages of the pending snapshot revalidated against the current tipset, the
+// snapshot is removed once loaded. It must be called with mp.curTSLk and mp.lk held.
+func (mp *MessagePool) loadPending(ctx context.Context) error {
+	res, err := mp.pendingMsgs.Query(query.Query{})
+	if err != nil {
+		return xerrors.Errorf("query pending messages: %v", err)
+	}
+
+	var msgs []*types.SignedMessage
+	for r := range res.Next() {
+		if r.Error != nil {
+			return xerrors.Errorf("r.Error: %v", r.Error)
+		}
+
+		var sm types.SignedMessage
+		if err := sm.UnmarshalCBOR(bytes.NewReader(r.Value)); err != nil {
+			return xerrors.Errorf("unmarshaling pending message: %v", err)
+		}
+		msgs = append(msgs, &sm)
+	}
+
+	if len(msgs) == 0 {
+		return nil
+	}
+
+	// add the messages of an actor in nonce order so that no nonce gap is created
+	sortMessages(msgs)
+
+	loaded := 0
+	for _, m := range msgs {
+		local, err := mp.isLocal(ctx, m.Message.From)
+		if err != nil {
+			log.Debugf("loading pending message: %s", err)
+			continue
+		}
+		if local {
+			// local messages are loaded with loadLocal
+			continue
+		}
+
+		if err := mp.addLoaded(ctx, m, false); err != nil {
+			log.Debugf("dropping pending message %s: %s", m.Cid(), err)
+			continue
+		}
+		loaded++
+	}
+	log.Infof("loaded %d of %d pending messages", loaded, len(msgs))
+
+	return mp.clearPendingSnapshot()
+}
+
+func (mp *MessagePool) clearPendingSnapshot() error {
+	res, err := mp.pendingMsgs.Query(query.Query{KeysOnly: true})
+	if err != nil {
+		return xerrors.Errorf("query pending messages: %v", err)
+	}
+	entries, err := res.Rest()
+	if err != nil {
+		return xerrors.Errorf("query pending messages: %v", err)
+	}
+	for _, e := range entries {
+		if err := mp.pendingMsgs.Delete(datastore.RawKey(e.Key)); err != nil {
+			return xerrors.Errorf("deleting pending message: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package messagepool
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/venus/pkg/config"
+	tf "github.com/filecoin-project/venus/pkg/testhelpers/testflags"
+)
+
+func TestPersistPending(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	tma := newTestMpoolAPI()
+	ds := datastore.NewMapDatastore()
+	mp, err := New(tma, ds, config.DefaultForkUpgradeParam, config.DefaultMessagePoolParam, "mptest", nil, nil, nil)
+	require.NoError(t, err)
+
+	cfg := mp.GetConfig()
+	cfg.PersistPending = true
+	require.NoError(t, mp.SetConfig(cfg))
+
+	w := newWallet(t)
+	a1, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	a2, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	target := mkAddress(1001)
+	tma.setBalance(a1, 1)
+	tma.setBalance(a2, 1)
+	tma.nextBlock()
+
+	for i := uint64(0); i < 3; i++ {
+		mustAdd(t, mp, mkMessage(a1, target, i, w))
+	}
+	for i := uint64(0); i < 2; i++ {
+		mustAdd(t, mp, mkMessage(a2, target, i, w))
+	}
+	expected, _ := mp.Pending(ctx)
+	require.Len(t, expected, 5)
+	require.NoError(t, mp.Close())
+
+	countSnapshot := func() int {
+		res, err := ds.Query(query.Query{Prefix: pendingMsgsDs, KeysOnly: true})
+		require.NoError(t, err)
+		entries, err := res.Rest()
+		require.NoError(t, err)
+		return len(entries)
+	}
+	assert.Equal(t, 5, countSnapshot())
+
+	// the snapshot is loaded on start and removed.
+	mp, err = New(tma, ds, config.DefaultForkUpgradeParam, config.DefaultMessagePoolParam, "mptest", nil, nil, nil)
+	require.NoError(t, err)
+	loaded, _ := mp.Pending(ctx)
+	assert.ElementsMatch(t, expected, loaded)
+	assert.Equal(t, 0, countSnapshot())
+
+	// with a limit only the best messages are kept, in nonce order.
+	cfg = mp.GetConfig()
+	cfg.PersistPendingLimit = 2
+	require.NoError(t, mp.SetConfig(cfg))
+	require.NoError(t, mp.Close())
+	assert.Equal(t, 2, countSnapshot())
+
+	mp, err = New(tma, ds, config.DefaultForkUpgradeParam, config.DefaultMessagePoolParam, "mptest", nil, nil, nil)
+	require.NoError(t, err)
+	loaded, _ = mp.Pending(ctx)
+	require.Len(t, loaded, 2)
+	for _, m := range loaded {
+		assert.Equal(t, loaded[0].Message.From, m.Message.From)
+		assert.Less(t, m.Message.Nonce, uint64(2))
+	}
+
+	// nothing is persisted when disabled.
+	cfg = mp.GetConfig()
+	cfg.PersistPending = false
+	require.NoError(t, mp.SetConfig(cfg))
+	require.NoError(t, mp.Close())
+	assert.Equal(t, 0, countSnapshot())
+}
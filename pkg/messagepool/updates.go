@@ -0,0 +1,155 @@
+package messagepool
+
+import (
+	"context"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+)
+
+// MpoolUpdateFilter selects the updates of a subscription. The set fields must all match, an
+// empty filter matches every update.
+type MpoolUpdateFilter struct {
+	// From and To match the sender or the recipient of the message against any of the addresses
+	From []address.Address
+	To   []address.Address
+	// Methods matches the method of the message against any of the methods
+	Methods []abi.MethodNum
+	// MinValue matches the messages sending at least this value
+	MinValue abi.TokenAmount
+	// LocalOnly matches the messages from the local addresses
+	LocalOnly bool
+	// Republished also sends the MpoolRepublish updates, which are left out otherwise
+	Republished bool
+}
+
+type updateMatcher struct {
+	filter *MpoolUpdateFilter
+	from   map[address.Address]struct{}
+	to     map[address.Address]struct{}
+}
+
+func (um *updateMatcher) match(u MpoolUpdate) bool {
+	f := um.filter
+	if u.Type == MpoolRepublish && (f == nil || !f.Republished) {
+		return false
+	}
+	if f == nil {
+		return true
+	}
+
+	msg := &u.Message.Message
+	if f.LocalOnly && !u.Local {
+		return false
+	}
+	if len(um.from) > 0 {
+		if _, ok := um.from[msg.From]; !ok {
+			return false
+		}
+	}
+	if len(um.to) > 0 {
+		if _, ok := um.to[msg.To]; !ok {
+			return false
+		}
+	}
+	if len(f.Methods) > 0 {
+		found := false
+		for _, method := range f.Methods {
+			if msg.Method == method {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if !isUnset(f.MinValue) && big.Cmp(msg.Value, f.MinValue) < 0 {
+		return false
+	}
+	return true
+}
+
+// newUpdateMatcher indexes the addresses of the filter, a sender ID address also matches its key
+// address as the messages are usually sent from it.
+func (mp *MessagePool) newUpdateMatcher(ctx context.Context, filter *MpoolUpdateFilter) *updateMatcher {
+	um := &updateMatcher{filter: filter}
+	if filter == nil {
+		return um
+	}
+
+	um.from = make(map[address.Address]struct{}, len(filter.From))
+	um.to = make(map[address.Address]struct{}, len(filter.To))
+	for _, addr := range filter.From {
+		um.from[addr] = struct{}{}
+	}
+	for _, addr := range filter.To {
+		um.to[addr] = struct{}{}
+	}
+	if len(filter.From) == 0 {
+		return um
+	}
+
+	mp.curTSLk.Lock()
+	defer mp.curTSLk.Unlock()
+
+	mp.lk.Lock()
+	defer mp.lk.Unlock()
+
+	for _, addr := range filter.From {
+		ka, err := mp.resolveToKey(ctx, addr)
+		if err != nil {
+			log.Debugf("resolving filter address %s: %s", addr, err)
+			continue
+		}
+		um.from[ka] = struct{}{}
+	}
+	return um
+}
+
+// publishUpdate sends the update to the subscriptions, or holds it while a batch is added. It must
+// be called with mp.lk held.
+func (mp *MessagePool) publishUpdate(u MpoolUpdate) {
+	if mp.heldUpdates != nil {
+		*mp.heldUpdates = append(*mp.heldUpdates, u)
+		return
+	}
+	mp.changes.Pub(u, localUpdates)
+}
+
+// UpdatesFiltered streams the additions to and removals from the pool matching the filter, all the
+// additions and removals with a nil filter. The channel is closed when ctx is done or the pool is closed.
+func (mp *MessagePool) UpdatesFiltered(ctx context.Context, filter *MpoolUpdateFilter) (<-chan MpoolUpdate, error) {
+	um := mp.newUpdateMatcher(ctx, filter)
+
+	out := make(chan MpoolUpdate, 20)
+	sub := mp.changes.Sub(localUpdates)
+
+	go func() {
+		defer mp.changes.Unsub(sub, localUpdates)
+		defer close(out)
+
+		for {
+			select {
+			case u := <-sub:
+				if !um.match(u.(MpoolUpdate)) {
+					continue
+				}
+				select {
+				case out <- u.(MpoolUpdate):
+				case <-ctx.Done():
+					return
+				case <-mp.closer:
+					return
+				}
+			case <-ctx.Done():
+				return
+			case <-mp.closer:
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
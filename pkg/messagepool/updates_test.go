@@ -0,0 +1,265 @@
+package messagepool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tf "github.com/filecoin-project/venus/pkg/testhelpers/testflags"
+	"github.com/filecoin-project/venus/pkg/types"
+	"github.com/filecoin-project/venus/pkg/wallet"
+)
+
+func nextUpdate(t *testing.T, ch <-chan MpoolUpdate) MpoolUpdate {
+	t.Helper()
+
+	select {
+	case u, ok := <-ch:
+		require.True(t, ok, "expected update, but got a closed channel instead")
+		return u
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for an update")
+	}
+	return MpoolUpdate{}
+}
+
+func TestUpdatesFiltered(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tma := newTestMpoolAPI()
+	w, mp := newWalletAndMpool(t, tma)
+
+	a1, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	a2, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	target := mkAddress(1001)
+	tma.setBalance(a1, 1)
+	tma.setBalance(a2, 1)
+
+	local, err := mp.UpdatesFiltered(ctx, &MpoolUpdateFilter{LocalOnly: true})
+	require.NoError(t, err)
+	remote, err := mp.UpdatesFiltered(ctx, &MpoolUpdateFilter{
+		From:     []address.Address{a2},
+		To:       []address.Address{target},
+		Methods:  []abi.MethodNum{0},
+		MinValue: abi.NewTokenAmount(1),
+	})
+	require.NoError(t, err)
+
+	// remote message first, so that the local subscription would see it before the local ones.
+	m2 := mkMessage(a2, target, 0, w)
+	mustAdd(t, mp, m2)
+
+	var msgs []*types.SignedMessage
+	for i := uint64(0); i < 2; i++ {
+		m := mkMessage(a1, target, i, w)
+		_, err := mp.Push(ctx, m)
+		require.NoError(t, err)
+		msgs = append(msgs, m)
+	}
+
+	u := nextUpdate(t, remote)
+	assert.Equal(t, MpoolAdd, u.Type)
+	assert.Equal(t, m2.Cid(), u.Message.Cid())
+	assert.False(t, u.Local)
+
+	for _, m := range msgs {
+		u := nextUpdate(t, local)
+		assert.Equal(t, MpoolAdd, u.Type)
+		assert.Equal(t, m.Cid(), u.Message.Cid())
+		assert.True(t, u.Local)
+	}
+
+	// replace by fee
+	rbf := mkMessage(a1, target, 0, w)
+	rbf.Message.GasPremium = ComputeMinRBF(rbf.Message.GasPremium)
+	sig, err := w.WalletSign(a1, rbf.Message.Cid().Bytes(), wallet.MsgMeta{})
+	require.NoError(t, err)
+	rbf.Signature = *sig
+	_, err = mp.Push(ctx, rbf)
+	require.NoError(t, err)
+
+	u = nextUpdate(t, local)
+	assert.Equal(t, MpoolRemove, u.Type)
+	assert.Equal(t, RemoveReplaced, u.Reason)
+	assert.Equal(t, msgs[0].Cid(), u.Message.Cid())
+	require.NotNil(t, u.ReplacedBy)
+	assert.Equal(t, rbf.Cid(), *u.ReplacedBy)
+	u = nextUpdate(t, local)
+	assert.Equal(t, MpoolAdd, u.Type)
+	assert.Equal(t, rbf.Cid(), u.Message.Cid())
+
+	// inclusion, a2's nonce is used by another message
+	other := mkMessage(a2, mkAddress(1002), 0, w)
+	b := tma.nextBlock()
+	tma.setBlockMessages(b, rbf, msgs[1], other)
+	tma.applyBlock(t, b)
+
+	for _, m := range []*types.SignedMessage{rbf, msgs[1]} {
+		u := nextUpdate(t, local)
+		assert.Equal(t, MpoolRemove, u.Type)
+		assert.Equal(t, RemoveIncluded, u.Reason)
+		assert.Equal(t, m.Cid(), u.Message.Cid())
+		require.NotNil(t, u.TipSet)
+		assert.Equal(t, mkTipSet(b).Key(), *u.TipSet)
+		assert.Equal(t, b.Height, u.Height)
+		assert.Nil(t, u.ReplacedBy)
+	}
+
+	u = nextUpdate(t, remote)
+	assert.Equal(t, MpoolRemove, u.Type)
+	assert.Equal(t, RemoveReplaced, u.Reason)
+	assert.Equal(t, m2.Cid(), u.Message.Cid())
+	require.NotNil(t, u.ReplacedBy)
+	assert.Equal(t, other.Cid(), *u.ReplacedBy)
+
+	// messages out of the filters are not sent
+	mustAdd(t, mp, mkMessage(a2, mkAddress(1002), 1, w))
+	select {
+	case u := <-remote:
+		t.Fatalf("unexpected update %v", u)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestRejectedPushDoesNotMarkLocal(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	tma := newTestMpoolAPI()
+	w, mp := newWalletAndMpool(t, tma)
+
+	sender, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	target := mkAddress(1001)
+	tma.setBalance(sender, 1)
+
+	// the pending message relayed from the network can not be replaced at the same premium.
+	mustAdd(t, mp, mkMessage(sender, target, 0, w))
+	replace := mkMessage(sender, target, 0, w)
+	replace.Message.Value = abi.NewTokenAmount(2)
+	sig, err := w.WalletSign(sender, replace.Message.Cid().Bytes(), wallet.MsgMeta{})
+	require.NoError(t, err)
+	replace.Signature = *sig
+
+	_, err = mp.Push(ctx, replace)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ErrRBFTooLowPremium.Error())
+
+	mp.lk.Lock()
+	local, err := mp.isLocal(ctx, sender)
+	mp.lk.Unlock()
+	require.NoError(t, err)
+	assert.False(t, local)
+
+	_, err = mp.Push(ctx, mkMessage(sender, target, 1, w))
+	require.NoError(t, err)
+
+	mp.lk.Lock()
+	local, err = mp.isLocal(ctx, sender)
+	mp.lk.Unlock()
+	require.NoError(t, err)
+	assert.True(t, local)
+}
+
+func TestRepublishUpdates(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tma := newTestMpoolAPI()
+	w, mp := newWalletAndMpool(t, tma)
+
+	sender, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	tma.setBalance(sender, 1)
+
+	all, err := mp.Updates(ctx)
+	require.NoError(t, err)
+	republished, err := mp.UpdatesFiltered(ctx, &MpoolUpdateFilter{Republished: true})
+	require.NoError(t, err)
+
+	m := mkMessage(sender, mkAddress(1001), 0, w)
+	_, err = mp.Push(ctx, m)
+	require.NoError(t, err)
+	assert.Equal(t, MpoolAdd, nextUpdate(t, all).Type)
+	assert.Equal(t, MpoolAdd, nextUpdate(t, republished).Type)
+
+	require.NoError(t, mp.republishPendingMessages(ctx))
+
+	u := nextUpdate(t, republished)
+	assert.Equal(t, MpoolRepublish, u.Type)
+	assert.Equal(t, m.Cid(), u.Message.Cid())
+
+	// the subscriptions not asking for the republished messages do not see them
+	select {
+	case u := <-all:
+		t.Fatalf("unexpected update %v", u)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestExpiredUpdates(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	tma := newTestMpoolAPI()
+	w, mp := newWalletAndMpool(t, tma)
+
+	local, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	remote, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	target := mkAddress(1001)
+	tma.setBalance(local, 1)
+	tma.setBalance(remote, 1)
+
+	cfg := mp.GetConfig()
+	cfg.MessageTTL = 2
+	require.NoError(t, mp.SetConfig(cfg))
+
+	updates, err := mp.UpdatesFiltered(ctx, &MpoolUpdateFilter{})
+	require.NoError(t, err)
+
+	lm := mkMessage(local, target, 0, w)
+	_, err = mp.Push(ctx, lm)
+	require.NoError(t, err)
+	rm := mkMessage(remote, target, 0, w)
+	mustAdd(t, mp, rm)
+	assert.Equal(t, MpoolAdd, nextUpdate(t, updates).Type)
+	assert.Equal(t, MpoolAdd, nextUpdate(t, updates).Type)
+
+	mp.expireMessages(ctx)
+	tma.applyBlock(t, tma.nextBlock())
+	mp.expireMessages(ctx)
+	msgs, _ := mp.PendingFor(ctx, remote)
+	require.Len(t, msgs, 1)
+
+	tma.applyBlock(t, tma.nextBlock())
+	mp.expireMessages(ctx)
+
+	u := nextUpdate(t, updates)
+	assert.Equal(t, MpoolRemove, u.Type)
+	assert.Equal(t, RemoveExpired, u.Reason)
+	assert.Equal(t, rm.Cid(), u.Message.Cid())
+	assert.False(t, u.Local)
+
+	msgs, _ = mp.PendingFor(ctx, remote)
+	assert.Empty(t, msgs)
+	// the messages of the local addresses do not expire
+	msgs, _ = mp.PendingFor(ctx, local)
+	require.Len(t, msgs, 1)
+	assert.Equal(t, lm.Cid(), msgs[0].Cid())
+}
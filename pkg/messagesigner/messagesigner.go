@@ -31,25 +31,42 @@ type MpoolNonceAPI interface {
 // when signing a message
 type MessageSigner struct {
 	wallet wallet.WalletIntersection
-	lk     sync.Mutex
-	mpool  MpoolNonceAPI
-	ds     datastore.Batching
+	// lk guards addrLks, the messages of an address are signed under its lock in addrLks
+	lk      sync.Mutex
+	addrLks map[address.Address]*sync.Mutex
+	mpool   MpoolNonceAPI
+	ds      datastore.Batching
 }
 
 func NewMessageSigner(wallet wallet.WalletIntersection, mpool MpoolNonceAPI, ds types.MetadataDS) *MessageSigner {
 	ds = namespace.Wrap(ds, datastore.NewKey("/message-signer/"))
 	return &MessageSigner{
-		wallet: wallet,
-		mpool:  mpool,
-		ds:     ds,
+		wallet:  wallet,
+		addrLks: make(map[address.Address]*sync.Mutex),
+		mpool:   mpool,
+		ds:      ds,
 	}
 }
 
+// lockAddr locks the signing of the messages of addr and returns the unlock function. Signing can
+// wait, eg. for the approvals of a threshold key, so the other addresses are not locked.
+func (ms *MessageSigner) lockAddr(addr address.Address) func() {
+	ms.lk.Lock()
+	lk, ok := ms.addrLks[addr]
+	if !ok {
+		lk = new(sync.Mutex)
+		ms.addrLks[addr] = lk
+	}
+	ms.lk.Unlock()
+
+	lk.Lock()
+	return lk.Unlock
+}
+
 // SignMessage increments the nonce for the message From address, and signs
 // the message
 func (ms *MessageSigner) SignMessage(ctx context.Context, msg *types.Message, cb func(*types.SignedMessage) error) (*types.SignedMessage, error) {
-	ms.lk.Lock()
-	defer ms.lk.Unlock()
+	defer ms.lockAddr(msg.From)()
 
 	// Get the next message nonce
 	nonce, err := ms.nextNonce(ctx, msg.From)
@@ -60,24 +77,12 @@ func (ms *MessageSigner) SignMessage(ctx context.Context, msg *types.Message, cb
 	// Sign the message with the nonce
 	msg.Nonce = nonce
 
-	mb, err := msg.ToStorageBlock()
+	smsg, err := ms.sign(msg)
 	if err != nil {
-		return nil, xerrors.Errorf("serializing message: %w", err)
-	}
-
-	sig, err := ms.wallet.WalletSign(msg.From, mb.Cid().Bytes(), wallet.MsgMeta{
-		Type:  wallet.MTChainMsg,
-		Extra: mb.RawData(),
-	})
-	if err != nil {
-		return nil, xerrors.Errorf("failed to sign message: %w", err)
+		return nil, err
 	}
 
 	// Callback with the signed message
-	smsg := &types.SignedMessage{
-		Message:   *msg,
-		Signature: *sig,
-	}
 	err = cb(smsg)
 	if err != nil {
 		return nil, err
@@ -91,6 +96,85 @@ func (ms *MessageSigner) SignMessage(ctx context.Context, msg *types.Message, cb
 	return smsg, nil
 }
 
+// SignMessages reserves a range of contiguous nonces for the messages of a sender and signs them in
+// order. prepare is called with the first nonce of the range once the nonces are set, to complete
+// the messages before they are signed, and cb with all the signed messages: the nonces are only
+// used up if both succeed, a failure leaves them all unassigned.
+func (ms *MessageSigner) SignMessages(ctx context.Context, msgs []*types.Message, prepare func(nonce uint64) error, cb func([]*types.SignedMessage) error) ([]*types.SignedMessage, error) {
+	if len(msgs) == 0 {
+		return nil, xerrors.New("no message to sign")
+	}
+	from := msgs[0].From
+	for _, msg := range msgs[1:] {
+		if msg.From != from {
+			return nil, xerrors.Errorf("messages are from both %s and %s", from, msg.From)
+		}
+	}
+
+	defer ms.lockAddr(from)()
+
+	nonce, err := ms.nextNonce(ctx, from)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create nonce: %w", err)
+	}
+	for i, msg := range msgs {
+		msg.Nonce = nonce + uint64(i)
+	}
+
+	if err := prepare(nonce); err != nil {
+		return nil, err
+	}
+
+	smsgs := make([]*types.SignedMessage, 0, len(msgs))
+	for i, msg := range msgs {
+		// prepare must not change the nonces of the reserved range
+		msg.Nonce = nonce + uint64(i)
+		smsg, err := ms.sign(msg)
+		if err != nil {
+			return nil, xerrors.Errorf("signing message %d: %w", i, err)
+		}
+		smsgs = append(smsgs, smsg)
+	}
+
+	if err := cb(smsgs); err != nil {
+		return nil, err
+	}
+
+	if err := ms.saveNonce(from, nonce+uint64(len(msgs))-1); err != nil {
+		return nil, xerrors.Errorf("failed to save nonce: %w", err)
+	}
+
+	return smsgs, nil
+}
+
+// ResignMessage signs a message keeping its nonce, it is used to replace a message
+// already in the message pool and does not change the tracked nonce.
+func (ms *MessageSigner) ResignMessage(ctx context.Context, msg *types.Message) (*types.SignedMessage, error) {
+	defer ms.lockAddr(msg.From)()
+
+	return ms.sign(msg)
+}
+
+func (ms *MessageSigner) sign(msg *types.Message) (*types.SignedMessage, error) {
+	mb, err := msg.ToStorageBlock()
+	if err != nil {
+		return nil, xerrors.Errorf("serializing message: %w", err)
+	}
+
+	sig, err := ms.wallet.WalletSign(msg.From, mb.Cid().Bytes(), wallet.MsgMeta{
+		Type:  wallet.MTChainMsg,
+		Extra: mb.RawData(),
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("failed to sign message: %w", err)
+	}
+
+	return &types.SignedMessage{
+		Message:   *msg,
+		Signature: *sig,
+	}, nil
+}
+
 // nextNonce gets the next nonce for the given address.
 // If there is no nonce in the datastore, gets the nonce from the message pool.
 func (ms *MessageSigner) nextNonce(ctx context.Context, addr address.Address) (uint64, error) {
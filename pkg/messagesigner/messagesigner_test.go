@@ -18,6 +18,7 @@ import (
 
 	"github.com/filecoin-project/go-address"
 
+	"github.com/filecoin-project/venus/pkg/crypto"
 	tf "github.com/filecoin-project/venus/pkg/testhelpers/testflags"
 	"github.com/filecoin-project/venus/pkg/types"
 	"github.com/ipfs/go-datastore"
@@ -216,3 +217,141 @@ func TestMessageSignerSignMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestMessageSignerResignMessage(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	r := repo.NewInMemoryRepo()
+	backend, err := wallet.NewDSBackend(r.WalletDatastore(), r.Config().Wallet.PassphraseConfig, wallet.TestPassword)
+	assert.NoError(t, err)
+
+	w := wallet.New(backend)
+	from, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	to, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+
+	mpool := newMockMpool()
+	ms := NewMessageSigner(w, mpool, ds_sync.MutexWrap(datastore.NewMapDatastore()))
+
+	smsg, err := ms.ResignMessage(ctx, &types.Message{To: to, From: from, Nonce: 5})
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), smsg.Message.Nonce)
+	c := smsg.Message.Cid()
+	require.NoError(t, crypto.Verify(&smsg.Signature, from, c.Bytes()))
+
+	// the tracked nonce is unchanged
+	smsg, err = ms.SignMessage(ctx, &types.Message{To: to, From: from}, func(*types.SignedMessage) error { return nil })
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), smsg.Message.Nonce)
+}
+
+func TestMessageSignerSignMessages(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	r := repo.NewInMemoryRepo()
+	backend, err := wallet.NewDSBackend(r.WalletDatastore(), r.Config().Wallet.PassphraseConfig, wallet.TestPassword)
+	assert.NoError(t, err)
+
+	w := wallet.New(backend)
+	from, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	other, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	to, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+
+	mpool := newMockMpool()
+	mpool.setNonce(from, 3)
+	ms := NewMessageSigner(w, mpool, ds_sync.MutexWrap(datastore.NewMapDatastore()))
+
+	newMsgs := func() []*types.Message {
+		return []*types.Message{{To: to, From: from}, {To: to, From: from}, {To: to, From: from}}
+	}
+	noop := func([]*types.SignedMessage) error { return nil }
+
+	// a failure leaves the nonces unassigned
+	_, err = ms.SignMessages(ctx, newMsgs(), func(uint64) error { return xerrors.Errorf("estimate failed") }, noop)
+	require.Error(t, err)
+	_, err = ms.SignMessages(ctx, newMsgs(), func(uint64) error { return nil }, func([]*types.SignedMessage) error {
+		return xerrors.Errorf("push failed")
+	})
+	require.Error(t, err)
+
+	var prepared uint64
+	smsgs, err := ms.SignMessages(ctx, newMsgs(), func(nonce uint64) error {
+		prepared = nonce
+		return nil
+	}, noop)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), prepared)
+	require.Len(t, smsgs, 3)
+	for i, smsg := range smsgs {
+		assert.Equal(t, uint64(3+i), smsg.Message.Nonce)
+		c := smsg.Message.Cid()
+		require.NoError(t, crypto.Verify(&smsg.Signature, from, c.Bytes()))
+	}
+
+	// the next message follows the range
+	smsg, err := ms.SignMessage(ctx, &types.Message{To: to, From: from}, func(*types.SignedMessage) error { return nil })
+	require.NoError(t, err)
+	assert.Equal(t, uint64(6), smsg.Message.Nonce)
+
+	_, err = ms.SignMessages(ctx, []*types.Message{{To: to, From: from}, {To: to, From: other}}, func(uint64) error { return nil }, noop)
+	require.Error(t, err)
+}
+
+// blockingWallet blocks the signing with an address until unblock is closed.
+type blockingWallet struct {
+	wallet.WalletIntersection
+	blocked address.Address
+	signing chan struct{}
+	unblock chan struct{}
+}
+
+func (w *blockingWallet) WalletSign(addr address.Address, msg []byte, meta wallet.MsgMeta) (*crypto.Signature, error) {
+	if addr == w.blocked {
+		close(w.signing)
+		<-w.unblock
+	}
+	return w.WalletIntersection.WalletSign(addr, msg, meta)
+}
+
+func TestMessageSignerLocksPerAddress(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	r := repo.NewInMemoryRepo()
+	backend, err := wallet.NewDSBackend(r.WalletDatastore(), r.Config().Wallet.PassphraseConfig, wallet.TestPassword)
+	assert.NoError(t, err)
+
+	w := wallet.New(backend)
+	from, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	other, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+
+	bw := &blockingWallet{WalletIntersection: w, blocked: from, signing: make(chan struct{}), unblock: make(chan struct{})}
+	ms := NewMessageSigner(bw, newMockMpool(), ds_sync.MutexWrap(datastore.NewMapDatastore()))
+	noop := func(*types.SignedMessage) error { return nil }
+
+	done := make(chan error)
+	go func() {
+		_, err := ms.SignMessage(ctx, &types.Message{To: other, From: from}, noop)
+		done <- err
+	}()
+	<-bw.signing
+
+	// the messages of another address are signed while signing with from waits
+	smsg, err := ms.SignMessage(ctx, &types.Message{To: from, From: other}, noop)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), smsg.Message.Nonce)
+
+	close(bw.unblock)
+	require.NoError(t, <-done)
+	smsg, err = ms.SignMessage(ctx, &types.Message{To: other, From: from}, noop)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), smsg.Message.Nonce)
+}
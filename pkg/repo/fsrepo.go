@@ -17,6 +17,7 @@ import (
 	bstore "github.com/ipfs/go-ipfs-blockstore"
 
 	"github.com/filecoin-project/go-multistore"
+	"github.com/filecoin-project/go-state-types/abi"
 	badgerds "github.com/ipfs/go-ds-badger2"
 	lockfile "github.com/ipfs/go-fs-lock"
 	logging "github.com/ipfs/go-log/v2"
@@ -24,6 +25,7 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/filecoin-project/venus/pkg/config"
+	"github.com/filecoin-project/venus/pkg/constants"
 )
 
 // Version is the version of repo schema that this code understands.
@@ -44,6 +46,7 @@ const (
 	snapshotStorePrefix    = "snapshots"
 	snapshotFilenamePrefix = "snapshot"
 	dataTransfer           = "data-transfer"
+	splitStoreMarkSetDir   = "splitstore-markset"
 )
 
 var log = logging.Logger("repo")
@@ -58,13 +61,14 @@ type FSRepo struct {
 	lk  sync.RWMutex
 	cfg *config.Config
 
-	ds        *blockstoreutil.BadgerBlockstore
-	stagingDs Datastore
-	mds       *multistore.MultiStore
-	keystore  fskeystore.Keystore
-	walletDs  Datastore
-	chainDs   Datastore
-	metaDs    Datastore
+	ds         *blockstoreutil.BadgerBlockstore
+	splitstore *blockstoreutil.SplitStore
+	stagingDs  Datastore
+	mds        *multistore.MultiStore
+	keystore   fskeystore.Keystore
+	walletDs   Datastore
+	chainDs    Datastore
+	metaDs     Datastore
 	//marketDs  Datastore
 	paychDs Datastore
 	// lockfile is the file system lock to prevent others from opening the same repo.
@@ -250,6 +254,10 @@ func (r *FSRepo) loadFromDisk() error {
 		return errors.Wrap(err, "failed to open metadata datastore")
 	}
 
+	if err := r.openSplitStore(); err != nil {
+		return errors.Wrap(err, "failed to open splitstore")
+	}
+
 	if err := r.openMultiStore(); err != nil {
 		return errors.Wrap(err, "failed to open staging datastore")
 	}
@@ -309,6 +317,9 @@ func (r *FSRepo) SnapshotConfig(cfg *config.Config) error {
 
 // Datastore returns the datastore.
 func (r *FSRepo) Datastore() blockstoreutil.Blockstore {
+	if r.splitstore != nil {
+		return r.splitstore
+	}
 	return r.ds
 }
 
@@ -346,6 +357,12 @@ func (r *FSRepo) Keystore() fskeystore.Keystore {
 
 // Close closes the repo.
 func (r *FSRepo) Close() error {
+	if r.splitstore != nil {
+		if err := r.splitstore.Close(); err != nil {
+			return errors.Wrap(err, "failed to close splitstore")
+		}
+	}
+
 	if err := r.ds.Close(); err != nil {
 		return errors.Wrap(err, "failed to close datastore")
 	}
@@ -432,13 +449,11 @@ func (r *FSRepo) readVersion() (uint, error) {
 func (r *FSRepo) openDatastore() error {
 	switch r.cfg.Datastore.Type {
 	case "badgerds":
-		path := filepath.Join(r.path, r.cfg.Datastore.Path)
-		opts, err := blockstoreutil.BadgerBlockstoreOptions(path, false)
-		if err != nil {
+		path := r.BlockstorePath()
+		if err := recoverBlockstore(path); err != nil {
 			return err
 		}
-		opts.Prefix = bstore.BlockPrefix.String()
-		ds, err := blockstoreutil.Open(opts)
+		ds, err := OpenBadgerBlockstore(path, false)
 		if err != nil {
 			return err
 		}
@@ -450,6 +465,102 @@ func (r *FSRepo) openDatastore() error {
 	return nil
 }
 
+// BlockstorePath returns the path of the chain blockstore.
+func (r *FSRepo) BlockstorePath() string {
+	return filepath.Join(r.path, r.cfg.Datastore.Path)
+}
+
+// OpenBadgerBlockstore opens the badger blockstore at path with the options used for the chain blockstore.
+func OpenBadgerBlockstore(path string, readonly bool) (*blockstoreutil.BadgerBlockstore, error) {
+	opts, err := blockstoreutil.BadgerBlockstoreOptions(path, readonly)
+	if err != nil {
+		return nil, err
+	}
+	opts.Prefix = bstore.BlockPrefix.String()
+	return blockstoreutil.Open(opts)
+}
+
+// ReplaceBlockstore moves the blockstore at newPath to path, replacing the
+// blockstore there. Neither of them may be open. If the process stops in the
+// middle, the old blockstore is restored the next time the repo is opened.
+func ReplaceBlockstore(path, newPath string) error {
+	oldPath := path + ".old"
+	if err := os.RemoveAll(oldPath); err != nil {
+		return err
+	}
+	if err := os.Rename(path, oldPath); err != nil {
+		return errors.Wrap(err, "failed to move away old blockstore")
+	}
+	if err := os.Rename(newPath, path); err != nil {
+		return errors.Wrap(err, "failed to move new blockstore in place")
+	}
+	return os.RemoveAll(oldPath)
+}
+
+// recoverBlockstore restores the blockstore left behind by an interrupted ReplaceBlockstore.
+func recoverBlockstore(path string) error {
+	oldPath := path + ".old"
+	if _, err := os.Stat(oldPath); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		// the replacement completed, the old blockstore just was not removed yet.
+		return os.RemoveAll(oldPath)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	log.Warnf("restoring blockstore %s from interrupted replacement", path)
+	return os.Rename(oldPath, path)
+}
+
+func (r *FSRepo) openSplitStore() error {
+	cfg := r.cfg.Datastore.SplitStore
+	if cfg == nil || !cfg.Enable {
+		return nil
+	}
+
+	if cfg.HotStoreFinalities < 1 {
+		return fmt.Errorf("invalid splitstore hot store finalities: %d", cfg.HotStoreFinalities)
+	}
+	if cfg.CompactionFinalities < 1 {
+		return fmt.Errorf("invalid splitstore compaction finalities: %d", cfg.CompactionFinalities)
+	}
+	if cfg.ColdStoreFinalities != 0 && cfg.ColdStoreFinalities < cfg.HotStoreFinalities {
+		return fmt.Errorf("invalid splitstore cold store finalities: %d", cfg.ColdStoreFinalities)
+	}
+
+	var cold blockstoreutil.Blockstore
+	switch cfg.ColdStoreType {
+	case "badger":
+		var err error
+		cold, err = OpenBadgerBlockstore(filepath.Join(r.path, cfg.ColdStorePath), false)
+		if err != nil {
+			return err
+		}
+	case "discard":
+	default:
+		return fmt.Errorf("unknown splitstore cold store type in config: %s", cfg.ColdStoreType)
+	}
+
+	ss, err := blockstoreutil.NewSplitStore(r.ds, cold, r.metaDs, blockstoreutil.SplitStoreOptions{
+		HotStoreEpochs:      abi.ChainEpoch(cfg.HotStoreFinalities) * constants.Finality,
+		CompactionThreshold: abi.ChainEpoch(cfg.CompactionFinalities) * constants.Finality,
+		ColdStoreEpochs:     abi.ChainEpoch(cfg.ColdStoreFinalities) * constants.Finality,
+		MarkSetPath:         filepath.Join(r.path, splitStoreMarkSetDir),
+	})
+	if err != nil {
+		return err
+	}
+	r.splitstore = ss
+
+	return nil
+}
+
 func (r *FSRepo) openKeystore() error {
 	ksp := filepath.Join(r.path, "keystore")
 
@@ -10,6 +10,7 @@ import (
 	"strings"
 	"testing"
 
+	blocks "github.com/ipfs/go-block-format"
 	ds "github.com/ipfs/go-datastore"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -372,3 +373,87 @@ func ConfigExists(dir string) bool {
 	}
 	return err == nil
 }
+
+func TestReplaceBlockstore(t *testing.T) {
+	tf.UnitTest(t)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "badger")
+	newPath := filepath.Join(dir, "badger.new")
+	oldBlk := blocks.NewBlock([]byte("old"))
+	newBlk := blocks.NewBlock([]byte("new"))
+	putBlock(t, path, oldBlk)
+	putBlock(t, newPath, newBlk)
+
+	require.NoError(t, ReplaceBlockstore(path, newPath))
+
+	bs, err := OpenBadgerBlockstore(path, true)
+	require.NoError(t, err)
+	defer bs.Close() // nolint: errcheck
+	has, err := bs.Has(newBlk.Cid())
+	require.NoError(t, err)
+	assert.True(t, has)
+	has, err = bs.Has(oldBlk.Cid())
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	for _, p := range []string{newPath, path + ".old"} {
+		_, err := os.Stat(p)
+		assert.True(t, os.IsNotExist(err), p)
+	}
+}
+
+func TestRecoverBlockstore(t *testing.T) {
+	tf.UnitTest(t)
+
+	t.Run("interrupted before the new blockstore is moved in place", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "badger")
+		blk := blocks.NewBlock([]byte("old"))
+		putBlock(t, path, blk)
+		require.NoError(t, os.Rename(path, path+".old"))
+
+		require.NoError(t, recoverBlockstore(path))
+
+		bs, err := OpenBadgerBlockstore(path, true)
+		require.NoError(t, err)
+		defer bs.Close() // nolint: errcheck
+		has, err := bs.Has(blk.Cid())
+		require.NoError(t, err)
+		assert.True(t, has)
+		_, err = os.Stat(path + ".old")
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("interrupted before the old blockstore is removed", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "badger")
+		oldBlk := blocks.NewBlock([]byte("old"))
+		newBlk := blocks.NewBlock([]byte("new"))
+		putBlock(t, path+".old", oldBlk)
+		putBlock(t, path, newBlk)
+
+		require.NoError(t, recoverBlockstore(path))
+
+		bs, err := OpenBadgerBlockstore(path, true)
+		require.NoError(t, err)
+		defer bs.Close() // nolint: errcheck
+		has, err := bs.Has(newBlk.Cid())
+		require.NoError(t, err)
+		assert.True(t, has)
+		_, err = os.Stat(path + ".old")
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("no replacement", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "badger")
+		require.NoError(t, recoverBlockstore(path))
+		_, err := os.Stat(path)
+		assert.True(t, os.IsNotExist(err))
+	})
+}
+
+func putBlock(t *testing.T, path string, blk blocks.Block) {
+	bs, err := OpenBadgerBlockstore(path, false)
+	require.NoError(t, err)
+	require.NoError(t, bs.Put(blk))
+	require.NoError(t, bs.Close())
+}
@@ -0,0 +1,54 @@
+package builtin_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/venus/pkg/specactors"
+	"github.com/filecoin-project/venus/pkg/specactors/adt"
+	"github.com/filecoin-project/venus/pkg/specactors/builtin"
+	"github.com/filecoin-project/venus/pkg/specactors/builtin/cron"
+	"github.com/filecoin-project/venus/pkg/specactors/builtin/system"
+	tf "github.com/filecoin-project/venus/pkg/testhelpers/testflags"
+	"github.com/filecoin-project/venus/pkg/types"
+	"github.com/filecoin-project/venus/pkg/util/blockstoreutil"
+)
+
+func TestLoadActorState(t *testing.T) {
+	tf.UnitTest(t)
+
+	store := adt.WrapStore(context.Background(), cbor.NewCborStore(blockstoreutil.NewTemporary()))
+	for _, v := range specactors.Versions {
+		av := specactors.Version(v)
+
+		cronState, err := cron.MakeState(store, av)
+		require.NoError(t, err)
+		cronCode, err := cron.GetActorCodeID(av)
+		require.NoError(t, err)
+
+		systemState, err := system.MakeState(store, av)
+		require.NoError(t, err)
+		systemCode, err := system.GetActorCodeID(av)
+		require.NoError(t, err)
+
+		for code, state := range map[cid.Cid]interface{}{cronCode: cronState, systemCode: systemState} {
+			head, err := store.Put(store.Context(), state)
+			require.NoError(t, err)
+
+			loaded, err := builtin.Load(store, &types.Actor{Code: code, Head: head})
+			require.NoError(t, err, "loading %s", builtin.ActorNameByCode(code))
+
+			expected, err := json.Marshal(state)
+			require.NoError(t, err)
+			actual, err := json.Marshal(loaded)
+			require.NoError(t, err)
+			assert.JSONEq(t, string(expected), string(actual))
+		}
+	}
+}
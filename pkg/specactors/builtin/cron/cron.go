@@ -1,11 +1,14 @@
 package cron
 
 import (
+	"github.com/filecoin-project/go-state-types/cbor"
 	"github.com/filecoin-project/venus/pkg/specactors"
 	"github.com/filecoin-project/venus/pkg/specactors/adt"
 	"github.com/ipfs/go-cid"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/venus/pkg/specactors/builtin"
+
 	builtin0 "github.com/filecoin-project/specs-actors/actors/builtin"
 
 	builtin2 "github.com/filecoin-project/specs-actors/v2/actors/builtin"
@@ -17,6 +20,29 @@ import (
 	builtin5 "github.com/filecoin-project/specs-actors/v5/actors/builtin"
 )
 
+func init() {
+
+	builtin.RegisterActorState(builtin0.CronActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
+		return load0(store, root)
+	})
+
+	builtin.RegisterActorState(builtin2.CronActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
+		return load2(store, root)
+	})
+
+	builtin.RegisterActorState(builtin3.CronActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
+		return load3(store, root)
+	})
+
+	builtin.RegisterActorState(builtin4.CronActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
+		return load4(store, root)
+	})
+
+	builtin.RegisterActorState(builtin5.CronActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
+		return load5(store, root)
+	})
+}
+
 func MakeState(store adt.Store, av specactors.Version) (State, error) {
 	switch av {
 
@@ -68,5 +94,7 @@ var (
 )
 
 type State interface {
+	cbor.Marshaler
+
 	GetState() interface{}
 }
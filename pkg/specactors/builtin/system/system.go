@@ -1,11 +1,14 @@
 package system
 
 import (
+	"github.com/filecoin-project/go-state-types/cbor"
 	"github.com/filecoin-project/venus/pkg/specactors"
 	"github.com/filecoin-project/venus/pkg/specactors/adt"
 	"github.com/ipfs/go-cid"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/venus/pkg/specactors/builtin"
+
 	builtin0 "github.com/filecoin-project/specs-actors/actors/builtin"
 
 	builtin2 "github.com/filecoin-project/specs-actors/v2/actors/builtin"
@@ -17,6 +20,29 @@ import (
 	builtin5 "github.com/filecoin-project/specs-actors/v5/actors/builtin"
 )
 
+func init() {
+
+	builtin.RegisterActorState(builtin0.SystemActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
+		return load0(store, root)
+	})
+
+	builtin.RegisterActorState(builtin2.SystemActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
+		return load2(store, root)
+	})
+
+	builtin.RegisterActorState(builtin3.SystemActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
+		return load3(store, root)
+	})
+
+	builtin.RegisterActorState(builtin4.SystemActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
+		return load4(store, root)
+	})
+
+	builtin.RegisterActorState(builtin5.SystemActorCodeID, func(store adt.Store, root cid.Cid) (cbor.Marshaler, error) {
+		return load5(store, root)
+	})
+}
+
 var (
 	Address = builtin5.SystemActorAddr
 )
@@ -67,5 +93,7 @@ func GetActorCodeID(av specactors.Version) (cid.Cid, error) {
 }
 
 type State interface {
+	cbor.Marshaler
+
 	GetState() interface{}
 }
@@ -0,0 +1,357 @@
+package state
+
+import (
+	"reflect"
+
+	addr "github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	xerrors "github.com/pkg/errors"
+
+	"github.com/filecoin-project/venus/pkg/specactors/adt"
+	"github.com/filecoin-project/venus/pkg/specactors/builtin"
+	"github.com/filecoin-project/venus/pkg/specactors/builtin/market"
+	"github.com/filecoin-project/venus/pkg/specactors/builtin/miner"
+	"github.com/filecoin-project/venus/pkg/specactors/builtin/power"
+	"github.com/filecoin-project/venus/pkg/types"
+)
+
+// ValueChange is a field of an actor state whose value changed.
+type ValueChange struct {
+	From interface{}
+	To   interface{}
+}
+
+// BalanceChange is an entry of a balance table whose amount changed, a zero amount
+// stands for a missing entry.
+type BalanceChange struct {
+	Address addr.Address
+	From    abi.TokenAmount
+	To      abi.TokenAmount
+}
+
+// ActorStateDiff is the decoded difference between two states of a builtin actor,
+// only the field matching the actor is set.
+type ActorStateDiff struct {
+	Miner  *MinerStateDiff  `json:",omitempty"`
+	Market *MarketStateDiff `json:",omitempty"`
+	Power  *PowerStateDiff  `json:",omitempty"`
+}
+
+// MinerStateDiff is the difference between two states of a miner actor, unchanged fields are nil.
+type MinerStateDiff struct {
+	Info        *ValueChange            `json:",omitempty"`
+	LockedFunds *ValueChange            `json:",omitempty"`
+	FeeDebt     *ValueChange            `json:",omitempty"`
+	PreCommits  *miner.PreCommitChanges `json:",omitempty"`
+	Sectors     *miner.SectorChanges    `json:",omitempty"`
+}
+
+// MarketStateDiff is the difference between two states of the storage market actor, unchanged fields are nil.
+type MarketStateDiff struct {
+	TotalLocked *ValueChange                `json:",omitempty"`
+	Escrow      []BalanceChange             `json:",omitempty"`
+	Locked      []BalanceChange             `json:",omitempty"`
+	Proposals   *market.DealProposalChanges `json:",omitempty"`
+	States      *market.DealStateChanges    `json:",omitempty"`
+}
+
+// PowerStateDiff is the difference between two states of the storage power actor, unchanged fields are nil.
+type PowerStateDiff struct {
+	TotalPower     *ValueChange        `json:",omitempty"`
+	TotalCommitted *ValueChange        `json:",omitempty"`
+	MinerCount     *ValueChange        `json:",omitempty"`
+	Claims         *power.ClaimChanges `json:",omitempty"`
+}
+
+// DiffActorState decodes the changes between two states of the actor at address, it returns
+// nil for the actors other than the miners, the storage market and the storage power actor.
+// Like the state predicates, the sub-structures are only diffed when their roots changed.
+func DiffActorState(store adt.Store, address addr.Address, pre, cur *types.Actor) (*ActorStateDiff, error) {
+	switch {
+	case builtin.IsStorageMinerActor(pre.Code) && builtin.IsStorageMinerActor(cur.Code):
+		preState, err := miner.Load(store, pre)
+		if err != nil {
+			return nil, xerrors.Wrapf(err, "loading miner state %s", pre.Head)
+		}
+		curState, err := miner.Load(store, cur)
+		if err != nil {
+			return nil, xerrors.Wrapf(err, "loading miner state %s", cur.Head)
+		}
+		diff, err := diffMinerState(preState, curState)
+		if err != nil {
+			return nil, xerrors.Wrapf(err, "diffing miner %s", address)
+		}
+		return &ActorStateDiff{Miner: diff}, nil
+	case address == market.Address:
+		preState, err := market.Load(store, pre)
+		if err != nil {
+			return nil, xerrors.Wrapf(err, "loading market state %s", pre.Head)
+		}
+		curState, err := market.Load(store, cur)
+		if err != nil {
+			return nil, xerrors.Wrapf(err, "loading market state %s", cur.Head)
+		}
+		diff, err := diffMarketState(preState, curState)
+		if err != nil {
+			return nil, xerrors.Wrap(err, "diffing market")
+		}
+		return &ActorStateDiff{Market: diff}, nil
+	case address == power.Address:
+		preState, err := power.Load(store, pre)
+		if err != nil {
+			return nil, xerrors.Wrapf(err, "loading power state %s", pre.Head)
+		}
+		curState, err := power.Load(store, cur)
+		if err != nil {
+			return nil, xerrors.Wrapf(err, "loading power state %s", cur.Head)
+		}
+		diff, err := diffPowerState(preState, curState)
+		if err != nil {
+			return nil, xerrors.Wrap(err, "diffing power")
+		}
+		return &ActorStateDiff{Power: diff}, nil
+	}
+	return nil, nil
+}
+
+func diffMinerState(pre, cur miner.State) (*MinerStateDiff, error) {
+	diff := &MinerStateDiff{}
+
+	changed, err := pre.MinerInfoChanged(cur)
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		preInfo, err := pre.Info()
+		if err != nil {
+			return nil, err
+		}
+		curInfo, err := cur.Info()
+		if err != nil {
+			return nil, err
+		}
+		diff.Info = valueChange(preInfo, curInfo)
+	}
+
+	preFunds, err := pre.LockedFunds()
+	if err != nil {
+		return nil, err
+	}
+	curFunds, err := cur.LockedFunds()
+	if err != nil {
+		return nil, err
+	}
+	diff.LockedFunds = valueChange(preFunds, curFunds)
+
+	preDebt, err := pre.FeeDebt()
+	if err != nil {
+		return nil, err
+	}
+	curDebt, err := cur.FeeDebt()
+	if err != nil {
+		return nil, err
+	}
+	diff.FeeDebt = valueChange(preDebt, curDebt)
+
+	preCommits, err := miner.DiffPreCommits(pre, cur)
+	if err != nil {
+		return nil, xerrors.Wrap(err, "diffing precommits")
+	}
+	if len(preCommits.Added)+len(preCommits.Removed) > 0 {
+		diff.PreCommits = preCommits
+	}
+
+	sectors, err := miner.DiffSectors(pre, cur)
+	if err != nil {
+		return nil, xerrors.Wrap(err, "diffing sectors")
+	}
+	if len(sectors.Added)+len(sectors.Extended)+len(sectors.Removed) > 0 {
+		diff.Sectors = sectors
+	}
+	return diff, nil
+}
+
+func diffMarketState(pre, cur market.State) (*MarketStateDiff, error) {
+	diff := &MarketStateDiff{}
+
+	preLocked, err := pre.TotalLocked()
+	if err != nil {
+		return nil, err
+	}
+	curLocked, err := cur.TotalLocked()
+	if err != nil {
+		return nil, err
+	}
+	diff.TotalLocked = valueChange(preLocked, curLocked)
+
+	changed, err := pre.BalancesChanged(cur)
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		preEscrow, err := pre.EscrowTable()
+		if err != nil {
+			return nil, err
+		}
+		curEscrow, err := cur.EscrowTable()
+		if err != nil {
+			return nil, err
+		}
+		if diff.Escrow, err = diffBalanceTables(preEscrow, curEscrow); err != nil {
+			return nil, xerrors.Wrap(err, "diffing escrow table")
+		}
+
+		preLockedTable, err := pre.LockedTable()
+		if err != nil {
+			return nil, err
+		}
+		curLockedTable, err := cur.LockedTable()
+		if err != nil {
+			return nil, err
+		}
+		if diff.Locked, err = diffBalanceTables(preLockedTable, curLockedTable); err != nil {
+			return nil, xerrors.Wrap(err, "diffing locked table")
+		}
+	}
+
+	changed, err = pre.ProposalsChanged(cur)
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		preProposals, err := pre.Proposals()
+		if err != nil {
+			return nil, err
+		}
+		curProposals, err := cur.Proposals()
+		if err != nil {
+			return nil, err
+		}
+		if diff.Proposals, err = market.DiffDealProposals(preProposals, curProposals); err != nil {
+			return nil, err
+		}
+	}
+
+	changed, err = pre.StatesChanged(cur)
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		preStates, err := pre.States()
+		if err != nil {
+			return nil, err
+		}
+		curStates, err := cur.States()
+		if err != nil {
+			return nil, err
+		}
+		if diff.States, err = market.DiffDealStates(preStates, curStates); err != nil {
+			return nil, err
+		}
+	}
+	return diff, nil
+}
+
+func diffPowerState(pre, cur power.State) (*PowerStateDiff, error) {
+	diff := &PowerStateDiff{}
+
+	prePower, err := pre.TotalPower()
+	if err != nil {
+		return nil, err
+	}
+	curPower, err := cur.TotalPower()
+	if err != nil {
+		return nil, err
+	}
+	diff.TotalPower = valueChange(prePower, curPower)
+
+	preCommitted, err := pre.TotalCommitted()
+	if err != nil {
+		return nil, err
+	}
+	curCommitted, err := cur.TotalCommitted()
+	if err != nil {
+		return nil, err
+	}
+	diff.TotalCommitted = valueChange(preCommitted, curCommitted)
+
+	_, preCount, err := pre.MinerCounts()
+	if err != nil {
+		return nil, err
+	}
+	_, curCount, err := cur.MinerCounts()
+	if err != nil {
+		return nil, err
+	}
+	diff.MinerCount = valueChange(preCount, curCount)
+
+	changed, err := pre.ClaimsChanged(cur)
+	if err != nil {
+		return nil, err
+	}
+	if changed {
+		if diff.Claims, err = power.DiffClaims(pre, cur); err != nil {
+			return nil, xerrors.Wrap(err, "diffing claims")
+		}
+	}
+	return diff, nil
+}
+
+// diffBalanceTables returns the entries of the two tables whose amounts differ, in the order of pre then cur.
+func diffBalanceTables(pre, cur market.BalanceTable) ([]BalanceChange, error) {
+	var changes []BalanceChange
+	seen := make(map[addr.Address]struct{})
+	err := pre.ForEach(func(a addr.Address, from abi.TokenAmount) error {
+		seen[a] = struct{}{}
+		to, err := cur.Get(a)
+		if err != nil {
+			return err
+		}
+		if !from.Equals(to) {
+			changes = append(changes, BalanceChange{Address: a, From: from, To: to})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = cur.ForEach(func(a addr.Address, to abi.TokenAmount) error {
+		if _, ok := seen[a]; ok {
+			return nil
+		}
+		if !to.IsZero() {
+			changes = append(changes, BalanceChange{Address: a, From: abi.NewTokenAmount(0), To: to})
+		}
+		return nil
+	})
+	return changes, err
+}
+
+// valueChange returns nil when from and to are equal.
+func valueChange(from, to interface{}) *ValueChange {
+	if valuesEqual(from, to) {
+		return nil
+	}
+	return &ValueChange{From: from, To: to}
+}
+
+// valuesEqual compares the amounts by value, reflect.DeepEqual tells equal big.Int apart when their
+// internal representations differ.
+func valuesEqual(from, to interface{}) bool {
+	switch f := from.(type) {
+	case big.Int:
+		t, ok := to.(big.Int)
+		return ok && f.Equals(t)
+	case miner.LockedFunds:
+		t, ok := to.(miner.LockedFunds)
+		return ok && f.VestingFunds.Equals(t.VestingFunds) &&
+			f.InitialPledgeRequirement.Equals(t.InitialPledgeRequirement) &&
+			f.PreCommitDeposits.Equals(t.PreCommitDeposits)
+	case power.Claim:
+		t, ok := to.(power.Claim)
+		return ok && f.RawBytePower.Equals(t.RawBytePower) && f.QualityAdjPower.Equals(t.QualityAdjPower)
+	}
+	return reflect.DeepEqual(from, to)
+}
@@ -0,0 +1,23 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/filecoin-project/venus/pkg/specactors/builtin/power"
+	tf "github.com/filecoin-project/venus/pkg/testhelpers/testflags"
+)
+
+func TestValueChangeComparesAmounts(t *testing.T) {
+	tf.UnitTest(t)
+
+	// the computed zero keeps an empty slice of words, big.NewInt(0) has none
+	zero := big.Sub(big.NewInt(5), big.NewInt(5))
+	assert.Nil(t, valueChange(big.NewInt(0), zero))
+	assert.Nil(t, valueChange(power.Claim{RawBytePower: big.NewInt(0), QualityAdjPower: big.NewInt(1)},
+		power.Claim{RawBytePower: zero, QualityAdjPower: big.NewInt(1)}))
+	assert.NotNil(t, valueChange(big.NewInt(1), zero))
+	assert.NotNil(t, valueChange(uint64(1), uint64(2)))
+}
@@ -0,0 +1,56 @@
+package state_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/venus/pkg/specactors"
+	"github.com/filecoin-project/venus/pkg/specactors/adt"
+	"github.com/filecoin-project/venus/pkg/specactors/builtin/power"
+	"github.com/filecoin-project/venus/pkg/specactors/builtin/reward"
+	"github.com/filecoin-project/venus/pkg/state"
+	tf "github.com/filecoin-project/venus/pkg/testhelpers/testflags"
+	"github.com/filecoin-project/venus/pkg/types"
+	"github.com/filecoin-project/venus/pkg/util/blockstoreutil"
+)
+
+func TestDiffActorState(t *testing.T) {
+	tf.UnitTest(t)
+
+	store := adt.WrapStore(context.Background(), cbor.NewCborStore(blockstoreutil.NewTemporary()))
+	code, err := power.GetActorCodeID(specactors.Version5)
+	require.NoError(t, err)
+
+	putPower := func(raw int64) *types.Actor {
+		st, err := power.MakeState(store, specactors.Version5)
+		require.NoError(t, err)
+		require.NoError(t, st.SetTotalRawBytePower(abi.NewStoragePower(raw)))
+		head, err := store.Put(store.Context(), st)
+		require.NoError(t, err)
+		return &types.Actor{Code: code, Head: head}
+	}
+
+	pre, cur := putPower(1), putPower(2)
+	diff, err := state.DiffActorState(store, power.Address, pre, cur)
+	require.NoError(t, err)
+	require.NotNil(t, diff.Power)
+	assert.Nil(t, diff.Miner)
+	assert.Nil(t, diff.Market)
+
+	require.NotNil(t, diff.Power.TotalPower)
+	assert.Equal(t, abi.NewStoragePower(1), diff.Power.TotalPower.From.(power.Claim).RawBytePower)
+	assert.Equal(t, abi.NewStoragePower(2), diff.Power.TotalPower.To.(power.Claim).RawBytePower)
+	assert.Nil(t, diff.Power.TotalCommitted)
+	assert.Nil(t, diff.Power.MinerCount)
+	assert.Nil(t, diff.Power.Claims)
+
+	// other actors have no decoded diff.
+	diff, err = state.DiffActorState(store, reward.Address, pre, cur)
+	require.NoError(t, err)
+	assert.Nil(t, diff)
+}
@@ -0,0 +1,102 @@
+package tree
+
+import (
+	"bytes"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus/pkg/specactors/adt"
+	"github.com/filecoin-project/venus/pkg/types"
+)
+
+// ActorChanges are the actors added, modified and removed between two state trees.
+type ActorChanges struct {
+	Added    []ActorInfo
+	Modified []ActorModification
+	Removed  []ActorInfo
+}
+
+// ActorInfo is an actor with its address in the state tree.
+type ActorInfo struct {
+	Address address.Address
+	Actor   types.Actor
+}
+
+// ActorModification is an actor whose balance, nonce, code or head changed.
+type ActorModification struct {
+	Address address.Address
+	From    types.Actor
+	To      types.Actor
+}
+
+// DiffActors compares the actor HAMTs of two state trees, only the actors whose
+// encoding differs are decoded.
+func DiffActors(pre, cur *State) (*ActorChanges, error) {
+	results := new(ActorChanges)
+	if err := adt.DiffAdtMap(pre.root, cur.root, &actorDiffer{results}); err != nil {
+		return nil, xerrors.Errorf("diffing actors: %w", err)
+	}
+	return results, nil
+}
+
+type actorDiffer struct {
+	Results *ActorChanges
+}
+
+func (d *actorDiffer) AsKey(key string) (abi.Keyer, error) {
+	addr, err := address.NewFromBytes([]byte(key))
+	if err != nil {
+		return nil, xerrors.Errorf("address in state tree was not valid: %w", err)
+	}
+	return abi.AddrKey(addr), nil
+}
+
+func (d *actorDiffer) Add(key string, val *cbg.Deferred) error {
+	info, err := decodeActorInfo(key, val)
+	if err != nil {
+		return err
+	}
+	d.Results.Added = append(d.Results.Added, info)
+	return nil
+}
+
+func (d *actorDiffer) Modify(key string, from, to *cbg.Deferred) error {
+	fromInfo, err := decodeActorInfo(key, from)
+	if err != nil {
+		return err
+	}
+	toInfo, err := decodeActorInfo(key, to)
+	if err != nil {
+		return err
+	}
+	d.Results.Modified = append(d.Results.Modified, ActorModification{
+		Address: fromInfo.Address,
+		From:    fromInfo.Actor,
+		To:      toInfo.Actor,
+	})
+	return nil
+}
+
+func (d *actorDiffer) Remove(key string, val *cbg.Deferred) error {
+	info, err := decodeActorInfo(key, val)
+	if err != nil {
+		return err
+	}
+	d.Results.Removed = append(d.Results.Removed, info)
+	return nil
+}
+
+func decodeActorInfo(key string, val *cbg.Deferred) (ActorInfo, error) {
+	addr, err := address.NewFromBytes([]byte(key))
+	if err != nil {
+		return ActorInfo{}, xerrors.Errorf("address in state tree was not valid: %w", err)
+	}
+	var act types.Actor
+	if err := act.UnmarshalCBOR(bytes.NewReader(val.Raw)); err != nil {
+		return ActorInfo{}, xerrors.Errorf("decoding actor %s: %w", addr, err)
+	}
+	return ActorInfo{Address: addr, Actor: act}, nil
+}
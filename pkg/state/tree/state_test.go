@@ -169,3 +169,58 @@ func TestStateTreeConsistency(t *testing.T) {
 	}
 
 }
+
+func TestDiffActors(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+
+	bs := repo.NewInMemoryRepo().Datastore()
+	cst := cbor.NewCborStore(bs)
+	tree, err := NewStateWithBuiltinActor(t, cst, StateTreeVersion1)
+	require.NoError(t, err)
+
+	addrGetter := types.NewForTestGetter()
+	addr1 := addrGetter()
+	addr2 := addrGetter()
+	addr3, err := address.NewIDAddress(500)
+	require.NoError(t, err)
+	AddAccount(t, tree, cst, addr1)
+	AddAccount(t, tree, cst, addr2)
+	preRoot, err := tree.Flush(ctx)
+	require.NoError(t, err)
+	// the state tree is keyed by id addresses.
+	id1, err := tree.LookupID(addr1)
+	require.NoError(t, err)
+	id2, err := tree.LookupID(addr2)
+	require.NoError(t, err)
+
+	UpdateAccount(t, tree, addr1, func(act *types.Actor) {
+		act.IncrementSeqNum()
+	})
+	require.NoError(t, tree.DeleteActor(ctx, addr2))
+	require.NoError(t, tree.SetActor(ctx, addr3, &types.Actor{Code: builtin2.AccountActorCodeID, Head: preRoot, Balance: abi.NewTokenAmount(1)}))
+	curRoot, err := tree.Flush(ctx)
+	require.NoError(t, err)
+
+	pre, err := LoadState(ctx, cst, preRoot)
+	require.NoError(t, err)
+	cur, err := LoadState(ctx, cst, curRoot)
+	require.NoError(t, err)
+
+	changes, err := DiffActors(pre, cur)
+	require.NoError(t, err)
+
+	require.Len(t, changes.Added, 1)
+	assert.Equal(t, addr3, changes.Added[0].Address)
+	assert.Equal(t, abi.NewTokenAmount(1), changes.Added[0].Actor.Balance)
+
+	require.Len(t, changes.Removed, 1)
+	assert.Equal(t, id2, changes.Removed[0].Address)
+
+	// the init actor changed by AddAccount is not modified after preRoot.
+	require.Len(t, changes.Modified, 1)
+	assert.Equal(t, id1, changes.Modified[0].Address)
+	assert.Equal(t, uint64(0), changes.Modified[0].From.Nonce)
+	assert.Equal(t, uint64(1), changes.Modified[0].To.Nonce)
+}
@@ -86,8 +86,8 @@ func BadgerBlockstoreOptions(path string, readonly bool) (Options, error) {
 	// Default table size is already 64MiB. This is here to make it explicit.
 	opts.MaxTableSize = 64 << 20
 
-	// NOTE: The chain blockstore doesn't require any GC (blocks are never
-	// deleted). This will change if we move to a tiered blockstore.
+	// NOTE: The chain blockstore only requires GC when it is used as the hot
+	// store of a SplitStore, which runs value log GC after each compaction.
 
 	opts.ReadOnly = readonly
 
@@ -398,7 +398,8 @@ func (b *BadgerBlockstore) AllKeysChan(ctx context.Context) (<-chan cid.Cid, err
 			}
 			k := iter.Item().Key()
 			// need to convert to key.Key using key.KeyFromDsKey.
-			bk, err := dshelp.BinaryFromDsKey(datastore.RawKey(string(k)))
+			dsKey := b.keyTransform.InvertKey(datastore.RawKey(string(k)))
+			bk, err := dshelp.BinaryFromDsKey(dsKey)
 			if err != nil {
 				log.Warnf("error parsing key from binary: %s", err)
 				continue
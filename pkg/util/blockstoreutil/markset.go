@@ -0,0 +1,146 @@
+package blockstoreutil
+
+import (
+	"io/ioutil"
+	"os"
+
+	"github.com/dgraph-io/badger/v2"
+	"github.com/ipfs/go-cid"
+	"go.uber.org/zap"
+	"golang.org/x/xerrors"
+)
+
+// markSet is the set of the objects found reachable by a compaction.
+type markSet interface {
+	Mark(c cid.Cid) error
+	Has(c cid.Cid) (bool, error)
+	// Count returns the number of marked objects.
+	Count() int
+	// Close discards the set.
+	Close() error
+}
+
+// newMarkSet creates a mark set on disk in a temporary directory of path, or in
+// memory if path is empty.
+func newMarkSet(path string) (markSet, error) {
+	if path == "" {
+		return &mapMarkSet{set: make(map[string]struct{})}, nil
+	}
+	return newBadgerMarkSet(path)
+}
+
+type mapMarkSet struct {
+	set map[string]struct{}
+}
+
+func (s *mapMarkSet) Mark(c cid.Cid) error {
+	s.set[string(c.Hash())] = struct{}{}
+	return nil
+}
+
+func (s *mapMarkSet) Has(c cid.Cid) (bool, error) {
+	_, ok := s.set[string(c.Hash())]
+	return ok, nil
+}
+
+func (s *mapMarkSet) Count() int {
+	return len(s.set)
+}
+
+func (s *mapMarkSet) Close() error {
+	s.set = nil
+	return nil
+}
+
+// badgerMarkSet keeps the marked objects in a temporary badger database, so that
+// the live set of the whole state does not have to fit in memory. The marks are
+// written in batches, flushed before the first lookup.
+type badgerMarkSet struct {
+	dir   string
+	db    *badger.DB
+	batch *badger.WriteBatch
+	count int
+}
+
+func newBadgerMarkSet(path string) (*badgerMarkSet, error) {
+	if err := os.MkdirAll(path, 0755); err != nil {
+		return nil, xerrors.Errorf("failed to create mark set directory: %w", err)
+	}
+	dir, err := ioutil.TempDir(path, "markset-")
+	if err != nil {
+		return nil, xerrors.Errorf("failed to create mark set directory: %w", err)
+	}
+
+	opts := badger.DefaultOptions(dir)
+	// the set is discarded after the compaction, nothing has to survive a crash
+	opts.SyncWrites = false
+	opts.Logger = &badgerLogger{
+		SugaredLogger: log.Desugar().WithOptions(zap.AddCallerSkip(1)).Sugar(),
+		skip2:         log.Desugar().WithOptions(zap.AddCallerSkip(2)).Sugar(),
+	}
+	db, err := badger.Open(opts)
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, xerrors.Errorf("failed to open mark set: %w", err)
+	}
+	return &badgerMarkSet{dir: dir, db: db}, nil
+}
+
+func (s *badgerMarkSet) Mark(c cid.Cid) error {
+	if s.batch == nil {
+		s.batch = s.db.NewWriteBatch()
+	}
+	if err := s.batch.Set(c.Hash(), nil); err != nil {
+		return xerrors.Errorf("failed to mark %s: %w", c, err)
+	}
+	s.count++
+	return nil
+}
+
+func (s *badgerMarkSet) flush() error {
+	if s.batch == nil {
+		return nil
+	}
+	batch := s.batch
+	s.batch = nil
+	if err := batch.Flush(); err != nil {
+		return xerrors.Errorf("failed to write mark set: %w", err)
+	}
+	return nil
+}
+
+func (s *badgerMarkSet) Has(c cid.Cid) (bool, error) {
+	if err := s.flush(); err != nil {
+		return false, err
+	}
+
+	err := s.db.View(func(txn *badger.Txn) error {
+		_, err := txn.Get(c.Hash())
+		return err
+	})
+	switch err {
+	case nil:
+		return true, nil
+	case badger.ErrKeyNotFound:
+		return false, nil
+	default:
+		return false, xerrors.Errorf("failed to look up mark of %s: %w", c, err)
+	}
+}
+
+// Count returns the number of marks, an object marked twice is counted twice.
+func (s *badgerMarkSet) Count() int {
+	return s.count
+}
+
+func (s *badgerMarkSet) Close() error {
+	if s.batch != nil {
+		s.batch.Cancel()
+		s.batch = nil
+	}
+	err := s.db.Close()
+	if rmErr := os.RemoveAll(s.dir); err == nil {
+		err = rmErr
+	}
+	return err
+}
@@ -0,0 +1,602 @@
+package blockstoreutil
+
+import (
+	"context"
+	"encoding/binary"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
+	dshelp "github.com/ipfs/go-ipfs-ds-help"
+	"golang.org/x/xerrors"
+)
+
+// baseEpochKey is the key at which the epoch of the last compaction is stored
+// in the splitstore metadata datastore.
+var baseEpochKey = datastore.NewKey("/splitstore/baseEpoch")
+
+// writeEpochsPrefix is the prefix of the keys at which the epoch every hot object
+// was last written at is stored in the splitstore metadata datastore.
+var writeEpochsPrefix = datastore.NewKey("/splitstore/writeEpochs")
+
+// splitStoreBatchSize is the number of blocks moved or deleted at once during compaction.
+const splitStoreBatchSize = 16384
+
+// ChainAccessor is the view of the chain needed by the SplitStore to decide
+// which objects are still reachable. It is implemented by chain.Store.
+type ChainAccessor interface {
+	// WalkHotObjects calls cb for every object reachable from the current head,
+	// including the state roots of the last inclRecentRoots epochs, and returns
+	// the height of the head it walked from.
+	WalkHotObjects(ctx context.Context, inclRecentRoots abi.ChainEpoch, cb func(cid.Cid) error) (abi.ChainEpoch, error)
+}
+
+// SplitStoreOptions configures a SplitStore.
+type SplitStoreOptions struct {
+	// HotStoreEpochs is the number of epochs of state kept in the hot store.
+	HotStoreEpochs abi.ChainEpoch
+	// CompactionThreshold is the number of epochs the head has to advance
+	// past the last compaction before a new one is started.
+	CompactionThreshold abi.ChainEpoch
+	// ColdStoreEpochs is the number of epochs of state kept in the cold store,
+	// older objects are deleted from it at each compaction. Zero keeps the cold
+	// store forever.
+	ColdStoreEpochs abi.ChainEpoch
+	// MarkSetPath is the directory the reachable objects are marked in during
+	// a compaction, they are marked in memory if it is empty.
+	MarkSetPath string
+}
+
+// SplitStore is a blockstore split in a hot store, which receives all writes
+// and keeps the recent part of the chain, and an optional cold store, which
+// receives objects that are no longer reachable from the recent chain.
+// When the cold store is nil, unreachable objects are discarded. The cold store
+// grows without bound unless ColdStoreEpochs is set.
+//
+// Compaction runs in the background whenever the head has advanced by
+// CompactionThreshold epochs; reads and writes are served while it runs.
+// Objects written since the last compaction are kept in the hot store even if
+// the head does not reach them (yet), like the blocks of a chain being synced.
+type SplitStore struct {
+	hot  Blockstore
+	cold Blockstore
+	meta datastore.Datastore
+	opts SplitStoreOptions
+
+	chain ChainAccessor
+
+	// compacting is set while a compaction is running, guarded by atomic.
+	compacting int32
+	// protected tracks objects written, checked or read during compaction, which
+	// must not be purged from the hot store even if the walk did not mark them.
+	protectLk sync.Mutex
+	protected map[string]struct{}
+
+	baseEpoch abi.ChainEpoch
+	headCh    chan abi.ChainEpoch
+	// epoch is the latest head epoch, which objects are written at, guarded by atomic.
+	epoch int64
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+var _ Blockstore = (*SplitStore)(nil)
+var _ Viewer = (*SplitStore)(nil)
+
+// NewSplitStore creates a split blockstore on top of the hot and cold stores.
+// The epoch of the last compaction is persisted in meta.
+func NewSplitStore(hot, cold Blockstore, meta datastore.Datastore, opts SplitStoreOptions) (*SplitStore, error) {
+	if opts.HotStoreEpochs <= 0 {
+		return nil, xerrors.Errorf("invalid hot store epochs %d", opts.HotStoreEpochs)
+	}
+	if opts.CompactionThreshold <= 0 {
+		return nil, xerrors.Errorf("invalid compaction threshold %d", opts.CompactionThreshold)
+	}
+	if opts.ColdStoreEpochs != 0 && opts.ColdStoreEpochs < opts.HotStoreEpochs {
+		return nil, xerrors.Errorf("cold store epochs %d less than hot store epochs %d", opts.ColdStoreEpochs, opts.HotStoreEpochs)
+	}
+
+	if opts.MarkSetPath != "" {
+		// the mark sets of a compaction interrupted by a crash
+		if err := os.RemoveAll(opts.MarkSetPath); err != nil {
+			return nil, xerrors.Errorf("failed to remove splitstore mark sets: %w", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ss := &SplitStore{
+		hot:    hot,
+		cold:   cold,
+		meta:   meta,
+		opts:   opts,
+		headCh: make(chan abi.ChainEpoch, 1),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	val, err := meta.Get(baseEpochKey)
+	switch err {
+	case nil:
+		ss.baseEpoch = abi.ChainEpoch(binary.BigEndian.Uint64(val))
+	case datastore.ErrNotFound:
+	default:
+		cancel()
+		return nil, xerrors.Errorf("failed to load splitstore base epoch: %w", err)
+	}
+	// until the head is known, writes are dated at the head of the last compaction.
+	ss.epoch = int64(ss.baseEpoch + opts.HotStoreEpochs)
+
+	return ss, nil
+}
+
+// Start begins compacting the store in the background using the chain to
+// find reachable objects. The caller has to report head changes with HeadChange.
+func (s *SplitStore) Start(chain ChainAccessor) {
+	s.chain = chain
+	s.wg.Add(1)
+	go s.compactLoop()
+}
+
+// HeadChange notifies the store that the head is now at epoch. It never blocks.
+func (s *SplitStore) HeadChange(epoch abi.ChainEpoch) {
+	atomic.StoreInt64(&s.epoch, int64(epoch))
+	select {
+	case s.headCh <- epoch:
+	default:
+		// a notification is already pending, replace it with the latest epoch.
+		select {
+		case <-s.headCh:
+		default:
+		}
+		select {
+		case s.headCh <- epoch:
+		default:
+		}
+	}
+}
+
+// Close stops the compaction loop and closes the cold store, the hot store
+// is owned by the caller.
+func (s *SplitStore) Close() error {
+	s.cancel()
+	s.wg.Wait()
+
+	if closer, ok := s.cold.(interface{ Close() error }); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+func (s *SplitStore) compactLoop() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case epoch := <-s.headCh:
+			if epoch-s.baseEpoch < s.opts.HotStoreEpochs+s.opts.CompactionThreshold {
+				continue
+			}
+			if err := s.compact(s.ctx); err != nil {
+				log.Errorf("splitstore compaction failed: %s", err)
+			}
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// compact marks all objects reachable from the head and moves every other
+// object of the hot store to the cold store, or deletes it if there is none.
+// Objects written since the last compaction are kept whether they are marked or not.
+func (s *SplitStore) compact(ctx context.Context) error {
+	s.protectLk.Lock()
+	s.protected = make(map[string]struct{})
+	s.protectLk.Unlock()
+	atomic.StoreInt32(&s.compacting, 1)
+	defer func() {
+		atomic.StoreInt32(&s.compacting, 0)
+		s.protectLk.Lock()
+		s.protected = nil
+		s.protectLk.Unlock()
+	}()
+
+	log.Infow("splitstore compaction started", "baseEpoch", s.baseEpoch)
+	lastHead := s.baseEpoch + s.opts.HotStoreEpochs
+
+	marked, err := newMarkSet(s.opts.MarkSetPath)
+	if err != nil {
+		return err
+	}
+	defer marked.Close() // nolint: errcheck
+
+	head, err := s.chain.WalkHotObjects(ctx, s.opts.HotStoreEpochs, marked.Mark)
+	if err != nil {
+		return xerrors.Errorf("failed to mark hot objects: %w", err)
+	}
+
+	keys, err := s.hot.AllKeysChan(ctx)
+	if err != nil {
+		return xerrors.Errorf("failed to list hot objects: %w", err)
+	}
+
+	var purged, recent int
+	batch := make([]cid.Cid, 0, splitStoreBatchSize)
+	for c := range keys {
+		live, err := marked.Has(c)
+		if err != nil {
+			return err
+		}
+		if live {
+			continue
+		}
+		written, err := s.writtenSince(c, lastHead)
+		if err != nil {
+			return err
+		}
+		if written {
+			recent++
+			continue
+		}
+		batch = append(batch, c)
+		if len(batch) >= splitStoreBatchSize {
+			n, err := s.purge(batch)
+			if err != nil {
+				return err
+			}
+			purged += n
+			batch = batch[:0]
+		}
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	n, err := s.purge(batch)
+	if err != nil {
+		return err
+	}
+	purged += n
+
+	if gc, ok := s.hot.(*BadgerBlockstore); ok {
+		// reclaim the space of purged values; ErrNoRewrite just means there was nothing to collect.
+		_ = gc.DB.RunValueLogGC(0.125)
+	}
+
+	if s.cold != nil && s.opts.ColdStoreEpochs > 0 {
+		if err := s.pruneCold(ctx); err != nil {
+			return err
+		}
+	}
+
+	// the objects written before the last compaction are either marked or purged now.
+	if err := s.forgetWritesBefore(ctx, lastHead); err != nil {
+		return err
+	}
+
+	s.baseEpoch = head - s.opts.HotStoreEpochs
+	buf := make([]byte, 8)
+	binary.BigEndian.PutUint64(buf, uint64(s.baseEpoch))
+	if err := s.meta.Put(baseEpochKey, buf); err != nil {
+		return xerrors.Errorf("failed to save splitstore base epoch: %w", err)
+	}
+
+	log.Infow("splitstore compaction finished", "head", head, "live", marked.Count(), "recent", recent, "purged", purged)
+	return nil
+}
+
+// purge moves the given objects to the cold store and removes them from the
+// hot store, skipping those protected since the compaction started.
+func (s *SplitStore) purge(cids []cid.Cid) (int, error) {
+	s.protectLk.Lock()
+	defer s.protectLk.Unlock()
+
+	toMove := make([]blocks.Block, 0, len(cids))
+	toDelete := make([]cid.Cid, 0, len(cids))
+	for _, c := range cids {
+		if _, ok := s.protected[string(c.Hash())]; ok {
+			continue
+		}
+		if s.cold != nil {
+			blk, err := s.hot.Get(c)
+			if err != nil {
+				if err == ErrNotFound {
+					continue
+				}
+				return 0, xerrors.Errorf("failed to read hot object %s: %w", c, err)
+			}
+			toMove = append(toMove, blk)
+		}
+		toDelete = append(toDelete, c)
+	}
+
+	if len(toMove) > 0 {
+		if err := s.cold.PutMany(toMove); err != nil {
+			return 0, xerrors.Errorf("failed to move objects to cold store: %w", err)
+		}
+	}
+
+	for _, c := range toDelete {
+		if err := s.hot.DeleteBlock(c); err != nil {
+			return 0, xerrors.Errorf("failed to delete hot object %s: %w", c, err)
+		}
+	}
+	return len(toDelete), nil
+}
+
+// pruneCold deletes from the cold store every object that is not reachable from
+// the head within the last ColdStoreEpochs epochs.
+func (s *SplitStore) pruneCold(ctx context.Context) error {
+	marked, err := newMarkSet(s.opts.MarkSetPath)
+	if err != nil {
+		return err
+	}
+	defer marked.Close() // nolint: errcheck
+
+	if _, err := s.chain.WalkHotObjects(ctx, s.opts.ColdStoreEpochs, marked.Mark); err != nil {
+		return xerrors.Errorf("failed to mark cold objects: %w", err)
+	}
+
+	keys, err := s.cold.AllKeysChan(ctx)
+	if err != nil {
+		return xerrors.Errorf("failed to list cold objects: %w", err)
+	}
+
+	var pruned int
+	for c := range keys {
+		live, err := marked.Has(c)
+		if err != nil {
+			return err
+		}
+		if live {
+			continue
+		}
+		if err := s.cold.DeleteBlock(c); err != nil {
+			return xerrors.Errorf("failed to delete cold object %s: %w", c, err)
+		}
+		pruned++
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	if gc, ok := s.cold.(*BadgerBlockstore); ok {
+		_ = gc.DB.RunValueLogGC(0.125)
+	}
+
+	log.Infow("splitstore cold store pruned", "live", marked.Count(), "pruned", pruned)
+	return nil
+}
+
+func writeEpochKey(c cid.Cid) datastore.Key {
+	return writeEpochsPrefix.Child(dshelp.MultihashToDsKey(c.Hash()))
+}
+
+// trackWrites records that the objects are written at the current head epoch.
+func (s *SplitStore) trackWrites(cids ...cid.Cid) error {
+	val := make([]byte, 8)
+	binary.BigEndian.PutUint64(val, uint64(atomic.LoadInt64(&s.epoch)))
+
+	var dst datastore.Write = s.meta
+	var batch datastore.Batch
+	if bds, ok := s.meta.(datastore.Batching); ok && len(cids) > 1 {
+		var err error
+		if batch, err = bds.Batch(); err != nil {
+			return xerrors.Errorf("failed to track writes: %w", err)
+		}
+		dst = batch
+	}
+	for _, c := range cids {
+		if err := dst.Put(writeEpochKey(c), val); err != nil {
+			return xerrors.Errorf("failed to track write of %s: %w", c, err)
+		}
+	}
+	if batch != nil {
+		if err := batch.Commit(); err != nil {
+			return xerrors.Errorf("failed to track writes: %w", err)
+		}
+	}
+	return nil
+}
+
+// writtenSince reports whether the object was last written at epoch or later.
+func (s *SplitStore) writtenSince(c cid.Cid, epoch abi.ChainEpoch) (bool, error) {
+	val, err := s.meta.Get(writeEpochKey(c))
+	switch err {
+	case nil:
+		return abi.ChainEpoch(binary.BigEndian.Uint64(val)) >= epoch, nil
+	case datastore.ErrNotFound:
+		return false, nil
+	default:
+		return false, xerrors.Errorf("failed to load write epoch of %s: %w", c, err)
+	}
+}
+
+// forgetWritesBefore deletes the write epochs older than epoch.
+func (s *SplitStore) forgetWritesBefore(ctx context.Context, epoch abi.ChainEpoch) error {
+	res, err := s.meta.Query(query.Query{Prefix: writeEpochsPrefix.String()})
+	if err != nil {
+		return xerrors.Errorf("failed to list write epochs: %w", err)
+	}
+	defer res.Close() //nolint:errcheck
+
+	for r := range res.Next() {
+		if r.Error != nil {
+			return xerrors.Errorf("failed to list write epochs: %w", r.Error)
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if abi.ChainEpoch(binary.BigEndian.Uint64(r.Value)) >= epoch {
+			continue
+		}
+		if err := s.meta.Delete(datastore.NewKey(r.Key)); err != nil {
+			return xerrors.Errorf("failed to delete write epoch: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *SplitStore) protect(cids ...cid.Cid) {
+	if atomic.LoadInt32(&s.compacting) == 0 {
+		return
+	}
+
+	s.protectLk.Lock()
+	defer s.protectLk.Unlock()
+	if s.protected == nil {
+		return
+	}
+	for _, c := range cids {
+		s.protected[string(c.Hash())] = struct{}{}
+	}
+}
+
+func (s *SplitStore) DeleteBlock(c cid.Cid) error {
+	if err := s.hot.DeleteBlock(c); err != nil {
+		return err
+	}
+	if s.cold != nil {
+		return s.cold.DeleteBlock(c)
+	}
+	return nil
+}
+
+func (s *SplitStore) Has(c cid.Cid) (bool, error) {
+	has, err := s.hot.Has(c)
+	if err != nil {
+		return false, err
+	}
+	if has {
+		s.protect(c)
+		return true, nil
+	}
+
+	if s.cold == nil {
+		return false, nil
+	}
+	return s.cold.Has(c)
+}
+
+func (s *SplitStore) Get(c cid.Cid) (blocks.Block, error) {
+	blk, err := s.hot.Get(c)
+	if err == nil {
+		s.protect(c)
+		return blk, nil
+	}
+	if err != ErrNotFound || s.cold == nil {
+		return blk, err
+	}
+	return s.cold.Get(c)
+}
+
+func (s *SplitStore) View(c cid.Cid, callback func([]byte) error) error {
+	if v, ok := s.hot.(Viewer); ok {
+		err := v.View(c, callback)
+		if err == nil {
+			s.protect(c)
+		}
+		if err != ErrNotFound || s.cold == nil {
+			return err
+		}
+	} else {
+		blk, err := s.hot.Get(c)
+		if err == nil {
+			s.protect(c)
+			return callback(blk.RawData())
+		}
+		if err != ErrNotFound || s.cold == nil {
+			return err
+		}
+	}
+
+	if v, ok := s.cold.(Viewer); ok {
+		return v.View(c, callback)
+	}
+	blk, err := s.cold.Get(c)
+	if err != nil {
+		return err
+	}
+	return callback(blk.RawData())
+}
+
+func (s *SplitStore) GetSize(c cid.Cid) (int, error) {
+	size, err := s.hot.GetSize(c)
+	if err != ErrNotFound || s.cold == nil {
+		return size, err
+	}
+	return s.cold.GetSize(c)
+}
+
+func (s *SplitStore) Put(blk blocks.Block) error {
+	s.protect(blk.Cid())
+	if err := s.trackWrites(blk.Cid()); err != nil {
+		return err
+	}
+	return s.hot.Put(blk)
+}
+
+func (s *SplitStore) PutMany(blks []blocks.Block) error {
+	cids := make([]cid.Cid, len(blks))
+	for i, blk := range blks {
+		cids[i] = blk.Cid()
+	}
+	s.protect(cids...)
+	if err := s.trackWrites(cids...); err != nil {
+		return err
+	}
+	return s.hot.PutMany(blks)
+}
+
+// AllKeysChan returns the keys of the hot store followed by those of the cold store.
+func (s *SplitStore) AllKeysChan(ctx context.Context) (<-chan cid.Cid, error) {
+	hotKeys, err := s.hot.AllKeysChan(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if s.cold == nil {
+		return hotKeys, nil
+	}
+
+	coldKeys, err := s.cold.AllKeysChan(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make(chan cid.Cid)
+	go func() {
+		defer close(out)
+		for _, in := range []<-chan cid.Cid{hotKeys, coldKeys} {
+			for c := range in {
+				select {
+				case out <- c:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out, nil
+}
+
+func (s *SplitStore) HashOnRead(enabled bool) {
+	s.hot.HashOnRead(enabled)
+	if s.cold != nil {
+		s.cold.HashOnRead(enabled)
+	}
+}
+
+// Hot returns the hot blockstore.
+func (s *SplitStore) Hot() Blockstore {
+	return s.hot
+}
+
+// Cold returns the cold blockstore, nil if old objects are discarded.
+func (s *SplitStore) Cold() Blockstore {
+	return s.cold
+}
@@ -0,0 +1,246 @@
+package blockstoreutil
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/filecoin-project/go-state-types/abi"
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tf "github.com/filecoin-project/venus/pkg/testhelpers/testflags"
+)
+
+type fakeChain struct {
+	head abi.ChainEpoch
+	live []cid.Cid
+	// aged objects are only reachable when walking at least their age in epochs.
+	aged map[cid.Cid]abi.ChainEpoch
+}
+
+func (fc *fakeChain) WalkHotObjects(ctx context.Context, inclRecentRoots abi.ChainEpoch, cb func(cid.Cid) error) (abi.ChainEpoch, error) {
+	for _, c := range fc.live {
+		if err := cb(c); err != nil {
+			return 0, err
+		}
+	}
+	for c, age := range fc.aged {
+		if age > inclRecentRoots {
+			continue
+		}
+		if err := cb(c); err != nil {
+			return 0, err
+		}
+	}
+	return fc.head, nil
+}
+
+func newSplitStoreForTest(t *testing.T, cold Blockstore) (*SplitStore, MemStore, datastore.Datastore) {
+	hot := NewTemporary()
+	meta := datastore.NewMapDatastore()
+	ss, err := NewSplitStore(hot, cold, meta, SplitStoreOptions{HotStoreEpochs: 10, CompactionThreshold: 5})
+	require.NoError(t, err)
+	return ss, hot, meta
+}
+
+func TestSplitStoreCompactMovesToCold(t *testing.T) {
+	tf.UnitTest(t)
+
+	cold := NewTemporary()
+	ss, hot, meta := newSplitStoreForTest(t, cold)
+
+	live := blocks.NewBlock([]byte("live"))
+	dead := blocks.NewBlock([]byte("dead"))
+	writeAt(t, ss, 5, live, dead)
+
+	ss.chain = &fakeChain{head: 100, live: []cid.Cid{live.Cid()}}
+	require.NoError(t, ss.compact(context.Background()))
+
+	has, _ := hot.Has(live.Cid())
+	assert.True(t, has)
+	has, _ = hot.Has(dead.Cid())
+	assert.False(t, has)
+	has, _ = cold.Has(dead.Cid())
+	assert.True(t, has)
+
+	// objects moved to the cold store are still readable through the split store.
+	blk, err := ss.Get(dead.Cid())
+	require.NoError(t, err)
+	assert.Equal(t, dead.RawData(), blk.RawData())
+
+	// the base epoch survives a restart.
+	ss2, err := NewSplitStore(hot, cold, meta, ss.opts)
+	require.NoError(t, err)
+	assert.Equal(t, abi.ChainEpoch(90), ss2.baseEpoch)
+}
+
+func TestSplitStoreCompactPrunesCold(t *testing.T) {
+	tf.UnitTest(t)
+
+	hot := NewTemporary()
+	cold := NewTemporary()
+	ss, err := NewSplitStore(hot, cold, datastore.NewMapDatastore(), SplitStoreOptions{
+		HotStoreEpochs:      10,
+		CompactionThreshold: 5,
+		ColdStoreEpochs:     20,
+	})
+	require.NoError(t, err)
+
+	live := blocks.NewBlock([]byte("live"))
+	recent := blocks.NewBlock([]byte("recent"))
+	old := blocks.NewBlock([]byte("old"))
+	writeAt(t, ss, 5, live, recent, old)
+
+	ss.chain = &fakeChain{
+		head: 100,
+		live: []cid.Cid{live.Cid()},
+		aged: map[cid.Cid]abi.ChainEpoch{recent.Cid(): 15, old.Cid(): 30},
+	}
+	require.NoError(t, ss.compact(context.Background()))
+
+	has, _ := hot.Has(live.Cid())
+	assert.True(t, has)
+	has, _ = cold.Has(recent.Cid())
+	assert.True(t, has)
+	has, _ = ss.Has(old.Cid())
+	assert.False(t, has)
+
+	_, err = NewSplitStore(hot, cold, datastore.NewMapDatastore(), SplitStoreOptions{
+		HotStoreEpochs:      10,
+		CompactionThreshold: 5,
+		ColdStoreEpochs:     5,
+	})
+	assert.Error(t, err)
+}
+
+func TestSplitStoreCompactDiscard(t *testing.T) {
+	tf.UnitTest(t)
+
+	ss, hot, _ := newSplitStoreForTest(t, nil)
+
+	live := blocks.NewBlock([]byte("live"))
+	dead := blocks.NewBlock([]byte("dead"))
+	writeAt(t, ss, 5, live, dead)
+
+	ss.chain = &fakeChain{head: 100, live: []cid.Cid{live.Cid()}}
+	require.NoError(t, ss.compact(context.Background()))
+
+	assert.Len(t, hot, 1)
+	_, err := ss.Get(dead.Cid())
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestSplitStoreKeepsWritesSinceLastCompaction(t *testing.T) {
+	tf.UnitTest(t)
+
+	ss, hot, meta := newSplitStoreForTest(t, nil)
+
+	old := blocks.NewBlock([]byte("old"))
+	syncing := blocks.NewBlock([]byte("syncing"))
+	writeAt(t, ss, 5, old)
+	// a block of a chain being synced is not reachable from the head yet.
+	writeAt(t, ss, 95, syncing)
+
+	ss.chain = &fakeChain{head: 100}
+	require.NoError(t, ss.compact(context.Background()))
+
+	has, _ := hot.Has(old.Cid())
+	assert.False(t, has)
+	has, _ = hot.Has(syncing.Cid())
+	assert.True(t, has)
+	has, _ = meta.Has(writeEpochKey(old.Cid()))
+	assert.False(t, has)
+
+	// the next compaction purges it if it is still unreachable.
+	fork := blocks.NewBlock([]byte("fork"))
+	writeAt(t, ss, 110, fork)
+	ss.chain = &fakeChain{head: 120}
+	require.NoError(t, ss.compact(context.Background()))
+
+	has, _ = hot.Has(syncing.Cid())
+	assert.False(t, has)
+	has, _ = hot.Has(fork.Cid())
+	assert.True(t, has)
+}
+
+func TestSplitStoreProtectsWritesDuringCompaction(t *testing.T) {
+	tf.UnitTest(t)
+
+	ss, hot, _ := newSplitStoreForTest(t, nil)
+
+	written := blocks.NewBlock([]byte("written during compaction"))
+	read := blocks.NewBlock([]byte("read during compaction"))
+	viewed := blocks.NewBlock([]byte("viewed during compaction"))
+	require.NoError(t, hot.PutMany([]blocks.Block{read, viewed}))
+	ss.chain = chainFunc(func() {
+		require.NoError(t, ss.Put(written))
+		_, err := ss.Get(read.Cid())
+		require.NoError(t, err)
+		require.NoError(t, ss.View(viewed.Cid(), func([]byte) error { return nil }))
+	})
+	require.NoError(t, ss.compact(context.Background()))
+
+	for _, blk := range []blocks.Block{written, read, viewed} {
+		has, _ := hot.Has(blk.Cid())
+		assert.True(t, has, string(blk.RawData()))
+	}
+}
+
+func TestSplitStoreCompactMarkSetOnDisk(t *testing.T) {
+	tf.UnitTest(t)
+
+	markSetPath := filepath.Join(t.TempDir(), "markset")
+	// the mark set of an interrupted compaction is removed on start
+	require.NoError(t, os.MkdirAll(filepath.Join(markSetPath, "markset-interrupted"), 0755))
+
+	hot := NewTemporary()
+	cold := NewTemporary()
+	ss, err := NewSplitStore(hot, cold, datastore.NewMapDatastore(), SplitStoreOptions{
+		HotStoreEpochs:      10,
+		CompactionThreshold: 5,
+		MarkSetPath:         markSetPath,
+	})
+	require.NoError(t, err)
+	_, err = os.Stat(markSetPath)
+	assert.True(t, os.IsNotExist(err))
+
+	live := blocks.NewBlock([]byte("live"))
+	dead := blocks.NewBlock([]byte("dead"))
+	writeAt(t, ss, 5, live, dead)
+
+	ss.chain = &fakeChain{head: 100, live: []cid.Cid{live.Cid()}}
+	require.NoError(t, ss.compact(context.Background()))
+
+	has, _ := hot.Has(live.Cid())
+	assert.True(t, has)
+	has, _ = hot.Has(dead.Cid())
+	assert.False(t, has)
+	has, _ = cold.Has(dead.Cid())
+	assert.True(t, has)
+
+	// the mark set is discarded after the compaction
+	entries, err := ioutil.ReadDir(markSetPath)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+// writeAt writes the blocks to the split store with the head at epoch.
+func writeAt(t *testing.T, ss *SplitStore, epoch abi.ChainEpoch, blks ...blocks.Block) {
+	ss.HeadChange(epoch)
+	require.NoError(t, ss.PutMany(blks))
+}
+
+// chainFunc runs a callback while the hot set is walked, simulating writes
+// racing with a compaction.
+type chainFunc func()
+
+func (f chainFunc) WalkHotObjects(ctx context.Context, inclRecentRoots abi.ChainEpoch, cb func(cid.Cid) error) (abi.ChainEpoch, error) {
+	f()
+	return 100, nil
+}
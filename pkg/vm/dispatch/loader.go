@@ -1,6 +1,7 @@
 package dispatch
 
 import (
+	"github.com/filecoin-project/go-state-types/abi"
 	"github.com/filecoin-project/go-state-types/exitcode"
 	rtt "github.com/filecoin-project/go-state-types/rt"
 	rt5 "github.com/filecoin-project/specs-actors/v5/actors/runtime"
@@ -8,6 +9,7 @@ import (
 	xerrors "github.com/pkg/errors"
 
 	"github.com/filecoin-project/venus/pkg/specactors"
+	"github.com/filecoin-project/venus/pkg/specactors/builtin"
 	vmr "github.com/filecoin-project/venus/pkg/vm/runtime"
 )
 
@@ -47,6 +49,43 @@ func (cl CodeLoader) GetUnsafeActorImpl(code cid.Cid) (Dispatcher, error) {
 	return &actorDispatcher{code: code, actor: actor.vmActor}, nil
 }
 
+// DecodeParams decodes the params of a call to the method of the actor with the given code
+// into the type the method expects.
+func (cl CodeLoader) DecodeParams(code cid.Cid, method abi.MethodNum, params []byte) (interface{}, error) {
+	sig, err := cl.methodSignature(code, method)
+	if err != nil || sig == nil {
+		return nil, err
+	}
+	return sig.ArgInterface(params)
+}
+
+// DecodeReturn decodes the value returned by the method of the actor with the given code
+// into the type the method returns.
+func (cl CodeLoader) DecodeReturn(code cid.Cid, method abi.MethodNum, ret []byte) (interface{}, error) {
+	sig, err := cl.methodSignature(code, method)
+	if err != nil || sig == nil {
+		return nil, err
+	}
+	return sig.ReturnInterface(ret)
+}
+
+// methodSignature returns the signature of the method of the actor with the given code,
+// nil for the send method which is handled by the vm and takes no params.
+func (cl CodeLoader) methodSignature(code cid.Cid, method abi.MethodNum) (MethodSignature, error) {
+	if method == builtin.MethodSend {
+		return nil, nil
+	}
+	impl, err := cl.GetUnsafeActorImpl(code)
+	if err != nil {
+		return nil, err
+	}
+	sig, excErr := impl.Signature(method)
+	if excErr != nil {
+		return nil, excErr
+	}
+	return sig, nil
+}
+
 // CodeLoaderBuilder helps you build a CodeLoader.
 type CodeLoaderBuilder struct {
 	actors map[cid.Cid]ActorInfo
@@ -0,0 +1,53 @@
+package dispatch_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	builtin5 "github.com/filecoin-project/specs-actors/v5/actors/builtin"
+	power5 "github.com/filecoin-project/specs-actors/v5/actors/builtin/power"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tf "github.com/filecoin-project/venus/pkg/testhelpers/testflags"
+	"github.com/filecoin-project/venus/pkg/vm/register"
+)
+
+func TestDecodeParamsAndReturn(t *testing.T) {
+	tf.UnitTest(t)
+
+	owner, err := address.NewIDAddress(100)
+	require.NoError(t, err)
+	params := power5.CreateMinerParams{
+		Owner:               owner,
+		Worker:              owner,
+		WindowPoStProofType: abi.RegisteredPoStProof_StackedDrgWindow32GiBV1,
+		Peer:                abi.PeerID("peer"),
+	}
+	buf := new(bytes.Buffer)
+	require.NoError(t, params.MarshalCBOR(buf))
+
+	decoded, err := register.DefaultActors.DecodeParams(builtin5.StoragePowerActorCodeID, builtin5.MethodsPower.CreateMiner, buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, &params, decoded)
+
+	idAddr, err := address.NewIDAddress(1000)
+	require.NoError(t, err)
+	ret := power5.CreateMinerReturn{IDAddress: idAddr, RobustAddress: idAddr}
+	buf.Reset()
+	require.NoError(t, ret.MarshalCBOR(buf))
+
+	decoded, err = register.DefaultActors.DecodeReturn(builtin5.StoragePowerActorCodeID, builtin5.MethodsPower.CreateMiner, buf.Bytes())
+	require.NoError(t, err)
+	assert.Equal(t, &ret, decoded)
+
+	// plain sends carry no params.
+	decoded, err = register.DefaultActors.DecodeParams(builtin5.AccountActorCodeID, builtin5.MethodSend, nil)
+	require.NoError(t, err)
+	assert.Nil(t, decoded)
+
+	_, err = register.DefaultActors.DecodeParams(builtin5.StoragePowerActorCodeID, 1000, buf.Bytes())
+	assert.Error(t, err)
+}
@@ -13,6 +13,8 @@ type MethodSignature interface {
 	ArgNil() reflect.Value
 	// ArgInterface returns the typed argument expected by the actor method.
 	ArgInterface(argBytes []byte) (interface{}, error)
+	// ReturnInterface returns the typed value returned by the actor method.
+	ReturnInterface(retBytes []byte) (interface{}, error)
 }
 
 type methodSignature struct {
@@ -43,3 +45,25 @@ func (ms *methodSignature) ArgInterface(argBytes []byte) (interface{}, error) {
 	return nil, fmt.Errorf("type %T does not implement UnmarshalCBOR", obj)
 
 }
+
+func (ms *methodSignature) ReturnInterface(retBytes []byte) (interface{}, error) {
+	// decode ret0 (methods without a meaningful result return *abi.EmptyValue)
+	mt := ms.method.Type()
+	if mt.NumOut() == 0 {
+		return nil, nil
+	}
+	t := mt.Out(0)
+	if t.Kind() != reflect.Ptr {
+		return nil, fmt.Errorf("return type %s can not be decoded", t)
+	}
+	obj := reflect.New(t.Elem()).Interface()
+
+	if val, ok := obj.(cbg.CBORUnmarshaler); ok {
+		buf := bytes.NewReader(retBytes)
+		if err := val.UnmarshalCBOR(buf); err != nil {
+			return nil, err
+		}
+		return val, nil
+	}
+	return nil, fmt.Errorf("type %T does not implement UnmarshalCBOR", obj)
+}
@@ -21,6 +21,14 @@ type GasTracker struct { //nolint
 	CallerValidated   bool      //nolint
 	LastGasChargeTime time.Time //nolint
 	LastGasCharge     *types2.GasTrace
+
+	// calls are the traces of the nested calls being executed, innermost last.
+	calls []callTrace
+}
+
+type callTrace struct {
+	trace *types2.ExecutionTrace
+	start time.Time
 }
 
 // NewGasTracker initializes a new empty gas tracker
@@ -77,7 +85,8 @@ func (t *GasTracker) TryCharge(gasCharge GasCharge) bool {
 		gasTrace.VirtualComputeGas = gasTrace.ComputeGas
 	}
 
-	t.ExecutionTrace.GasCharges = append(t.ExecutionTrace.GasCharges, &gasTrace)
+	current := t.currentTrace()
+	current.GasCharges = append(current.GasCharges, &gasTrace)
 	t.LastGasChargeTime = now
 	t.LastGasCharge = &gasTrace
 
@@ -90,3 +99,38 @@ func (t *GasTracker) TryCharge(gasCharge GasCharge) bool {
 	t.GasUsed += toUse
 	return true
 }
+
+// BeginCall starts the trace of a call made while executing the message, the gas
+// charged until the matching EndCall is recorded in it.
+func (t *GasTracker) BeginCall(msg *types2.UnsignedMessage) {
+	t.calls = append(t.calls, callTrace{
+		trace: &types2.ExecutionTrace{Msg: msg},
+		start: time.Now(),
+	})
+}
+
+// EndCall ends the trace of the innermost call and adds it to the subcalls of its caller.
+func (t *GasTracker) EndCall(rct *types2.MessageReceipt) {
+	if len(t.calls) == 0 {
+		return
+	}
+	call := t.calls[len(t.calls)-1]
+	t.calls = t.calls[:len(t.calls)-1]
+
+	call.trace.MsgRct = rct
+	call.trace.Duration = time.Since(call.start)
+	caller := t.currentTrace()
+	caller.Subcalls = append(caller.Subcalls, *call.trace)
+}
+
+// SetCallError records the error aborting the innermost call.
+func (t *GasTracker) SetCallError(msg string) {
+	t.currentTrace().Error = msg
+}
+
+func (t *GasTracker) currentTrace() *types2.ExecutionTrace {
+	if len(t.calls) == 0 {
+		return &t.ExecutionTrace
+	}
+	return t.calls[len(t.calls)-1].trace
+}
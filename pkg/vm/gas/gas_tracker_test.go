@@ -0,0 +1,54 @@
+package gas
+
+import (
+	"testing"
+
+	"github.com/filecoin-project/go-state-types/exitcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tf "github.com/filecoin-project/venus/pkg/testhelpers/testflags"
+	"github.com/filecoin-project/venus/pkg/types"
+)
+
+func TestGasTrackerNestedCalls(t *testing.T) {
+	tf.UnitTest(t)
+
+	tracker := NewGasTracker(1000)
+	require.True(t, tracker.TryCharge(NewGasCharge("top", 1, 0)))
+
+	tracker.BeginCall(&types.UnsignedMessage{Method: 2})
+	require.True(t, tracker.TryCharge(NewGasCharge("first", 2, 0)))
+	tracker.BeginCall(&types.UnsignedMessage{Method: 3})
+	require.True(t, tracker.TryCharge(NewGasCharge("nested", 3, 0)))
+	tracker.SetCallError("aborted")
+	tracker.EndCall(&types.MessageReceipt{ExitCode: exitcode.ErrForbidden})
+	tracker.EndCall(&types.MessageReceipt{ExitCode: exitcode.Ok})
+
+	tracker.BeginCall(&types.UnsignedMessage{Method: 4})
+	tracker.EndCall(&types.MessageReceipt{ExitCode: exitcode.Ok})
+	require.True(t, tracker.TryCharge(NewGasCharge("return", 4, 0)))
+
+	assert.Equal(t, int64(10), tracker.GasUsed)
+
+	root := tracker.ExecutionTrace
+	require.Len(t, root.GasCharges, 2)
+	assert.Equal(t, "top", root.GasCharges[0].Name)
+	assert.Equal(t, "return", root.GasCharges[1].Name)
+	require.Len(t, root.Subcalls, 2)
+
+	first := root.Subcalls[0]
+	assert.EqualValues(t, 2, first.Msg.Method)
+	assert.Equal(t, exitcode.Ok, first.MsgRct.ExitCode)
+	require.Len(t, first.GasCharges, 1)
+	require.Len(t, first.Subcalls, 1)
+
+	nested := first.Subcalls[0]
+	assert.EqualValues(t, 3, nested.Msg.Method)
+	assert.Equal(t, "aborted", nested.Error)
+	assert.Equal(t, exitcode.ErrForbidden, nested.MsgRct.ExitCode)
+	assert.Equal(t, "nested", nested.GasCharges[0].Name)
+
+	assert.EqualValues(t, 4, root.Subcalls[1].Msg.Method)
+	assert.Empty(t, root.Subcalls[1].GasCharges)
+}
@@ -204,9 +204,11 @@ func (ctx *invocationContext) invoke() (ret []byte, errcode exitcode.ExitCode) {
 					"gasLimit", ctx.gasTank.GasAvailable)
 				ret = []byte{} // The Empty here should never be used, but slightly safer than zero Value.
 				errcode = p.Code()
+				ctx.gasTank.SetCallError(p.String())
 			default:
 				errcode = 1
 				ret = []byte{}
+				ctx.gasTank.SetCallError(fmt.Sprintf("%v", r))
 				// do not trap unknown panics
 				vmlog.Errorf("spec actors failure: %s", r)
 				//debug.PrintStack()
@@ -284,6 +286,41 @@ func (ctx *invocationContext) invoke() (ret []byte, errcode exitcode.ExitCode) {
 	return ret, exitcode.Ok
 }
 
+// invokeSubcall invokes a call made by another actor, recording it in the execution trace
+// when tracing is enabled.
+func (ctx *invocationContext) invokeSubcall() ([]byte, exitcode.ExitCode) {
+	if !ctx.vm.vmOption.Tracing {
+		return ctx.invoke()
+	}
+	ctx.gasTank.BeginCall(traceMessage(ctx.originMsg))
+	ret, code := ctx.invoke()
+	ctx.gasTank.EndCall(&types.MessageReceipt{
+		ExitCode:    code,
+		ReturnValue: ret,
+	})
+	return ret, code
+}
+
+// traceMessage converts msg to the message recorded in the execution trace, with its params serialized.
+func traceMessage(msg VmMessage) *types.UnsignedMessage {
+	traced := &types.UnsignedMessage{
+		From:   msg.From,
+		To:     msg.To,
+		Value:  msg.Value,
+		Method: msg.Method,
+	}
+	switch params := msg.Params.(type) {
+	case []byte:
+		traced.Params = params
+	case cbor.Marshaler:
+		buf := new(bytes.Buffer)
+		if err := params.MarshalCBOR(buf); err == nil {
+			traced.Params = buf.Bytes()
+		}
+	}
+	return traced
+}
+
 // resolveTarget loads and actor and returns its ActorID address.
 //
 // If the target actor does not exist, and the target address is a pub-key address,
@@ -347,7 +384,7 @@ func (ctx *invocationContext) resolveTarget(target address.Address) (*types.Acto
 		}
 
 		newCtx := newInvocationContext(ctx.vm, ctx.gasIpld, ctx.topLevel, newMsg, ctx.gasTank, ctx.randSource, ctx)
-		_, code := newCtx.invoke()
+		_, code := newCtx.invokeSubcall()
 		if code.IsError() {
 			// we failed To construct an account actor..
 			runtime.Abort(code)
@@ -460,7 +497,7 @@ func (ctx *invocationContext) Send(toAddr address.Address, methodNum abi.MethodN
 	// 3. build new context
 	newCtx := newInvocationContext(ctx.vm, ctx.gasIpld, ctx.topLevel, newMsg, ctx.gasTank, ctx.randSource, ctx)
 	// 4. invoke
-	ret, code := newCtx.invoke()
+	ret, code := newCtx.invokeSubcall()
 	if code == 0 {
 		_ = ctx.gasTank.TryCharge(gasOnActorExec)
 		if err := out.UnmarshalCBOR(bytes.NewReader(ret)); err != nil {
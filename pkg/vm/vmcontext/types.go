@@ -33,6 +33,9 @@ type VmOption struct { //nolint
 	PRoot                cid.Cid
 	Bsstore              blockstoreutil.Blockstore
 	SysCallsImpl         SyscallsImpl
+	// Tracing records the messages, receipts and nested calls in the execution
+	// trace of each message applied, it is only needed to inspect an execution.
+	Tracing bool
 }
 
 type Ret struct {
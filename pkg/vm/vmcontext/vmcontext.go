@@ -37,6 +37,12 @@ import (
 
 const MaxCallDepth = 4096
 
+// gas limits of the implicit messages, which are part of their cids.
+const (
+	cronGasLimit   = constants.BlockGasLimit * 10000
+	rewardGasLimit = 1 << 30
+)
+
 var vmlog = logging.Logger("vm.context")
 
 // VM holds the stateView and executes messages over the stateView.
@@ -215,7 +221,7 @@ func (vm *VM) ApplyTipSetMessages(blocks []types.BlockMessagesInfo, ts *types.Ti
 				return cid.Undef, nil, xerrors.Errorf("can not Flush vm State To db %vs", err)
 			}
 			if cb != nil {
-				if err := cb(cid.Undef, cronMessage, ret); err != nil {
+				if err := vm.implicitCallback(cb, cronMessage, i, cronGasLimit, ret); err != nil {
 					return cid.Undef, nil, xerrors.Errorf("callback failed on cron message: %w", err)
 				}
 			}
@@ -304,7 +310,7 @@ func (vm *VM) ApplyTipSetMessages(blocks []types.BlockMessagesInfo, ts *types.Ti
 			return cid.Undef, nil, err
 		}
 		if cb != nil {
-			if err := cb(cid.Undef, rewardMessage, ret); err != nil {
+			if err := vm.implicitCallback(cb, rewardMessage, epoch, rewardGasLimit, ret); err != nil {
 				return cid.Undef, nil, xerrors.Errorf("callback failed on reward message: %w", err)
 			}
 		}
@@ -325,7 +331,7 @@ func (vm *VM) ApplyTipSetMessages(blocks []types.BlockMessagesInfo, ts *types.Ti
 		return cid.Undef, nil, err
 	}
 	if cb != nil {
-		if err := cb(cid.Undef, cronMessage, ret); err != nil {
+		if err := vm.implicitCallback(cb, cronMessage, epoch, cronGasLimit, ret); err != nil {
 			return cid.Undef, nil, xerrors.Errorf("callback failed on cron message: %w", err)
 		}
 	}
@@ -348,12 +354,26 @@ func (vm *VM) ApplyTipSetMessages(blocks []types.BlockMessagesInfo, ts *types.Ti
 	return root, receipts, nil
 }
 
+// implicitCallback calls cb with the result of the implicit message imsg applied at epoch,
+// identified by the cid of the equivalent unsigned message.
+func (vm *VM) implicitCallback(cb ExecCallBack, imsg VmMessage, epoch abi.ChainEpoch, gasLimit int64, ret *Ret) error {
+	msg := implicitMessage(imsg, epoch, gasLimit)
+	if vm.vmOption.Tracing {
+		ret.GasTracker.ExecutionTrace.Msg = msg
+	}
+	return cb(msg.Cid(), imsg, ret)
+}
+
 // applyImplicitMessage applies messages automatically generated by the vm itself.
 //
 // This messages do not consume client gas and must not fail.
 func (vm *VM) applyImplicitMessage(imsg VmMessage) (*Ret, error) {
 	// implicit messages gas is tracked separatly and not paid by the miner
-	gasTank := gas.NewGasTracker(constants.BlockGasLimit * 10000)
+	gasTank := gas.NewGasTracker(cronGasLimit)
+	start := time.Now()
+	if vm.vmOption.Tracing {
+		gasTank.ExecutionTrace.Msg = traceMessage(imsg)
+	}
 
 	// the execution of the implicit messages is simpler than full external/actor-actor messages
 	// execution:
@@ -390,14 +410,19 @@ func (vm *VM) applyImplicitMessage(imsg VmMessage) (*Ret, error) {
 		return nil, fmt.Errorf("invalid exit code %d during implicit message execution: From %s, To %s, Method %d, Value %s, Params %v",
 			code, imsg.From, imsg.To, imsg.Method, imsg.Value, imsg.Params)
 	}
+	receipt := types.MessageReceipt{
+		ExitCode:    code,
+		ReturnValue: ret,
+		GasUsed:     0,
+	}
+	if vm.vmOption.Tracing {
+		gasTank.ExecutionTrace.MsgRct = &receipt
+		gasTank.ExecutionTrace.Duration = time.Since(start)
+	}
 	return &Ret{
 		GasTracker: gasTank,
 		OutPuts:    gas.GasOutputs{},
-		Receipt: types.MessageReceipt{
-			ExitCode:    code,
-			ReturnValue: ret,
-			GasUsed:     0,
-		},
+		Receipt:    receipt,
 	}, nil
 }
 
@@ -421,6 +446,10 @@ func (vm *VM) applyMessage(msg *types.UnsignedMessage, onChainMsgSize int) (*Ret
 
 	// initiate gas tracking
 	gasTank := gas.NewGasTracker(msg.GasLimit)
+	start := time.Now()
+	if vm.vmOption.Tracing {
+		gasTank.ExecutionTrace.Msg = msg
+	}
 	// pre-send
 	// 1. charge for message existence
 	// 2. load sender actor
@@ -615,14 +644,19 @@ func (vm *VM) applyMessage(msg *types.UnsignedMessage, onChainMsgSize int) (*Ret
 	}
 
 	// 3. Success!
+	receipt := types.MessageReceipt{
+		ExitCode:    code,
+		ReturnValue: ret,
+		GasUsed:     gasUsed,
+	}
+	if vm.vmOption.Tracing {
+		gasTank.ExecutionTrace.MsgRct = &receipt
+		gasTank.ExecutionTrace.Duration = time.Since(start)
+	}
 	return &Ret{
 		GasTracker: gasTank,
 		OutPuts:    gasOutputs,
-		Receipt: types.MessageReceipt{
-			ExitCode:    code,
-			ReturnValue: ret,
-			GasUsed:     gasUsed,
-		},
+		Receipt:    receipt,
 	}, nil
 }
 
@@ -860,6 +894,17 @@ func makeBlockRewardMessage(blockMiner address.Address, penalty abi.TokenAmount,
 	}
 }
 
+// implicitMessage returns the unsigned message equivalent to the implicit message imsg
+// applied at epoch, the nonce of an implicit message is its epoch.
+func implicitMessage(imsg VmMessage, epoch abi.ChainEpoch, gasLimit int64) *types.UnsignedMessage {
+	msg := traceMessage(imsg)
+	msg.Nonce = uint64(epoch)
+	msg.GasLimit = gasLimit
+	msg.GasFeeCap = big.Zero()
+	msg.GasPremium = big.Zero()
+	return msg
+}
+
 func makeCronTickMessage() VmMessage {
 	return VmMessage{
 		From:   builtin.SystemActorAddr,
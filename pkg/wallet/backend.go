@@ -37,3 +37,10 @@ type Importer interface {
 	// into the backend
 	ImportKey(*crypto.KeyInfo) error
 }
+
+// MetaSigner is a specialization of a wallet backend that signs with the MsgMeta of the data,
+// WalletSign calls it instead of SignBytes.
+type MetaSigner interface {
+	// SignWithMeta cryptographically signs data with the private key associated with an address.
+	SignWithMeta([]byte, address.Address, MsgMeta) (*crypto.Signature, error)
+}
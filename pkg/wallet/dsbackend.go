@@ -46,6 +46,10 @@ type DSBackend struct {
 	password *memguard.Enclave
 	unLocked map[address.Address]*crypto.KeyInfo
 
+	hdLk sync.Mutex
+	// keysLk serializes the writes of encrypted data with the re-encryption of ChangePassword
+	keysLk sync.Mutex
+
 	state int
 }
 
@@ -53,6 +57,10 @@ var _ Backend = (*DSBackend)(nil)
 
 // NewDSBackend constructs a new backend using the passed in datastore.
 func NewDSBackend(ds repo.Datastore, passphraseCfg config.PassphraseConfig, password []byte) (*DSBackend, error) {
+	if err := recoverRekey(ds); err != nil {
+		return nil, errors.Wrap(err, "failed to recover the change of password")
+	}
+
 	result, err := ds.Query(dsq.Query{
 		KeysOnly: true,
 	})
@@ -67,6 +75,10 @@ func NewDSBackend(ds repo.Datastore, passphraseCfg config.PassphraseConfig, pass
 
 	addrCache := make(map[address.Address]struct{}, len(list))
 	for _, el := range list {
+		// the other data of the wallet, eg. the signing policies, is stored under namespaces
+		if strings.Contains(strings.Trim(el.Key, "/"), "/") {
+			continue
+		}
 		parsedAddr, err := address.NewFromString(strings.Trim(el.Key, "/"))
 		if err != nil {
 			return nil, errors.Wrapf(err, "trying to restore invalid address: %s", el.Key)
@@ -166,6 +178,9 @@ func (backend *DSBackend) putKeyInfo(ki *crypto.KeyInfo) error {
 		KeyInfo: ki,
 	}
 
+	backend.keysLk.Lock()
+	defer backend.keysLk.Unlock()
+
 	var keyJSON []byte
 	err = backend.UsePassword(func(password []byte) error {
 		var err error
@@ -290,7 +305,7 @@ func (backend *DSBackend) UnLockWallet(password []byte) error {
 	}
 
 	for _, addr := range backend.Addresses() {
-		ki, err := backend.GetKeyInfoPassphrase(addr, password)
+		ki, err := backend.GetKeyInfoPassphrase(addr, append([]byte{}, password...))
 		if err != nil {
 			return err
 		}
@@ -311,7 +326,7 @@ func (backend *DSBackend) SetPassword(password []byte) error {
 	}
 
 	for _, addr := range backend.Addresses() {
-		ki, err := backend.GetKeyInfoPassphrase(addr, password)
+		ki, err := backend.GetKeyInfoPassphrase(addr, append([]byte{}, password...))
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,164 @@
+// Package hd derives the keys of a hierarchical deterministic wallet from a BIP39 mnemonic, along
+// BIP32 derivation paths.
+package hd
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	gocrypto "github.com/filecoin-project/go-crypto"
+	"golang.org/x/xerrors"
+)
+
+// HardenedOffset is added to the index of a hardened child.
+const HardenedOffset uint32 = 0x80000000
+
+// FilecoinCoinType is the SLIP-44 coin type of Filecoin.
+const FilecoinCoinType = 461
+
+var (
+	masterKey = []byte("Bitcoin seed")
+
+	// order of the secp256k1 curve
+	curveN, _ = new(big.Int).SetString("FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEBAAEDCE6AF48A03BBFD25E8CD0364141", 16)
+)
+
+// Path is a BIP32 derivation path, the indexes of the children from the master key.
+type Path []uint32
+
+// ParsePath parses a path like m/44'/461'/0'/0/0, an index followed by ' or h is hardened.
+func ParsePath(s string) (Path, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) == 0 || parts[0] != "m" {
+		return nil, xerrors.Errorf("derivation path %q does not start with m", s)
+	}
+	path := make(Path, 0, len(parts)-1)
+	for _, part := range parts[1:] {
+		hardened := strings.HasSuffix(part, "'") || strings.HasSuffix(part, "h")
+		if hardened {
+			part = part[:len(part)-1]
+		}
+		index, err := strconv.ParseUint(part, 10, 32)
+		if err != nil || uint32(index) >= HardenedOffset {
+			return nil, xerrors.Errorf("invalid index %q in derivation path %q", part, s)
+		}
+		if hardened {
+			index += uint64(HardenedOffset)
+		}
+		path = append(path, uint32(index))
+	}
+	return path, nil
+}
+
+func (p Path) String() string {
+	var sb strings.Builder
+	sb.WriteString("m")
+	for _, index := range p {
+		if index >= HardenedOffset {
+			fmt.Fprintf(&sb, "/%d'", index-HardenedOffset)
+		} else {
+			fmt.Fprintf(&sb, "/%d", index)
+		}
+	}
+	return sb.String()
+}
+
+// SecpPath is the BIP44 path of the index-th secp256k1 key of a Filecoin wallet, m/44'/461'/0'/0/index.
+func SecpPath(index uint32) Path {
+	return Path{44 + HardenedOffset, FilecoinCoinType + HardenedOffset, HardenedOffset, 0, index}
+}
+
+// BLSPath is the path of the key seeding the index-th BLS key of a Filecoin wallet,
+// m/12381'/461'/0'/index'. The BLS key is generated from the 32 bytes of the derived key.
+func BLSPath(index uint32) Path {
+	return Path{12381 + HardenedOffset, FilecoinCoinType + HardenedOffset, HardenedOffset, index + HardenedOffset}
+}
+
+// ExtendedKey is a BIP32 extended private key.
+type ExtendedKey struct {
+	key       []byte
+	chainCode []byte
+}
+
+// NewMasterKey returns the master key of the seed.
+func NewMasterKey(seed []byte) (*ExtendedKey, error) {
+	if len(seed) < 16 || len(seed) > 64 {
+		return nil, xerrors.Errorf("seed of %d bytes, expected 16 to 64 bytes", len(seed))
+	}
+	mac := hmac.New(sha512.New, masterKey)
+	mac.Write(seed) //nolint:errcheck
+	sum := mac.Sum(nil)
+
+	k := new(big.Int).SetBytes(sum[:32])
+	if k.Sign() == 0 || k.Cmp(curveN) >= 0 {
+		return nil, xerrors.New("invalid master key, use another seed")
+	}
+	return &ExtendedKey{key: sum[:32], chainCode: sum[32:]}, nil
+}
+
+// Key returns the 32 bytes private key.
+func (k *ExtendedKey) Key() []byte {
+	return append([]byte(nil), k.key...)
+}
+
+// ChainCode returns the 32 bytes chain code.
+func (k *ExtendedKey) ChainCode() []byte {
+	return append([]byte(nil), k.chainCode...)
+}
+
+// Child derives the child key at index, hardened from HardenedOffset.
+func (k *ExtendedKey) Child(index uint32) (*ExtendedKey, error) {
+	data := make([]byte, 0, 37)
+	if index >= HardenedOffset {
+		data = append(data, 0)
+		data = append(data, k.key...)
+	} else {
+		data = append(data, compressPublicKey(gocrypto.PublicKey(k.key))...)
+	}
+	var indexBytes [4]byte
+	binary.BigEndian.PutUint32(indexBytes[:], index)
+	data = append(data, indexBytes[:]...)
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data) //nolint:errcheck
+	sum := mac.Sum(nil)
+
+	il := new(big.Int).SetBytes(sum[:32])
+	if il.Cmp(curveN) >= 0 {
+		return nil, xerrors.Errorf("invalid child key at %d, use the next index", index)
+	}
+	child := il.Add(il, new(big.Int).SetBytes(k.key))
+	child.Mod(child, curveN)
+	if child.Sign() == 0 {
+		return nil, xerrors.Errorf("invalid child key at %d, use the next index", index)
+	}
+
+	key := make([]byte, 32)
+	child.FillBytes(key)
+	return &ExtendedKey{key: key, chainCode: sum[32:]}, nil
+}
+
+// Derive derives the key at path from k.
+func (k *ExtendedKey) Derive(path Path) (*ExtendedKey, error) {
+	key := k
+	for _, index := range path {
+		var err error
+		if key, err = key.Child(index); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// compressPublicKey compresses an uncompressed secp256k1 public key, 0x04 || x || y.
+func compressPublicKey(pub []byte) []byte {
+	out := make([]byte, 33)
+	out[0] = 0x02 + pub[64]&1
+	copy(out[1:], pub[1:33])
+	return out
+}
@@ -0,0 +1,109 @@
+package hd
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	tf "github.com/filecoin-project/venus/pkg/testhelpers/testflags"
+)
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	b, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return b
+}
+
+func TestMnemonic(t *testing.T) {
+	tf.UnitTest(t)
+
+	// vectors of the BIP39 reference implementation
+	vectors := []struct {
+		entropy  string
+		mnemonic string
+		seed     string
+	}{
+		{
+			entropy:  "00000000000000000000000000000000",
+			mnemonic: "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			seed:     "c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+		},
+		{
+			entropy:  "7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+			mnemonic: "legal winner thank year wave sausage worth useful legal winner thank yellow",
+			seed:     "2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+		},
+		{
+			entropy:  "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+			mnemonic: "zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo zoo vote",
+			seed:     "dd48c104698c30cfe2b6142103248622fb7bb0ff692eebb00089b32d22484e1613912f0a5b694407be899ffd31ed3992c456cdf60f5d4564b8ba3f05a69890ad",
+		},
+	}
+	for _, v := range vectors {
+		mnemonic, err := MnemonicFromEntropy(mustDecodeHex(t, v.entropy))
+		require.NoError(t, err)
+		assert.Equal(t, v.mnemonic, mnemonic)
+
+		entropy, err := MnemonicToEntropy(v.mnemonic)
+		require.NoError(t, err)
+		assert.Equal(t, v.entropy, hex.EncodeToString(entropy))
+
+		seed, err := MnemonicToSeed(v.mnemonic, "TREZOR")
+		require.NoError(t, err)
+		assert.Equal(t, v.seed, hex.EncodeToString(seed))
+	}
+
+	mnemonic, err := NewMnemonic()
+	require.NoError(t, err)
+	assert.Len(t, strings.Fields(mnemonic), 24)
+	normalized, err := NormalizeMnemonic("  " + strings.ReplaceAll(mnemonic, " ", "\n ") + "\n")
+	require.NoError(t, err)
+	assert.Equal(t, mnemonic, normalized)
+
+	for _, invalid := range []string{
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon filecoin",
+	} {
+		_, err := MnemonicToEntropy(invalid)
+		assert.True(t, xerrors.Is(err, ErrInvalidMnemonic), invalid)
+	}
+}
+
+func TestDerive(t *testing.T) {
+	tf.UnitTest(t)
+
+	// test vector 1 of BIP32
+	master, err := NewMasterKey(mustDecodeHex(t, "000102030405060708090a0b0c0d0e0f"))
+	require.NoError(t, err)
+	assert.Equal(t, "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35", hex.EncodeToString(master.Key()))
+	assert.Equal(t, "873dff81c02f525623fd1fe5167eac3a55a049de3d314bb42ee227ffed37d508", hex.EncodeToString(master.ChainCode()))
+
+	for path, key := range map[string]string{
+		"m/0'":        "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea",
+		"m/0'/1":      "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368",
+		"m/0h/1/2h":   "cbce0d719ecf7431d88e6a89fa1483e02e35092af60c042b1df2ff59fa424dca",
+		"m/0'/1/2'/2": "0f479245fb19a38a1954c5c7c0ebab2f9bdfd96a17563ef28a6a4b1a2a764ef4",
+	} {
+		p, err := ParsePath(path)
+		require.NoError(t, err)
+		child, err := master.Derive(p)
+		require.NoError(t, err)
+		assert.Equal(t, key, hex.EncodeToString(child.Key()), path)
+	}
+
+	p, err := ParsePath("m/44'/461'/0'/0/3")
+	require.NoError(t, err)
+	assert.Equal(t, SecpPath(3), p)
+	assert.Equal(t, "m/44'/461'/0'/0/3", p.String())
+	assert.Equal(t, "m/12381'/461'/0'/3'", BLSPath(3).String())
+
+	for _, invalid := range []string{"", "44'/461'", "m/x", "m/2147483648"} {
+		_, err := ParsePath(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,106 @@
+package hd
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/xerrors"
+)
+
+// MnemonicEntropyBits is the entropy of the mnemonics generated by NewMnemonic, 24 words.
+const MnemonicEntropyBits = 256
+
+var ErrInvalidMnemonic = xerrors.New("invalid mnemonic")
+
+var wordIndex = func() map[string]int {
+	index := make(map[string]int, len(englishWords))
+	for i, w := range englishWords {
+		index[w] = i
+	}
+	return index
+}()
+
+// NewMnemonic generates a random BIP39 mnemonic of MnemonicEntropyBits.
+func NewMnemonic() (string, error) {
+	entropy := make([]byte, MnemonicEntropyBits/8)
+	if _, err := rand.Read(entropy); err != nil {
+		return "", xerrors.Errorf("reading from crypto/rand failed: %w", err)
+	}
+	return MnemonicFromEntropy(entropy)
+}
+
+// MnemonicFromEntropy encodes the entropy, 128 to 256 bits by steps of 32, as a BIP39 mnemonic.
+func MnemonicFromEntropy(entropy []byte) (string, error) {
+	bits := len(entropy) * 8
+	if bits < 128 || bits > 256 || bits%32 != 0 {
+		return "", xerrors.Errorf("entropy of %d bits, expected 128 to 256 bits by steps of 32", bits)
+	}
+	checksumBits := bits / 32
+	hash := sha256.Sum256(entropy)
+
+	// entropy || first checksumBits of the hash, read by groups of 11 bits
+	data := new(big.Int).SetBytes(entropy)
+	data.Lsh(data, uint(checksumBits))
+	data.Or(data, big.NewInt(int64(hash[0]>>(8-checksumBits))))
+
+	count := (bits + checksumBits) / 11
+	words := make([]string, count)
+	mask := big.NewInt(2047)
+	for i := count - 1; i >= 0; i-- {
+		words[i] = englishWords[new(big.Int).And(data, mask).Int64()]
+		data.Rsh(data, 11)
+	}
+	return strings.Join(words, " "), nil
+}
+
+// MnemonicToEntropy decodes a BIP39 mnemonic, it fails with ErrInvalidMnemonic on an unknown word
+// or a wrong checksum.
+func MnemonicToEntropy(mnemonic string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	if len(words) < 12 || len(words) > 24 || len(words)%3 != 0 {
+		return nil, xerrors.Errorf("%d words, expected 12 to 24 words by steps of 3: %w", len(words), ErrInvalidMnemonic)
+	}
+
+	data := new(big.Int)
+	for _, w := range words {
+		i, ok := wordIndex[w]
+		if !ok {
+			return nil, xerrors.Errorf("unknown word %q: %w", w, ErrInvalidMnemonic)
+		}
+		data.Lsh(data, 11)
+		data.Or(data, big.NewInt(int64(i)))
+	}
+
+	checksumBits := len(words) * 11 / 33
+	checksum := new(big.Int).And(data, big.NewInt(int64(1)<<checksumBits-1)).Int64()
+	data.Rsh(data, uint(checksumBits))
+
+	entropy := make([]byte, checksumBits*4)
+	data.FillBytes(entropy)
+	hash := sha256.Sum256(entropy)
+	if int64(hash[0]>>(8-checksumBits)) != checksum {
+		return nil, xerrors.Errorf("wrong checksum: %w", ErrInvalidMnemonic)
+	}
+	return entropy, nil
+}
+
+// NormalizeMnemonic returns the words of the mnemonic separated by a space, once checked.
+func NormalizeMnemonic(mnemonic string) (string, error) {
+	if _, err := MnemonicToEntropy(mnemonic); err != nil {
+		return "", err
+	}
+	return strings.Join(strings.Fields(mnemonic), " "), nil
+}
+
+// MnemonicToSeed returns the BIP39 seed of the mnemonic with an optional passphrase.
+func MnemonicToSeed(mnemonic, passphrase string) ([]byte, error) {
+	mnemonic, err := NormalizeMnemonic(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+	return pbkdf2.Key([]byte(mnemonic), []byte("mnemonic"+passphrase), 2048, 64, sha512.New), nil
+}
@@ -0,0 +1,256 @@
+package wallet
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/filecoin-project/go-address"
+	ds "github.com/ipfs/go-datastore"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus/pkg/crypto"
+	"github.com/filecoin-project/venus/pkg/wallet/hd"
+)
+
+var hdWalletKey = ds.NewKey("/hd/wallet")
+
+// ErrNoMnemonic is returned when the HD wallet is used before an address is created or restored.
+var ErrNoMnemonic = xerrors.New("the wallet has no mnemonic")
+
+// hdWallet is the mnemonic of the HD wallet, encrypted like the keys, and the next index of each
+// address protocol.
+type hdWallet struct {
+	Mnemonic CryptoJSON
+	Next     map[address.Protocol]uint32
+}
+
+// HasMnemonic returns whether HD addresses were created or restored.
+func (backend *DSBackend) HasMnemonic() bool {
+	has, err := backend.ds.Has(hdWalletKey)
+	return err == nil && has
+}
+
+// NewHDAddress derives the next address of the protocol from the mnemonic of the wallet, a new
+// mnemonic is generated for the first HD address.
+func (backend *DSBackend) NewHDAddress(protocol address.Protocol) (address.Address, error) {
+	if protocol != address.SECP256K1 && protocol != address.BLS {
+		return address.Undef, xerrors.Errorf("unknown address protocol %d", protocol)
+	}
+	if backend.state == Lock {
+		return address.Undef, xerrors.New("the wallet is locked")
+	}
+
+	backend.hdLk.Lock()
+	defer backend.hdLk.Unlock()
+
+	hdw, mnemonic, err := backend.loadHDWallet()
+	if err == ErrNoMnemonic {
+		if mnemonic, err = hd.NewMnemonic(); err != nil {
+			return address.Undef, err
+		}
+		hdw, err = backend.newHDWallet(mnemonic)
+	}
+	if err != nil {
+		return address.Undef, err
+	}
+
+	seed, err := hd.MnemonicToSeed(mnemonic, "")
+	if err != nil {
+		return address.Undef, err
+	}
+	// skips the addresses already in the wallet, eg. imported
+	for {
+		index := hdw.Next[protocol]
+		ki, err := deriveKeyInfo(seed, protocol, index)
+		if err != nil {
+			return address.Undef, xerrors.Errorf("deriving key %d: %w", index, err)
+		}
+		addr, err := ki.Address()
+		if err != nil {
+			return address.Undef, err
+		}
+
+		hdw.Next[protocol] = index + 1
+		if backend.HasAddress(addr) {
+			continue
+		}
+		if err := backend.putKeyInfo(ki); err != nil {
+			return address.Undef, err
+		}
+		return addr, backend.saveHDWallet(hdw)
+	}
+}
+
+// RestoreMnemonic sets the mnemonic of the HD wallet and derives its first count addresses of the
+// protocol. It fails when the wallet has another mnemonic.
+func (backend *DSBackend) RestoreMnemonic(mnemonic string, protocol address.Protocol, count int) ([]address.Address, error) {
+	if protocol != address.SECP256K1 && protocol != address.BLS {
+		return nil, xerrors.Errorf("unknown address protocol %d", protocol)
+	}
+	if count < 0 {
+		return nil, xerrors.Errorf("negative count %d", count)
+	}
+	if backend.state == Lock {
+		return nil, xerrors.New("the wallet is locked")
+	}
+	mnemonic, err := hd.NormalizeMnemonic(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+
+	backend.hdLk.Lock()
+	defer backend.hdLk.Unlock()
+
+	hdw, current, err := backend.loadHDWallet()
+	switch {
+	case err == ErrNoMnemonic:
+		if hdw, err = backend.newHDWallet(mnemonic); err != nil {
+			return nil, err
+		}
+	case err != nil:
+		return nil, err
+	case current != mnemonic:
+		return nil, xerrors.New("the wallet already has another mnemonic")
+	}
+
+	seed, err := hd.MnemonicToSeed(mnemonic, "")
+	if err != nil {
+		return nil, err
+	}
+	addrs := make([]address.Address, 0, count)
+	for index := uint32(0); index < uint32(count); index++ {
+		ki, err := deriveKeyInfo(seed, protocol, index)
+		if err != nil {
+			return nil, xerrors.Errorf("deriving key %d: %w", index, err)
+		}
+		addr, err := ki.Address()
+		if err != nil {
+			return nil, err
+		}
+		if !backend.HasAddress(addr) {
+			if err := backend.putKeyInfo(ki); err != nil {
+				return nil, err
+			}
+		}
+		addrs = append(addrs, addr)
+	}
+
+	if hdw.Next[protocol] < uint32(count) {
+		hdw.Next[protocol] = uint32(count)
+	}
+	return addrs, backend.saveHDWallet(hdw)
+}
+
+// ExportMnemonic decrypts the mnemonic of the HD wallet with the password.
+func (backend *DSBackend) ExportMnemonic(password []byte) (string, error) {
+	defer func() {
+		for i := range password {
+			password[i] = 0
+		}
+	}()
+
+	hdw, err := backend.getHDWallet()
+	if err != nil {
+		return "", err
+	}
+	mnemonic, err := decryptData(hdw.Mnemonic, password)
+	if err != nil {
+		return "", err
+	}
+	return string(mnemonic), nil
+}
+
+func (backend *DSBackend) getHDWallet() (*hdWallet, error) {
+	b, err := backend.ds.Get(hdWalletKey)
+	if err == ds.ErrNotFound {
+		return nil, ErrNoMnemonic
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("failed to fetch the HD wallet: %w", err)
+	}
+
+	var hdw hdWallet
+	if err := json.Unmarshal(b, &hdw); err != nil {
+		return nil, xerrors.Errorf("failed to decode the HD wallet: %w", err)
+	}
+	if hdw.Next == nil {
+		hdw.Next = make(map[address.Protocol]uint32)
+	}
+	return &hdw, nil
+}
+
+// loadHDWallet returns the HD wallet and its mnemonic decrypted with the wallet password.
+func (backend *DSBackend) loadHDWallet() (*hdWallet, string, error) {
+	hdw, err := backend.getHDWallet()
+	if err != nil {
+		return nil, "", err
+	}
+	var mnemonic []byte
+	err = backend.UsePassword(func(password []byte) error {
+		var err error
+		mnemonic, err = decryptData(hdw.Mnemonic, password)
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return hdw, string(mnemonic), nil
+}
+
+func (backend *DSBackend) newHDWallet(mnemonic string) (*hdWallet, error) {
+	hdw := &hdWallet{Next: make(map[address.Protocol]uint32)}
+	err := backend.UsePassword(func(password []byte) error {
+		var err error
+		hdw.Mnemonic, err = encryptData([]byte(mnemonic), password, backend.PassphraseConf.ScryptN, backend.PassphraseConf.ScryptP)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return hdw, nil
+}
+
+func (backend *DSBackend) saveHDWallet(hdw *hdWallet) error {
+	backend.keysLk.Lock()
+	defer backend.keysLk.Unlock()
+
+	b, err := json.Marshal(hdw)
+	if err != nil {
+		return err
+	}
+	if err := backend.ds.Put(hdWalletKey, b); err != nil {
+		return xerrors.Errorf("failed to store the HD wallet: %w", err)
+	}
+	return nil
+}
+
+// deriveKeyInfo derives the index-th key of the protocol from the seed, along hd.SecpPath or hd.BLSPath.
+func deriveKeyInfo(seed []byte, protocol address.Protocol, index uint32) (*crypto.KeyInfo, error) {
+	master, err := hd.NewMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	switch protocol {
+	case address.SECP256K1:
+		child, err := master.Derive(hd.SecpPath(index))
+		if err != nil {
+			return nil, err
+		}
+		ki := &crypto.KeyInfo{SigType: crypto.SigTypeSecp256k1}
+		ki.SetPrivateKey(child.Key())
+		return ki, nil
+	case address.BLS:
+		child, err := master.Derive(hd.BLSPath(index))
+		if err != nil {
+			return nil, err
+		}
+		ki, err := crypto.NewBLSKeyFromSeed(bytes.NewReader(child.Key()))
+		if err != nil {
+			return nil, err
+		}
+		return &ki, nil
+	default:
+		return nil, xerrors.Errorf("unknown address protocol %d", protocol)
+	}
+}
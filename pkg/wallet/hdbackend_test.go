@@ -0,0 +1,73 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/venus/pkg/config"
+	tf "github.com/filecoin-project/venus/pkg/testhelpers/testflags"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestHDAddresses(t *testing.T) {
+	tf.UnitTest(t)
+
+	ds := datastore.NewMapDatastore()
+	backend, err := NewDSBackend(ds, config.TestPassphraseConfig(), pw())
+	require.NoError(t, err)
+	assert.False(t, backend.HasMnemonic())
+	_, err = backend.ExportMnemonic(pw())
+	assert.Equal(t, ErrNoMnemonic, err)
+
+	addrs, err := backend.RestoreMnemonic(" "+testMnemonic+"\n", address.SECP256K1, 2)
+	require.NoError(t, err)
+	require.Len(t, addrs, 2)
+	assert.True(t, backend.HasMnemonic())
+
+	// m/44'/461'/0'/0/0 of the mnemonic
+	ki, err := backend.GetKeyInfo(addrs[0])
+	require.NoError(t, err)
+	require.NoError(t, ki.UsePrivateKey(func(key []byte) error {
+		assert.Equal(t, "e1808079c6734eff9a187c917455dc1b2c70385e13f1cd6cecc94978e57f7f76", hex.EncodeToString(key))
+		return nil
+	}))
+
+	next, err := backend.NewHDAddress(address.SECP256K1)
+	require.NoError(t, err)
+	assert.NotContains(t, addrs, next)
+
+	_, err = backend.RestoreMnemonic("legal winner thank year wave sausage worth useful legal winner thank yellow", address.SECP256K1, 1)
+	assert.Error(t, err)
+	_, err = backend.RestoreMnemonic("abandon abandon", address.SECP256K1, 1)
+	assert.Error(t, err)
+
+	_, err = backend.ExportMnemonic([]byte("wrong password"))
+	assert.Error(t, err)
+	mnemonic, err := backend.ExportMnemonic(pw())
+	require.NoError(t, err)
+	assert.Equal(t, testMnemonic, mnemonic)
+
+	// the addresses and the next index are reloaded, the HD wallet key is not an address
+	backend, err = NewDSBackend(ds, config.TestPassphraseConfig(), pw())
+	require.NoError(t, err)
+	assert.ElementsMatch(t, append(addrs, next), backend.Addresses())
+
+	// the same addresses are derived in another wallet
+	other, err := NewDSBackend(datastore.NewMapDatastore(), config.TestPassphraseConfig(), pw())
+	require.NoError(t, err)
+	restored, err := other.RestoreMnemonic(testMnemonic, address.SECP256K1, 3)
+	require.NoError(t, err)
+	assert.Equal(t, append(addrs, next), restored)
+
+	next2, err := backend.NewHDAddress(address.SECP256K1)
+	require.NoError(t, err)
+	next2Other, err := other.NewHDAddress(address.SECP256K1)
+	require.NoError(t, err)
+	assert.Equal(t, next2, next2Other)
+}
@@ -0,0 +1,326 @@
+package wallet
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/filecoin-project/go-address"
+	ds "github.com/ipfs/go-datastore"
+	dsq "github.com/ipfs/go-datastore/query"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus/pkg/config"
+	"github.com/filecoin-project/venus/pkg/crypto"
+	"github.com/filecoin-project/venus/pkg/repo"
+	"github.com/filecoin-project/venus/pkg/wallet/hd"
+)
+
+var (
+	// the entries re-encrypted by ChangePassword are staged under rekeyDataPrefix, they replace the
+	// current ones once rekeyCommitKey is written
+	rekeyPrefix     = ds.NewKey("/rekey")
+	rekeyDataPrefix = rekeyPrefix.ChildString("data")
+	rekeyCommitKey  = rekeyPrefix.ChildString("commit")
+)
+
+// keyStoreVersion is the version of the KeyStore format.
+const keyStoreVersion = 1
+
+// KeyStore is the export of all the keys and the mnemonic of a wallet, encrypted with a password.
+type KeyStore struct {
+	Version int
+	Crypto  CryptoJSON
+}
+
+type keyStoreContent struct {
+	Keys     []*crypto.KeyInfo
+	Mnemonic string                      `json:",omitempty"`
+	HDNext   map[address.Protocol]uint32 `json:",omitempty"`
+}
+
+func validatePassphraseConfig(cfg config.PassphraseConfig) error {
+	if cfg.ScryptN <= 1 || cfg.ScryptN&(cfg.ScryptN-1) != 0 {
+		return xerrors.Errorf("scrypt N %d is not a power of 2 greater than 1", cfg.ScryptN)
+	}
+	if cfg.ScryptP <= 0 {
+		return xerrors.Errorf("scrypt P %d is not positive", cfg.ScryptP)
+	}
+	return nil
+}
+
+// ChangePassword re-encrypts the keys and the mnemonic of the wallet with the new password and the
+// scrypt parameters of passphraseCfg. All of them are re-encrypted or none: the new entries are
+// staged and a commit marker is written before they replace the current ones, an interrupted change
+// is completed or discarded by NewDSBackend.
+func (backend *DSBackend) ChangePassword(oldPassword, newPassword []byte, passphraseCfg config.PassphraseConfig) error {
+	defer func() {
+		for i := range oldPassword {
+			oldPassword[i] = 0
+		}
+	}()
+	if len(newPassword) == 0 {
+		return xerrors.New("the new password is empty")
+	}
+	if err := validatePassphraseConfig(passphraseCfg); err != nil {
+		return err
+	}
+	if backend.password == nil && backend.state != Lock {
+		return xerrors.New("the wallet has no password, set it first")
+	}
+	if backend.password != nil {
+		err := backend.UsePassword(func(password []byte) error {
+			if !bytes.Equal(password, oldPassword) {
+				return ErrInvalidPassword
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	backend.hdLk.Lock()
+	defer backend.hdLk.Unlock()
+	backend.keysLk.Lock()
+	defer backend.keysLk.Unlock()
+
+	result, err := backend.ds.Query(dsq.Query{})
+	if err != nil {
+		return xerrors.Errorf("failed to query datastore: %w", err)
+	}
+	entries, err := result.Rest()
+	if err != nil {
+		return xerrors.Errorf("failed to read query results: %w", err)
+	}
+
+	// re-encrypt everything before staging, a wrong password fails here
+	staged := make(map[ds.Key][]byte, len(entries))
+	for _, e := range entries {
+		key := ds.NewKey(e.Key)
+		var val []byte
+		switch {
+		case key == hdWalletKey:
+			val, err = reencryptHDWallet(e.Value, oldPassword, newPassword, passphraseCfg)
+		case strings.Contains(strings.Trim(e.Key, "/"), "/"):
+			continue
+		default:
+			val, err = reencryptKey(e.Value, oldPassword, newPassword, passphraseCfg)
+		}
+		if err != nil {
+			return xerrors.Errorf("re-encrypting %s: %w", e.Key, err)
+		}
+		staged[key] = val
+	}
+
+	if err := clearRekey(backend.ds); err != nil {
+		return err
+	}
+	for key, val := range staged {
+		if err := backend.ds.Put(rekeyDataPrefix.Child(key), val); err != nil {
+			return xerrors.Errorf("staging %s: %w", key, err)
+		}
+	}
+	if err := backend.ds.Sync(rekeyPrefix); err != nil {
+		return err
+	}
+	if err := backend.ds.Put(rekeyCommitKey, []byte{1}); err != nil {
+		return xerrors.Errorf("committing the change of password: %w", err)
+	}
+	if err := backend.ds.Sync(rekeyCommitKey); err != nil {
+		return err
+	}
+
+	// committed, the change is completed on restart when it fails from here
+	backend.PassphraseConf = passphraseCfg
+	if backend.password != nil {
+		backend.setPassword(append([]byte{}, newPassword...))
+	}
+	return recoverRekey(backend.ds)
+}
+
+func reencryptKey(keyJSON, oldPassword, newPassword []byte, passphraseCfg config.PassphraseConfig) ([]byte, error) {
+	key, err := decryptKey(keyJSON, oldPassword)
+	if err != nil {
+		return nil, err
+	}
+	return encryptKey(key, newPassword, passphraseCfg.ScryptN, passphraseCfg.ScryptP)
+}
+
+func reencryptHDWallet(hdwJSON, oldPassword, newPassword []byte, passphraseCfg config.PassphraseConfig) ([]byte, error) {
+	var hdw hdWallet
+	if err := json.Unmarshal(hdwJSON, &hdw); err != nil {
+		return nil, err
+	}
+	mnemonic, err := decryptData(hdw.Mnemonic, oldPassword)
+	if err != nil {
+		return nil, err
+	}
+	if hdw.Mnemonic, err = encryptData(mnemonic, newPassword, passphraseCfg.ScryptN, passphraseCfg.ScryptP); err != nil {
+		return nil, err
+	}
+	return json.Marshal(hdw)
+}
+
+// recoverRekey replaces the entries of the wallet by the ones staged by ChangePassword when the
+// change was committed, it discards them otherwise.
+func recoverRekey(d repo.Datastore) error {
+	committed, err := d.Has(rekeyCommitKey)
+	if err != nil {
+		return err
+	}
+	if !committed {
+		return clearRekey(d)
+	}
+
+	result, err := d.Query(dsq.Query{Prefix: rekeyDataPrefix.String()})
+	if err != nil {
+		return err
+	}
+	entries, err := result.Rest()
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		key := ds.NewKey(strings.TrimPrefix(e.Key, rekeyDataPrefix.String()))
+		if err := d.Put(key, e.Value); err != nil {
+			return err
+		}
+	}
+	if err := d.Sync(ds.NewKey("/")); err != nil {
+		return err
+	}
+	return clearRekey(d)
+}
+
+// clearRekey deletes the entries staged by ChangePassword, the commit marker last.
+func clearRekey(d repo.Datastore) error {
+	result, err := d.Query(dsq.Query{Prefix: rekeyDataPrefix.String(), KeysOnly: true})
+	if err != nil {
+		return err
+	}
+	entries, err := result.Rest()
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := d.Delete(ds.NewKey(e.Key)); err != nil {
+			return err
+		}
+	}
+	return d.Delete(rekeyCommitKey)
+}
+
+// ExportKeyStore exports all the keys and the mnemonic of the wallet, decrypted with password and
+// encrypted with exportPassword.
+func (backend *DSBackend) ExportKeyStore(password, exportPassword []byte) (*KeyStore, error) {
+	defer func() {
+		for i := range password {
+			password[i] = 0
+		}
+	}()
+	if len(exportPassword) == 0 {
+		return nil, xerrors.New("the export password is empty")
+	}
+
+	var content keyStoreContent
+	for _, addr := range backend.Addresses() {
+		key, err := backend.getKey(addr, password)
+		if err != nil {
+			return nil, xerrors.Errorf("decrypting %s: %w", addr, err)
+		}
+		content.Keys = append(content.Keys, key.KeyInfo)
+	}
+
+	hdw, err := backend.getHDWallet()
+	switch {
+	case err == nil:
+		mnemonic, err := decryptData(hdw.Mnemonic, password)
+		if err != nil {
+			return nil, xerrors.Errorf("decrypting the mnemonic: %w", err)
+		}
+		content.Mnemonic = string(mnemonic)
+		content.HDNext = hdw.Next
+	case err != ErrNoMnemonic:
+		return nil, err
+	}
+
+	data, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+	cryptoJSON, err := encryptData(data, exportPassword, backend.PassphraseConf.ScryptN, backend.PassphraseConf.ScryptP)
+	if err != nil {
+		return nil, err
+	}
+	return &KeyStore{Version: keyStoreVersion, Crypto: cryptoJSON}, nil
+}
+
+// ImportKeyStore imports the keys and the mnemonic of a key store decrypted with exportPassword, the
+// keys already in the wallet are skipped. It returns the addresses of the keys store.
+func (backend *DSBackend) ImportKeyStore(ks *KeyStore, exportPassword []byte) ([]address.Address, error) {
+	if ks.Version != keyStoreVersion {
+		return nil, xerrors.Errorf("unsupported key store version %d", ks.Version)
+	}
+	if backend.state == Lock {
+		return nil, xerrors.New("the wallet is locked")
+	}
+	data, err := decryptData(ks.Crypto, exportPassword)
+	if err != nil {
+		return nil, err
+	}
+	var content keyStoreContent
+	if err := json.Unmarshal(data, &content); err != nil {
+		return nil, xerrors.Errorf("decoding the key store: %w", err)
+	}
+
+	backend.hdLk.Lock()
+	defer backend.hdLk.Unlock()
+
+	// the mnemonic is checked before importing anything
+	var hdw *hdWallet
+	if content.Mnemonic != "" {
+		mnemonic, err := hd.NormalizeMnemonic(content.Mnemonic)
+		if err != nil {
+			return nil, err
+		}
+		var current string
+		hdw, current, err = backend.loadHDWallet()
+		switch {
+		case err == ErrNoMnemonic:
+			if hdw, err = backend.newHDWallet(mnemonic); err != nil {
+				return nil, err
+			}
+		case err != nil:
+			return nil, err
+		case current != mnemonic:
+			return nil, xerrors.New("the wallet already has another mnemonic")
+		}
+		for p, next := range content.HDNext {
+			if hdw.Next[p] < next {
+				hdw.Next[p] = next
+			}
+		}
+	}
+
+	addrs := make([]address.Address, 0, len(content.Keys))
+	for _, ki := range content.Keys {
+		addr, err := ki.Address()
+		if err != nil {
+			return nil, err
+		}
+		if !backend.HasAddress(addr) {
+			if err := backend.putKeyInfo(ki); err != nil {
+				return nil, xerrors.Errorf("importing %s: %w", addr, err)
+			}
+		}
+		addrs = append(addrs, addr)
+	}
+
+	if hdw != nil {
+		if err := backend.saveHDWallet(hdw); err != nil {
+			return nil, err
+		}
+	}
+	return addrs, nil
+}
@@ -0,0 +1,155 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/venus/pkg/config"
+	tf "github.com/filecoin-project/venus/pkg/testhelpers/testflags"
+)
+
+// pw returns a copy of TestPassword, the passwords given to the backend are wiped.
+func pw() []byte {
+	return []byte("test-password")
+}
+
+func TestChangePassword(t *testing.T) {
+	tf.UnitTest(t)
+
+	ds := datastore.NewMapDatastore()
+	backend, err := NewDSBackend(ds, config.TestPassphraseConfig(), pw())
+	require.NoError(t, err)
+	addr, err := backend.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	hdAddrs, err := backend.RestoreMnemonic(testMnemonic, address.SECP256K1, 1)
+	require.NoError(t, err)
+	policies, err := NewSigningPolicies(ds)
+	require.NoError(t, err)
+	require.NoError(t, policies.Set(&SigningPolicy{Address: addr, AllowedTypes: []MsgType{MTChainMsg}}))
+
+	newPassword := []byte("new-password")
+	cfg := config.TestPassphraseConfig()
+	cfg.ScryptN = 1 << 14
+
+	assert.Error(t, backend.ChangePassword([]byte("wrong"), newPassword, cfg))
+	assert.Error(t, backend.ChangePassword(pw(), nil, cfg))
+	cfg.ScryptN = 1000
+	assert.Error(t, backend.ChangePassword(pw(), newPassword, cfg))
+	cfg.ScryptN = 1 << 14
+
+	require.NoError(t, backend.ChangePassword(pw(), newPassword, cfg))
+	assert.Equal(t, cfg, backend.PassphraseConf)
+
+	_, err = backend.GetKeyInfoPassphrase(addr, pw())
+	assert.Error(t, err)
+	_, err = backend.GetKeyInfoPassphrase(hdAddrs[0], append([]byte{}, newPassword...))
+	require.NoError(t, err)
+	mnemonic, err := backend.ExportMnemonic(append([]byte{}, newPassword...))
+	require.NoError(t, err)
+	assert.Equal(t, testMnemonic, mnemonic)
+	// new keys are encrypted with the new password
+	added, err := backend.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	_, err = backend.GetKeyInfoPassphrase(added, append([]byte{}, newPassword...))
+	require.NoError(t, err)
+
+	// the other data of the wallet is untouched
+	reloaded, err := NewSigningPolicies(ds)
+	require.NoError(t, err)
+	assert.Equal(t, policies.List(), reloaded.List())
+
+	_, err = NewDSBackend(ds, config.TestPassphraseConfig(), pw())
+	assert.Error(t, err)
+	backend, err = NewDSBackend(ds, config.TestPassphraseConfig(), append([]byte{}, newPassword...))
+	require.NoError(t, err)
+	assert.Len(t, backend.Addresses(), 3)
+}
+
+func TestRecoverRekey(t *testing.T) {
+	tf.UnitTest(t)
+
+	ds := datastore.NewMapDatastore()
+	backend, err := NewDSBackend(ds, config.TestPassphraseConfig(), pw())
+	require.NoError(t, err)
+	addr, err := backend.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	current, err := ds.Get(datastore.NewKey(addr.String()))
+	require.NoError(t, err)
+
+	newPassword := []byte("new-password")
+	staged, err := reencryptKey(current, pw(), newPassword, config.TestPassphraseConfig())
+	require.NoError(t, err)
+	require.NoError(t, ds.Put(rekeyDataPrefix.ChildString(addr.String()), staged))
+
+	// interrupted before the commit, the staged keys are discarded
+	backend, err = NewDSBackend(ds, config.TestPassphraseConfig(), pw())
+	require.NoError(t, err)
+	assert.Equal(t, []address.Address{addr}, backend.Addresses())
+	has, err := ds.Has(rekeyDataPrefix.ChildString(addr.String()))
+	require.NoError(t, err)
+	assert.False(t, has)
+
+	// interrupted after the commit, the staged keys replace the current ones
+	require.NoError(t, ds.Put(rekeyDataPrefix.ChildString(addr.String()), staged))
+	require.NoError(t, ds.Put(rekeyCommitKey, []byte{1}))
+	backend, err = NewDSBackend(ds, config.TestPassphraseConfig(), append([]byte{}, newPassword...))
+	require.NoError(t, err)
+	assert.Equal(t, []address.Address{addr}, backend.Addresses())
+	has, err = ds.Has(rekeyCommitKey)
+	require.NoError(t, err)
+	assert.False(t, has)
+}
+
+func TestKeyStore(t *testing.T) {
+	tf.UnitTest(t)
+
+	backend, err := NewDSBackend(datastore.NewMapDatastore(), config.TestPassphraseConfig(), pw())
+	require.NoError(t, err)
+	addr, err := backend.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	hdAddrs, err := backend.RestoreMnemonic(testMnemonic, address.SECP256K1, 2)
+	require.NoError(t, err)
+
+	exportPassword := []byte("export-password")
+	_, err = backend.ExportKeyStore([]byte("wrong"), exportPassword)
+	assert.Error(t, err)
+	ks, err := backend.ExportKeyStore(pw(), exportPassword)
+	require.NoError(t, err)
+
+	other, err := NewDSBackend(datastore.NewMapDatastore(), config.TestPassphraseConfig(), []byte("other-password"))
+	require.NoError(t, err)
+	_, err = other.ImportKeyStore(ks, []byte("wrong"))
+	assert.Error(t, err)
+	addrs, err := other.ImportKeyStore(ks, exportPassword)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, append(hdAddrs, addr), addrs)
+	assert.ElementsMatch(t, backend.Addresses(), other.Addresses())
+
+	// the mnemonic and its next index are imported
+	mnemonic, err := other.ExportMnemonic([]byte("other-password"))
+	require.NoError(t, err)
+	assert.Equal(t, testMnemonic, mnemonic)
+	next, err := backend.NewHDAddress(address.SECP256K1)
+	require.NoError(t, err)
+	otherNext, err := other.NewHDAddress(address.SECP256K1)
+	require.NoError(t, err)
+	assert.Equal(t, next, otherNext)
+
+	// importing again skips the known keys
+	addrs, err = other.ImportKeyStore(ks, exportPassword)
+	require.NoError(t, err)
+	assert.Len(t, addrs, 3)
+
+	// a wallet with another mnemonic is left untouched
+	third, err := NewDSBackend(datastore.NewMapDatastore(), config.TestPassphraseConfig(), pw())
+	require.NoError(t, err)
+	_, err = third.RestoreMnemonic("legal winner thank year wave sausage worth useful legal winner thank yellow", address.SECP256K1, 1)
+	require.NoError(t, err)
+	_, err = third.ImportKeyStore(ks, exportPassword)
+	assert.Error(t, err)
+	assert.Len(t, third.Addresses(), 1)
+}
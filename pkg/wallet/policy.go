@@ -0,0 +1,372 @@
+package wallet
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	ds "github.com/ipfs/go-datastore"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus/pkg/constants"
+	"github.com/filecoin-project/venus/pkg/crypto"
+	"github.com/filecoin-project/venus/pkg/repo"
+	"github.com/filecoin-project/venus/pkg/types"
+)
+
+var (
+	// signingPolicyPrefix is the prefix of the keys of the signing policies in the wallet datastore,
+	// the other keys are addresses.
+	signingPolicyPrefix = ds.NewKey("/signing-policy")
+	signingPoliciesKey  = signingPolicyPrefix.ChildString("policies")
+	signingSpendsKey    = signingPolicyPrefix.ChildString("spends")
+
+	// SigningValueWindow is the period over which MaxValuePerDay is enforced.
+	SigningValueWindow = 24 * time.Hour
+
+	ErrSigningPolicy = errors.New("signing refused by the wallet policy")
+)
+
+// SigningPolicy restricts what the wallet signs with an address, the zero value of a limit or an
+// empty list is not enforced.
+type SigningPolicy struct {
+	Address address.Address
+
+	// AllowedTypes are the MsgTypes the address may sign, only MTChainMsg when it is empty and
+	// the message rules below are set.
+	AllowedTypes []MsgType
+	// MaxValuePerDay is the max value of the MTChainMsg signed over SigningValueWindow. It caps
+	// msg.Value only, the gas fees of the messages are not counted.
+	MaxValuePerDay abi.TokenAmount
+	// AllowedTargets are the actors MTChainMsg may be sent to.
+	AllowedTargets []address.Address
+	// AllowedMethods are the methods MTChainMsg may call.
+	AllowedMethods []abi.MethodNum
+}
+
+func (p *SigningPolicy) validate() error {
+	if p.Address == address.Undef {
+		return xerrors.New("signing policy has no address")
+	}
+	if p.Address.Protocol() != address.SECP256K1 && p.Address.Protocol() != address.BLS {
+		return xerrors.Errorf("signing policy address %s is not a key address", p.Address)
+	}
+	if p.MaxValuePerDay.Int != nil && p.MaxValuePerDay.Sign() < 0 {
+		return xerrors.Errorf("'MaxValuePerDay' of signing policy %s is negative", p.Address)
+	}
+	return nil
+}
+
+// checksMessage reports whether the policy needs the message of a MTChainMsg.
+func (p *SigningPolicy) checksMessage() bool {
+	return !isUnsetAmount(p.MaxValuePerDay) || len(p.AllowedTargets) > 0 || len(p.AllowedMethods) > 0
+}
+
+// signingSpend is the value of a message signed with an address, kept for SigningValueWindow.
+type signingSpend struct {
+	At      time.Time
+	Address address.Address
+	Nonce   uint64
+	Value   abi.TokenAmount
+}
+
+// SigningPolicies enforces the signing policies of the addresses of a wallet, the policies and the
+// value signed over SigningValueWindow are stored in the wallet datastore.
+type SigningPolicies struct {
+	lk sync.Mutex
+	ds repo.Datastore
+
+	policies map[address.Address]*SigningPolicy
+	spends   []signingSpend
+}
+
+// NewSigningPolicies loads the signing policies stored in the wallet datastore.
+func NewSigningPolicies(ds repo.Datastore) (*SigningPolicies, error) {
+	sp := &SigningPolicies{
+		ds:       ds,
+		policies: make(map[address.Address]*SigningPolicy),
+	}
+
+	var policies []*SigningPolicy
+	if err := sp.load(signingPoliciesKey, &policies); err != nil {
+		return nil, xerrors.Errorf("loading signing policies: %w", err)
+	}
+	for _, p := range policies {
+		sp.policies[p.Address] = p
+	}
+	if err := sp.load(signingSpendsKey, &sp.spends); err != nil {
+		return nil, xerrors.Errorf("loading signing spends: %w", err)
+	}
+	return sp, nil
+}
+
+func (sp *SigningPolicies) load(key ds.Key, out interface{}) error {
+	val, err := sp.ds.Get(key)
+	if err == ds.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(val, out)
+}
+
+func (sp *SigningPolicies) save(key ds.Key, in interface{}) error {
+	val, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return sp.ds.Put(key, val)
+}
+
+func cloneSigningPolicy(p *SigningPolicy) *SigningPolicy {
+	c := *p
+	if c.MaxValuePerDay.Int == nil {
+		c.MaxValuePerDay = big.Zero()
+	}
+	c.AllowedTypes = append([]MsgType(nil), p.AllowedTypes...)
+	c.AllowedTargets = append([]address.Address(nil), p.AllowedTargets...)
+	c.AllowedMethods = append([]abi.MethodNum(nil), p.AllowedMethods...)
+	return &c
+}
+
+// List returns (a copy of) the policies, sorted by address.
+func (sp *SigningPolicies) List() []*SigningPolicy {
+	sp.lk.Lock()
+	defer sp.lk.Unlock()
+
+	out := make([]*SigningPolicy, 0, len(sp.policies))
+	for _, p := range sp.policies {
+		out = append(out, cloneSigningPolicy(p))
+	}
+	sortPolicies(out)
+	return out
+}
+
+// Set adds the policy, replacing the policy of the same address.
+func (sp *SigningPolicies) Set(policy *SigningPolicy) error {
+	if err := policy.validate(); err != nil {
+		return err
+	}
+
+	sp.lk.Lock()
+	defer sp.lk.Unlock()
+
+	policies := make(map[address.Address]*SigningPolicy, len(sp.policies)+1)
+	for a, p := range sp.policies {
+		policies[a] = p
+	}
+	policies[policy.Address] = cloneSigningPolicy(policy)
+	if err := sp.savePolicies(policies); err != nil {
+		return err
+	}
+	sp.policies = policies
+	return nil
+}
+
+// Remove removes the policy of the address, it returns false if there is none.
+func (sp *SigningPolicies) Remove(addr address.Address) (bool, error) {
+	sp.lk.Lock()
+	defer sp.lk.Unlock()
+
+	if _, ok := sp.policies[addr]; !ok {
+		return false, nil
+	}
+	policies := make(map[address.Address]*SigningPolicy, len(sp.policies))
+	for a, p := range sp.policies {
+		if a != addr {
+			policies[a] = p
+		}
+	}
+	if err := sp.savePolicies(policies); err != nil {
+		return false, err
+	}
+	sp.policies = policies
+	return true, nil
+}
+
+func (sp *SigningPolicies) savePolicies(policies map[address.Address]*SigningPolicy) error {
+	list := make([]*SigningPolicy, 0, len(policies))
+	for _, p := range policies {
+		list = append(list, p)
+	}
+	sortPolicies(list)
+	if err := sp.save(signingPoliciesKey, list); err != nil {
+		return xerrors.Errorf("persisting signing policies: %w", err)
+	}
+	return nil
+}
+
+// Sign checks the data to sign with addr against the policy of the address and calls sign if it is
+// allowed. The value of a signed MTChainMsg is recorded, a message signed again with the same nonce
+// keeps the largest value signed for it since every signature can be broadcast.
+// sign is called without the lock held. The value cap is checked again before the value is recorded
+// and the signature is dropped if concurrent signing used it up.
+func (sp *SigningPolicies) Sign(addr address.Address, data []byte, meta MsgMeta, sign func() (*crypto.Signature, error)) (*crypto.Signature, error) {
+	sp.lk.Lock()
+	p, ok := sp.policies[addr]
+	sp.lk.Unlock()
+	if !ok {
+		return sign()
+	}
+	reject := func(format string, args ...interface{}) error {
+		return xerrors.Errorf("signing policy of %s: %s: %w", addr, fmt.Sprintf(format, args...), ErrSigningPolicy)
+	}
+
+	if len(p.AllowedTypes) > 0 && !containsMsgType(p.AllowedTypes, meta.Type) {
+		return nil, reject("message type %q is not allowed", meta.Type)
+	}
+	// the data of another type could be a message signed around the message rules
+	if len(p.AllowedTypes) == 0 && p.checksMessage() && meta.Type != MTChainMsg {
+		return nil, reject("message type %q is not allowed with message rules", meta.Type)
+	}
+	if meta.Type != MTChainMsg || !p.checksMessage() {
+		return sign()
+	}
+
+	if len(meta.Extra) == 0 {
+		return nil, reject("the message to sign is unknown")
+	}
+	msg, err := types.DecodeMessage(meta.Extra)
+	if err != nil {
+		return nil, reject("decoding the message to sign: %s", err)
+	}
+	if !bytes.Equal(msg.Cid().Bytes(), data) {
+		return nil, reject("the data to sign is not the cid of message %s", msg.Cid())
+	}
+
+	if len(p.AllowedTargets) > 0 && !containsAddr(p.AllowedTargets, msg.To) {
+		return nil, reject("target %s is not allowed", msg.To)
+	}
+	if len(p.AllowedMethods) > 0 && !containsMethodNum(p.AllowedMethods, msg.Method) {
+		return nil, reject("method %d is not allowed", msg.Method)
+	}
+
+	sp.lk.Lock()
+	err = sp.checkSpend(p, msg, reject)
+	sp.lk.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	sig, err := sign()
+	if err != nil {
+		return nil, err
+	}
+
+	sp.lk.Lock()
+	defer sp.lk.Unlock()
+	if err := sp.checkSpend(p, msg, reject); err != nil {
+		return nil, err
+	}
+	sp.recordSpend(addr, msg)
+	return sig, nil
+}
+
+// checkSpend rejects msg if its value would take the value signed with the address of the policy
+// over SigningValueWindow above MaxValuePerDay, the value already signed for the nonce of msg only
+// counts once. It must be called with sp.lk held.
+func (sp *SigningPolicies) checkSpend(p *SigningPolicy, msg *types.UnsignedMessage, reject func(format string, args ...interface{}) error) error {
+	sp.pruneSpends()
+	if isUnsetAmount(p.MaxValuePerDay) {
+		return nil
+	}
+	total := big.Zero()
+	nonceValue := msg.Value
+	for _, s := range sp.spends {
+		if s.Address != p.Address {
+			continue
+		}
+		if s.Nonce != msg.Nonce {
+			total = big.Add(total, s.Value)
+		} else if s.Value.GreaterThan(nonceValue) {
+			nonceValue = s.Value
+		}
+	}
+	total = big.Add(total, nonceValue)
+	if total.GreaterThan(p.MaxValuePerDay) {
+		return reject("signing %s would total %s over %s, more than %s",
+			types.FIL(msg.Value), types.FIL(total), SigningValueWindow, types.FIL(p.MaxValuePerDay))
+	}
+	return nil
+}
+
+// recordSpend records the value of a signed message. The spend of a message signed before with the
+// same nonce is moved to now and never lowered, its signature can still be broadcast. It must be
+// called with sp.lk held.
+func (sp *SigningPolicies) recordSpend(addr address.Address, msg *types.UnsignedMessage) {
+	spend := signingSpend{
+		At:      constants.Clock.Now(),
+		Address: addr,
+		Nonce:   msg.Nonce,
+		Value:   msg.Value,
+	}
+	for i, s := range sp.spends {
+		if s.Address == addr && s.Nonce == msg.Nonce {
+			if s.Value.GreaterThan(spend.Value) {
+				spend.Value = s.Value
+			}
+			// the spends are kept in signing order for pruneSpends
+			sp.spends = append(sp.spends[:i:i], sp.spends[i+1:]...)
+			break
+		}
+	}
+	sp.spends = append(sp.spends, spend)
+	// the signature is already made, a spend failing to persist is only lost on restart
+	if err := sp.save(signingSpendsKey, sp.spends); err != nil {
+		walletLog.Errorf("persisting signing spends: %v", err)
+	}
+}
+
+func (sp *SigningPolicies) pruneSpends() {
+	cutoff := constants.Clock.Now().Add(-SigningValueWindow)
+	i := 0
+	for i < len(sp.spends) && !sp.spends[i].At.After(cutoff) {
+		i++
+	}
+	sp.spends = sp.spends[i:]
+}
+
+func sortPolicies(policies []*SigningPolicy) {
+	sort.Slice(policies, func(i, j int) bool {
+		return bytes.Compare(policies[i].Address.Bytes(), policies[j].Address.Bytes()) < 0
+	})
+}
+
+func isUnsetAmount(v abi.TokenAmount) bool {
+	return v.Int == nil || v.IsZero()
+}
+
+func containsMsgType(msgTypes []MsgType, t MsgType) bool {
+	for _, x := range msgTypes {
+		if x == t {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAddr(addrs []address.Address, a address.Address) bool {
+	for _, x := range addrs {
+		if x == a {
+			return true
+		}
+	}
+	return false
+}
+
+func containsMethodNum(methods []abi.MethodNum, m abi.MethodNum) bool {
+	for _, x := range methods {
+		if x == m {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,152 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/abi"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus/pkg/config"
+	"github.com/filecoin-project/venus/pkg/crypto"
+	tf "github.com/filecoin-project/venus/pkg/testhelpers/testflags"
+	"github.com/filecoin-project/venus/pkg/types"
+)
+
+func TestSigningPolicies(t *testing.T) {
+	tf.UnitTest(t)
+
+	ds := datastore.NewMapDatastore()
+	backend, err := NewDSBackend(ds, config.TestPassphraseConfig(), pw())
+	require.NoError(t, err)
+	w := New(backend)
+	policies, err := NewSigningPolicies(ds)
+	require.NoError(t, err)
+	w.SetSigningPolicies(policies)
+
+	addr, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	other, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	target := types.NewForTestGetter()()
+
+	signMessage := func(from, to address.Address, nonce uint64, value int64, method abi.MethodNum) error {
+		msg := &types.UnsignedMessage{
+			From:       from,
+			To:         to,
+			Nonce:      nonce,
+			Value:      big.NewInt(value),
+			Method:     method,
+			GasFeeCap:  big.Zero(),
+			GasPremium: big.Zero(),
+		}
+		mb, err := msg.ToStorageBlock()
+		require.NoError(t, err)
+		_, err = w.WalletSign(from, mb.Cid().Bytes(), MsgMeta{Type: MTChainMsg, Extra: mb.RawData()})
+		return err
+	}
+
+	assert.Error(t, policies.Set(&SigningPolicy{}))
+	require.NoError(t, policies.Set(&SigningPolicy{
+		Address:        addr,
+		MaxValuePerDay: big.NewInt(100),
+		AllowedTargets: []address.Address{target},
+		AllowedMethods: []abi.MethodNum{0},
+	}))
+
+	require.NoError(t, signMessage(addr, target, 0, 60, 0))
+	// signing the same nonce again counts the largest value signed for it once
+	require.NoError(t, signMessage(addr, target, 0, 70, 0))
+	err = signMessage(addr, target, 1, 40, 0)
+	assert.True(t, xerrors.Is(err, ErrSigningPolicy), err)
+	require.NoError(t, signMessage(addr, target, 1, 30, 0))
+	// a lower value for a signed nonce does not free the value cap
+	require.NoError(t, signMessage(addr, target, 0, 1, 0))
+	err = signMessage(addr, target, 2, 60, 0)
+	assert.True(t, xerrors.Is(err, ErrSigningPolicy), err)
+
+	assert.True(t, xerrors.Is(signMessage(addr, other, 2, 0, 0), ErrSigningPolicy))
+	assert.True(t, xerrors.Is(signMessage(addr, target, 2, 0, 2), ErrSigningPolicy))
+	// the message rules cannot be bypassed with another type or a missing message
+	_, err = w.WalletSign(addr, []byte("data"), MsgMeta{Type: MTUnknown})
+	assert.True(t, xerrors.Is(err, ErrSigningPolicy))
+	_, err = w.WalletSign(addr, []byte("data"), MsgMeta{Type: MTChainMsg})
+	assert.True(t, xerrors.Is(err, ErrSigningPolicy))
+	_, err = w.WalletSign(addr, []byte("data"), MsgMeta{Type: MTChainMsg, Extra: []byte("msg")})
+	assert.True(t, xerrors.Is(err, ErrSigningPolicy))
+
+	// addresses without policy sign anything
+	require.NoError(t, signMessage(other, other, 0, 1000, 5))
+	_, err = w.WalletSign(other, []byte("data"), MsgMeta{Type: MTUnknown})
+	require.NoError(t, err)
+
+	require.NoError(t, policies.Set(&SigningPolicy{Address: other, AllowedTypes: []MsgType{MTBlock}}))
+	_, err = w.WalletSign(other, []byte("data"), MsgMeta{Type: MTBlock})
+	require.NoError(t, err)
+	_, err = w.WalletSign(other, []byte("data"), MsgMeta{Type: MTDealProposal})
+	assert.True(t, xerrors.Is(err, ErrSigningPolicy))
+
+	// the policies and spends are reloaded, the backend ignores their keys
+	backend, err = NewDSBackend(ds, config.TestPassphraseConfig(), pw())
+	require.NoError(t, err)
+	assert.Len(t, backend.Addresses(), 2)
+	reloaded, err := NewSigningPolicies(ds)
+	require.NoError(t, err)
+	assert.Equal(t, policies.List(), reloaded.List())
+	w.SetSigningPolicies(reloaded)
+	assert.True(t, xerrors.Is(signMessage(addr, target, 2, 1, 0), ErrSigningPolicy))
+
+	removed, err := reloaded.Remove(addr)
+	require.NoError(t, err)
+	assert.True(t, removed)
+	removed, err = reloaded.Remove(addr)
+	require.NoError(t, err)
+	assert.False(t, removed)
+	require.NoError(t, signMessage(addr, other, 2, 1000, 2))
+}
+
+func TestSigningPoliciesSignUnlocked(t *testing.T) {
+	tf.UnitTest(t)
+
+	policies, err := NewSigningPolicies(datastore.NewMapDatastore())
+	require.NoError(t, err)
+	addr, err := address.NewSecp256k1Address([]byte("policy"))
+	require.NoError(t, err)
+	require.NoError(t, policies.Set(&SigningPolicy{Address: addr, MaxValuePerDay: big.NewInt(100)}))
+
+	signArgs := func(nonce uint64, value int64) ([]byte, MsgMeta) {
+		msg := &types.UnsignedMessage{
+			From:       addr,
+			To:         addr,
+			Nonce:      nonce,
+			Value:      big.NewInt(value),
+			GasFeeCap:  big.Zero(),
+			GasPremium: big.Zero(),
+		}
+		mb, err := msg.ToStorageBlock()
+		require.NoError(t, err)
+		return mb.Cid().Bytes(), MsgMeta{Type: MTChainMsg, Extra: mb.RawData()}
+	}
+	sig := &crypto.Signature{Type: crypto.SigTypeSecp256k1, Data: []byte("sig")}
+	signed := func() (*crypto.Signature, error) { return sig, nil }
+
+	// another message signed while the first one is being signed uses up the value cap
+	data, meta := signArgs(0, 60)
+	_, err = policies.Sign(addr, data, meta, func() (*crypto.Signature, error) {
+		data, meta := signArgs(1, 50)
+		return policies.Sign(addr, data, meta, signed)
+	})
+	assert.True(t, xerrors.Is(err, ErrSigningPolicy), err)
+
+	// only the value of the message signed within the cap is recorded
+	data, meta = signArgs(2, 50)
+	_, err = policies.Sign(addr, data, meta, signed)
+	require.NoError(t, err)
+	data, meta = signArgs(3, 1)
+	_, err = policies.Sign(addr, data, meta, signed)
+	assert.True(t, xerrors.Is(err, ErrSigningPolicy), err)
+}
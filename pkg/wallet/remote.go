@@ -0,0 +1,370 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus/pkg/constants"
+	"github.com/filecoin-project/venus/pkg/crypto"
+)
+
+// LocalBackend is the name of the local backends of the wallet.
+const LocalBackend = "local"
+
+var (
+	// DefaultRemoteCheckPeriod is how often the remote wallets are health checked by default.
+	DefaultRemoteCheckPeriod = 30 * time.Second
+	// remoteCheckTimeout bounds a health check of a remote wallet.
+	remoteCheckTimeout = 10 * time.Second
+)
+
+// RemoteWallet is a wallet served by another process, such as venus-wallet.
+type RemoteWallet interface {
+	WalletIntersection
+	// ListAddresses returns the addresses of the wallet, it fails when the wallet cannot be reached.
+	ListAddresses(ctx context.Context) ([]address.Address, error)
+	// Close closes the connection to the wallet.
+	Close()
+}
+
+// AddressBackend is an address of the wallet and the backends owning it.
+type AddressBackend struct {
+	Address address.Address
+	// Backends are the names of the backends owning the address, in the order they sign with it.
+	Backends []string
+}
+
+// BackendStatus is the state of a backend of the wallet.
+type BackendStatus struct {
+	Name      string
+	Remote    bool
+	Default   bool
+	Healthy   bool
+	Addresses int
+	// CheckedAt is the time of the last health check of a remote backend.
+	CheckedAt time.Time `json:",omitempty"`
+	Error     string    `json:",omitempty"`
+}
+
+// remoteBackend is a remote wallet with the addresses it had on its last successful health check.
+type remoteBackend struct {
+	name   string
+	wallet RemoteWallet
+
+	lk        sync.Mutex
+	healthy   bool
+	err       error
+	checkedAt time.Time
+	addrs     map[address.Address]struct{}
+}
+
+// check lists the addresses of the wallet and updates its health, it returns whether it is healthy.
+func (rb *remoteBackend) check(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, remoteCheckTimeout)
+	defer cancel()
+	addrs, err := rb.wallet.ListAddresses(ctx)
+
+	rb.lk.Lock()
+	defer rb.lk.Unlock()
+	rb.checkedAt = constants.Clock.Now()
+	if err != nil {
+		if rb.healthy || rb.err == nil {
+			walletLog.Warnf("remote wallet %s is unhealthy: %v", rb.name, err)
+		}
+		rb.healthy = false
+		rb.err = err
+		return false
+	}
+	if !rb.healthy && rb.err != nil {
+		walletLog.Infof("remote wallet %s is healthy again", rb.name)
+	}
+	rb.healthy = true
+	rb.err = nil
+	// the addresses are kept while the wallet is unhealthy, it still owns them
+	rb.addrs = make(map[address.Address]struct{}, len(addrs))
+	for _, addr := range addrs {
+		rb.addrs[addr] = struct{}{}
+	}
+	return true
+}
+
+func (rb *remoteBackend) owns(addr address.Address) (owns bool, healthy bool) {
+	rb.lk.Lock()
+	defer rb.lk.Unlock()
+	_, owns = rb.addrs[addr]
+	return owns, rb.healthy
+}
+
+func (rb *remoteBackend) addAddress(addr address.Address) {
+	rb.lk.Lock()
+	defer rb.lk.Unlock()
+	if rb.addrs == nil {
+		rb.addrs = make(map[address.Address]struct{})
+	}
+	rb.addrs[addr] = struct{}{}
+}
+
+func (rb *remoteBackend) status() BackendStatus {
+	rb.lk.Lock()
+	defer rb.lk.Unlock()
+	st := BackendStatus{
+		Name:      rb.name,
+		Remote:    true,
+		Healthy:   rb.healthy,
+		Addresses: len(rb.addrs),
+		CheckedAt: rb.checkedAt,
+	}
+	if rb.err != nil {
+		st.Error = rb.err.Error()
+	}
+	return st
+}
+
+// AddRemote adds a remote wallet to the wallet, its addresses are known after its first health check.
+func (w *Wallet) AddRemote(name string, rw RemoteWallet) error {
+	if name == "" || name == LocalBackend || name == ThresholdBackendName {
+		return xerrors.Errorf("invalid remote wallet name %q", name)
+	}
+
+	w.lk.Lock()
+	defer w.lk.Unlock()
+	for _, rb := range w.remotes {
+		if rb.name == name {
+			return xerrors.Errorf("duplicate remote wallet %s", name)
+		}
+	}
+	w.remotes = append(w.remotes, &remoteBackend{name: name, wallet: rw})
+	return nil
+}
+
+// SetDefaultBackend sets the backend new addresses are created and imported in.
+func (w *Wallet) SetDefaultBackend(name string) error {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+	if name != LocalBackend && w.remoteLocked(name) == nil {
+		return xerrors.Errorf("unknown wallet backend %s", name)
+	}
+	w.defaultBackend = name
+	return nil
+}
+
+// remoteLocked returns the remote wallet with the name, nil if there is none. It must be called
+// with w.lk held.
+func (w *Wallet) remoteLocked(name string) *remoteBackend {
+	for _, rb := range w.remotes {
+		if rb.name == name {
+			return rb
+		}
+	}
+	return nil
+}
+
+// defaultRemote returns the remote wallet new addresses go to, nil if it is the local wallet.
+func (w *Wallet) defaultRemote() *remoteBackend {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+	return w.remoteLocked(w.defaultBackend)
+}
+
+func (w *Wallet) remoteBackends() []*remoteBackend {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+	return append([]*remoteBackend(nil), w.remotes...)
+}
+
+// CheckRemotes health checks the remote wallets.
+func (w *Wallet) CheckRemotes(ctx context.Context) {
+	var wg sync.WaitGroup
+	for _, rb := range w.remoteBackends() {
+		wg.Add(1)
+		go func(rb *remoteBackend) {
+			defer wg.Done()
+			rb.check(ctx)
+		}(rb)
+	}
+	wg.Wait()
+}
+
+// RunRemoteChecks health checks the remote wallets right away, then every period until ctx is done.
+func (w *Wallet) RunRemoteChecks(ctx context.Context, period time.Duration) {
+	w.CheckRemotes(ctx)
+
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.CheckRemotes(ctx)
+		}
+	}
+}
+
+// CloseRemotes closes the connections to the remote wallets.
+func (w *Wallet) CloseRemotes() {
+	for _, rb := range w.remoteBackends() {
+		rb.wallet.Close()
+	}
+}
+
+// remoteOwners returns the remote wallets owning the address, the healthy ones first.
+func (w *Wallet) remoteOwners(addr address.Address) (healthy []*remoteBackend, unhealthy []*remoteBackend) {
+	for _, rb := range w.remoteBackends() {
+		owns, ok := rb.owns(addr)
+		switch {
+		case !owns:
+		case ok:
+			healthy = append(healthy, rb)
+		default:
+			unhealthy = append(unhealthy, rb)
+		}
+	}
+	return healthy, unhealthy
+}
+
+// remoteOwnersAsked is remoteOwners, the healthy remote wallets are asked when none is known to own
+// the address, as it may have been created on one of them since their last health check.
+func (w *Wallet) remoteOwnersAsked(addr address.Address) (healthy []*remoteBackend, unhealthy []*remoteBackend) {
+	healthy, unhealthy = w.remoteOwners(addr)
+	if len(healthy)+len(unhealthy) > 0 {
+		return healthy, unhealthy
+	}
+
+	for _, rb := range w.remoteBackends() {
+		if _, ok := rb.owns(addr); !ok {
+			continue
+		}
+		if rb.wallet.HasAddress(addr) {
+			rb.addAddress(addr)
+			healthy = append(healthy, rb)
+		}
+	}
+	return healthy, nil
+}
+
+func (w *Wallet) remoteHasAddress(addr address.Address) bool {
+	healthy, unhealthy := w.remoteOwnersAsked(addr)
+	return len(healthy)+len(unhealthy) > 0
+}
+
+// remoteSign signs with a healthy remote wallet owning the address. When the wallet fails and is no
+// longer healthy, the next one is tried.
+func (w *Wallet) remoteSign(addr address.Address, msg []byte, meta MsgMeta) (*crypto.Signature, error) {
+	healthy, unhealthy := w.remoteOwnersAsked(addr)
+	if len(healthy) == 0 {
+		if len(unhealthy) > 0 {
+			return nil, xerrors.Errorf("no healthy wallet backend owns %s", addr)
+		}
+		return nil, xerrors.Errorf("wallet has no address %s", addr)
+	}
+
+	var lastErr error
+	for _, rb := range healthy {
+		sig, err := rb.wallet.WalletSign(addr, msg, meta)
+		if err == nil {
+			return sig, nil
+		}
+		// a healthy wallet refused to sign, the others would refuse as well
+		if rb.check(context.TODO()) {
+			return nil, xerrors.Errorf("remote wallet %s: %w", rb.name, err)
+		}
+		walletLog.Warnf("signing with remote wallet %s failed, failing over: %v", rb.name, err)
+		lastErr = err
+	}
+	return nil, xerrors.Errorf("all the wallet backends of %s failed: %w", addr, lastErr)
+}
+
+func (w *Wallet) remoteExport(addr address.Address, password string) (*crypto.KeyInfo, error) {
+	healthy, _ := w.remoteOwners(addr)
+	if len(healthy) == 0 {
+		return nil, xerrors.Errorf("no healthy wallet backend owns %s", addr)
+	}
+	return healthy[0].wallet.Export(addr, password)
+}
+
+// localAddresses returns the addresses of the local backends by backend name.
+func (w *Wallet) localAddresses() map[string][]address.Address {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+
+	out := map[string][]address.Address{LocalBackend: nil}
+	for typ, backends := range w.backends {
+		name := LocalBackend
+		if typ == ThresholdBackendType {
+			name = ThresholdBackendName
+		}
+		for _, backend := range backends {
+			out[name] = append(out[name], backend.Addresses()...)
+		}
+	}
+	return out
+}
+
+// AddressBackends returns the addresses of the wallet with the backends owning them, sorted by address.
+func (w *Wallet) AddressBackends() []AddressBackend {
+	owners := make(map[address.Address][]string)
+	for name, addrs := range w.localAddresses() {
+		for _, addr := range addrs {
+			owners[addr] = append(owners[addr], name)
+		}
+	}
+	// the healthy remote wallets sign first
+	for _, healthy := range []bool{true, false} {
+		for _, rb := range w.remoteBackends() {
+			rb.lk.Lock()
+			if rb.healthy == healthy {
+				for addr := range rb.addrs {
+					owners[addr] = append(owners[addr], rb.name)
+				}
+			}
+			rb.lk.Unlock()
+		}
+	}
+
+	out := make([]AddressBackend, 0, len(owners))
+	for addr, backends := range owners {
+		out = append(out, AddressBackend{Address: addr, Backends: backends})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return bytes.Compare(out[i].Address.Bytes(), out[j].Address.Bytes()) < 0
+	})
+	return out
+}
+
+// BackendStatuses returns the state of the local backend and of the remote wallets.
+func (w *Wallet) BackendStatuses() []BackendStatus {
+	w.lk.Lock()
+	defaultBackend := w.defaultBackend
+	w.lk.Unlock()
+	if defaultBackend == "" {
+		defaultBackend = LocalBackend
+	}
+
+	locals := w.localAddresses()
+	out := []BackendStatus{{
+		Name:      LocalBackend,
+		Healthy:   true,
+		Addresses: len(locals[LocalBackend]),
+	}}
+	if addrs, ok := locals[ThresholdBackendName]; ok {
+		out = append(out, BackendStatus{
+			Name:      ThresholdBackendName,
+			Healthy:   true,
+			Addresses: len(addrs),
+		})
+	}
+	for _, rb := range w.remoteBackends() {
+		out = append(out, rb.status())
+	}
+	for i := range out {
+		out[i].Default = out[i].Name == defaultBackend
+	}
+	return out
+}
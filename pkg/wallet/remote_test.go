@@ -0,0 +1,156 @@
+package wallet
+
+import (
+	"context"
+	"testing"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus/pkg/config"
+	"github.com/filecoin-project/venus/pkg/crypto"
+	tf "github.com/filecoin-project/venus/pkg/testhelpers/testflags"
+)
+
+// testRemoteWallet is a RemoteWallet backed by a local wallet, it fails while it is down.
+type testRemoteWallet struct {
+	*Wallet
+	down  bool
+	signs int
+}
+
+var errRemoteDown = xerrors.New("remote wallet is down")
+
+func (rw *testRemoteWallet) ListAddresses(ctx context.Context) ([]address.Address, error) {
+	if rw.down {
+		return nil, errRemoteDown
+	}
+	return rw.Wallet.Addresses(), nil
+}
+
+func (rw *testRemoteWallet) WalletSign(addr address.Address, msg []byte, meta MsgMeta) (*crypto.Signature, error) {
+	if rw.down {
+		return nil, errRemoteDown
+	}
+	rw.signs++
+	return rw.Wallet.WalletSign(addr, msg, meta)
+}
+
+func (rw *testRemoteWallet) Close() {}
+
+func newTestWallet(t *testing.T) *Wallet {
+	backend, err := NewDSBackend(datastore.NewMapDatastore(), config.TestPassphraseConfig(), pw())
+	require.NoError(t, err)
+	return New(backend)
+}
+
+func TestRemoteWallets(t *testing.T) {
+	tf.UnitTest(t)
+
+	ctx := context.Background()
+	w := newTestWallet(t)
+	local, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+
+	// two replicas of a team wallet sharing their keys and a wallet of another team
+	teamA := &testRemoteWallet{Wallet: newTestWallet(t)}
+	teamA2 := &testRemoteWallet{Wallet: newTestWallet(t)}
+	teamB := &testRemoteWallet{Wallet: newTestWallet(t)}
+	shared, err := teamA.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	ki, err := teamA.Export(shared, string(pw()))
+	require.NoError(t, err)
+	_, err = teamA2.Import(ki)
+	require.NoError(t, err)
+	other, err := teamB.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+
+	require.NoError(t, w.AddRemote("team-a", teamA))
+	require.NoError(t, w.AddRemote("team-a2", teamA2))
+	require.NoError(t, w.AddRemote("team-b", teamB))
+	assert.Error(t, w.AddRemote("team-b", teamB))
+	assert.Error(t, w.AddRemote(LocalBackend, teamB))
+	w.CheckRemotes(ctx)
+
+	assert.ElementsMatch(t, []address.Address{local, shared, other}, w.Addresses())
+	owners := make(map[address.Address][]string)
+	for _, ab := range w.AddressBackends() {
+		owners[ab.Address] = ab.Backends
+	}
+	assert.Equal(t, []string{LocalBackend}, owners[local])
+	assert.Equal(t, []string{"team-a", "team-a2"}, owners[shared])
+	assert.Equal(t, []string{"team-b"}, owners[other])
+
+	data := []byte("data")
+	for _, addr := range []address.Address{local, shared, other} {
+		sig, err := w.WalletSign(addr, data, MsgMeta{Type: MTUnknown})
+		require.NoError(t, err)
+		assert.NoError(t, crypto.Verify(sig, addr, data))
+	}
+	assert.Equal(t, 1, teamA.signs)
+	assert.Equal(t, 0, teamA2.signs)
+
+	// the replica signs when the first wallet goes down
+	teamA.down = true
+	sig, err := w.WalletSign(shared, data, MsgMeta{Type: MTUnknown})
+	require.NoError(t, err)
+	assert.NoError(t, crypto.Verify(sig, shared, data))
+	assert.Equal(t, 1, teamA2.signs)
+	for _, st := range w.BackendStatuses() {
+		assert.Equal(t, st.Name != "team-a", st.Healthy, st.Name)
+	}
+
+	// the address of a wallet down is still owned but cannot be signed with
+	teamB.down = true
+	w.CheckRemotes(ctx)
+	assert.True(t, w.HasAddress(other))
+	_, err = w.WalletSign(other, data, MsgMeta{Type: MTUnknown})
+	assert.Error(t, err)
+	teamB.down = false
+	w.CheckRemotes(ctx)
+	_, err = w.WalletSign(other, data, MsgMeta{Type: MTUnknown})
+	assert.NoError(t, err)
+
+	// an address created on a remote wallet since its last check is asked for
+	fresh, err := teamB.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	sig, err = w.WalletSign(fresh, data, MsgMeta{Type: MTUnknown})
+	require.NoError(t, err)
+	assert.NoError(t, crypto.Verify(sig, fresh, data))
+	assert.True(t, w.HasAddress(fresh))
+
+	_, err = w.WalletSign(address.TestAddress, data, MsgMeta{Type: MTUnknown})
+	assert.Error(t, err)
+
+	// new addresses are created in the default backend
+	assert.Error(t, w.SetDefaultBackend("unknown"))
+	require.NoError(t, w.SetDefaultBackend("team-b"))
+	created, err := w.NewAddress(address.SECP256K1)
+	require.NoError(t, err)
+	assert.True(t, teamB.HasAddress(created))
+	assert.True(t, w.HasAddress(created))
+	for _, st := range w.BackendStatuses() {
+		assert.Equal(t, st.Name == "team-b", st.Default, st.Name)
+	}
+}
+
+func TestDefaultRemoteWalletState(t *testing.T) {
+	tf.UnitTest(t)
+
+	// a node with only a remote wallet has no local password
+	w := New()
+	assert.False(t, w.HasPassword())
+	assert.NotEqual(t, Unlock, w.WalletState())
+
+	remote := &testRemoteWallet{Wallet: newTestWallet(t)}
+	require.NoError(t, w.AddRemote("remote", remote))
+	require.NoError(t, w.SetDefaultBackend("remote"))
+	assert.True(t, w.HasPassword())
+	assert.Equal(t, Unlock, w.WalletState())
+
+	require.NoError(t, w.SetDefaultBackend(LocalBackend))
+	assert.False(t, w.HasPassword())
+}
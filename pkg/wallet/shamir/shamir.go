@@ -0,0 +1,142 @@
+// Package shamir implements Shamir's secret sharing over GF(2^8), a secret is split in N shares
+// and any M of them reconstruct it.
+package shamir
+
+import (
+	"crypto/rand"
+	"io"
+
+	"golang.org/x/xerrors"
+)
+
+// ShareOverhead is the length of a share minus the length of the secret, the x coordinate of the
+// share is its last byte.
+const ShareOverhead = 1
+
+var (
+	// expTable and logTable are the exponents and the logarithms of the generator 3 in GF(2^8)
+	// with the polynomial x^8 + x^4 + x^3 + x + 1.
+	expTable [510]byte
+	logTable [256]byte
+)
+
+func init() {
+	x := byte(1)
+	for i := 0; i < 255; i++ {
+		expTable[i] = x
+		expTable[i+255] = x
+		logTable[x] = byte(i)
+		// x *= 3
+		hi := x & 0x80
+		x ^= x << 1
+		if hi != 0 {
+			x ^= 0x1b
+		}
+	}
+}
+
+func mul(a, b byte) byte {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return expTable[int(logTable[a])+int(logTable[b])]
+}
+
+func div(a, b byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return expTable[int(logTable[a])+255-int(logTable[b])]
+}
+
+// evaluate returns the value at x of the polynomial of coefficients, lowest degree first.
+func evaluate(coefficients []byte, x byte) byte {
+	var y byte
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		y = mul(y, x) ^ coefficients[i]
+	}
+	return y
+}
+
+// Split splits the secret in parts shares, threshold of them are needed to combine it.
+func Split(secret []byte, parts, threshold int) ([][]byte, error) {
+	if len(secret) == 0 {
+		return nil, xerrors.New("the secret is empty")
+	}
+	if threshold < 2 || threshold > parts {
+		return nil, xerrors.Errorf("threshold %d is not between 2 and the %d parts", threshold, parts)
+	}
+	if parts > 255 {
+		return nil, xerrors.Errorf("%d parts are more than 255", parts)
+	}
+
+	shares := make([][]byte, parts)
+	for i := range shares {
+		shares[i] = make([]byte, len(secret)+ShareOverhead)
+		shares[i][len(secret)] = byte(i + 1)
+	}
+	coefficients := make([]byte, threshold)
+	defer wipe(coefficients)
+	for b, s := range secret {
+		coefficients[0] = s
+		if _, err := io.ReadFull(rand.Reader, coefficients[1:]); err != nil {
+			return nil, xerrors.Errorf("reading from crypto/rand: %w", err)
+		}
+		for i := range shares {
+			shares[i][b] = evaluate(coefficients, byte(i+1))
+		}
+	}
+	return shares, nil
+}
+
+// Combine reconstructs the secret from shares, it returns garbage when there are fewer shares than
+// the threshold of the split.
+func Combine(shares [][]byte) ([]byte, error) {
+	if len(shares) < 2 {
+		return nil, xerrors.New("at least 2 shares are needed")
+	}
+	size := len(shares[0])
+	if size <= ShareOverhead {
+		return nil, xerrors.New("the shares are too short")
+	}
+	xs := make([]byte, len(shares))
+	for i, share := range shares {
+		if len(share) != size {
+			return nil, xerrors.New("the shares have different lengths")
+		}
+		xs[i] = share[size-1]
+		if xs[i] == 0 {
+			return nil, xerrors.Errorf("share %d has no x coordinate", i)
+		}
+		for j := 0; j < i; j++ {
+			if xs[j] == xs[i] {
+				return nil, xerrors.Errorf("shares %d and %d are the same", j, i)
+			}
+		}
+	}
+
+	// the lagrange basis polynomials at 0
+	basis := make([]byte, len(shares))
+	for i := range shares {
+		basis[i] = 1
+		for j := range shares {
+			if i != j {
+				basis[i] = mul(basis[i], div(xs[j], xs[j]^xs[i]))
+			}
+		}
+	}
+
+	secret := make([]byte, size-ShareOverhead)
+	for b := range secret {
+		for i, share := range shares {
+			secret[b] ^= mul(share[b], basis[i])
+		}
+	}
+	return secret, nil
+}
+
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
@@ -0,0 +1,53 @@
+package shamir
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	tf "github.com/filecoin-project/venus/pkg/testhelpers/testflags"
+)
+
+func TestField(t *testing.T) {
+	tf.UnitTest(t)
+
+	// the AES field, 0x53 and 0xca are inverses
+	assert.Equal(t, byte(0xc1), mul(0x57, 0x83))
+	assert.Equal(t, byte(1), mul(0x53, 0xca))
+	for a := 1; a < 256; a++ {
+		for b := 1; b < 256; b++ {
+			require.Equal(t, byte(a), div(mul(byte(a), byte(b)), byte(b)))
+		}
+	}
+}
+
+func TestSplitCombine(t *testing.T) {
+	tf.UnitTest(t)
+
+	secret := []byte("a 32 bytes secp256k1 private key")
+	shares, err := Split(secret, 5, 3)
+	require.NoError(t, err)
+	require.Len(t, shares, 5)
+
+	for _, subset := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4}, {0, 1, 2, 3, 4}} {
+		var parts [][]byte
+		for _, i := range subset {
+			parts = append(parts, shares[i])
+		}
+		combined, err := Combine(parts)
+		require.NoError(t, err)
+		assert.Equal(t, secret, combined, subset)
+	}
+
+	combined, err := Combine(shares[:2])
+	require.NoError(t, err)
+	assert.NotEqual(t, secret, combined)
+
+	_, err = Combine([][]byte{shares[0], shares[0]})
+	assert.Error(t, err)
+	_, err = Split(secret, 3, 4)
+	assert.Error(t, err)
+	_, err = Split(secret, 3, 1)
+	assert.Error(t, err)
+}
@@ -0,0 +1,615 @@
+package wallet
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	ds "github.com/ipfs/go-datastore"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus/pkg/config"
+	"github.com/filecoin-project/venus/pkg/constants"
+	"github.com/filecoin-project/venus/pkg/crypto"
+	"github.com/filecoin-project/venus/pkg/repo"
+	"github.com/filecoin-project/venus/pkg/types"
+	"github.com/filecoin-project/venus/pkg/wallet/shamir"
+)
+
+// ThresholdBackendName is the name of the threshold backend in AddressBackend.
+const ThresholdBackendName = "threshold"
+
+var (
+	thresholdKeysKey = ds.NewKey("/threshold/keys")
+
+	// ThresholdBackendType is the reflect type of the ThresholdBackend.
+	ThresholdBackendType = reflect.TypeOf(&ThresholdBackend{})
+
+	// DefaultApprovalWait is how long signing with a threshold key waits for the approvals by default.
+	DefaultApprovalWait = time.Minute
+	// SignRequestTTL is how long the sign requests are kept, the pending ones expire after it.
+	SignRequestTTL = 24 * time.Hour
+
+	ErrApprovalPending = errors.New("the sign request is waiting for approvals")
+	ErrSignRejected    = errors.New("the sign request was rejected")
+	ErrThresholdExport = errors.New("threshold keys cannot be exported")
+	ErrApprovedPushed  = errors.New("the approved message was pushed by the wallet")
+)
+
+// ThresholdApprover is an approver of a threshold key and the password encrypting its share.
+type ThresholdApprover struct {
+	Name     string
+	Password string
+}
+
+// ThresholdKeyInfo describes a threshold key, Threshold of the shares of its Approvers sign.
+type ThresholdKeyInfo struct {
+	Address   address.Address
+	Threshold int
+	Approvers []string
+}
+
+// thresholdKey is a secp256k1 key split in shares, one per approver encrypted with its password.
+type thresholdKey struct {
+	Address   address.Address
+	Threshold int
+	Shares    map[string]CryptoJSON
+}
+
+func (k *thresholdKey) info() *ThresholdKeyInfo {
+	approvers := make([]string, 0, len(k.Shares))
+	for name := range k.Shares {
+		approvers = append(approvers, name)
+	}
+	sort.Strings(approvers)
+	return &ThresholdKeyInfo{Address: k.Address, Threshold: k.Threshold, Approvers: approvers}
+}
+
+// SignRequestState is the state of a SignRequest.
+type SignRequestState string
+
+const (
+	SignRequestPending  SignRequestState = "pending"
+	SignRequestApproved SignRequestState = "approved"
+	SignRequestRejected SignRequestState = "rejected"
+	SignRequestExpired  SignRequestState = "expired"
+)
+
+// SignRequest is a request to sign with a threshold key.
+type SignRequest struct {
+	ID      uint64
+	Address address.Address
+	Type    MsgType
+	Data    []byte
+	// Message is the message to sign of a MTChainMsg.
+	Message    *types.UnsignedMessage `json:",omitempty"`
+	Created    time.Time
+	State      SignRequestState
+	Approvals  []string
+	Rejections []string
+	// Pushed is set when the approved message was pushed by the wallet, no signer waiting for it.
+	Pushed bool `json:",omitempty"`
+}
+
+type signRequest struct {
+	SignRequest
+
+	// shares are the decrypted shares of the approvals, wiped when the request is done
+	shares    map[string][]byte
+	signature *crypto.Signature
+	done      chan struct{}
+	// waiters is the number of signers waiting for the signature
+	waiters int
+}
+
+func (req *signRequest) finish(state SignRequestState) {
+	req.State = state
+	for name, share := range req.shares {
+		wipe(share)
+		delete(req.shares, name)
+	}
+	close(req.done)
+}
+
+func (req *signRequest) hasVoted(approver string) bool {
+	for _, names := range [][]string{req.Approvals, req.Rejections} {
+		for _, name := range names {
+			if name == approver {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ThresholdBackend is a wallet backend of secp256k1 keys split with Shamir's secret sharing, a key
+// signs once Threshold of its approvers approve the request. The key is only reconstructed in memory
+// to sign and is never exported. The sign requests are kept in memory.
+type ThresholdBackend struct {
+	lk sync.Mutex
+	ds repo.Datastore
+
+	passphraseConf config.PassphraseConfig
+	// Wait is how long SignBytes waits for the approvals of a request before failing with
+	// ErrApprovalPending, signing the same data again waits for the same request.
+	Wait time.Duration
+
+	keys     map[address.Address]*thresholdKey
+	requests []*signRequest
+	nextID   uint64
+	// onApproved pushes the approved messages no signer waits for anymore
+	onApproved func(*types.SignedMessage)
+}
+
+var _ Backend = (*ThresholdBackend)(nil)
+var _ MetaSigner = (*ThresholdBackend)(nil)
+
+// NewThresholdBackend loads the threshold keys stored in the wallet datastore.
+func NewThresholdBackend(d repo.Datastore, passphraseCfg config.PassphraseConfig) (*ThresholdBackend, error) {
+	backend := &ThresholdBackend{
+		ds:             d,
+		passphraseConf: passphraseCfg,
+		Wait:           DefaultApprovalWait,
+		keys:           make(map[address.Address]*thresholdKey),
+		nextID:         1,
+	}
+
+	val, err := d.Get(thresholdKeysKey)
+	switch {
+	case err == nil:
+		var keys []*thresholdKey
+		if err := json.Unmarshal(val, &keys); err != nil {
+			return nil, xerrors.Errorf("decoding threshold keys: %w", err)
+		}
+		for _, k := range keys {
+			backend.keys[k.Address] = k
+		}
+	case err != ds.ErrNotFound:
+		return nil, xerrors.Errorf("loading threshold keys: %w", err)
+	}
+	return backend, nil
+}
+
+// NewAddress generates a secp256k1 key split in a share per approver, threshold of them sign.
+func (backend *ThresholdBackend) NewAddress(threshold int, approvers []ThresholdApprover) (address.Address, error) {
+	if threshold < 2 || threshold > len(approvers) {
+		return address.Undef, xerrors.Errorf("threshold %d is not between 2 and the %d approvers", threshold, len(approvers))
+	}
+	names := make(map[string]struct{}, len(approvers))
+	for _, approver := range approvers {
+		if approver.Name == "" || approver.Password == "" {
+			return address.Undef, xerrors.New("an approver has no name or no password")
+		}
+		if _, ok := names[approver.Name]; ok {
+			return address.Undef, xerrors.Errorf("duplicate approver %s", approver.Name)
+		}
+		names[approver.Name] = struct{}{}
+	}
+
+	ki, err := crypto.NewSecpKeyFromSeed(rand.Reader)
+	if err != nil {
+		return address.Undef, err
+	}
+	addr, err := ki.Address()
+	if err != nil {
+		return address.Undef, err
+	}
+	var shares [][]byte
+	err = ki.UsePrivateKey(func(privateKey []byte) error {
+		var err error
+		shares, err = shamir.Split(privateKey, len(approvers), threshold)
+		return err
+	})
+	if err != nil {
+		return address.Undef, err
+	}
+	defer func() {
+		for _, share := range shares {
+			wipe(share)
+		}
+	}()
+
+	key := &thresholdKey{Address: addr, Threshold: threshold, Shares: make(map[string]CryptoJSON, len(approvers))}
+	for i, approver := range approvers {
+		key.Shares[approver.Name], err = encryptData(shares[i], []byte(approver.Password), backend.passphraseConf.ScryptN, backend.passphraseConf.ScryptP)
+		if err != nil {
+			return address.Undef, err
+		}
+	}
+
+	backend.lk.Lock()
+	defer backend.lk.Unlock()
+	keys := make(map[address.Address]*thresholdKey, len(backend.keys)+1)
+	for a, k := range backend.keys {
+		keys[a] = k
+	}
+	keys[addr] = key
+	if err := backend.saveKeys(keys); err != nil {
+		return address.Undef, err
+	}
+	backend.keys = keys
+	return addr, nil
+}
+
+func (backend *ThresholdBackend) saveKeys(keys map[address.Address]*thresholdKey) error {
+	list := make([]*thresholdKey, 0, len(keys))
+	for _, k := range keys {
+		list = append(list, k)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return bytes.Compare(list[i].Address.Bytes(), list[j].Address.Bytes()) < 0
+	})
+	val, err := json.Marshal(list)
+	if err != nil {
+		return err
+	}
+	if err := backend.ds.Put(thresholdKeysKey, val); err != nil {
+		return xerrors.Errorf("persisting threshold keys: %w", err)
+	}
+	return nil
+}
+
+// Keys returns the threshold keys, sorted by address.
+func (backend *ThresholdBackend) Keys() []*ThresholdKeyInfo {
+	backend.lk.Lock()
+	defer backend.lk.Unlock()
+
+	out := make([]*ThresholdKeyInfo, 0, len(backend.keys))
+	for _, k := range backend.keys {
+		out = append(out, k.info())
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return bytes.Compare(out[i].Address.Bytes(), out[j].Address.Bytes()) < 0
+	})
+	return out
+}
+
+// Addresses returns the addresses of the threshold keys.
+func (backend *ThresholdBackend) Addresses() []address.Address {
+	keys := backend.Keys()
+	out := make([]address.Address, 0, len(keys))
+	for _, k := range keys {
+		out = append(out, k.Address)
+	}
+	return out
+}
+
+// HasAddress checks if the address is a threshold key.
+func (backend *ThresholdBackend) HasAddress(addr address.Address) bool {
+	backend.lk.Lock()
+	defer backend.lk.Unlock()
+	_, ok := backend.keys[addr]
+	return ok
+}
+
+// OnApprovedMessage sets the function pushing the approved chain messages no signer waits for
+// anymore, such as those of an mpool push which gave up waiting after Wait.
+func (backend *ThresholdBackend) OnApprovedMessage(push func(*types.SignedMessage)) {
+	backend.lk.Lock()
+	defer backend.lk.Unlock()
+	backend.onApproved = push
+}
+
+// SignBytes signs data of an unknown type once the request is approved.
+func (backend *ThresholdBackend) SignBytes(data []byte, addr address.Address) (*crypto.Signature, error) {
+	return backend.SignWithMeta(data, addr, MsgMeta{Type: MTUnknown})
+}
+
+// SignWithMeta queues a request to sign data and waits for its approvals up to Wait. The request
+// of the same data is waited for when it is already queued, its signature is returned when it is
+// approved. A chain message is matched by the message it signs whatever its gas values, which an
+// mpool push estimates again at every try: the request queued for it is not duplicated and its
+// message is pushed by the wallet once approved if no signer waits for it anymore.
+func (backend *ThresholdBackend) SignWithMeta(data []byte, addr address.Address, meta MsgMeta) (*crypto.Signature, error) {
+	req, err := backend.request(addr, data, meta)
+	if err != nil {
+		return nil, err
+	}
+
+	timer := time.NewTimer(backend.Wait)
+	defer timer.Stop()
+	select {
+	case <-req.done:
+	case <-timer.C:
+	}
+
+	backend.lk.Lock()
+	defer backend.lk.Unlock()
+	req.waiters--
+	switch req.State {
+	case SignRequestPending:
+		// the request stays queued
+		if req.Message != nil {
+			return nil, xerrors.Errorf("sign request %d of %s: %w, its message is pushed once approved", req.ID, addr, ErrApprovalPending)
+		}
+		return nil, xerrors.Errorf("sign request %d of %s: %w, sign the same data again once it is approved", req.ID, addr, ErrApprovalPending)
+	case SignRequestApproved:
+		if req.Pushed {
+			return nil, xerrors.Errorf("sign request %d of %s: %w", req.ID, addr, ErrApprovedPushed)
+		}
+		return req.signature, nil
+	case SignRequestRejected:
+		return nil, xerrors.Errorf("sign request %d of %s: %w", req.ID, addr, ErrSignRejected)
+	default:
+		return nil, xerrors.Errorf("sign request %d of %s is %s", req.ID, addr, req.State)
+	}
+}
+
+// request returns the request to sign data with addr to wait for, queuing it if there is none. It
+// fails when the message of data is requested with other gas values.
+func (backend *ThresholdBackend) request(addr address.Address, data []byte, meta MsgMeta) (*signRequest, error) {
+	// the message is shown to the approvers, it must be the data to sign
+	var msg *types.UnsignedMessage
+	if meta.Type == MTChainMsg && len(meta.Extra) > 0 {
+		if m, err := types.DecodeMessage(meta.Extra); err == nil && bytes.Equal(m.Cid().Bytes(), data) {
+			msg = m
+		}
+	}
+
+	backend.lk.Lock()
+	defer backend.lk.Unlock()
+
+	if _, ok := backend.keys[addr]; !ok {
+		return nil, xerrors.Errorf("%s is not a threshold key", addr)
+	}
+	backend.pruneRequests()
+	for _, req := range backend.requests {
+		if req.Address != addr {
+			continue
+		}
+		if bytes.Equal(req.Data, data) {
+			req.waiters++
+			return req, nil
+		}
+		if msg != nil && req.Message != nil && sameMessage(req.Message, msg) {
+			return nil, req.otherGasError()
+		}
+	}
+
+	req := &signRequest{
+		SignRequest: SignRequest{
+			ID:      backend.nextID,
+			Address: addr,
+			Type:    meta.Type,
+			Data:    append([]byte{}, data...),
+			Created: constants.Clock.Now(),
+			State:   SignRequestPending,
+		},
+		shares:  make(map[string][]byte),
+		done:    make(chan struct{}),
+		waiters: 1,
+	}
+	req.Message = msg
+	backend.nextID++
+	backend.requests = append(backend.requests, req)
+	walletLog.Infof("sign request %d of %s is waiting for approvals", req.ID, addr)
+	return req, nil
+}
+
+// sameMessage reports whether a and b are the same message but for their gas values.
+func sameMessage(a, b *types.UnsignedMessage) bool {
+	return a.Version == b.Version &&
+		a.From == b.From &&
+		a.To == b.To &&
+		a.Nonce == b.Nonce &&
+		a.Value.Equals(b.Value) &&
+		a.Method == b.Method &&
+		bytes.Equal(a.Params, b.Params)
+}
+
+// otherGasError is the failure to sign the message of the request with other gas values. It must be
+// called with backend.lk held.
+func (req *signRequest) otherGasError() error {
+	switch req.State {
+	case SignRequestPending:
+		return xerrors.Errorf("sign request %d of %s has the message with other gas values: %w, it is pushed once approved", req.ID, req.Address, ErrApprovalPending)
+	case SignRequestApproved:
+		return xerrors.Errorf("sign request %d of %s approved the message with other gas values: %w", req.ID, req.Address, ErrApprovedPushed)
+	case SignRequestRejected:
+		return xerrors.Errorf("sign request %d of %s: %w", req.ID, req.Address, ErrSignRejected)
+	default:
+		return xerrors.Errorf("sign request %d of %s is %s", req.ID, req.Address, req.State)
+	}
+}
+
+// pruneRequests removes the requests older than SignRequestTTL, it must be called with backend.lk held.
+func (backend *ThresholdBackend) pruneRequests() {
+	cutoff := constants.Clock.Now().Add(-SignRequestTTL)
+	i := 0
+	for i < len(backend.requests) && !backend.requests[i].Created.After(cutoff) {
+		if backend.requests[i].State == SignRequestPending {
+			backend.requests[i].finish(SignRequestExpired)
+		}
+		i++
+	}
+	backend.requests = backend.requests[i:]
+}
+
+// Requests returns (a copy of) the sign requests, the oldest first.
+func (backend *ThresholdBackend) Requests() []*SignRequest {
+	backend.lk.Lock()
+	defer backend.lk.Unlock()
+
+	backend.pruneRequests()
+	out := make([]*SignRequest, 0, len(backend.requests))
+	for _, req := range backend.requests {
+		r := req.SignRequest
+		r.Approvals = append([]string(nil), req.Approvals...)
+		r.Rejections = append([]string(nil), req.Rejections...)
+		out = append(out, &r)
+	}
+	return out
+}
+
+// checkVote checks that the request is pending and that the approver can vote on it, it returns the
+// request and its key. It must be called with backend.lk held.
+func (backend *ThresholdBackend) checkVote(id uint64, approver string) (*signRequest, *thresholdKey, error) {
+	backend.pruneRequests()
+	var req *signRequest
+	for _, r := range backend.requests {
+		if r.ID == id {
+			req = r
+			break
+		}
+	}
+	if req == nil {
+		return nil, nil, xerrors.Errorf("no sign request %d", id)
+	}
+	if req.State != SignRequestPending {
+		return nil, nil, xerrors.Errorf("sign request %d is %s", id, req.State)
+	}
+	key, ok := backend.keys[req.Address]
+	if !ok {
+		return nil, nil, xerrors.Errorf("%s is not a threshold key", req.Address)
+	}
+	if _, ok := key.Shares[approver]; !ok {
+		return nil, nil, xerrors.Errorf("%s is not an approver of %s", approver, req.Address)
+	}
+	if req.hasVoted(approver) {
+		return nil, nil, xerrors.Errorf("%s already voted on sign request %d", approver, id)
+	}
+	return req, key, nil
+}
+
+// vote decrypts the share of the approver of the request with password and checks the vote again,
+// it returns the request, its key and the decrypted share with backend.lk held on success. The
+// share is decrypted without the lock, scrypt would stall the signing and the other votes.
+func (backend *ThresholdBackend) vote(id uint64, approver string, password []byte) (*signRequest, *thresholdKey, []byte, error) {
+	backend.lk.Lock()
+	_, key, err := backend.checkVote(id, approver)
+	backend.lk.Unlock()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	// the keys are never modified, only replaced
+	share, err := decryptData(key.Shares[approver], password)
+	if err != nil {
+		return nil, nil, nil, ErrInvalidPassword
+	}
+
+	backend.lk.Lock()
+	// the request may be done or voted on by the approver meanwhile
+	req, key, err := backend.checkVote(id, approver)
+	if err != nil {
+		backend.lk.Unlock()
+		wipe(share)
+		return nil, nil, nil, err
+	}
+	return req, key, share, nil
+}
+
+// Approve approves the request with the share of the approver decrypted with password, the data is
+// signed once the request has Threshold approvals.
+func (backend *ThresholdBackend) Approve(id uint64, approver string, password []byte) error {
+	defer wipe(password)
+
+	req, key, share, err := backend.vote(id, approver, password)
+	if err != nil {
+		return err
+	}
+	var approved *types.SignedMessage
+	push := backend.onApproved
+	defer func() {
+		// pushed without the lock, the message pool may sign with the wallet
+		if approved != nil {
+			push(approved)
+		}
+	}()
+	defer backend.lk.Unlock()
+
+	req.Approvals = append(req.Approvals, approver)
+	req.shares[approver] = share
+	if len(req.shares) < key.Threshold {
+		return nil
+	}
+
+	shares := make([][]byte, 0, len(req.shares))
+	for _, s := range req.shares {
+		shares = append(shares, s)
+	}
+	privateKey, err := shamir.Combine(shares)
+	if err != nil {
+		req.finish(SignRequestRejected)
+		return xerrors.Errorf("combining the shares of %s: %w", req.Address, err)
+	}
+	ki := &crypto.KeyInfo{SigType: crypto.SigTypeSecp256k1}
+	ki.SetPrivateKey(privateKey)
+	if addr, err := ki.Address(); err != nil || addr != req.Address {
+		req.finish(SignRequestRejected)
+		return xerrors.Errorf("the shares of %s do not combine to its key", req.Address)
+	}
+	err = ki.UsePrivateKey(func(privateKey []byte) error {
+		var err error
+		req.signature, err = crypto.Sign(req.Data, privateKey, crypto.SigTypeSecp256k1)
+		return err
+	})
+	if err != nil {
+		req.finish(SignRequestRejected)
+		return err
+	}
+	req.finish(SignRequestApproved)
+	walletLog.Infof("sign request %d of %s is approved by %v", req.ID, req.Address, req.Approvals)
+	if req.Message != nil && req.waiters == 0 && push != nil {
+		req.Pushed = true
+		approved = &types.SignedMessage{Message: *req.Message, Signature: *req.signature}
+	}
+	return nil
+}
+
+// Reject rejects the request as the approver, authenticated with the password of its share. The
+// request is rejected once too few approvers are left to approve it.
+func (backend *ThresholdBackend) Reject(id uint64, approver string, password []byte) error {
+	defer wipe(password)
+
+	req, key, share, err := backend.vote(id, approver, password)
+	if err != nil {
+		return err
+	}
+	defer backend.lk.Unlock()
+
+	wipe(share)
+	req.Rejections = append(req.Rejections, approver)
+	if len(key.Shares)-len(req.Rejections) < key.Threshold {
+		req.finish(SignRequestRejected)
+		walletLog.Infof("sign request %d of %s is rejected by %v", req.ID, req.Address, req.Rejections)
+	}
+	return nil
+}
+
+// GetKeyInfo fails, the threshold keys are never reconstructed outside of signing.
+func (backend *ThresholdBackend) GetKeyInfo(addr address.Address) (*crypto.KeyInfo, error) {
+	return nil, ErrThresholdExport
+}
+
+// GetKeyInfoPassphrase fails, the threshold keys are never reconstructed outside of signing.
+func (backend *ThresholdBackend) GetKeyInfoPassphrase(addr address.Address, password []byte) (*crypto.KeyInfo, error) {
+	return nil, ErrThresholdExport
+}
+
+// LockWallet does nothing, the shares are encrypted with the passwords of the approvers.
+func (backend *ThresholdBackend) LockWallet() error {
+	return nil
+}
+
+// UnLockWallet does nothing, the shares are encrypted with the passwords of the approvers.
+func (backend *ThresholdBackend) UnLockWallet([]byte) error {
+	return nil
+}
+
+// WalletState returns Unlock, the shares are encrypted with the passwords of the approvers.
+func (backend *ThresholdBackend) WalletState() int {
+	return Unlock
+}
+
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
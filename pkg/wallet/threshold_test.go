@@ -0,0 +1,179 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/filecoin-project/go-address"
+	"github.com/filecoin-project/go-state-types/big"
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/venus/pkg/config"
+	"github.com/filecoin-project/venus/pkg/crypto"
+	tf "github.com/filecoin-project/venus/pkg/testhelpers/testflags"
+	"github.com/filecoin-project/venus/pkg/types"
+)
+
+func TestThresholdBackend(t *testing.T) {
+	tf.UnitTest(t)
+
+	ds := datastore.NewMapDatastore()
+	backend, err := NewDSBackend(ds, config.TestPassphraseConfig(), pw())
+	require.NoError(t, err)
+	tb, err := NewThresholdBackend(ds, config.TestPassphraseConfig())
+	require.NoError(t, err)
+	w := New(backend, tb)
+
+	approvers := []ThresholdApprover{{"alice", "alice-pw"}, {"bob", "bob-pw"}, {"carol", "carol-pw"}}
+	_, err = tb.NewAddress(1, approvers)
+	assert.Error(t, err)
+	_, err = tb.NewAddress(4, approvers)
+	assert.Error(t, err)
+	_, err = tb.NewAddress(2, []ThresholdApprover{{"alice", "pw"}, {"alice", "pw"}})
+	assert.Error(t, err)
+	addr, err := tb.NewAddress(2, approvers)
+	require.NoError(t, err)
+	assert.Equal(t, address.SECP256K1, addr.Protocol())
+	assert.True(t, w.HasAddress(addr))
+	assert.Equal(t, []AddressBackend{{Address: addr, Backends: []string{ThresholdBackendName}}}, w.AddressBackends())
+	_, err = w.Export(addr, string(pw()))
+	assert.True(t, xerrors.Is(err, ErrThresholdExport))
+
+	msg := &types.UnsignedMessage{
+		From:       addr,
+		To:         addr,
+		Value:      big.NewInt(1),
+		GasFeeCap:  big.Zero(),
+		GasPremium: big.Zero(),
+	}
+	mb, err := msg.ToStorageBlock()
+	require.NoError(t, err)
+	data := mb.Cid().Bytes()
+	meta := MsgMeta{Type: MTChainMsg, Extra: mb.RawData()}
+
+	// the signature waits for the approvals of 2 approvers
+	type result struct {
+		sig *crypto.Signature
+		err error
+	}
+	tb.Wait = 10 * time.Second
+	signed := make(chan result, 1)
+	go func() {
+		sig, err := w.WalletSign(addr, data, meta)
+		signed <- result{sig, err}
+	}()
+	var requests []*SignRequest
+	require.Eventually(t, func() bool {
+		requests = tb.Requests()
+		return len(requests) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	req := requests[0]
+	assert.Equal(t, SignRequestPending, req.State)
+	assert.Equal(t, MTChainMsg, req.Type)
+	assert.Equal(t, msg.Cid(), req.Message.Cid())
+
+	assert.True(t, xerrors.Is(tb.Approve(req.ID, "alice", []byte("bob-pw")), ErrInvalidPassword))
+	assert.Error(t, tb.Approve(req.ID, "dave", []byte("dave-pw")))
+	require.NoError(t, tb.Approve(req.ID, "alice", []byte("alice-pw")))
+	assert.Error(t, tb.Approve(req.ID, "alice", []byte("alice-pw")))
+	select {
+	case <-signed:
+		t.Fatal("signed with a single approval")
+	case <-time.After(50 * time.Millisecond):
+	}
+	require.NoError(t, tb.Approve(req.ID, "carol", []byte("carol-pw")))
+	res := <-signed
+	require.NoError(t, res.err)
+	assert.NoError(t, crypto.Verify(res.sig, addr, data))
+	assert.Equal(t, SignRequestApproved, tb.Requests()[0].State)
+	assert.Error(t, tb.Approve(req.ID, "bob", []byte("bob-pw")))
+
+	// signing the same data again returns the signature of the approved request
+	sig, err := w.WalletSign(addr, data, meta)
+	require.NoError(t, err)
+	assert.Equal(t, res.sig, sig)
+
+	// the request is rejected once too few approvers are left
+	tb.Wait = 10 * time.Millisecond
+	_, err = w.SignBytes([]byte("data"), addr)
+	assert.True(t, xerrors.Is(err, ErrApprovalPending))
+	requests = tb.Requests()
+	require.Len(t, requests, 2)
+	req = requests[1]
+	assert.Equal(t, MTUnknown, req.Type)
+	require.NoError(t, tb.Reject(req.ID, "bob", []byte("bob-pw")))
+	assert.Equal(t, SignRequestPending, tb.Requests()[1].State)
+	require.NoError(t, tb.Approve(req.ID, "alice", []byte("alice-pw")))
+	require.NoError(t, tb.Reject(req.ID, "carol", []byte("carol-pw")))
+	assert.Equal(t, SignRequestRejected, tb.Requests()[1].State)
+	_, err = w.SignBytes([]byte("data"), addr)
+	assert.True(t, xerrors.Is(err, ErrSignRejected))
+
+	// the requests expire
+	SignRequestTTL = 0
+	defer func() { SignRequestTTL = 24 * time.Hour }()
+	assert.Empty(t, tb.Requests())
+
+	// the keys are reloaded, the local backend ignores them
+	reloaded, err := NewThresholdBackend(ds, config.TestPassphraseConfig())
+	require.NoError(t, err)
+	assert.Equal(t, []*ThresholdKeyInfo{{Address: addr, Threshold: 2, Approvers: []string{"alice", "bob", "carol"}}}, reloaded.Keys())
+	backend, err = NewDSBackend(ds, config.TestPassphraseConfig(), pw())
+	require.NoError(t, err)
+	assert.Empty(t, backend.Addresses())
+}
+
+func TestThresholdBackendPushesApprovedMessages(t *testing.T) {
+	tf.UnitTest(t)
+
+	tb, err := NewThresholdBackend(datastore.NewMapDatastore(), config.TestPassphraseConfig())
+	require.NoError(t, err)
+	addr, err := tb.NewAddress(2, []ThresholdApprover{{"alice", "alice-pw"}, {"bob", "bob-pw"}})
+	require.NoError(t, err)
+	var pushed []*types.SignedMessage
+	tb.OnApprovedMessage(func(smsg *types.SignedMessage) {
+		pushed = append(pushed, smsg)
+	})
+
+	signArgs := func(premium int64) (*types.UnsignedMessage, []byte, MsgMeta) {
+		msg := &types.UnsignedMessage{
+			From:       addr,
+			To:         addr,
+			Value:      big.NewInt(1),
+			GasFeeCap:  big.NewInt(premium),
+			GasPremium: big.NewInt(premium),
+		}
+		mb, err := msg.ToStorageBlock()
+		require.NoError(t, err)
+		return msg, mb.Cid().Bytes(), MsgMeta{Type: MTChainMsg, Extra: mb.RawData()}
+	}
+
+	// the push gives up waiting, its retries estimate other gas values
+	tb.Wait = 10 * time.Millisecond
+	msg, data, meta := signArgs(1)
+	_, err = tb.SignWithMeta(data, addr, meta)
+	assert.True(t, xerrors.Is(err, ErrApprovalPending), err)
+	_, retryData, retryMeta := signArgs(2)
+	_, err = tb.SignWithMeta(retryData, addr, retryMeta)
+	assert.True(t, xerrors.Is(err, ErrApprovalPending), err)
+	requests := tb.Requests()
+	require.Len(t, requests, 1)
+
+	// the message is pushed once approved
+	require.NoError(t, tb.Approve(requests[0].ID, "alice", []byte("alice-pw")))
+	assert.Empty(t, pushed)
+	require.NoError(t, tb.Approve(requests[0].ID, "bob", []byte("bob-pw")))
+	require.Len(t, pushed, 1)
+	assert.Equal(t, msg.Cid(), pushed[0].Message.Cid())
+	assert.NoError(t, crypto.Verify(&pushed[0].Signature, addr, data))
+	assert.True(t, tb.Requests()[0].Pushed)
+
+	// signing it again does not push it twice
+	_, err = tb.SignWithMeta(data, addr, meta)
+	assert.True(t, xerrors.Is(err, ErrApprovedPushed), err)
+	_, err = tb.SignWithMeta(retryData, addr, retryMeta)
+	assert.True(t, xerrors.Is(err, ErrApprovedPushed), err)
+}
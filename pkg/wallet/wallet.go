@@ -1,16 +1,15 @@
 package wallet
 
 import (
-	"bytes"
 	"fmt"
 	"reflect"
-	"sort"
 	"sync"
 
 	"github.com/filecoin-project/go-address"
 	logging "github.com/ipfs/go-log/v2"
 	"github.com/pkg/errors"
 
+	"github.com/filecoin-project/venus/pkg/config"
 	"github.com/filecoin-project/venus/pkg/crypto"
 )
 
@@ -33,11 +32,15 @@ type WalletIntersection interface {
 
 var _ WalletIntersection = &Wallet{}
 
-// wallet manages the locally stored addresses.
+// wallet manages the locally stored addresses, and the addresses of the remote wallets added to it.
 type Wallet struct {
 	lk sync.Mutex
 
 	backends map[reflect.Type][]Backend
+	policies *SigningPolicies
+
+	remotes        []*remoteBackend
+	defaultBackend string
 }
 
 // New constructs a new wallet, that manages addresses in all the
@@ -58,11 +61,13 @@ func New(backends ...Backend) *Wallet {
 // HasAddress checks if the given address is stored.
 // Safe for concurrent access.
 func (w *Wallet) HasAddress(a address.Address) bool {
-	_, err := w.Find(a)
-	return err == nil
+	if _, err := w.Find(a); err == nil {
+		return true
+	}
+	return w.remoteHasAddress(a)
 }
 
-// Find searches through all backends and returns the one storing the passed
+// Find searches through all local backends and returns the one storing the passed
 // in address.
 // Safe for concurrent access.
 func (w *Wallet) Find(addr address.Address) (Backend, error) {
@@ -80,23 +85,15 @@ func (w *Wallet) Find(addr address.Address) (Backend, error) {
 	return nil, fmt.Errorf("wallet has no address %s", addr)
 }
 
-// Addresses retrieves all stored addresses.
+// Addresses retrieves all stored addresses, including the ones of the remote wallets.
 // Safe for concurrent access.
 // Always sorted in the same order.
 func (w *Wallet) Addresses() []address.Address {
-	w.lk.Lock()
-	defer w.lk.Unlock()
-
-	var out []address.Address
-	for _, backends := range w.backends {
-		for _, backend := range backends {
-			out = append(out, backend.Addresses()...)
-		}
+	owners := w.AddressBackends()
+	out := make([]address.Address, 0, len(owners))
+	for _, owner := range owners {
+		out = append(out, owner.Address)
 	}
-	sort.Slice(out, func(i, j int) bool {
-		return bytes.Compare(out[i].Bytes(), out[j].Bytes()) < 0
-	})
-
 	return out
 }
 
@@ -111,18 +108,37 @@ func (w *Wallet) Backends(typ reflect.Type) []Backend {
 }
 
 // SignBytes cryptographically signs `data` using the private key corresponding to
-// address `addr`
+// address `addr`, the signing policies do not apply.
 func (w *Wallet) SignBytes(data []byte, addr address.Address) (*crypto.Signature, error) {
+	return w.signBytes(data, addr, MsgMeta{Type: MTUnknown})
+}
+
+// signBytes signs with the local backend storing the address, or with a remote wallet owning it.
+func (w *Wallet) signBytes(data []byte, addr address.Address, meta MsgMeta) (*crypto.Signature, error) {
 	// Check that we are storing the address to sign for.
 	backend, err := w.Find(addr)
-	if err != nil {
+	if err == nil {
+		if signer, ok := backend.(MetaSigner); ok {
+			return signer.SignWithMeta(data, addr, meta)
+		}
+		return backend.SignBytes(data, addr)
+	}
+	if !w.remoteHasAddress(addr) {
 		return nil, errors.Wrapf(err, "could not find address: %s", addr)
 	}
-	return backend.SignBytes(data, addr)
+	return w.remoteSign(addr, data, meta)
 }
 
 // NewAddress creates a new account address on the default wallet backend.
 func (w *Wallet) NewAddress(p address.Protocol) (address.Address, error) {
+	if rb := w.defaultRemote(); rb != nil {
+		addr, err := rb.wallet.NewAddress(p)
+		if err != nil {
+			return address.Undef, err
+		}
+		rb.addAddress(addr)
+		return addr, nil
+	}
 	backend, err := w.DSBacked()
 	if err != nil {
 		return address.Undef, err
@@ -130,6 +146,34 @@ func (w *Wallet) NewAddress(p address.Protocol) (address.Address, error) {
 	return backend.NewAddress(p)
 }
 
+// NewHDAddress derives the next address of the protocol from the mnemonic of the default wallet backend.
+func (w *Wallet) NewHDAddress(p address.Protocol) (address.Address, error) {
+	backend, err := w.DSBacked()
+	if err != nil {
+		return address.Undef, err
+	}
+	return backend.NewHDAddress(p)
+}
+
+// RestoreMnemonic restores the first count addresses of the protocol derived from the mnemonic in
+// the default wallet backend.
+func (w *Wallet) RestoreMnemonic(mnemonic string, p address.Protocol, count int) ([]address.Address, error) {
+	backend, err := w.DSBacked()
+	if err != nil {
+		return nil, err
+	}
+	return backend.RestoreMnemonic(mnemonic, p, count)
+}
+
+// ExportMnemonic returns the mnemonic of the default wallet backend, decrypted with the password.
+func (w *Wallet) ExportMnemonic(password []byte) (string, error) {
+	backend, err := w.DSBacked()
+	if err != nil {
+		return "", err
+	}
+	return backend.ExportMnemonic(password)
+}
+
 // GetPubKeyForAddress returns the public key in the keystore associated with
 // the given address.
 func (w *Wallet) GetPubKeyForAddress(addr address.Address) ([]byte, error) {
@@ -164,8 +208,16 @@ func (w *Wallet) keyInfoForAddr(addr address.Address) (*crypto.KeyInfo, error) {
 	return info, nil
 }
 
-// Import adds the given keyinfo to the wallet
+// Import adds the given keyinfo to the default wallet backend
 func (w *Wallet) Import(ki *crypto.KeyInfo) (address.Address, error) {
+	if rb := w.defaultRemote(); rb != nil {
+		addr, err := rb.wallet.Import(ki)
+		if err != nil {
+			return address.Undef, err
+		}
+		rb.addAddress(addr)
+		return addr, nil
+	}
 	dsb := w.Backends(DSBackendType)
 	if len(dsb) != 1 {
 		return address.Undef, fmt.Errorf("expected exactly one datastore wallet backend")
@@ -191,6 +243,9 @@ func (w *Wallet) Import(ki *crypto.KeyInfo) (address.Address, error) {
 func (w *Wallet) Export(addr address.Address, password string) (*crypto.KeyInfo, error) {
 	bck, err := w.Find(addr)
 	if err != nil {
+		if w.remoteHasAddress(addr) {
+			return w.remoteExport(addr, password)
+		}
 		return nil, err
 	}
 
@@ -204,15 +259,31 @@ func (w *Wallet) Export(addr address.Address, password string) (*crypto.KeyInfo,
 
 //WalletSign used to sign message with private key
 func (w *Wallet) WalletSign(addr address.Address, msg []byte, meta MsgMeta) (*crypto.Signature, error) {
-	ki, err := w.Find(addr)
-	if err != nil {
+	if _, err := w.Find(addr); err != nil && !w.remoteHasAddress(addr) {
 		return nil, err
 	}
-	if ki == nil {
-		return nil, errors.Errorf("signing using key '%s': %v", addr.String(), ErrKeyInfoNotFound)
+
+	policies := w.SigningPolicies()
+	if policies == nil {
+		return w.signBytes(msg, addr, meta)
 	}
+	return policies.Sign(addr, msg, meta, func() (*crypto.Signature, error) {
+		return w.signBytes(msg, addr, meta)
+	})
+}
 
-	return ki.SignBytes(msg, addr)
+// SetSigningPolicies sets the signing policies enforced by WalletSign.
+func (w *Wallet) SetSigningPolicies(policies *SigningPolicies) {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+	w.policies = policies
+}
+
+// SigningPolicies returns the signing policies enforced by WalletSign, nil if there are none.
+func (w *Wallet) SigningPolicies() *SigningPolicies {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+	return w.policies
 }
 
 //DSBacked return the first wallet backend
@@ -226,6 +297,16 @@ func (w *Wallet) DSBacked() (*DSBackend, error) {
 	return (backends[0]).(*DSBackend), nil
 }
 
+// ThresholdBacked returns the threshold backend of the wallet.
+func (w *Wallet) ThresholdBacked() (*ThresholdBackend, error) {
+	backends := w.Backends(ThresholdBackendType)
+	if len(backends) == 0 {
+		return nil, errors.Errorf("missing threshold backend")
+	}
+
+	return (backends[0]).(*ThresholdBackend), nil
+}
+
 //LockWallet lock lock wallet
 func (w *Wallet) LockWallet() error {
 	backend, err := w.DSBacked()
@@ -255,8 +336,44 @@ func (w *Wallet) SetPassword(password []byte) error {
 	return backend.SetPassword(password)
 }
 
-//HasPassword return whether the password has been set in the wallet
+// ChangePassword re-encrypts the keys of the default wallet backend with the new password, and the
+// scrypt parameters of passphraseCfg if it is not nil.
+func (w *Wallet) ChangePassword(oldPassword, newPassword []byte, passphraseCfg *config.PassphraseConfig) error {
+	backend, err := w.DSBacked()
+	if err != nil {
+		return err
+	}
+	cfg := backend.PassphraseConf
+	if passphraseCfg != nil {
+		cfg = *passphraseCfg
+	}
+	return backend.ChangePassword(oldPassword, newPassword, cfg)
+}
+
+// ExportKeyStore exports all the keys of the default wallet backend encrypted with exportPassword.
+func (w *Wallet) ExportKeyStore(password, exportPassword []byte) (*KeyStore, error) {
+	backend, err := w.DSBacked()
+	if err != nil {
+		return nil, err
+	}
+	return backend.ExportKeyStore(password, exportPassword)
+}
+
+// ImportKeyStore imports the keys of a key store in the default wallet backend.
+func (w *Wallet) ImportKeyStore(ks *KeyStore, exportPassword []byte) ([]address.Address, error) {
+	backend, err := w.DSBacked()
+	if err != nil {
+		return nil, err
+	}
+	return backend.ImportKeyStore(ks, exportPassword)
+}
+
+//HasPassword return whether the password has been set in the wallet, or in the default remote
+//wallet new addresses go to
 func (w *Wallet) HasPassword() bool {
+	if rb := w.defaultRemote(); rb != nil {
+		return rb.wallet.HasPassword()
+	}
 	backend, err := w.DSBacked()
 	if err != nil {
 		walletLog.Errorf("get DSBacked failed: %v", err)
@@ -265,8 +382,12 @@ func (w *Wallet) HasPassword() bool {
 	return backend.HasPassword()
 }
 
-//WalletState return wallet state(lock/unlock)
+//WalletState return wallet state(lock/unlock), a default remote wallet locks its keys itself and
+//is reported unlocked
 func (w *Wallet) WalletState() int {
+	if rb := w.defaultRemote(); rb != nil {
+		return Unlock
+	}
 	backend, err := w.DSBacked()
 	if err != nil {
 		walletLog.Errorf("get DSBacked failed: %v", err)